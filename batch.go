@@ -0,0 +1,192 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+// #include "cnuodb.h"
+import "C"
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// BatchResult is the result of executing a statement with many parameter
+// sets in a batch.
+type BatchResult struct {
+	RowsAffected []int64 // by parameter set; -1 if the server didn't tell
+	Errors       []error // by parameter set; nil for those which succeeded
+}
+
+// Err returns the error of the first failed parameter set, if any.
+func (r *BatchResult) Err() error {
+	for _, err := range r.Errors {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BatchExecer is implemented by the statements of the driver. The driver
+// statement is reachable through sql.Conn.Raw; ExecBatch does that.
+type BatchExecer interface {
+	ExecBatch(ctx context.Context, args [][]driver.Value) (*BatchResult, error)
+}
+
+var _ BatchExecer = (*Stmt)(nil)
+
+// Update counts of the failed and the uncounted parameter sets of a batch.
+const (
+	batchExecuteFailed = -3
+	batchSuccessNoInfo = -2
+)
+
+// ExecBatch executes the statement with each of the parameter sets of args
+// in a single round trip. The parameter sets must bind all the parameters
+// and lobs and sql.Out are not supported. The error is returned if the
+// batch couldn't be executed at all; the failures of single parameter sets
+// are reported in the BatchResult.
+func (stmt *Stmt) ExecBatch(ctx context.Context, args [][]driver.Value) (_ *BatchResult, err error) {
+	c := stmt.c
+	if c.db == nil {
+		return nil, errClosed
+	}
+	exit, err := c.enter()
+	if err != nil {
+		return nil, err
+	}
+	defer exit()
+	if c.bad {
+		return nil, driver.ErrBadConn
+	}
+	if stmt.names != nil {
+		return nil, errors.New("nuodb: named parameters are not supported in batches")
+	}
+	if len(args) == 0 {
+		return &BatchResult{}, nil
+	}
+	defer observeStatement(stmt.sql, time.Now(), &err)
+	defer c.metrics.observe(metricExec, time.Now(), &err)
+	n := int(stmt.parameterCount)
+	size := 0
+	for i, row := range args {
+		if len(row) != n {
+			return nil, fmt.Errorf("nuodb: batch row %d: expected %d parameters, got %d", i, n, len(row))
+		}
+		size += dataSize(row)
+	}
+	parameters := newCValues(len(args)*n, size, c.loc)
+	parameters.emptyAsNull = c.emptyStringAsNull
+	c.metrics.sent(size)
+	defer parameters.free()
+	for i, row := range args {
+		for j, v := range row {
+			if err := parameters.add(v); err != nil {
+				return nil, fmt.Errorf("nuodb: batch row %d: parameter %d: %s", i, j+1, err)
+			}
+		}
+	}
+	c.takeOptions()
+	if err := c.applyContext(ctx); err != nil {
+		return nil, err
+	}
+	restore, err := c.enterReadOnly(ctx, stmt.sql)
+	if err != nil {
+		return nil, err
+	}
+	defer restore()
+	if err := stmt.addTimeoutFromContext(ctx); err != nil {
+		return nil, err
+	}
+	counts := make([]C.int64_t, len(args))
+	waitCtx, cancel := c.defaultContext(ctx)
+	err = c.limiter.acquire(waitCtx)
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.faults.inject(ctx, c); err != nil {
+		c.limiter.release()
+		return nil, err
+	}
+	unlock, err := stmt.lock()
+	if err != nil {
+		c.limiter.release()
+		return nil, err
+	}
+	cancelled := stmt.watchCancel(ctx)
+	c.stats.call()
+	rc := C.nuodb_statement_execute_batch(c.db, stmt.st, parameters.ptr(), C.int(len(args)), &counts[0])
+	c.limiter.release()
+	if cancelled() && rc != 0 {
+		unlock()
+		return nil, contextError(ctx)
+	}
+	if rc != 0 {
+		err := c.lastError(rc)
+		unlock()
+		return nil, err
+	}
+	unlock()
+	result := &BatchResult{RowsAffected: make([]int64, len(args)), Errors: make([]error, len(args))}
+	for i, count := range counts {
+		switch count {
+		case batchExecuteFailed:
+			result.Errors[i] = &Error{Code: BatchUpdateError, Message: fmt.Sprintf("batch row %d failed", i),
+				Conn: c.id, Session: c.sessionID}
+		case batchSuccessNoInfo:
+			result.RowsAffected[i] = -1
+		default:
+			result.RowsAffected[i] = int64(count)
+		}
+	}
+	return result, nil
+}
+
+// ExecBatch prepares query on conn and executes it with each of the
+// parameter sets of args in a single round trip, e.g. to insert many rows:
+//
+//	result, err := nuodb.ExecBatch(ctx, conn, "INSERT INTO t (id, name) VALUES (?, ?)", [][]interface{}{
+//		{1, "a"},
+//		{2, "b"},
+//	})
+//
+// See Stmt.ExecBatch.
+func ExecBatch(ctx context.Context, conn *sql.Conn, query string, args [][]interface{}) (*BatchResult, error) {
+	rows := make([][]driver.Value, len(args))
+	for i, row := range args {
+		rows[i] = make([]driver.Value, len(row))
+		for j, v := range row {
+			var err error
+			if rows[i][j], err = batchValue(v); err != nil {
+				return nil, fmt.Errorf("nuodb: batch row %d: parameter %d: %s", i, j+1, err)
+			}
+		}
+	}
+	var result *BatchResult
+	err := conn.Raw(func(driverConn interface{}) error {
+		c, ok := driverConn.(*Conn)
+		if !ok {
+			return errors.New("nuodb: not a nuodb connection")
+		}
+		ds, err := c.Prepare(query)
+		if err != nil {
+			return err
+		}
+		defer ds.Close()
+		result, err = ds.(*Stmt).ExecBatch(ctx, rows)
+		return err
+	})
+	return result, err
+}
+
+// batchValue converts an argument like database/sql would.
+func batchValue(v interface{}) (driver.Value, error) {
+	if dv, ok, err := convertValue(v); ok || err != nil {
+		return dv, err
+	}
+	return driver.DefaultParameterConverter.ConvertValue(v)
+}
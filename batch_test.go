@@ -0,0 +1,78 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+)
+
+func TestBatchValue(t *testing.T) {
+	for _, test := range []struct {
+		v        interface{}
+		expected driver.Value
+	}{
+		{1, int64(1)},
+		{int32(2), int64(2)},
+		{"a", "a"},
+		{nil, nil},
+		{float32(0.5), float64(0.5)},
+	} {
+		v, err := batchValue(test.v)
+		if err != nil || v != test.expected {
+			t.Errorf("%v: expected %v, got %v (%v)", test.v, test.expected, v, err)
+		}
+	}
+	if _, err := batchValue(struct{}{}); err == nil {
+		t.Error("Expected an unsupported type")
+	}
+}
+
+func TestBatchResultErr(t *testing.T) {
+	failed := errors.New("failed")
+	r := &BatchResult{Errors: []error{nil, failed, nil}}
+	if r.Err() != failed {
+		t.Fatalf("Expected %v, got %v", failed, r.Err())
+	}
+	if r := (&BatchResult{Errors: make([]error, 2)}); r.Err() != nil {
+		t.Fatalf("Expected no error, got %v", r.Err())
+	}
+}
+
+func TestExecBatch(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	ctx := context.Background()
+	exec(t, db, "CREATE TABLE FooBar (id INTEGER PRIMARY KEY, name STRING)")
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	args := make([][]interface{}, 1000)
+	for i := range args {
+		args[i] = []interface{}{i, "name"}
+	}
+	result, err := ExecBatch(ctx, conn, "INSERT INTO FooBar (id, name) VALUES (?, ?)", args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := result.Err(); err != nil {
+		t.Fatal(err)
+	}
+	for i, n := range result.RowsAffected {
+		if n != 1 {
+			t.Fatalf("Row %d: expected 1 affected row, got %d", i, n)
+		}
+	}
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM FooBar").Scan(&count); err != nil || count != len(args) {
+		t.Fatalf("Expected %d rows, got %d (%v)", len(args), count, err)
+	}
+
+	if _, err := ExecBatch(ctx, conn, "INSERT INTO FooBar (id, name) VALUES (?, ?)", [][]interface{}{{1}}); err == nil {
+		t.Fatal("Expected an error for a missing parameter")
+	}
+}
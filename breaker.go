@@ -0,0 +1,121 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is matched by the error of a connect which a
+// CircuitBreaker failed without trying the brokers.
+var ErrCircuitOpen = errors.New("nuodb: circuit breaker open")
+
+// CircuitBreaker fails the connects of the Connector configured with it
+// fast, after Threshold consecutive connects failed with a network or
+// connection error, e.g. while the brokers are down, so that the goroutines
+// of the requests don't all block on slow dials and the brokers aren't hit
+// by a storm of connects when they come back:
+//
+//	cfg.Breaker = nuodb.NewCircuitBreaker(5, 10*time.Second)
+//
+// The breaker stays open for Cooldown, failing the connects with an error
+// matching ErrCircuitOpen. Then it lets a single connect through as a
+// probe, while the others still fail fast: a successful probe closes the
+// breaker, and a failed one opens it for another Cooldown. Errors other
+// than a network or connection error, e.g. a wrong password, don't count.
+// Give each Connector a breaker of its own. Set the fields before the
+// Connector is used; CircuitBreaker is otherwise safe for concurrent use.
+type CircuitBreaker struct {
+	Threshold int           // consecutive failed connects which open the breaker
+	Cooldown  time.Duration // before a probe is let through
+
+	mu       sync.Mutex
+	failures int       // consecutive failed connects
+	openedAt time.Time // zero while closed
+	probing  bool      // a probe is in progress
+	lastErr  error     // of the connect which opened the breaker
+	stats    BreakerStats
+}
+
+// BreakerStats are the statistics of a CircuitBreaker.
+type BreakerStats struct {
+	Open     bool  // the breaker fails the connects now
+	Opened   int64 // times the breaker opened
+	Rejected int64 // connects failed fast
+	Probes   int64 // connects let through after a cooldown
+}
+
+// NewCircuitBreaker returns a CircuitBreaker which opens after threshold
+// consecutive failed connects, for cooldown.
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &CircuitBreaker{Threshold: threshold, Cooldown: cooldown}
+}
+
+// Stats returns the statistics of the breaker.
+func (b *CircuitBreaker) Stats() BreakerStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	stats := b.stats
+	stats.Open = !b.openedAt.IsZero()
+	return stats
+}
+
+// allow returns an error matching ErrCircuitOpen unless a connect may try
+// the brokers at now, and whether the connect is the probe of an open
+// breaker. A nil breaker allows every connect.
+func (b *CircuitBreaker) allow(now time.Time) (probe bool, err error) {
+	if b == nil {
+		return false, nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.openedAt.IsZero() {
+		return false, nil
+	}
+	if wait := b.Cooldown - now.Sub(b.openedAt); wait > 0 || b.probing {
+		b.stats.Rejected++
+		if wait < 0 {
+			wait = 0
+		}
+		return false, fmt.Errorf("%w after %d failed connects, retrying in %s: %v", ErrCircuitOpen, b.failures, wait, b.lastErr)
+	}
+	b.probing = true
+	b.stats.Probes++
+	return true, nil
+}
+
+// done records the result of a connect, the probe or not, which ended at
+// now, and reports whether it opened the breaker.
+func (b *CircuitBreaker) done(now time.Time, probe bool, err error) (opened bool) {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if probe {
+		b.probing = false
+	}
+	if err == nil || !errors.Is(err, driver.ErrBadConn) {
+		// the brokers answered
+		b.failures = 0
+		b.openedAt = time.Time{}
+		return false
+	}
+	b.failures++
+	if probe || b.openedAt.IsZero() && b.failures >= b.Threshold {
+		opened = b.openedAt.IsZero()
+		if opened {
+			b.stats.Opened++
+		}
+		b.openedAt = now
+		b.lastErr = err
+	}
+	return opened
+}
@@ -0,0 +1,95 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var none *CircuitBreaker
+	if probe, err := none.allow(time.Now()); probe || err != nil || none.done(time.Now(), false, &Error{Code: NetworkError}) {
+		t.Fatal("Expected a nil breaker to allow every connect")
+	}
+
+	b := NewCircuitBreaker(2, time.Minute)
+	now := time.Now()
+	failed := &Error{Code: ConnectionError, Message: "broker down"}
+	connect := func(err error) (bool, error) {
+		probe, aerr := b.allow(now)
+		if aerr != nil {
+			return false, aerr
+		}
+		return b.done(now, probe, err), nil
+	}
+	if opened, err := connect(&Error{Code: SecurityError}); opened || err != nil {
+		t.Fatal("Expected a security error not to count")
+	}
+	if opened, _ := connect(failed); opened {
+		t.Fatal("Expected the breaker to stay closed below the threshold")
+	}
+	if opened, _ := connect(failed); !opened {
+		t.Fatal("Expected the breaker to open at the threshold")
+	}
+	if _, err := b.allow(now.Add(time.Second)); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected %v, got %v", ErrCircuitOpen, err)
+	}
+
+	now = now.Add(time.Minute)
+	probe, err := b.allow(now)
+	if !probe || err != nil {
+		t.Fatalf("Expected a probe after the cooldown, got %v", err)
+	}
+	if _, err := b.allow(now); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected a single probe, got %v", err)
+	}
+	if b.done(now, false, failed) {
+		t.Fatal("Expected a connect begun before the breaker opened not to reopen it")
+	}
+	if b.done(now, probe, failed) {
+		t.Fatal("Expected a failed probe to keep the breaker open")
+	}
+	if _, err := b.allow(now.Add(time.Second)); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected another cooldown after a failed probe, got %v", err)
+	}
+
+	now = now.Add(time.Minute)
+	if probe, err := b.allow(now); !probe || err != nil || b.done(now, probe, nil) {
+		t.Fatalf("Expected a successful probe, got %v", err)
+	}
+	if _, err := b.allow(now); err != nil {
+		t.Fatalf("Expected the breaker to close after a successful probe, got %v", err)
+	}
+	expected := BreakerStats{Opened: 1, Rejected: 3, Probes: 2}
+	if s := b.Stats(); s != expected {
+		t.Fatalf("Expected %+v, got %+v", expected, s)
+	}
+}
+
+func TestCircuitBreakerConnect(t *testing.T) {
+	faults := NewFaultInjector(1)
+	faults.ConnectRate = 1
+	breaker := NewCircuitBreaker(2, time.Hour)
+	connector, err := NewConnector(&Config{Hosts: []string{"localhost"}, Database: "tests", User: "robinh",
+		Faults: faults, Breaker: breaker})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := connector.Connect(context.Background()); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("Expected a connection error, got %v", err)
+		}
+	}
+	if _, err := connector.Connect(context.Background()); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected %v, got %v", ErrCircuitOpen, err)
+	}
+	if s := faults.Stats(); s.Connects != 2 {
+		t.Fatalf("Expected the open breaker to fail the connect fast, got %+v", s)
+	}
+	if s := breaker.Stats(); !s.Open || s.Opened != 1 || s.Rejected != 1 {
+		t.Fatalf("Unexpected %+v", s)
+	}
+}
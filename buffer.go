@@ -0,0 +1,69 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"sync"
+)
+
+// ReuseBuffers makes a query return its string and bytes values in buffers
+// which are reused for the following rows, instead of allocating them for
+// each row, e.g. to export a large table with little garbage:
+//
+//	rows, err := db.Query("SELECT * FROM events", nuodb.ReuseBuffers(true))
+//	...
+//	var payload sql.RawBytes
+//	err = rows.Scan(&id, &payload)
+//
+// As with sql.RawBytes, a value is only valid until the next call to Next,
+// Scan or Close. database/sql copies the values scanned into other than
+// sql.RawBytes, so only RawBytes gains from the reuse. A Masker must not
+// retain the values it is passed.
+type ReuseBuffers bool
+
+func (r ReuseBuffers) apply(o *callOptions) {
+	o.reuseBuffers = bool(r)
+}
+
+// rowBuffer holds the byte values of the current row with ReuseBuffers.
+type rowBuffer struct {
+	data []byte
+}
+
+// rowBuffers pools the buffers of the closed rows.
+var rowBuffers = sync.Pool{
+	New: func() interface{} { return new(rowBuffer) },
+}
+
+// maxPooledRowBuffer is the size above which a buffer is dropped instead
+// of pooled, so that one huge row doesn't pin its memory.
+const maxPooledRowBuffer = 1 << 20
+
+func getRowBuffer() *rowBuffer {
+	return rowBuffers.Get().(*rowBuffer)
+}
+
+func putRowBuffer(b *rowBuffer) {
+	if cap(b.data) > maxPooledRowBuffer {
+		return
+	}
+	b.reset()
+	rowBuffers.Put(b)
+}
+
+// reset makes the buffer reusable for the next row.
+func (b *rowBuffer) reset() {
+	b.data = b.data[:0]
+}
+
+// copy appends src to the buffer and returns the copy. The capacity of the
+// copy is limited, so that appending to it doesn't overwrite the following
+// values.
+func (b *rowBuffer) copy(src []byte) []byte {
+	if len(src) == 0 {
+		return []byte{}
+	}
+	start := len(b.data)
+	b.data = append(b.data, src...)
+	return b.data[start:len(b.data):len(b.data)]
+}
@@ -0,0 +1,63 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"bytes"
+	"database/sql"
+	"testing"
+)
+
+func TestRowBuffer(t *testing.T) {
+	b := getRowBuffer()
+	defer putRowBuffer(b)
+	first := b.copy([]byte("abc"))
+	second := b.copy([]byte("defg"))
+	if empty := b.copy(nil); empty == nil || len(empty) != 0 {
+		t.Fatalf("Expected an empty value, got %#v", empty)
+	}
+	if string(first) != "abc" || string(second) != "defg" {
+		t.Fatalf("Unexpected %q, %q", first, second)
+	}
+	_ = append(first, 'x') // must not overwrite second
+	if string(second) != "defg" {
+		t.Fatalf("Expected defg, got %q", second)
+	}
+
+	data := b.data
+	b.reset()
+	if reused := b.copy([]byte("hij")); &reused[0] != &data[0] {
+		t.Fatal("Expected the buffer to be reused")
+	}
+}
+
+func TestReuseBuffers(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	exec(t, db, "CREATE TABLE FooBar (id INTEGER, name STRING, data BLOB)")
+	for i := 0; i < 100; i++ {
+		exec(t, db, "INSERT INTO FooBar (id, name, data) VALUES (?, ?, ?)", i, "name", bytes.Repeat([]byte{byte(i)}, i))
+	}
+	rows := query(t, db, "SELECT id, name, data FROM FooBar ORDER BY id", ReuseBuffers(true))
+	defer rows.Close()
+	i := 0
+	for ; rows.Next(); i++ {
+		var id int
+		var name, data sql.RawBytes
+		if err := rows.Scan(&id, &name, &data); err != nil {
+			t.Fatal(err)
+		}
+		if id != i || string(name) != "name" || !bytes.Equal(data, bytes.Repeat([]byte{byte(i)}, i)) {
+			t.Fatalf("Row %d: unexpected %d, %q, %v", i, id, name, data)
+		}
+		if data == nil {
+			t.Fatalf("Row %d: expected empty data, got NULL", i)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if i != 100 {
+		t.Fatalf("Expected 100 rows, got %d", i)
+	}
+}
@@ -0,0 +1,11 @@
+@echo off
+rem Copyright (C) 2013 Timo Linna. All Rights Reserved.
+rem
+rem Builds the C shim into cnuodb.dll with MSVC, from a Developer Command
+rem Prompt of the architecture of the Go toolchain. NUODB_HOME defaults to
+rem the default installation of NuoDB.
+
+if "%NUODB_HOME%"=="" set NUODB_HOME=C:\Program Files\NuoDB
+
+cl /nologo /LD /EHsc /O2 /DCNUODB_EXPORTS /I"%NUODB_HOME%\include" cnuodb.cpp ^
+    /link /LIBPATH:"%NUODB_HOME%\lib" NuoRemote.lib /OUT:cnuodb.dll
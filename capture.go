@@ -0,0 +1,339 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CapturedStatement is a statement, or a transaction boundary, recorded by
+// a StatementCapture.
+type CapturedStatement struct {
+	Time     time.Time       `json:"time"`
+	Conn     uint64          `json:"conn"` // local number of the connection
+	Kind     string          `json:"kind"` // exec, query, begin, commit or rollback
+	SQL      string          `json:"sql,omitempty"`
+	Params   []CapturedParam `json:"params,omitempty"`
+	Duration time.Duration   `json:"duration"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// Kinds of a CapturedStatement.
+const (
+	CaptureExec     = "exec"
+	CaptureQuery    = "query"
+	CaptureBegin    = "begin"
+	CaptureCommit   = "commit"
+	CaptureRollback = "rollback"
+)
+
+// CapturedParam is a snapshot of a parameter of a CapturedStatement. Type
+// is null, int64, float64, bool, string, bytes, time, date, timeofday, lob
+// or out. Value is the value in text form: bytes in base64, times in RFC
+// 3339 format, dates as 2006-01-02 and times of day as 15:04:05.
+// The value of a redacted parameter, a lob or an out parameter is empty.
+type CapturedParam struct {
+	Name     string `json:"name,omitempty"`
+	Type     string `json:"type"`
+	Value    string `json:"value,omitempty"`
+	Redacted bool   `json:"redacted,omitempty"`
+}
+
+// StatementCapture records the statements executed by the driver with their
+// parameters, as JSON lines, so that a problem seen in production can be
+// reproduced against a test database with Replay. Enable the capture with
+// CaptureStatements:
+//
+//	f, err := os.Create("statements.jsonl")
+//	...
+//	capture := nuodb.NewStatementCapture(f)
+//	capture.Redact = func(sql string, param int, name string) bool {
+//		return strings.Contains(sql, "password")
+//	}
+//	nuodb.CaptureStatements(capture)
+//	defer nuodb.CaptureStatements(nil)
+//
+// The capture slows every statement down, so enable it only while
+// reproducing a problem. Batches are not captured. StatementCapture is safe
+// for concurrent use.
+type StatementCapture struct {
+	// Redact tells whether to leave out the value of the parameter of
+	// sql with the ordinal param, counting from 1, and the name, if any.
+	// Replay binds the zero value of the type of a redacted parameter.
+	Redact func(sql string, param int, name string) bool
+
+	mu  sync.Mutex
+	enc *json.Encoder
+	err error
+}
+
+// NewStatementCapture returns a StatementCapture which writes to w.
+func NewStatementCapture(w io.Writer) *StatementCapture {
+	return &StatementCapture{enc: json.NewEncoder(w)}
+}
+
+// Err returns the first error writing the capture, after which nothing
+// more is written.
+func (s *StatementCapture) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+var statementCapture atomic.Value // *StatementCapture
+
+// CaptureStatements starts capturing all statements executed by the driver
+// into s. A nil s stops the capture.
+func CaptureStatements(s *StatementCapture) {
+	statementCapture.Store(s)
+}
+
+// captureStatement records a statement of kind on c which started at
+// start, if the statements are being captured.
+func captureStatement(c *Conn, kind, sql string, args []driver.NamedValue, start time.Time, err *error) {
+	s, _ := statementCapture.Load().(*StatementCapture)
+	if s == nil || *err == driver.ErrSkip {
+		return
+	}
+	cs := &CapturedStatement{Time: start, Conn: c.id, Kind: kind, SQL: sql, Duration: time.Since(start)}
+	for i, arg := range args {
+		redact := s.Redact != nil && s.Redact(sql, i+1, arg.Name)
+		cs.Params = append(cs.Params, captureParam(arg, redact))
+	}
+	if *err != nil {
+		cs.Error = (*err).Error()
+	}
+	s.write(cs)
+}
+
+func (s *StatementCapture) write(cs *CapturedStatement) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err == nil {
+		s.err = s.enc.Encode(cs)
+	}
+}
+
+func captureParam(arg driver.NamedValue, redact bool) CapturedParam {
+	p := CapturedParam{Name: arg.Name, Redacted: redact}
+	switch v := arg.Value.(type) {
+	case nil:
+		p.Type = "null"
+		p.Redacted = false
+		return p
+	case int64:
+		p.Type, p.Value = "int64", strconv.FormatInt(v, 10)
+	case float64:
+		p.Type, p.Value = "float64", strconv.FormatFloat(v, 'g', -1, 64)
+	case bool:
+		p.Type, p.Value = "bool", strconv.FormatBool(v)
+	case string:
+		p.Type, p.Value = "string", v
+	case []byte:
+		p.Type, p.Value = "bytes", base64.StdEncoding.EncodeToString(v)
+	case time.Time:
+		p.Type, p.Value = "time", v.Format(time.RFC3339Nano)
+	case Date:
+		p.Type, p.Value = "date", v.String()
+	case TimeOfDay:
+		p.Type, p.Value = "timeofday", v.String()
+	case LobSource:
+		p.Type, p.Value = "lob", ""
+	case sql.Out:
+		p.Type, p.Value = "out", ""
+	default:
+		p.Type, p.Value = fmt.Sprintf("%T", v), ""
+	}
+	if p.Redacted {
+		p.Value = ""
+	}
+	return p
+}
+
+// redactedValues are the zero values bound for the redacted parameters.
+var redactedValues = map[string]string{
+	"int64":     "0",
+	"float64":   "0",
+	"bool":      "false",
+	"time":      time.Time{}.Format(time.RFC3339Nano),
+	"date":      Date{1, time.January, 1}.String(),
+	"timeofday": TimeOfDay{}.String(),
+}
+
+// value returns the value to bind for the parameter on replay. A lob is
+// bound as NULL.
+func (p CapturedParam) value() (interface{}, error) {
+	s := p.Value
+	if p.Redacted {
+		s = redactedValues[p.Type]
+	}
+	var v interface{}
+	var err error
+	switch p.Type {
+	case "null", "lob":
+	case "out":
+		v = sql.Out{Dest: new(interface{})}
+	case "int64":
+		v, err = strconv.ParseInt(s, 10, 64)
+	case "float64":
+		v, err = strconv.ParseFloat(s, 64)
+	case "bool":
+		v, err = strconv.ParseBool(s)
+	case "string":
+		v = s
+	case "bytes":
+		v, err = base64.StdEncoding.DecodeString(s)
+	case "time":
+		v, err = time.Parse(time.RFC3339Nano, s)
+	case "date":
+		v, err = ParseDate(s)
+	case "timeofday":
+		v, err = ParseTimeOfDay(s)
+	default:
+		return nil, fmt.Errorf("nuodb: can't replay a parameter of type %s", p.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("nuodb: invalid %s parameter: %q", p.Type, p.Value)
+	}
+	if p.Name != "" {
+		return sql.Named(p.Name, v), nil
+	}
+	return v, nil
+}
+
+// ReplayMismatch is a statement whose replay failed although it succeeded
+// when captured, or the other way round.
+type ReplayMismatch struct {
+	Line      int // of the statement in the capture, counting from 1
+	Statement CapturedStatement
+	Err       error // of the replay, if any
+}
+
+// ReplayResult is the outcome of Replay.
+type ReplayResult struct {
+	Statements int // replayed
+	Mismatches []ReplayMismatch
+}
+
+// Replay executes the statements of a capture written by a
+// StatementCapture again on db, e.g. on a test database restored from a
+// backup, and reports the statements whose outcome differs:
+//
+//	f, err := os.Open("statements.jsonl")
+//	...
+//	result, err := nuodb.Replay(ctx, db, f)
+//
+// The statements of each captured connection are replayed in order on a
+// connection of their own, within their transactions. The rows of the
+// queries are read and discarded. Replay stops at the first error which
+// isn't the outcome of a statement, like a malformed capture.
+func Replay(ctx context.Context, db *sql.DB, r io.Reader) (*ReplayResult, error) {
+	result := &ReplayResult{}
+	conns := make(map[uint64]*replayConn)
+	defer func() {
+		for _, rc := range conns {
+			rc.close()
+		}
+	}()
+	dec := json.NewDecoder(r)
+	for line := 1; ; line++ {
+		var cs CapturedStatement
+		if err := dec.Decode(&cs); err == io.EOF {
+			return result, nil
+		} else if err != nil {
+			return result, fmt.Errorf("nuodb: capture line %d: %w", line, err)
+		}
+		rc, ok := conns[cs.Conn]
+		if !ok {
+			conn, err := db.Conn(ctx)
+			if err != nil {
+				return result, err
+			}
+			rc = &replayConn{conn: conn}
+			conns[cs.Conn] = rc
+		}
+		err := rc.replay(ctx, &cs)
+		if err != nil && ctx.Err() != nil {
+			return result, err
+		}
+		result.Statements++
+		if (err != nil) != (cs.Error != "") {
+			result.Mismatches = append(result.Mismatches, ReplayMismatch{Line: line, Statement: cs, Err: err})
+		}
+	}
+}
+
+// replayConn replays the statements of a captured connection.
+type replayConn struct {
+	conn *sql.Conn
+	tx   *sql.Tx // the open transaction, if any
+}
+
+func (rc *replayConn) replay(ctx context.Context, cs *CapturedStatement) error {
+	switch cs.Kind {
+	case CaptureBegin:
+		tx, err := rc.conn.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		rc.tx = tx
+		return nil
+	case CaptureCommit, CaptureRollback:
+		if rc.tx == nil {
+			return fmt.Errorf("nuodb: %s without a transaction", cs.Kind)
+		}
+		tx := rc.tx
+		rc.tx = nil
+		if cs.Kind == CaptureCommit {
+			return tx.Commit()
+		}
+		return tx.Rollback()
+	case CaptureExec, CaptureQuery:
+	default:
+		return fmt.Errorf("nuodb: unknown statement kind %q", cs.Kind)
+	}
+	args := make([]interface{}, len(cs.Params))
+	for i, p := range cs.Params {
+		v, err := p.value()
+		if err != nil {
+			return err
+		}
+		args[i] = v
+	}
+	var q interface {
+		ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+		QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	} = rc.conn
+	if rc.tx != nil {
+		q = rc.tx
+	}
+	if cs.Kind == CaptureExec {
+		_, err := q.ExecContext(ctx, cs.SQL, args...)
+		return err
+	}
+	rows, err := q.QueryContext(ctx, cs.SQL, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+	}
+	return rows.Err()
+}
+
+func (rc *replayConn) close() {
+	if rc.tx != nil {
+		rc.tx.Rollback()
+	}
+	rc.conn.Close()
+}
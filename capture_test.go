@@ -0,0 +1,111 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCaptureParams(t *testing.T) {
+	at := time.Date(2013, 5, 6, 7, 8, 9, 123456789, time.UTC)
+	for _, v := range []interface{}{nil, int64(-42), 0.1, true, "text", []byte{0, 1, 255}, at} {
+		p := captureParam(driver.NamedValue{Ordinal: 1, Value: v}, false)
+		replayed, err := p.value()
+		if err != nil {
+			t.Fatalf("%#v: %s", v, err)
+		}
+		if !reflect.DeepEqual(replayed, v) {
+			t.Errorf("Expected %#v, got %#v (%+v)", v, replayed, p)
+		}
+	}
+
+	p := captureParam(driver.NamedValue{Name: "secret", Ordinal: 1, Value: "hunter2"}, true)
+	if p.Value != "" || !p.Redacted {
+		t.Fatalf("Expected a redacted value, got %+v", p)
+	}
+	if v, err := p.value(); err != nil || !reflect.DeepEqual(v, sql.Named("secret", "")) {
+		t.Fatalf("Expected the zero value, got %#v (%v)", v, err)
+	}
+	p = captureParam(driver.NamedValue{Ordinal: 1, Value: at}, true)
+	if v, err := p.value(); err != nil || !v.(time.Time).IsZero() {
+		t.Fatalf("Expected the zero time, got %#v (%v)", v, err)
+	}
+	if _, err := (CapturedParam{Type: "int64", Value: "x"}).value(); err == nil {
+		t.Fatal("Expected an invalid value")
+	}
+}
+
+func TestCaptureStatement(t *testing.T) {
+	var buf bytes.Buffer
+	s := NewStatementCapture(&buf)
+	s.Redact = func(sql string, param int, name string) bool { return param == 2 }
+	CaptureStatements(s)
+	defer CaptureStatements(nil)
+
+	c := &Conn{id: 3}
+	err := errors.New("nuodb: failed")
+	captureStatement(c, CaptureExec, "UPDATE t SET a = ? WHERE b = ?",
+		[]driver.NamedValue{{Ordinal: 1, Value: int64(1)}, {Ordinal: 2, Value: "x"}}, time.Now(), &err)
+	skipped := driver.ErrSkip
+	captureStatement(c, CaptureQuery, "SELECT 1 FROM DUAL", nil, time.Now(), &skipped)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("Expected 1 line, got %q", lines)
+	}
+	var cs CapturedStatement
+	if err := json.Unmarshal([]byte(lines[0]), &cs); err != nil {
+		t.Fatal(err)
+	}
+	expected := []CapturedParam{{Type: "int64", Value: "1"}, {Type: "string", Redacted: true}}
+	if cs.Conn != 3 || cs.Kind != CaptureExec || cs.Error != "nuodb: failed" || !reflect.DeepEqual(cs.Params, expected) {
+		t.Fatalf("Unexpected capture %+v", cs)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	exec(t, db, "CREATE TABLE tests.FooBar (id INTEGER, name STRING)")
+
+	var buf bytes.Buffer
+	CaptureStatements(NewStatementCapture(&buf))
+	exec(t, db, "INSERT INTO tests.FooBar VALUES (?, ?)", 1, "one")
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Exec("INSERT INTO tests.FooBar VALUES (?, ?)", 2, "two"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("INSERT INTO NoSuchTable VALUES (1)"); err == nil {
+		t.Fatal("Expected an error")
+	}
+	CaptureStatements(nil)
+
+	// the replay runs on other connections, so the names are qualified
+	exec(t, db, "DELETE FROM tests.FooBar")
+	result, err := Replay(context.Background(), db, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Statements < 5 || len(result.Mismatches) != 0 {
+		t.Fatalf("Unexpected result %+v", result)
+	}
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM tests.FooBar").Scan(&count); err != nil || count != 2 {
+		t.Fatalf("Expected 2 replayed rows, got %d (%v)", count, err)
+	}
+}
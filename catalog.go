@@ -0,0 +1,163 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"database/sql"
+)
+
+// TableInfo is a table or a view, as listed in SYSTEM.TABLES.
+type TableInfo struct {
+	Schema  string
+	Name    string
+	Type    string // e.g. TABLE or VIEW
+	Remarks string // the comment of the table, if any
+}
+
+// ColumnInfo is a column of a table, as listed in SYSTEM.FIELDS.
+type ColumnInfo struct {
+	Schema    string
+	Table     string
+	Name      string
+	Position  int    // of the column in the table, from 1
+	DataType  string // e.g. INTEGER or STRING
+	Length    int    // of a character or binary column
+	Precision int    // of a numeric column
+	Scale     int    // of a numeric column
+	Nullable  bool
+	Default   sql.NullString // the expression of the default value, if any
+	Remarks   string         // the comment of the column, if any
+}
+
+// IndexInfo is an index of a table, as listed in SYSTEM.INDEXES.
+type IndexInfo struct {
+	Schema  string
+	Table   string
+	Name    string
+	Primary bool // the primary key
+	Unique  bool // also for the primary key
+	Columns []string
+}
+
+// NuoDB index types of SYSTEM.INDEXES.
+const (
+	indexPrimary = 0
+	indexUnique  = 1
+)
+
+// fieldNotNull is the flag of SYSTEM.FIELDS of a NOT NULL column.
+const fieldNotNull = 1
+
+// ListSchemas returns the names of the schemas of the database, e.g. for a
+// migration or a code generation tool.
+func ListSchemas(ctx context.Context, db Queryer) ([]string, error) {
+	rows, err := db.QueryContext(ctx, "SELECT SCHEMA FROM SYSTEM.SCHEMAS ORDER BY SCHEMA")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var schemas []string
+	for rows.Next() {
+		var schema string
+		if err := rows.Scan(&schema); err != nil {
+			return nil, err
+		}
+		schemas = append(schemas, schema)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return schemas, nil
+}
+
+// ListTables returns the tables and the views of schema. The names are
+// matched case insensitively, here and in ListColumns and ListIndexes.
+func ListTables(ctx context.Context, db Queryer, schema string) ([]TableInfo, error) {
+	rows, err := db.QueryContext(ctx, `SELECT SCHEMA, TABLENAME, TYPE, REMARKS FROM SYSTEM.TABLES
+		WHERE UPPER(SCHEMA) = UPPER(?) ORDER BY TABLENAME`, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var tables []TableInfo
+	for rows.Next() {
+		var t TableInfo
+		var remarks sql.NullString
+		if err := rows.Scan(&t.Schema, &t.Name, &t.Type, &remarks); err != nil {
+			return nil, err
+		}
+		t.Remarks = remarks.String
+		tables = append(tables, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return tables, nil
+}
+
+// ListColumns returns the columns of a table, in their order in the table.
+func ListColumns(ctx context.Context, db Queryer, schema, table string) ([]ColumnInfo, error) {
+	rows, err := db.QueryContext(ctx, `SELECT F.SCHEMA, F.TABLENAME, F.FIELD, F.FIELDID, D.NAME,
+		F.LENGTH, F.PRECISION, F.SCALE, F.FLAGS, F.DEFAULTVALUE, F.REMARKS
+		FROM SYSTEM.FIELDS F JOIN SYSTEM.DATATYPES D ON F.DATATYPE = D.ID
+		WHERE UPPER(F.SCHEMA) = UPPER(?) AND UPPER(F.TABLENAME) = UPPER(?) ORDER BY F.FIELDID`, schema, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var columns []ColumnInfo
+	for rows.Next() {
+		var c ColumnInfo
+		var length, precision, scale, flags sql.NullInt64
+		var remarks sql.NullString
+		if err := rows.Scan(&c.Schema, &c.Table, &c.Name, &c.Position, &c.DataType,
+			&length, &precision, &scale, &flags, &c.Default, &remarks); err != nil {
+			return nil, err
+		}
+		c.Position++ // FIELDID is from 0
+		c.Length, c.Precision, c.Scale = int(length.Int64), int(precision.Int64), int(scale.Int64)
+		c.Nullable = flags.Int64&fieldNotNull == 0
+		c.Remarks = remarks.String
+		columns = append(columns, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return columns, nil
+}
+
+// ListIndexes returns the indexes of a table, with their columns in the
+// order of the index.
+func ListIndexes(ctx context.Context, db Queryer, schema, table string) ([]IndexInfo, error) {
+	rows, err := db.QueryContext(ctx, `SELECT I.SCHEMA, I.TABLENAME, I.INDEXNAME, I.INDEXTYPE, F.FIELD
+		FROM SYSTEM.INDEXES I JOIN SYSTEM.INDEXFIELDS F
+		ON I.SCHEMA = F.SCHEMA AND I.TABLENAME = F.TABLENAME AND I.INDEXNAME = F.INDEXNAME
+		WHERE UPPER(I.SCHEMA) = UPPER(?) AND UPPER(I.TABLENAME) = UPPER(?)
+		ORDER BY I.INDEXNAME, F.POSITION`, schema, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var indexes []IndexInfo
+	for rows.Next() {
+		var i IndexInfo
+		var indexType int
+		var column string
+		if err := rows.Scan(&i.Schema, &i.Table, &i.Name, &indexType, &column); err != nil {
+			return nil, err
+		}
+		if n := len(indexes); n > 0 && indexes[n-1].Name == i.Name {
+			indexes[n-1].Columns = append(indexes[n-1].Columns, column)
+			continue
+		}
+		i.Primary = indexType == indexPrimary
+		i.Unique = indexType == indexPrimary || indexType == indexUnique
+		i.Columns = []string{column}
+		indexes = append(indexes, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return indexes, nil
+}
@@ -0,0 +1,75 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestListCatalog(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	exec(t, db, "CREATE TABLE tests.FooBarCatalog (id BIGINT NOT NULL PRIMARY KEY, name STRING, amount DECIMAL(10,2) DEFAULT 0)")
+	exec(t, db, "CREATE UNIQUE INDEX FooBarCatalog_name_idx ON tests.FooBarCatalog (name, amount)")
+
+	ctx := context.Background()
+	schemas, err := ListSchemas(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found bool
+	for _, schema := range schemas {
+		found = found || schema == "TESTS"
+	}
+	if !found {
+		t.Fatalf("Expected schema TESTS in %v", schemas)
+	}
+
+	tables, err := ListTables(ctx, db, "tests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	found = false
+	for _, table := range tables {
+		found = found || table.Name == "FOOBARCATALOG" && table.Type == "TABLE"
+	}
+	if !found {
+		t.Fatalf("Expected table FOOBARCATALOG in %+v", tables)
+	}
+
+	columns, err := ListColumns(ctx, db, "tests", "foobarcatalog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(columns) != 3 {
+		t.Fatalf("Expected 3 columns, got %+v", columns)
+	}
+	if c := columns[0]; c.Name != "ID" || c.Position != 1 || c.DataType != "BIGINT" || c.Nullable {
+		t.Fatalf("Unexpected column %+v", c)
+	}
+	if c := columns[1]; c.Name != "NAME" || c.Position != 2 || !c.Nullable || c.Default.Valid {
+		t.Fatalf("Unexpected column %+v", c)
+	}
+	if c := columns[2]; c.Name != "AMOUNT" || c.Precision != 10 || c.Scale != 2 || !c.Default.Valid {
+		t.Fatalf("Unexpected column %+v", c)
+	}
+
+	indexes, err := ListIndexes(ctx, db, "tests", "foobarcatalog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var primary, unique bool
+	for _, i := range indexes {
+		switch {
+		case i.Primary:
+			primary = i.Unique && reflect.DeepEqual(i.Columns, []string{"ID"})
+		case i.Name == "FOOBARCATALOG_NAME_IDX":
+			unique = i.Unique && reflect.DeepEqual(i.Columns, []string{"NAME", "AMOUNT"})
+		}
+	}
+	if !primary || !unique {
+		t.Fatalf("Unexpected indexes %+v", indexes)
+	}
+}
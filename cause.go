@@ -0,0 +1,33 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+//go:build go1.20
+// +build go1.20
+
+package nuodb
+
+import "context"
+
+// contextError returns the error of a done ctx, annotated with the cause
+// of the cancellation if it was given with context.WithCancelCause or the
+// like, e.g. to tell a shutdown from a user abort. The error matches both
+// the context error and the cause with errors.Is.
+func contextError(ctx context.Context) error {
+	err := ctx.Err()
+	if cause := context.Cause(ctx); err != nil && cause != nil && cause != err {
+		return &causeError{err: err, cause: cause}
+	}
+	return err
+}
+
+type causeError struct {
+	err   error // context.Canceled or context.DeadlineExceeded
+	cause error
+}
+
+func (e *causeError) Error() string {
+	return e.err.Error() + ": " + e.cause.Error()
+}
+
+func (e *causeError) Unwrap() []error {
+	return []error{e.err, e.cause}
+}
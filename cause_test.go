@@ -0,0 +1,32 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+//go:build go1.20
+// +build go1.20
+
+package nuodb
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestContextErrorCause(t *testing.T) {
+	shutdown := errors.New("shutting down")
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(shutdown)
+	_, err := getMicrosecondsUntilDeadline(ctx)
+	if !errors.Is(err, context.Canceled) || !errors.Is(err, shutdown) {
+		t.Fatalf("Expected the cancellation and its cause, got %v", err)
+	}
+	if want := "context canceled: shutting down"; err.Error() != want {
+		t.Fatalf("Expected %q, got %q", want, err.Error())
+	}
+
+	// Without a cause, the context error is returned as is
+	ctx, stop := context.WithCancel(context.Background())
+	stop()
+	if err := contextError(ctx); err != context.Canceled {
+		t.Fatalf("Expected %v, got %v", context.Canceled, err)
+	}
+}
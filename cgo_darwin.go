@@ -0,0 +1,13 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+//go:build darwin && !nuodb_pkgconfig && !nuodb_env
+// +build darwin,!nuodb_pkgconfig,!nuodb_env
+
+package nuodb
+
+// On macOS the NuoDB client library of the default installation is
+// /opt/nuodb/lib/libNuoRemote.dylib.
+
+// #cgo CPPFLAGS: -I/opt/nuodb/include
+// #cgo LDFLAGS: -L/opt/nuodb/lib -Wl,-rpath,/opt/nuodb/lib -lNuoRemote
+import "C"
@@ -0,0 +1,14 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+//go:build !nuodb_pkgconfig && !nuodb_env && !darwin && !windows
+// +build !nuodb_pkgconfig,!nuodb_env,!darwin,!windows
+
+package nuodb
+
+// The C shim, cnuodb.cpp, is compiled by cgo with the package, and linked
+// with the NuoDB client library of the default installation, /opt/nuodb.
+// The nuodb_pkgconfig and nuodb_env build tags find it elsewhere.
+
+// #cgo CPPFLAGS: -I/opt/nuodb/include
+// #cgo LDFLAGS: -L/opt/nuodb/lib64 -Wl,-rpath,/opt/nuodb/lib64 -lNuoRemote
+import "C"
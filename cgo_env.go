@@ -0,0 +1,14 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+//go:build nuodb_env && !windows
+// +build nuodb_env,!windows
+
+package nuodb
+
+// The nuodb_env build tag leaves the paths of the NuoDB client library to
+// the CGO_CPPFLAGS and CGO_LDFLAGS environment variables, e.g.
+//
+//	CGO_CPPFLAGS=-I$NUODB_HOME/include CGO_LDFLAGS="-L$NUODB_HOME/lib64 -Wl,-rpath,$NUODB_HOME/lib64" go build -tags nuodb_env
+
+// #cgo LDFLAGS: -lNuoRemote
+import "C"
@@ -0,0 +1,13 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+//go:build nuodb_pkgconfig && !windows
+// +build nuodb_pkgconfig,!windows
+
+package nuodb
+
+// The nuodb_pkgconfig build tag takes the flags of the NuoDB client library
+// from the nuodb package of pkg-config, e.g. of a nuodb.pc in a directory of
+// PKG_CONFIG_PATH.
+
+// #cgo pkg-config: nuodb
+import "C"
@@ -0,0 +1,12 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+// On Windows the C shim isn't compiled by cgo, whose MinGW can't link with
+// the C++ API of the NuoDB client library built with MSVC. The shim is
+// built with MSVC into cnuodb.dll by build_windows.cmd instead, and linked
+// from the package directory. cnuodb.dll and NuoRemote.dll must be in the
+// PATH of the program.
+
+// #cgo LDFLAGS: -L${SRCDIR} -lcnuodb
+import "C"
@@ -0,0 +1,81 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	runtimedebug "runtime/debug"
+	"strconv"
+	"sync"
+)
+
+const modulePath = "github.com/tilinna/go-nuodb"
+
+// DriverVersion returns the version of the driver module built into the
+// program, e.g. v1.2.0, or (devel) if it is unknown, e.g. in a build
+// without module information.
+func DriverVersion() string {
+	info, ok := runtimedebug.ReadBuildInfo()
+	if !ok {
+		return "(devel)"
+	}
+	if info.Main.Path == modulePath {
+		return info.Main.Version
+	}
+	for _, m := range info.Deps {
+		if m.Path == modulePath {
+			if m.Replace != nil && m.Replace.Version != "" {
+				return m.Replace.Version
+			}
+			return m.Version
+		}
+	}
+	return "(devel)"
+}
+
+var (
+	clientInfoOnce sync.Once
+	clientInfo     string
+)
+
+// defaultClientInfo returns the clientInfo of the connections which don't
+// set it: the program, the host and the driver version, e.g.
+// "orders-api on host1 (go-nuodb v1.2.0)".
+func defaultClientInfo() string {
+	clientInfoOnce.Do(func() {
+		program := "unknown"
+		if len(os.Args) > 0 {
+			program = filepath.Base(os.Args[0])
+		}
+		host, err := os.Hostname()
+		if err != nil {
+			host = "unknown"
+		}
+		clientInfo = fmt.Sprintf("%s on %s (go-nuodb %s)", program, host, DriverVersion())
+	})
+	return clientInfo
+}
+
+// clientProps returns props with the clientInfo and clientProcessID
+// properties filled in, unless they are set, so that the connections of a
+// program can be told apart in the NuoDB admin tools.
+func clientProps(props map[string]string) map[string]string {
+	_, info := props[propClientInfo]
+	_, pid := props[propClientProcessID]
+	if info && pid {
+		return props
+	}
+	filled := make(map[string]string, len(props)+2)
+	for k, v := range props {
+		filled[k] = v
+	}
+	if !info {
+		filled[propClientInfo] = defaultClientInfo()
+	}
+	if !pid {
+		filled[propClientProcessID] = strconv.Itoa(os.Getpid())
+	}
+	return filled
+}
@@ -0,0 +1,38 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestClientProps(t *testing.T) {
+	props := map[string]string{"schema": "tests"}
+	filled := clientProps(props)
+	if len(props) != 1 {
+		t.Fatalf("Expected the properties to be copied, got %v", props)
+	}
+	if info := filled[propClientInfo]; !strings.Contains(info, " on ") || !strings.Contains(info, "(go-nuodb ") {
+		t.Fatalf("Unexpected clientInfo %q", info)
+	}
+	if pid := filled[propClientProcessID]; pid != strconv.Itoa(os.Getpid()) {
+		t.Fatalf("Expected the process id, got %q", pid)
+	}
+	if filled["schema"] != "tests" {
+		t.Fatalf("Expected the other properties, got %v", filled)
+	}
+
+	set := map[string]string{propClientInfo: "orders-api", propClientProcessID: "1"}
+	if filled := clientProps(set); filled[propClientInfo] != "orders-api" || filled[propClientProcessID] != "1" {
+		t.Fatalf("Expected the set properties to be kept, got %v", filled)
+	}
+}
+
+func TestDriverVersion(t *testing.T) {
+	if v := DriverVersion(); v == "" {
+		t.Fatal("Expected a version")
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+// #include "cnuodb.h"
+// #include <stdlib.h>
+import "C"
+import (
+	"sync"
+	"unsafe"
+)
+
+// closeQueue holds the statements and result sets which Stmt.Close and
+// Rows.Close of a connection with the deferClose property queued rather
+// than closed, so that the calls into the client library tearing them down
+// don't add to the latency of the caller. A goroutine of the connection
+// closes them in order, holding callMu like any other call. The queue is
+// flushed before the pool reuses the connection and before it is closed.
+type closeQueue struct {
+	mu      sync.Mutex
+	pending []closeHandles
+	done    chan struct{} // closed when the goroutine has emptied the queue; nil if not running
+}
+
+// closeHandles are the handles of a closed statement or result set, freed
+// by the goroutine of the closeQueue.
+type closeHandles struct {
+	rs     *C.struct_nuodb_resultset
+	st     *C.struct_nuodb_statement
+	buffer unsafe.Pointer // of the fetched rows, see rowBatch
+	lobs   []*lobParam    // bound to st, released after it is closed
+}
+
+// queueClose queues h to be closed by the goroutine of c, starting it if
+// it isn't running.
+func (c *Conn) queueClose(h closeHandles) {
+	q := &c.closes
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pending = append(q.pending, h)
+	if q.done == nil {
+		q.done = make(chan struct{})
+		go c.closeQueued(q.done)
+	}
+}
+
+// closeQueued closes the queued handles until the queue is empty, and then
+// closes done.
+func (c *Conn) closeQueued(done chan struct{}) {
+	q := &c.closes
+	for {
+		q.mu.Lock()
+		pending := q.pending
+		q.pending = nil
+		if len(pending) == 0 {
+			q.done = nil
+			q.mu.Unlock()
+			close(done)
+			return
+		}
+		q.mu.Unlock()
+		c.callMu.Lock() // not lock, which would take this for a call of the pool's user
+		for _, h := range pending {
+			c.closeHandles(h)
+		}
+		c.callMu.Unlock()
+	}
+}
+
+// closeHandles frees h, with callMu held. A failure to close is only
+// logged, as the caller of Close has already returned.
+func (c *Conn) closeHandles(h closeHandles) {
+	if h.buffer != nil {
+		C.free(h.buffer)
+	}
+	if c.db == nil {
+		return // closed with the connection
+	}
+	rc := C.nuodb_resultset_close(c.db, &h.rs)
+	if st := C.nuodb_statement_close(c.db, &h.st); rc == 0 {
+		rc = st
+	}
+	releaseLobs(h.lobs)
+	if rc != 0 {
+		c.log(LevelWarn, "deferred close failed", errorKeyvals(c.lastError(rc))...)
+	}
+}
+
+// flushCloses waits until the queued handles of c are closed.
+func (c *Conn) flushCloses() {
+	q := &c.closes
+	q.mu.Lock()
+	done := q.done
+	q.mu.Unlock()
+	if done != nil {
+		<-done
+	}
+}
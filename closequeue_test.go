@@ -0,0 +1,55 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestCloseQueue(t *testing.T) {
+	c := &Conn{}
+	for i := 0; i < 100; i++ {
+		c.queueClose(closeHandles{})
+	}
+	c.flushCloses()
+	if len(c.closes.pending) != 0 || c.closes.done != nil {
+		t.Fatalf("Expected an empty queue, got %d pending", len(c.closes.pending))
+	}
+	c.flushCloses() // nothing queued
+}
+
+func TestDeferCloseOption(t *testing.T) {
+	setup := testConn(t)
+	defer setup.Close()
+	exec(t, setup, "CREATE TABLE tests.FooBarDeferred (id BIGINT)")
+	exec(t, setup, "INSERT INTO tests.FooBarDeferred VALUES (1), (2), (3)")
+
+	db, err := sql.Open("nuodb", default_dsn+"&deferClose=true")
+	if err != nil {
+		t.Fatal("sql.Open:", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	stmt, err := db.Prepare("SELECT COUNT(*) FROM tests.FooBarDeferred WHERE id >= ?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 50; i++ {
+		var n int
+		if err := stmt.QueryRow(i%3 + 1).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		if n != 3-i%3 {
+			t.Fatalf("Expected %d rows, got %d", 3-i%3, n)
+		}
+		rows, err := db.Query("SELECT id FROM tests.FooBarDeferred")
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows.Close()
+	}
+	if err := stmt.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
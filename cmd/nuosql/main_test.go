@@ -0,0 +1,53 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestComplete(t *testing.T) {
+	for input, expected := range map[string]bool{
+		"SELECT 1 FROM DUAL;\n":                    true,
+		"SELECT 1\nFROM DUAL;\n":                   true,
+		"SELECT 1 FROM DUAL\n":                     false,
+		"SELECT ';\n":                              false,
+		"SELECT ';\n' FROM DUAL;\n":                true,
+		"/* ;\n":                                   false,
+		"CREATE PROCEDURE p() AS\n  x = 1;\n":      false,
+		"CREATE PROCEDURE p() AS\n END_PROCEDURE;": true,
+	} {
+		if complete(input) != expected {
+			t.Errorf("%q: expected complete %v", input, expected)
+		}
+	}
+}
+
+func TestWriters(t *testing.T) {
+	columns := []string{"ID", "NAME", "BORN"}
+	rows := [][]interface{}{
+		{int64(1), []byte("Robin, Hood"), time.Date(1160, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{int64(2), nil, nil},
+	}
+	for format, expected := range map[string]string{
+		"table": "ID  NAME         BORN\n--  ----         ----\n1   Robin, Hood  1160-01-02T00:00:00Z\n2   NULL         NULL\n\n2 rows\n\n",
+		"csv":   "ID,NAME,BORN\n1,\"Robin, Hood\",1160-01-02T00:00:00Z\n2,,\n",
+		"json":  "{\"ID\":1,\"NAME\":\"Robin, Hood\",\"BORN\":\"1160-01-02T00:00:00Z\"}\n{\"ID\":2,\"NAME\":null,\"BORN\":null}\n",
+	} {
+		var out bytes.Buffer
+		w := writers[format](&out, columns)
+		for _, row := range rows {
+			if err := w.row(row); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := w.flush(); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != expected {
+			t.Errorf("%s: expected\n%q, got\n%q", format, expected, out.String())
+		}
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// resultWriter writes the rows of a result set.
+type resultWriter interface {
+	row(values []interface{}) error
+	flush() error
+}
+
+// writers are the resultWriters by the -format flag.
+var writers = map[string]func(io.Writer, []string) resultWriter{
+	"table": newTableWriter,
+	"csv":   newCSVWriter,
+	"json":  newJSONWriter,
+}
+
+// text returns a value scanned into an interface{} as text, and false for
+// NULL.
+func text(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case nil:
+		return "", false
+	case []byte:
+		return string(v), true
+	case time.Time:
+		return v.Format(time.RFC3339Nano), true
+	default:
+		return fmt.Sprint(v), true
+	}
+}
+
+// tableWriter writes the rows aligned in columns under a header, with the
+// number of rows after them.
+type tableWriter struct {
+	tw *tabwriter.Writer
+	n  int
+}
+
+func newTableWriter(w io.Writer, columns []string) resultWriter {
+	t := &tableWriter{tw: tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)}
+	fmt.Fprintln(t.tw, strings.Join(columns, "\t"))
+	dashes := make([]string, len(columns))
+	for i, c := range columns {
+		dashes[i] = strings.Repeat("-", len(c))
+	}
+	fmt.Fprintln(t.tw, strings.Join(dashes, "\t"))
+	return t
+}
+
+func (t *tableWriter) row(values []interface{}) error {
+	fields := make([]string, len(values))
+	for i, v := range values {
+		s, ok := text(v)
+		if !ok {
+			s = "NULL"
+		}
+		// a tab or a newline would break the alignment
+		fields[i] = strings.NewReplacer("\t", " ", "\n", " ").Replace(s)
+	}
+	t.n++
+	_, err := fmt.Fprintln(t.tw, strings.Join(fields, "\t"))
+	return err
+}
+
+func (t *tableWriter) flush() error {
+	if err := t.tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(t.tw, "\n%d rows\n\n", t.n)
+	if err != nil {
+		return err
+	}
+	return t.tw.Flush()
+}
+
+// csvWriter writes the rows as CSV records after a header record. NULL is
+// an empty field.
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer, columns []string) resultWriter {
+	c := &csvWriter{w: csv.NewWriter(w)}
+	_ = c.w.Write(columns)
+	return c
+}
+
+func (c *csvWriter) row(values []interface{}) error {
+	record := make([]string, len(values))
+	for i, v := range values {
+		record[i], _ = text(v)
+	}
+	return c.w.Write(record)
+}
+
+func (c *csvWriter) flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// jsonWriter writes each row as a JSON object of its columns on a line of
+// its own. The numbers and the booleans stay JSON numbers and booleans.
+type jsonWriter struct {
+	enc     *json.Encoder
+	columns []string
+}
+
+func newJSONWriter(w io.Writer, columns []string) resultWriter {
+	return &jsonWriter{enc: json.NewEncoder(w), columns: columns}
+}
+
+func (j *jsonWriter) row(values []interface{}) error {
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, v := range values {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		name, _ := json.Marshal(j.columns[i])
+		b.Write(name)
+		b.WriteByte(':')
+		switch v.(type) {
+		case nil, bool, int64, float64:
+		default:
+			v, _ = text(v)
+		}
+		value, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	// re-encoded to keep the order of the columns
+	return j.enc.Encode(json.RawMessage(b.String()))
+}
+
+func (j *jsonWriter) flush() error {
+	return nil
+}
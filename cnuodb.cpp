@@ -1,24 +1,55 @@
+//go:build !windows
+// +build !windows
+
 /*
     Copyright (C) 2013 Timo Linna. All Rights Reserved.
 */
 #include <cstddef>
 #include "cnuodb.h"
 #include "NuoDB.h"
+#include <algorithm>
 #include <cstring>
+#include <mutex>
 #include <string>
+#include <vector>
 
 using namespace NuoDB;
 
 struct nuodb {
     Connection *conn;
     std::string error;
+    std::string sqlstate;
+    std::vector<int> warningCodes; // of the last statement executed
+    std::vector<std::string> warningMessages;
+
+    // The statement of nuodb_execute or nuodb_query in progress, which
+    // nuodb_cancel cancels from another thread, and whether it has.
+    std::mutex cancelMutex;
+    Statement *running = 0;
+    bool cancelled = false;
 };
 
 static int setError(struct nuodb *db, SQLException &e) {
+    db->warningCodes.clear();
+    db->warningMessages.clear();
     db->error.assign(e.getText());
+    const char *sqlstate = e.getSQLState();
+    db->sqlstate.assign(sqlstate ? sqlstate : "");
     return e.getSqlcode();
 }
 
+// saveWarnings replaces the warnings of the connection with those of the
+// statement just executed.
+static void saveWarnings(struct nuodb *db, Statement *stmt) {
+    db->warningCodes.clear();
+    db->warningMessages.clear();
+    for (SQLWarning *w = stmt->getWarnings(); w; w = w->getNextWarning()) {
+        db->warningCodes.push_back(w->getSqlcode());
+        db->warningMessages.push_back(w->getText() ? w->getText() : "");
+    }
+    stmt->clearWarnings();
+}
+
 static int closeDb(struct nuodb *db) {
     if (db->conn) {
         try {
@@ -40,6 +71,10 @@ const char *nuodb_error(const struct nuodb *db) {
     return db ? db->error.c_str() : "null db";
 }
 
+const char *nuodb_sqlstate(const struct nuodb *db) {
+    return db ? db->sqlstate.c_str() : "";
+}
+
 int nuodb_open(struct nuodb *db, const char *database, const char *username,
                const char *password, const char **props, int props_count) {
     closeDb(db);
@@ -98,6 +133,24 @@ int nuodb_autocommit_set(struct nuodb *db, int state) {
     }
 }
 
+int nuodb_read_only(struct nuodb *db, int *state) {
+    try {
+        *state = db->conn->isReadOnly();
+        return 0;
+    } catch (SQLException &e) {
+        return setError(db, e);
+    }
+}
+
+int nuodb_read_only_set(struct nuodb *db, int state) {
+    try {
+        db->conn->setReadOnly(!!state);
+        return 0;
+    } catch (SQLException &e) {
+        return setError(db, e);
+    }
+}
+
 int nuodb_commit(struct nuodb *db) {
     try {
         db->conn->commit();
@@ -116,6 +169,186 @@ int nuodb_rollback(struct nuodb *db) {
     }
 }
 
+int nuodb_isolation(struct nuodb *db, int *level) {
+    try {
+        *level = db->conn->getTransactionIsolation();
+        return 0;
+    } catch (SQLException &e) {
+        return setError(db, e);
+    }
+}
+
+int nuodb_isolation_set(struct nuodb *db, int level) {
+    try {
+        db->conn->setTransactionIsolation(level);
+        return 0;
+    } catch (SQLException &e) {
+        return setError(db, e);
+    }
+}
+
+int nuodb_warning_count(struct nuodb *db) {
+    return db->warningCodes.size();
+}
+
+void nuodb_warning(struct nuodb *db, int index, int *code, const char **message) {
+    *code = db->warningCodes[index];
+    *message = db->warningMessages[index].c_str();
+}
+
+int nuodb_client_version(struct nuodb *db, const char **version) {
+    try {
+        *version = db->conn->getMetaData()->getDriverVersion();
+        return 0;
+    } catch (SQLException &e) {
+        return setError(db, e);
+    }
+}
+
+int nuodb_server_version(struct nuodb *db, const char **version) {
+    try {
+        *version = db->conn->getMetaData()->getDatabaseProductVersion();
+        return 0;
+    } catch (SQLException &e) {
+        return setError(db, e);
+    }
+}
+
+int nuodb_last_commit_info(struct nuodb *db, const char **info) {
+    try {
+        *info = db->conn->getLastCommitInfo();
+        return 0;
+    } catch (SQLException &e) {
+        return setError(db, e);
+    }
+}
+
+int nuodb_commit_info_set(struct nuodb *db, const char *info) {
+    try {
+        db->conn->setLastCommitInfo(info);
+        return 0;
+    } catch (SQLException &e) {
+        return setError(db, e);
+    }
+}
+
+int nuodb_session_id(struct nuodb *db, int64_t *id) {
+    Statement *stmt = 0;
+    ResultSet *resultSet = 0;
+    try {
+        stmt = db->conn->createStatement();
+        resultSet = stmt->executeQuery("SELECT GETCONNECTIONID() FROM DUAL");
+        *id = resultSet->next() ? resultSet->getLong(1) : 0;
+        resultSet->close();
+        stmt->close();
+        return 0;
+    } catch (SQLException &e) {
+        if (resultSet) {
+            resultSet->close();
+        }
+        if (stmt) {
+            stmt->close();
+        }
+        return setError(db, e);
+    }
+}
+
+int nuodb_reset(struct nuodb *db, int isolation, int read_only, int autocommit) {
+    try {
+        if (!db->conn->getAutoCommit()) {
+            // discard whatever an abandoned transaction left behind
+            db->conn->rollback();
+        }
+        if (db->conn->getAutoCommit() != !!autocommit) {
+            db->conn->setAutoCommit(!!autocommit);
+        }
+        if (db->conn->getTransactionIsolation() != isolation) {
+            db->conn->setTransactionIsolation(isolation);
+        }
+        if (db->conn->isReadOnly() != !!read_only) {
+            db->conn->setReadOnly(!!read_only);
+        }
+        return 0;
+    } catch (SQLException &e) {
+        return setError(db, e);
+    }
+}
+
+// bindParameters binds the first parameterCount parameters; throws SQLException.
+static void bindParameters(PreparedStatement *stmt, struct nuodb_value parameters[],
+                           int parameterCount) {
+    for (int i=0; i < parameterCount; ++i) {
+        int parameterIndex = i+1;
+        switch (parameters[i].vt) {
+            case NUODB_TYPE_NULL:
+                stmt->setNull(parameterIndex, NUOSQL_NULL);
+                break;
+            case NUODB_TYPE_INT64:
+                stmt->setLong(parameterIndex, parameters[i].i64);
+                break;
+            case NUODB_TYPE_FLOAT64: {
+                union {
+                    int64_t i64;
+                    double float64;
+                } value = { parameters[i].i64 };
+                stmt->setDouble(parameterIndex, value.float64);
+                break;
+            }
+            case NUODB_TYPE_BOOL:
+                stmt->setBoolean(parameterIndex, !!parameters[i].i64);
+                break;
+            case NUODB_TYPE_STRING: {
+                size_t length = parameters[i].i32;
+                const char *s = reinterpret_cast<const char*>(parameters[i].i64);
+                // Extra conversion due to missing length param in the setString API
+                const std::string str(s, length);
+                stmt->setString(parameterIndex, str.c_str());
+                break;
+            }
+            case NUODB_TYPE_BYTES: {
+                int length = parameters[i].i32;
+                const unsigned char *bytes = reinterpret_cast<const unsigned char*>(parameters[i].i64);
+                stmt->setBytes(parameterIndex, length, bytes);
+                break;
+            }
+            case NUODB_TYPE_TIME: {
+                int64_t seconds = parameters[i].i64;
+                int32_t nanos = parameters[i].i32;
+                SqlTimestamp ts(seconds, nanos);
+                // bound as the type of the target, so that the server doesn't
+                // cut the timestamp in its own way
+                switch (stmt->getParameterMetaData()->getParameterType(parameterIndex)) {
+                    case NUOSQL_DATE:
+                        stmt->setDate(parameterIndex, &ts);
+                        break;
+                    case NUOSQL_TIME:
+                        stmt->setTime(parameterIndex, &ts);
+                        break;
+                    default:
+                        stmt->setTimestamp(parameterIndex, &ts);
+                }
+                break;
+            }
+            case NUODB_TYPE_DATE: {
+                SqlTimestamp ts(parameters[i].i64, 0);
+                stmt->setDate(parameterIndex, &ts);
+                break;
+            }
+            case NUODB_TYPE_TIME_OF_DAY: {
+                SqlTimestamp ts(parameters[i].i64, parameters[i].i32);
+                stmt->setTime(parameterIndex, &ts);
+                break;
+            }
+            case NUODB_TYPE_BLOB:
+                stmt->setBlob(parameterIndex, reinterpret_cast<Blob *>(parameters[i].i64));
+                break;
+            case NUODB_TYPE_CLOB:
+                stmt->setClob(parameterIndex, reinterpret_cast<Clob *>(parameters[i].i64));
+                break;
+        }
+    }
+}
+
 static int fetchExecuteResult(struct nuodb *db, Statement *stmt,
                               int64_t *rows_affected, int64_t *last_insert_id) {
     ResultSet *resultSet = 0;
@@ -163,17 +396,104 @@ static int fetchExecuteResult(struct nuodb *db, Statement *stmt,
     }
 }
 
+// NUODB_CANCELLED is returned for a call cancelled before its statement ran;
+// the caller knows it cancelled the call, so it isn't an error code of NuoDB.
+static const int NUODB_CANCELLED = -10000;
+
+// startRunning publishes stmt to nuodb_cancel, unless the call was already
+// cancelled, which is reported as an error instead.
+static int startRunning(struct nuodb *db, Statement *stmt) {
+    std::lock_guard<std::mutex> lock(db->cancelMutex);
+    if (db->cancelled) {
+        db->warningCodes.clear();
+        db->warningMessages.clear();
+        db->error.assign("statement cancelled");
+        db->sqlstate.clear();
+        return NUODB_CANCELLED;
+    }
+    db->running = stmt;
+    return 0;
+}
+
+// checkParameterCount fails unless the statement has as many placeholders
+// as there are parameters, which database/sql checks only for the prepared
+// statements.
+static int checkParameterCount(struct nuodb *db, int placeholders, int parameter_count) {
+    if (placeholders == parameter_count) {
+        return 0;
+    }
+    db->warningCodes.clear();
+    db->warningMessages.clear();
+    db->error.assign("expected " + std::to_string(placeholders) + " arguments, got " +
+                     std::to_string(parameter_count));
+    db->sqlstate.clear();
+    return NUODB_PARAMETER_COUNT_MISMATCH;
+}
+
+static void stopRunning(struct nuodb *db) {
+    std::lock_guard<std::mutex> lock(db->cancelMutex);
+    db->running = 0;
+}
+
+void nuodb_cancel(struct nuodb *db) {
+    // Called from another thread than the one executing the statement, so
+    // errors are not recorded on the connection.
+    std::lock_guard<std::mutex> lock(db->cancelMutex);
+    db->cancelled = true;
+    if (db->running) {
+        try {
+            db->running->cancel();
+        } catch (SQLException &e) {
+        }
+    }
+}
+
+void nuodb_cancel_reset(struct nuodb *db) {
+    std::lock_guard<std::mutex> lock(db->cancelMutex);
+    db->cancelled = false;
+}
+
+// nuodb_execute executes sql in one call. With parameters the statement is
+// prepared on the server and closed again, as the client API has no one-shot
+// execute with parameters; only a statement without them is sent as is.
 int nuodb_execute(struct nuodb *db, const char *sql,
+                  struct nuodb_value parameters[], int parameter_count,
                   int64_t *rows_affected, int64_t *last_insert_id, int64_t timeout_micro_seconds) {
     Statement *stmt = 0;
     try {
-        stmt = db->conn->createStatement();
-        stmt->setQueryTimeoutMicros(timeout_micro_seconds);
-        stmt->executeUpdate(sql, RETURN_GENERATED_KEYS);
+        if (parameter_count > 0) {
+            PreparedStatement *pstmt = db->conn->prepareStatement(sql, RETURN_GENERATED_KEYS);
+            stmt = pstmt;
+            int parameterCount = pstmt->getParameterMetaData()->getParameterCount();
+            if (int rc = checkParameterCount(db, parameterCount, parameter_count)) {
+                pstmt->close();
+                return rc;
+            }
+            bindParameters(pstmt, parameters, parameterCount);
+            pstmt->setQueryTimeoutMicros(timeout_micro_seconds);
+            if (int rc = startRunning(db, pstmt)) {
+                pstmt->close();
+                return rc;
+            }
+            pstmt->executeUpdate();
+            stopRunning(db);
+            saveWarnings(db, pstmt);
+        } else {
+            stmt = db->conn->createStatement();
+            stmt->setQueryTimeoutMicros(timeout_micro_seconds);
+            if (int rc = startRunning(db, stmt)) {
+                stmt->close();
+                return rc;
+            }
+            stmt->executeUpdate(sql, RETURN_GENERATED_KEYS);
+            stopRunning(db);
+            saveWarnings(db, stmt);
+        }
         int rc = fetchExecuteResult(db, stmt, rows_affected, last_insert_id);
         stmt->close();
         return rc;
     } catch (SQLException &e) {
+        stopRunning(db);
         if (stmt) {
             stmt->close();
         }
@@ -181,15 +501,44 @@ int nuodb_execute(struct nuodb *db, const char *sql,
     }
 }
 
-int nuodb_statement_prepare(struct nuodb *db, const char *sql,
-                            struct nuodb_statement **st, int *parameter_count) {
+int nuodb_query(struct nuodb *db, const char *sql,
+                struct nuodb_value parameters[], int parameter_count, int fetch_size, int max_rows,
+                struct nuodb_statement **st, struct nuodb_resultset **rs, int *column_count,
+                int64_t timeout_micro_seconds) {
     PreparedStatement *stmt = 0;
+    ResultSet *resultSet = 0;
     try {
         stmt = db->conn->prepareStatement(sql, RETURN_GENERATED_KEYS);
-        *parameter_count = stmt->getParameterMetaData()->getParameterCount();
+        int parameterCount = stmt->getParameterMetaData()->getParameterCount();
+        if (int rc = checkParameterCount(db, parameterCount, parameter_count)) {
+            stmt->close();
+            return rc;
+        }
+        bindParameters(stmt, parameters, parameterCount);
+        stmt->setQueryTimeoutMicros(timeout_micro_seconds);
+        stmt->setFetchSize(fetch_size);
+        stmt->setMaxRows(max_rows);
+        if (int rc = startRunning(db, stmt)) {
+            stmt->close();
+            return rc;
+        }
+        bool hasResults = stmt->execute();
+        stopRunning(db);
+        saveWarnings(db, stmt);
+        if (hasResults) {
+            resultSet = stmt->getResultSet();
+        } else {
+            resultSet = stmt->getGeneratedKeys();
+        }
+        *column_count = resultSet->getMetaData()->getColumnCount();
         *st = reinterpret_cast<struct nuodb_statement *>(stmt);
+        *rs = reinterpret_cast<struct nuodb_resultset *>(resultSet);
         return 0;
     } catch (SQLException &e) {
+        stopRunning(db);
+        if (resultSet) {
+            resultSet->close();
+        }
         if (stmt) {
             stmt->close();
         }
@@ -197,221 +546,867 @@ int nuodb_statement_prepare(struct nuodb *db, const char *sql,
     }
 }
 
-int nuodb_statement_bind(struct nuodb *db, struct nuodb_statement *st,
-                         struct nuodb_value parameters[]) {
-    PreparedStatement *stmt = reinterpret_cast<PreparedStatement *>(st);
+int nuodb_statement_prepare(struct nuodb *db, const char *sql,
+                            struct nuodb_statement **st, int *parameter_count) {
+    PreparedStatement *stmt = 0;
     try {
-        int parameterCount = stmt->getParameterMetaData()->getParameterCount();
-        for (int i=0; i < parameterCount; ++i) {
-            int parameterIndex = i+1;
-            switch (parameters[i].vt) {
-                case NUODB_TYPE_NULL:
-                    stmt->setNull(parameterIndex, NUOSQL_NULL);
-                    break;
-                case NUODB_TYPE_INT64:
-                    stmt->setLong(parameterIndex, parameters[i].i64);
-                    break;
-                case NUODB_TYPE_FLOAT64: {
-                    union {
-                        int64_t i64;
-                        double float64;
-                    } value = { parameters[i].i64 };
-                    stmt->setDouble(parameterIndex, value.float64);
-                    break;
-                }
-                case NUODB_TYPE_BOOL:
-                    stmt->setBoolean(parameterIndex, !!parameters[i].i64);
-                    break;
-                case NUODB_TYPE_STRING: {
-                    size_t length = parameters[i].i32;
-                    const char *s = reinterpret_cast<const char*>(parameters[i].i64);
-                    // Extra conversion due to missing length param in the setString API
-                    const std::string str(s, length);
-                    stmt->setString(parameterIndex, str.c_str());
-                    break;
-                }
-                case NUODB_TYPE_BYTES: {
-                    int length = parameters[i].i32;
-                    const unsigned char *bytes = reinterpret_cast<const unsigned char*>(parameters[i].i64);
-                    stmt->setBytes(parameterIndex, length, bytes);
-                    break;
-                }
-                case NUODB_TYPE_TIME: {
-                    int64_t seconds = parameters[i].i64;
-                    int32_t nanos = parameters[i].i32;
-                    SqlTimestamp ts(seconds, nanos);
-                    stmt->setTimestamp(parameterIndex, &ts);
-                    break;
-                }
-            }
-        }
+        stmt = db->conn->prepareStatement(sql, RETURN_GENERATED_KEYS);
+        *parameter_count = stmt->getParameterMetaData()->getParameterCount();
+        *st = reinterpret_cast<struct nuodb_statement *>(stmt);
         return 0;
-    } catch  (SQLException &e) {
+    } catch (SQLException &e) {
+        if (stmt) {
+            stmt->close();
+        }
         return setError(db, e);
     }
 }
 
-int nuodb_statement_execute(struct nuodb *db, struct nuodb_statement *st,
-                            int64_t *rows_affected, int64_t *last_insert_id) {
-    PreparedStatement *stmt = reinterpret_cast<PreparedStatement *>(st);
+int nuodb_statement_prepare_keys(struct nuodb *db, const char *sql, const char **columns, int column_count,
+                                 struct nuodb_statement **st, int *parameter_count) {
+    PreparedStatement *stmt = 0;
     try {
-        stmt->executeUpdate();
-        return fetchExecuteResult(db, stmt, rows_affected, last_insert_id);
+        stmt = db->conn->prepareStatement(sql, column_count, columns);
+        *parameter_count = stmt->getParameterMetaData()->getParameterCount();
+        *st = reinterpret_cast<struct nuodb_statement *>(stmt);
+        return 0;
     } catch (SQLException &e) {
+        if (stmt) {
+            stmt->close();
+        }
         return setError(db, e);
     }
 }
 
-int nuodb_statement_query(struct nuodb *db, struct nuodb_statement *st,
-                          struct nuodb_resultset **rs, int *column_count) {
-    ResultSet *resultSet = 0;
-    PreparedStatement *stmt = reinterpret_cast<PreparedStatement *>(st);
+int nuodb_statement_prepare_call(struct nuodb *db, const char *sql,
+                                 struct nuodb_statement **st, int *parameter_count) {
+    CallableStatement *stmt = 0;
     try {
-        bool hasResults = stmt->execute();
-        if (hasResults) {
-            resultSet = stmt->getResultSet();
-        } else {
-            resultSet = stmt->getGeneratedKeys();
-        }
-        *column_count = resultSet->getMetaData()->getColumnCount();
-        *rs = reinterpret_cast<struct nuodb_resultset *>(resultSet);
+        stmt = db->conn->prepareCall(sql);
+        *parameter_count = stmt->getParameterMetaData()->getParameterCount();
+        *st = reinterpret_cast<struct nuodb_statement *>(static_cast<PreparedStatement *>(stmt));
         return 0;
     } catch (SQLException &e) {
-        if (resultSet) {
-            resultSet->close();
+        if (stmt) {
+            stmt->close();
         }
         return setError(db, e);
     }
 }
 
-int nuodb_statement_close(struct nuodb *db, struct nuodb_statement **st) {
+int nuodb_statement_prepare_cursor(struct nuodb *db, const char *sql, int scrollable, int holdable,
+                                   struct nuodb_statement **st, int *parameter_count) {
+    PreparedStatement *stmt = 0;
     try {
-        if (st && *st) {
-            PreparedStatement *stmt = reinterpret_cast<PreparedStatement *>(*st);
-            stmt->close();
-            *st = 0;
-        }
+        stmt = db->conn->prepareStatement(sql,
+                                          scrollable ? TYPE_SCROLL_INSENSITIVE : TYPE_FORWARD_ONLY,
+                                          CONCUR_READ_ONLY,
+                                          holdable ? HOLD_CURSORS_OVER_COMMIT : CLOSE_CURSORS_AT_COMMIT);
+        *parameter_count = stmt->getParameterMetaData()->getParameterCount();
+        *st = reinterpret_cast<struct nuodb_statement *>(stmt);
         return 0;
     } catch (SQLException &e) {
+        if (stmt) {
+            stmt->close();
+        }
         return setError(db, e);
     }
 }
 
-int nuodb_statement_set_query_micros(struct nuodb *db, struct nuodb_statement *st,
-                                     int64_t timeout_micro_seconds) {
+int nuodb_statement_register_out(struct nuodb *db, struct nuodb_statement *st, int index) {
+    CallableStatement *stmt = static_cast<CallableStatement *>(reinterpret_cast<PreparedStatement *>(st));
     try {
-        if (st) {
-            PreparedStatement *stmt = reinterpret_cast<PreparedStatement *>(st);
-            // Set the timeout in micro seconds; zero means there is no limit.
-            stmt->setQueryTimeoutMicros(timeout_micro_seconds);
-        }
+        int parameterIndex = index+1;
+        stmt->registerOutParameter(parameterIndex, stmt->getParameterMetaData()->getParameterType(parameterIndex));
         return 0;
     } catch (SQLException &e) {
         return setError(db, e);
     }
 }
 
-int nuodb_resultset_column_names(struct nuodb *db, struct nuodb_resultset *rs,
-                                 struct nuodb_value names[]) {
-    ResultSet *resultSet = reinterpret_cast<ResultSet *>(rs);
+int nuodb_statement_out_value(struct nuodb *db, struct nuodb_statement *st, int index,
+                              struct nuodb_value *value) {
+    CallableStatement *stmt = static_cast<CallableStatement *>(reinterpret_cast<PreparedStatement *>(st));
     try {
-        ResultSetMetaData *resultSetMetaData = resultSet->getMetaData();
-        int columnCount = resultSetMetaData->getColumnCount();
-        for (int i=0; i < columnCount; ++i) {
-            int columnIndex = i+1;
-            const char *string = resultSetMetaData->getColumnLabel(columnIndex);
-            names[i].i64 = reinterpret_cast<int64_t>(string);
-            names[i].i32 = std::strlen(string);
+        int parameterIndex = index+1;
+        int64_t i64 = 0;
+        int32_t i32 = 0;
+        enum nuodb_value_type vt = NUODB_TYPE_NULL;
+        switch (stmt->getParameterMetaData()->getParameterType(parameterIndex)) {
+            case NUOSQL_NULL:
+                break;
+            case NUOSQL_TINYINT:
+            case NUOSQL_SMALLINT:
+            case NUOSQL_INTEGER:
+            case NUOSQL_BIGINT:
+                i64 = stmt->getLong(parameterIndex);
+                if (!stmt->wasNull()) {
+                    vt = NUODB_TYPE_INT64;
+                }
+                break;
+            case NUOSQL_FLOAT:
+            case NUOSQL_DOUBLE: {
+                union {
+                    double float64;
+                    int64_t i64;
+                } value = { stmt->getDouble(parameterIndex) };
+                if (!stmt->wasNull()) {
+                    vt = NUODB_TYPE_FLOAT64;
+                    i64 = value.i64;
+                }
+                break;
+            }
+            case NUOSQL_BIT:
+            case NUOSQL_BOOLEAN:
+                i64 = stmt->getBoolean(parameterIndex);
+                if (!stmt->wasNull()) {
+                    vt = NUODB_TYPE_BOOL;
+                }
+                break;
+            case NUOSQL_DATE: {
+                Timestamp *ts = stmt->getDate(parameterIndex);
+                if (ts && !stmt->wasNull()) {
+                    vt = NUODB_TYPE_DATE;
+                    i64 = ts->getSeconds();
+                }
+                break;
+            }
+            case NUOSQL_TIME: {
+                Timestamp *ts = stmt->getTime(parameterIndex);
+                if (ts && !stmt->wasNull()) {
+                    vt = NUODB_TYPE_TIME_OF_DAY;
+                    i64 = ts->getSeconds();
+                    i32 = ts->getNanos();
+                }
+                break;
+            }
+            case NUOSQL_TIMESTAMP: {
+                Timestamp *ts = stmt->getTimestamp(parameterIndex);
+                if (ts && !stmt->wasNull()) {
+                    vt = NUODB_TYPE_TIME;
+                    i64 = ts->getSeconds();
+                    i32 = ts->getNanos();
+                }
+                break;
+            }
+            case NUOSQL_BLOB:
+            case NUOSQL_BINARY:
+            case NUOSQL_VARBINARY:
+            case NUOSQL_LONGVARBINARY: {
+                const Bytes b = stmt->getBytes(parameterIndex);
+                if (!stmt->wasNull()) {
+                    vt = NUODB_TYPE_BYTES;
+                    i64 = reinterpret_cast<int64_t>(b.data);
+                    i32 = b.length;
+                }
+                break;
+            }
+            default: {
+                // strings, decimals and the rest are returned as bytes
+                const char *string = stmt->getString(parameterIndex);
+                if (string && !stmt->wasNull()) {
+                    vt = NUODB_TYPE_BYTES;
+                    i64 = reinterpret_cast<int64_t>(string);
+                    i32 = std::strlen(string);
+                }
+                break;
+            }
         }
+        value->i64 = i64;
+        value->i32 = i32;
+        value->vt = vt;
         return 0;
     } catch (SQLException &e) {
         return setError(db, e);
     }
 }
 
+int nuodb_statement_bind(struct nuodb *db, struct nuodb_statement *st,
+                         struct nuodb_value parameters[]) {
+    PreparedStatement *stmt = reinterpret_cast<PreparedStatement *>(st);
+    try {
+        int parameterCount = stmt->getParameterMetaData()->getParameterCount();
+        bindParameters(stmt, parameters, parameterCount);
+        return 0;
+    } catch  (SQLException &e) {
+        return setError(db, e);
+    }
+}
+
+int nuodb_statement_execute(struct nuodb *db, struct nuodb_statement *st,
+                            int64_t *rows_affected, int64_t *last_insert_id) {
+    PreparedStatement *stmt = reinterpret_cast<PreparedStatement *>(st);
+    try {
+        stmt->executeUpdate();
+        saveWarnings(db, stmt);
+        return fetchExecuteResult(db, stmt, rows_affected, last_insert_id);
+    } catch (SQLException &e) {
+        return setError(db, e);
+    }
+}
+
+int nuodb_statement_execute_batch(struct nuodb *db, struct nuodb_statement *st,
+                                  struct nuodb_value parameters[], int row_count,
+                                  int64_t rows_affected[]) {
+    PreparedStatement *stmt = reinterpret_cast<PreparedStatement *>(st);
+    try {
+        int parameterCount = stmt->getParameterMetaData()->getParameterCount();
+        for (int i = 0; i < row_count; ++i) {
+            bindParameters(stmt, parameters + i * parameterCount, parameterCount);
+            stmt->addBatch();
+        }
+        const int *counts = stmt->executeBatch();
+        saveWarnings(db, stmt);
+        for (int i = 0; i < row_count; ++i) {
+            rows_affected[i] = counts[i];
+        }
+        return 0;
+    } catch (SQLException &e) {
+        return setError(db, e);
+    }
+}
+
+int nuodb_statement_query(struct nuodb *db, struct nuodb_statement *st,
+                          struct nuodb_resultset **rs, int *column_count) {
+    ResultSet *resultSet = 0;
+    PreparedStatement *stmt = reinterpret_cast<PreparedStatement *>(st);
+    try {
+        bool hasResults = stmt->execute();
+        saveWarnings(db, stmt);
+        if (hasResults) {
+            resultSet = stmt->getResultSet();
+        } else {
+            resultSet = stmt->getGeneratedKeys();
+        }
+        *column_count = resultSet->getMetaData()->getColumnCount();
+        *rs = reinterpret_cast<struct nuodb_resultset *>(resultSet);
+        return 0;
+    } catch (SQLException &e) {
+        if (resultSet) {
+            resultSet->close();
+        }
+        return setError(db, e);
+    }
+}
+
+int nuodb_statement_next_resultset(struct nuodb *db, struct nuodb_statement *st,
+                                   struct nuodb_resultset **rs, int *column_count) {
+    PreparedStatement *stmt = reinterpret_cast<PreparedStatement *>(st);
+    try {
+        *rs = 0;
+        *column_count = 0;
+        // getMoreResults closes the current result set
+        if (stmt->getMoreResults()) {
+            ResultSet *resultSet = stmt->getResultSet();
+            *column_count = resultSet->getMetaData()->getColumnCount();
+            *rs = reinterpret_cast<struct nuodb_resultset *>(resultSet);
+        }
+        return 0;
+    } catch (SQLException &e) {
+        return setError(db, e);
+    }
+}
+
+int nuodb_statement_close(struct nuodb *db, struct nuodb_statement **st) {
+    try {
+        if (st && *st) {
+            PreparedStatement *stmt = reinterpret_cast<PreparedStatement *>(*st);
+            stmt->close();
+            *st = 0;
+        }
+        return 0;
+    } catch (SQLException &e) {
+        return setError(db, e);
+    }
+}
+
+// columnNames stores the labels of the columns; throws SQLException.
+static void columnNames(ResultSetMetaData *resultSetMetaData, struct nuodb_value names[]) {
+    int columnCount = resultSetMetaData->getColumnCount();
+    for (int i=0; i < columnCount; ++i) {
+        int columnIndex = i+1;
+        const char *string = resultSetMetaData->getColumnLabel(columnIndex);
+        names[i].i64 = reinterpret_cast<int64_t>(string);
+        names[i].i32 = std::strlen(string);
+    }
+}
+
+// columnOrigins stores the schema, the table and the column name of each
+// column, three values per column, empty for a computed column.
+static void columnOrigins(ResultSetMetaData *resultSetMetaData, struct nuodb_value origins[]) {
+    int columnCount = resultSetMetaData->getColumnCount();
+    for (int i=0; i < columnCount; ++i) {
+        int columnIndex = i+1;
+        const char *strings[3] = {
+            resultSetMetaData->getSchemaName(columnIndex),
+            resultSetMetaData->getTableName(columnIndex),
+            resultSetMetaData->getColumnName(columnIndex),
+        };
+        for (int j=0; j < 3; ++j) {
+            origins[3*i+j].i64 = reinterpret_cast<int64_t>(strings[j]);
+            origins[3*i+j].i32 = strings[j] ? std::strlen(strings[j]) : 0;
+        }
+    }
+}
+
+int nuodb_statement_column_count(struct nuodb *db, struct nuodb_statement *st, int *column_count) {
+    PreparedStatement *stmt = reinterpret_cast<PreparedStatement *>(st);
+    try {
+        ResultSetMetaData *resultSetMetaData = stmt->getMetaData();
+        *column_count = resultSetMetaData ? resultSetMetaData->getColumnCount() : 0;
+        return 0;
+    } catch (SQLException &e) {
+        return setError(db, e);
+    }
+}
+
+int nuodb_statement_column_names(struct nuodb *db, struct nuodb_statement *st,
+                                 struct nuodb_value names[]) {
+    PreparedStatement *stmt = reinterpret_cast<PreparedStatement *>(st);
+    try {
+        ResultSetMetaData *resultSetMetaData = stmt->getMetaData();
+        if (resultSetMetaData) {
+            columnNames(resultSetMetaData, names);
+        }
+        return 0;
+    } catch (SQLException &e) {
+        return setError(db, e);
+    }
+}
+
+int nuodb_statement_column_origins(struct nuodb *db, struct nuodb_statement *st,
+                                   struct nuodb_value origins[]) {
+    PreparedStatement *stmt = reinterpret_cast<PreparedStatement *>(st);
+    try {
+        ResultSetMetaData *resultSetMetaData = stmt->getMetaData();
+        if (resultSetMetaData) {
+            columnOrigins(resultSetMetaData, origins);
+        }
+        return 0;
+    } catch (SQLException &e) {
+        return setError(db, e);
+    }
+}
+
+int nuodb_statement_parameter_info(struct nuodb *db, struct nuodb_statement *st,
+                                   struct nuodb_value types[], struct nuodb_column_info info[]) {
+    PreparedStatement *stmt = reinterpret_cast<PreparedStatement *>(st);
+    try {
+        ParameterMetaData *parameterMetaData = stmt->getParameterMetaData();
+        int parameterCount = parameterMetaData->getParameterCount();
+        for (int i=0; i < parameterCount; ++i) {
+            int parameterIndex = i+1;
+            const char *string = parameterMetaData->getParameterTypeName(parameterIndex);
+            types[i].i64 = reinterpret_cast<int64_t>(string);
+            types[i].i32 = string ? std::strlen(string) : 0;
+            info[i].nullable = parameterMetaData->isNullable(parameterIndex);
+            info[i].length = -1;
+            info[i].precision = -1;
+            info[i].scale = -1;
+            switch (parameterMetaData->getParameterType(parameterIndex)) {
+                case NUOSQL_TINYINT:
+                case NUOSQL_SMALLINT:
+                case NUOSQL_INTEGER:
+                case NUOSQL_BIGINT:
+                    if (parameterMetaData->getScale(parameterIndex) == 0) {
+                        break;
+                    }
+                    // fallthrough; scaled integers are decimals
+                case NUOSQL_NUMERIC:
+                case NUOSQL_DECIMAL:
+                case NUOSQL_TIME:
+                case NUOSQL_TIMESTAMP: // the scale is the digits of the fraction of a second
+                    info[i].precision = parameterMetaData->getPrecision(parameterIndex);
+                    info[i].scale = parameterMetaData->getScale(parameterIndex);
+                    break;
+            }
+        }
+        return 0;
+    } catch (SQLException &e) {
+        return setError(db, e);
+    }
+}
+
+int nuodb_statement_set_query_micros(struct nuodb *db, struct nuodb_statement *st,
+                                     int64_t timeout_micro_seconds) {
+    try {
+        if (st) {
+            PreparedStatement *stmt = reinterpret_cast<PreparedStatement *>(st);
+            // Set the timeout in micro seconds; zero means there is no limit.
+            stmt->setQueryTimeoutMicros(timeout_micro_seconds);
+        }
+        return 0;
+    } catch (SQLException &e) {
+        return setError(db, e);
+    }
+}
+
+void nuodb_statement_cancel(struct nuodb_statement *st) {
+    // Called from another thread than the one executing the statement, so
+    // errors are not recorded on the connection.
+    try {
+        reinterpret_cast<PreparedStatement *>(st)->cancel();
+    } catch (SQLException &e) {
+    }
+}
+
+int nuodb_statement_set_fetch_size(struct nuodb *db, struct nuodb_statement *st,
+                                   int fetch_size) {
+    try {
+        if (st) {
+            PreparedStatement *stmt = reinterpret_cast<PreparedStatement *>(st);
+            // Zero lets the server choose the fetch size.
+            stmt->setFetchSize(fetch_size);
+        }
+        return 0;
+    } catch (SQLException &e) {
+        return setError(db, e);
+    }
+}
+
+int nuodb_statement_set_max_rows(struct nuodb *db, struct nuodb_statement *st,
+                                 int max_rows) {
+    try {
+        if (st) {
+            PreparedStatement *stmt = reinterpret_cast<PreparedStatement *>(st);
+            // Zero means no limit.
+            stmt->setMaxRows(max_rows);
+        }
+        return 0;
+    } catch (SQLException &e) {
+        return setError(db, e);
+    }
+}
+
+int nuodb_resultset_column_names(struct nuodb *db, struct nuodb_resultset *rs,
+                                 struct nuodb_value names[]) {
+    ResultSet *resultSet = reinterpret_cast<ResultSet *>(rs);
+    try {
+        columnNames(resultSet->getMetaData(), names);
+        return 0;
+    } catch (SQLException &e) {
+        return setError(db, e);
+    }
+}
+
+int nuodb_resultset_column_origins(struct nuodb *db, struct nuodb_resultset *rs,
+                                   struct nuodb_value origins[]) {
+    ResultSet *resultSet = reinterpret_cast<ResultSet *>(rs);
+    try {
+        columnOrigins(resultSet->getMetaData(), origins);
+        return 0;
+    } catch (SQLException &e) {
+        return setError(db, e);
+    }
+}
+
+// columnValueType returns the type of the values nuodb_resultset_next
+// returns for the column.
+// characterType reports whether sqlType is a type of character strings,
+// whose values are returned as strings rather than bytes.
+static bool characterType(int sqlType) {
+    switch (sqlType) {
+        case NUOSQL_CHAR:
+        case NUOSQL_VARCHAR:
+        case NUOSQL_LONGVARCHAR:
+        case NUOSQL_CLOB:
+            return true;
+        default:
+            return false;
+    }
+}
+
+static enum nuodb_value_type columnValueType(ResultSetMetaData *resultSetMetaData, int columnIndex) {
+    switch (resultSetMetaData->getColumnType(columnIndex)) {
+        case NUOSQL_NULL:
+            return NUODB_TYPE_NULL;
+        case NUOSQL_TINYINT:
+        case NUOSQL_SMALLINT:
+        case NUOSQL_INTEGER:
+        case NUOSQL_BIGINT:
+            if (resultSetMetaData->getScale(columnIndex) == 0) {
+                return NUODB_TYPE_INT64;
+            }
+            return NUODB_TYPE_BYTES; // fetched as a string
+        case NUOSQL_FLOAT:
+        case NUOSQL_DOUBLE:
+            return NUODB_TYPE_FLOAT64;
+        case NUOSQL_BIT:
+        case NUOSQL_BOOLEAN:
+            return NUODB_TYPE_BOOL;
+        case NUOSQL_DATE:
+            return NUODB_TYPE_DATE;
+        case NUOSQL_TIME:
+            return NUODB_TYPE_TIME_OF_DAY;
+        case NUOSQL_TIMESTAMP:
+            return NUODB_TYPE_TIME;
+        default:
+            return characterType(resultSetMetaData->getColumnType(columnIndex)) ?
+                NUODB_TYPE_STRING : NUODB_TYPE_BYTES;
+    }
+}
+
+// columnTypes stores the type name and the value type of each column.
+static void columnTypes(ResultSetMetaData *resultSetMetaData, struct nuodb_value types[]) {
+    int columnCount = resultSetMetaData->getColumnCount();
+    for (int i=0; i < columnCount; ++i) {
+        int columnIndex = i+1;
+        const char *string = resultSetMetaData->getColumnTypeName(columnIndex);
+        types[i].i64 = reinterpret_cast<int64_t>(string);
+        types[i].i32 = std::strlen(string);
+        types[i].vt = columnValueType(resultSetMetaData, columnIndex);
+    }
+}
+
+// columnInfo stores the nullability, the length, the precision and the
+// scale of each column.
+static void columnInfo(ResultSetMetaData *resultSetMetaData, struct nuodb_column_info info[]) {
+    int columnCount = resultSetMetaData->getColumnCount();
+    for (int i=0; i < columnCount; ++i) {
+        int columnIndex = i+1;
+        info[i].nullable = resultSetMetaData->isNullable(columnIndex);
+        info[i].length = -1;
+        info[i].precision = -1;
+        info[i].scale = -1;
+        switch (resultSetMetaData->getColumnType(columnIndex)) {
+            case NUOSQL_CHAR:
+            case NUOSQL_VARCHAR:
+            case NUOSQL_LONGVARCHAR:
+            case NUOSQL_CLOB:
+            case NUOSQL_BINARY:
+            case NUOSQL_VARBINARY:
+            case NUOSQL_LONGVARBINARY:
+            case NUOSQL_BLOB:
+                info[i].length = resultSetMetaData->getColumnDisplaySize(columnIndex);
+                break;
+            case NUOSQL_TINYINT:
+            case NUOSQL_SMALLINT:
+            case NUOSQL_INTEGER:
+            case NUOSQL_BIGINT:
+                if (resultSetMetaData->getScale(columnIndex) == 0) {
+                    break;
+                }
+                // fallthrough; scaled integers are decimals
+            case NUOSQL_NUMERIC:
+            case NUOSQL_DECIMAL:
+            case NUOSQL_TIME:
+            case NUOSQL_TIMESTAMP: // the scale is the digits of the fraction of a second
+                info[i].precision = resultSetMetaData->getPrecision(columnIndex);
+                info[i].scale = resultSetMetaData->getScale(columnIndex);
+                break;
+        }
+    }
+}
+
+int nuodb_resultset_column_types(struct nuodb *db, struct nuodb_resultset *rs,
+                                 struct nuodb_value types[]) {
+    ResultSet *resultSet = reinterpret_cast<ResultSet *>(rs);
+    try {
+        columnTypes(resultSet->getMetaData(), types);
+        return 0;
+    } catch (SQLException &e) {
+        return setError(db, e);
+    }
+}
+
+int nuodb_resultset_column_info(struct nuodb *db, struct nuodb_resultset *rs,
+                                struct nuodb_column_info info[]) {
+    ResultSet *resultSet = reinterpret_cast<ResultSet *>(rs);
+    try {
+        columnInfo(resultSet->getMetaData(), info);
+        return 0;
+    } catch (SQLException &e) {
+        return setError(db, e);
+    }
+}
+
+int nuodb_statement_column_types(struct nuodb *db, struct nuodb_statement *st,
+                                 struct nuodb_value types[], struct nuodb_column_info info[]) {
+    PreparedStatement *stmt = reinterpret_cast<PreparedStatement *>(st);
+    try {
+        ResultSetMetaData *resultSetMetaData = stmt->getMetaData();
+        if (resultSetMetaData) {
+            columnTypes(resultSetMetaData, types);
+            columnInfo(resultSetMetaData, info);
+        }
+        return 0;
+    } catch (SQLException &e) {
+        return setError(db, e);
+    }
+}
+
+// fetchRow decodes the values of the current row of resultSet. The data of
+// strings and bytes is only valid until the next call to next().
+static void fetchRow(ResultSet *resultSet, ResultSetMetaData *resultSetMetaData, int columnCount,
+                     struct nuodb_value values[], int stream_lobs) {
+    for (int i=0; i < columnCount; ++i) {
+        int64_t i64 = 0;
+        int32_t i32 = 0;
+        enum nuodb_value_type vt = NUODB_TYPE_NULL;
+        int columnIndex = i+1;
+        switch (resultSetMetaData->getColumnType(columnIndex)) {
+            case NUOSQL_NULL:
+                vt = NUODB_TYPE_NULL;
+                break;
+            case NUOSQL_TINYINT:
+            case NUOSQL_SMALLINT:
+            case NUOSQL_INTEGER:
+            case NUOSQL_BIGINT:
+                if (resultSetMetaData->getScale(columnIndex) == 0) {
+                    i64 = resultSet->getLong(columnIndex);
+                    if (!resultSet->wasNull()) {
+                        vt = NUODB_TYPE_INT64;
+                    }
+                    break;
+                }
+                // fallthrough; must be fetched as a string
+            case NUOSQL_NUMERIC:
+            case NUOSQL_DECIMAL: {
+                const char *string = resultSet->getString(columnIndex);
+                if (!resultSet->wasNull()) {
+                    vt = NUODB_TYPE_BYTES; // strings are returned as bytes
+                    i64 = reinterpret_cast<int64_t>(string);
+                    i32 = std::strlen(string);
+                }
+                break;
+            }
+            case NUOSQL_FLOAT:
+            case NUOSQL_DOUBLE: {
+                union {
+                    double float64;
+                    int64_t i64;
+                } value = { resultSet->getDouble(columnIndex) };
+                if (!resultSet->wasNull()) {
+                    vt = NUODB_TYPE_FLOAT64;
+                    i64 = value.i64;
+                }
+                break;
+            }
+            case NUOSQL_BIT:
+            case NUOSQL_BOOLEAN:
+                i64 = resultSet->getBoolean(columnIndex);
+                if (!resultSet->wasNull()) {
+                    vt = NUODB_TYPE_BOOL;
+                }
+                break;
+            case NUOSQL_DATE: {
+                Timestamp *ts = resultSet->getDate(columnIndex);
+                if (ts && !resultSet->wasNull()) {
+                    vt = NUODB_TYPE_DATE;
+                    i64 = ts->getSeconds();
+                }
+                break;
+            }
+            case NUOSQL_TIME: {
+                Timestamp *ts = resultSet->getTime(columnIndex);
+                if (ts && !resultSet->wasNull()) {
+                    vt = NUODB_TYPE_TIME_OF_DAY;
+                    i64 = ts->getSeconds();
+                    i32 = ts->getNanos();
+                }
+                break;
+            }
+            case NUOSQL_TIMESTAMP: {
+                Timestamp *ts = resultSet->getTimestamp(columnIndex);
+                if (ts && !resultSet->wasNull()) {
+                    vt = NUODB_TYPE_TIME;
+                    i64 = ts->getSeconds();
+                    i32 = ts->getNanos();
+                }
+                break;
+            }
+            case NUOSQL_BLOB:
+            case NUOSQL_CLOB:
+                if (stream_lobs) {
+                    // return a handle instead of the whole value
+                    if (resultSetMetaData->getColumnType(columnIndex) == NUOSQL_BLOB) {
+                        Blob *blob = resultSet->getBlob(columnIndex);
+                        if (blob && !resultSet->wasNull()) {
+                            vt = NUODB_TYPE_BLOB;
+                            i64 = reinterpret_cast<int64_t>(blob);
+                        }
+                    } else {
+                        Clob *clob = resultSet->getClob(columnIndex);
+                        if (clob && !resultSet->wasNull()) {
+                            vt = NUODB_TYPE_CLOB;
+                            i64 = reinterpret_cast<int64_t>(clob);
+                        }
+                    }
+                    break;
+                }
+                // fallthrough; fetched as bytes
+            default: {
+                const Bytes b = resultSet->getBytes(columnIndex);
+                if (!resultSet->wasNull()) {
+                    vt = characterType(resultSetMetaData->getColumnType(columnIndex)) ?
+                        NUODB_TYPE_STRING : NUODB_TYPE_BYTES;
+                    i64 = reinterpret_cast<int64_t>(b.data);
+                    i32 = b.length;
+                }
+                break;
+            }
+        }
+        values[i].i64 = i64;
+        values[i].i32 = i32;
+        values[i].vt = vt;
+    }
+}
+
 int nuodb_resultset_next(struct nuodb *db, struct nuodb_resultset *rs,
-                         int *has_values, struct nuodb_value values[]) {
+                         int *has_values, struct nuodb_value values[], int stream_lobs) {
     ResultSet *resultSet = reinterpret_cast<ResultSet *>(rs);
     try {
         *has_values = resultSet->next();
         if (*has_values) {
             ResultSetMetaData *resultSetMetaData = resultSet->getMetaData();
-            int columnCount = resultSetMetaData->getColumnCount();
+            fetchRow(resultSet, resultSetMetaData, resultSetMetaData->getColumnCount(), values, stream_lobs);
+        }
+        return 0;
+    } catch (SQLException &e) {
+        return setError(db, e);
+    }
+}
+
+// copyRow copies the data of the byte values of a row to buffer, after its
+// first *used bytes, and points the values to the copies. It returns false,
+// leaving the values as they are, if the data doesn't fit.
+static bool copyRow(struct nuodb_value values[], int columnCount,
+                    unsigned char *buffer, int64_t buffer_size, int64_t *used) {
+    int64_t size = 0;
+    for (int i=0; i < columnCount; ++i) {
+        if (values[i].vt == NUODB_TYPE_BYTES || values[i].vt == NUODB_TYPE_STRING) {
+            size += values[i].i32;
+        }
+    }
+    if (*used + size > buffer_size) {
+        return false;
+    }
+    for (int i=0; i < columnCount; ++i) {
+        if ((values[i].vt == NUODB_TYPE_BYTES || values[i].vt == NUODB_TYPE_STRING) && values[i].i32 > 0) {
+            unsigned char *copy = buffer + *used;
+            std::memcpy(copy, reinterpret_cast<const void *>(values[i].i64), values[i].i32);
+            values[i].i64 = reinterpret_cast<int64_t>(copy);
+            *used += values[i].i32;
+        }
+    }
+    return true;
+}
+
+int nuodb_resultset_next_batch(struct nuodb *db, struct nuodb_resultset *rs, int max_rows,
+                               struct nuodb_value values[], unsigned char *buffer, int64_t buffer_size,
+                               int *row_count, int *done) {
+    ResultSet *resultSet = reinterpret_cast<ResultSet *>(rs);
+    *row_count = 0;
+    *done = 0;
+    try {
+        ResultSetMetaData *resultSetMetaData = resultSet->getMetaData();
+        int columnCount = resultSetMetaData->getColumnCount();
+        int64_t used = 0;
+        while (*row_count < max_rows) {
+            if (*row_count > 0) {
+                // the next row invalidates the data of the previous one; a row
+                // which doesn't fit is left in place as the last of the batch
+                struct nuodb_value *previous = values + (*row_count - 1) * columnCount;
+                if (!copyRow(previous, columnCount, buffer, buffer_size, &used)) {
+                    break;
+                }
+            }
+            if (!resultSet->next()) {
+                *done = 1;
+                break;
+            }
+            fetchRow(resultSet, resultSetMetaData, columnCount, values + *row_count * columnCount, 0);
+            ++*row_count;
+        }
+        return 0;
+    } catch (SQLException &e) {
+        return setError(db, e);
+    }
+}
+
+int nuodb_resultset_next_columns(struct nuodb *db, struct nuodb_resultset *rs, int max_rows,
+                                 int64_t values[], uint64_t valid[], int *row_count, int *done) {
+    ResultSet *resultSet = reinterpret_cast<ResultSet *>(rs);
+    *row_count = 0;
+    *done = 0;
+    try {
+        ResultSetMetaData *resultSetMetaData = resultSet->getMetaData();
+        int columnCount = resultSetMetaData->getColumnCount();
+        int words = (max_rows + 63) / 64;
+        std::memset(valid, 0, sizeof(uint64_t) * words * columnCount);
+        while (*row_count < max_rows) {
+            if (!resultSet->next()) {
+                *done = 1;
+                break;
+            }
+            int row = *row_count;
             for (int i=0; i < columnCount; ++i) {
-                int64_t i64 = 0;
-                int32_t i32 = 0;
-                enum nuodb_value_type vt = NUODB_TYPE_NULL;
                 int columnIndex = i+1;
-                switch (resultSetMetaData->getColumnType(columnIndex)) {
-                    case NUOSQL_NULL:
-                        vt = NUODB_TYPE_NULL;
-                        break;
-                    case NUOSQL_TINYINT:
-                    case NUOSQL_SMALLINT:
-                    case NUOSQL_INTEGER:
-                    case NUOSQL_BIGINT:
-                        if (resultSetMetaData->getScale(columnIndex) == 0) {
-                            i64 = resultSet->getLong(columnIndex);
-                            if (!resultSet->wasNull()) {
-                                vt = NUODB_TYPE_INT64;
-                            }
-                            break;
-                        }
-                        // fallthrough; must be fetched as a string
-                    case NUOSQL_NUMERIC:
-                    case NUOSQL_DECIMAL: {
-                        const char *string = resultSet->getString(columnIndex);
-                        if (!resultSet->wasNull()) {
-                            vt = NUODB_TYPE_BYTES; // strings are returned as bytes
-                            i64 = reinterpret_cast<int64_t>(string);
-                            i32 = std::strlen(string);
-                        }
-                        break;
-                    }
-                    case NUOSQL_FLOAT:
-                    case NUOSQL_DOUBLE: {
+                int64_t i64;
+                switch (columnValueType(resultSetMetaData, columnIndex)) {
+                    case NUODB_TYPE_FLOAT64: {
                         union {
                             double float64;
                             int64_t i64;
                         } value = { resultSet->getDouble(columnIndex) };
-                        if (!resultSet->wasNull()) {
-                            vt = NUODB_TYPE_FLOAT64;
-                            i64 = value.i64;
-                        }
+                        i64 = value.i64;
                         break;
                     }
-                    case NUOSQL_BIT:
-                    case NUOSQL_BOOLEAN:
+                    case NUODB_TYPE_BOOL:
                         i64 = resultSet->getBoolean(columnIndex);
-                        if (!resultSet->wasNull()) {
-                            vt = NUODB_TYPE_BOOL;
-                        }
                         break;
-                    case NUOSQL_DATE:
-                    case NUOSQL_TIME:
-                    case NUOSQL_TIMESTAMP: {
-                        Timestamp *ts = resultSet->getTimestamp(columnIndex);
-                        if (ts && !resultSet->wasNull()) {
-                            vt = NUODB_TYPE_TIME;
-                            i64 = ts->getSeconds();
-                            i32 = ts->getNanos();
-                        }
+                    default: // NUODB_TYPE_INT64, checked by the caller
+                        i64 = resultSet->getLong(columnIndex);
                         break;
-                    }
-                    default: {
-                        const Bytes b = resultSet->getBytes(columnIndex);
-                        if (!resultSet->wasNull()) {
-                            vt = NUODB_TYPE_BYTES;
-                            i64 = reinterpret_cast<int64_t>(b.data);
-                            i32 = b.length;
-                        }
-                        break;
-                    }
                 }
-                values[i].i64 = i64;
-                values[i].i32 = i32;
-                values[i].vt = vt;
+                values[i * max_rows + row] = i64;
+                if (!resultSet->wasNull()) {
+                    valid[i * words + row / 64] |= uint64_t(1) << (row % 64);
+                }
             }
+            ++*row_count;
+        }
+        return 0;
+    } catch (SQLException &e) {
+        return setError(db, e);
+    }
+}
+
+int nuodb_lob_length(struct nuodb *db, struct nuodb_lob *lob, enum nuodb_value_type vt,
+                     int64_t *length) {
+    try {
+        if (vt == NUODB_TYPE_CLOB) {
+            *length = reinterpret_cast<Clob *>(lob)->length();
+        } else {
+            *length = reinterpret_cast<Blob *>(lob)->length();
+        }
+        return 0;
+    } catch (SQLException &e) {
+        return setError(db, e);
+    }
+}
+
+int nuodb_lob_read(struct nuodb *db, struct nuodb_lob *lob, enum nuodb_value_type vt,
+                   int64_t offset, unsigned char *buffer, int32_t length) {
+    try {
+        // LOB positions are 1-based
+        if (vt == NUODB_TYPE_CLOB) {
+            reinterpret_cast<Clob *>(lob)->getChars(offset + 1, length, reinterpret_cast<char *>(buffer));
+        } else {
+            reinterpret_cast<Blob *>(lob)->getBytes(offset + 1, length, buffer);
+        }
+        return 0;
+    } catch (SQLException &e) {
+        return setError(db, e);
+    }
+}
+
+int nuodb_lob_create(struct nuodb *db, enum nuodb_value_type vt, struct nuodb_lob **lob) {
+    try {
+        if (vt == NUODB_TYPE_CLOB) {
+            *lob = reinterpret_cast<struct nuodb_lob *>(db->conn->createClob());
+        } else {
+            *lob = reinterpret_cast<struct nuodb_lob *>(db->conn->createBlob());
         }
         return 0;
     } catch (SQLException &e) {
@@ -419,6 +1414,47 @@ int nuodb_resultset_next(struct nuodb *db, struct nuodb_resultset *rs,
     }
 }
 
+int nuodb_lob_write(struct nuodb *db, struct nuodb_lob *lob, enum nuodb_value_type vt,
+                    int64_t offset, const unsigned char *buffer, int32_t length) {
+    try {
+        // LOB positions are 1-based
+        if (vt == NUODB_TYPE_CLOB) {
+            reinterpret_cast<Clob *>(lob)->setChars(offset + 1, length, reinterpret_cast<const char *>(buffer));
+        } else {
+            reinterpret_cast<Blob *>(lob)->setBytes(offset + 1, length, buffer);
+        }
+        return 0;
+    } catch (SQLException &e) {
+        return setError(db, e);
+    }
+}
+
+void nuodb_lob_release(struct nuodb_lob *lob, enum nuodb_value_type vt) {
+    if (vt == NUODB_TYPE_CLOB) {
+        reinterpret_cast<Clob *>(lob)->release();
+    } else {
+        reinterpret_cast<Blob *>(lob)->release();
+    }
+}
+
+// nuodb_resultset_move positions the cursor of a scrollable result set on
+// the given row, or by the given number of rows if relative, and returns the
+// number of the row it is on, 0 if before the first or after the last row.
+int nuodb_resultset_move(struct nuodb *db, struct nuodb_resultset *rs, int rows, int relative, int *row) {
+    ResultSet *resultSet = reinterpret_cast<ResultSet *>(rs);
+    try {
+        if (relative) {
+            resultSet->relative(rows);
+        } else {
+            resultSet->absolute(rows);
+        }
+        *row = resultSet->getRow();
+        return 0;
+    } catch (SQLException &e) {
+        return setError(db, e);
+    }
+}
+
 int nuodb_resultset_close(struct nuodb *db, struct nuodb_resultset **rs) {
     try {
         if (rs && *rs) {
@@ -431,3 +1467,23 @@ int nuodb_resultset_close(struct nuodb *db, struct nuodb_resultset **rs) {
         return setError(db, e);
     }
 }
+
+void nuodb_resultset_cancel(struct nuodb *db, struct nuodb_statement *st, struct nuodb_resultset **rs) {
+    // Cancels the statement producing the unread rows, so that the TE stops
+    // sending them, and closes the result set. The errors of the cancelled
+    // statement are not recorded on the connection, which stays usable.
+    try {
+        if (st) {
+            reinterpret_cast<PreparedStatement *>(st)->cancel();
+        }
+    } catch (SQLException &e) {
+    }
+    try {
+        if (rs && *rs) {
+            ResultSet *resultSet = reinterpret_cast<ResultSet *>(*rs);
+            *rs = 0;
+            resultSet->close();
+        }
+    } catch (SQLException &e) {
+    }
+}
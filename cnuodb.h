@@ -8,7 +8,7 @@
 
     To reduce CGO function call and memory allocation overhead, the API
     enforces the client to batch operations in:
-    - fetch column names
+    - fetch column names and types
     - bind parameters to a statement
     - fetch row values from a result set
 */
@@ -20,18 +20,31 @@ extern "C" {
 
 #include <stdint.h>
 
+/* On Windows the API is a DLL of its own, built with the compiler of the
+   NuoDB client library, since the C++ ABI of MSVC isn't the one of MinGW. */
+#if defined(_WIN32) && defined(CNUODB_EXPORTS)
+#define CNUODB_API __declspec(dllexport)
+#else
+#define CNUODB_API
+#endif
+
 struct nuodb;
 struct nuodb_statement;
 struct nuodb_resultset;
+struct nuodb_lob;
 
 enum nuodb_value_type {
     NUODB_TYPE_NULL = 0,
     NUODB_TYPE_INT64,
     NUODB_TYPE_FLOAT64,
     NUODB_TYPE_BOOL,
-    NUODB_TYPE_STRING, // used only for bind parameter
+    NUODB_TYPE_STRING, // a bind parameter, or the value of a character column
     NUODB_TYPE_BYTES,
-    NUODB_TYPE_TIME
+    NUODB_TYPE_TIME,
+    NUODB_TYPE_BLOB, // LOB handles, streamed from a row or written for a parameter
+    NUODB_TYPE_CLOB,
+    NUODB_TYPE_DATE, // seconds of the midnight of a DATE
+    NUODB_TYPE_TIME_OF_DAY // seconds and nanos of a TIME on 1970-01-01
 };
 
 struct nuodb_value {
@@ -40,27 +53,82 @@ struct nuodb_value {
     enum nuodb_value_type vt;
 };
 
-void nuodb_init(struct nuodb **db);
-const char *nuodb_error(const struct nuodb *db);
-int nuodb_open(struct nuodb *db, const char *database, const char *username, const char *password, const char **props, int props_count);
-int nuodb_close(struct nuodb **db);
-
-int nuodb_autocommit(struct nuodb *db, int *state);
-int nuodb_autocommit_set(struct nuodb *db, int state);
-int nuodb_commit(struct nuodb *db);
-int nuodb_rollback(struct nuodb *db);
-int nuodb_execute(struct nuodb *db, const char *sql, int64_t *rows_affected, int64_t *last_insert_id, int64_t timeout_micro_seconds);
-
-int nuodb_statement_prepare(struct nuodb *db, const char *sql, struct nuodb_statement **st, int *parameter_count);
-int nuodb_statement_bind(struct nuodb *db, struct nuodb_statement *st, struct nuodb_value parameters[]);
-int nuodb_statement_execute(struct nuodb *db, struct nuodb_statement *st, int64_t *rows_affected, int64_t *last_insert_id);
-int nuodb_statement_query(struct nuodb *db, struct nuodb_statement *st, struct nuodb_resultset **rs, int *column_count);
-int nuodb_statement_close(struct nuodb *db, struct nuodb_statement **st);
-int nuodb_statement_set_query_micros(struct nuodb *db, struct nuodb_statement *st, int64_t timeout_micro_seconds);
-
-int nuodb_resultset_column_names(struct nuodb *db, struct nuodb_resultset *rs, struct nuodb_value names[]);
-int nuodb_resultset_next(struct nuodb *db, struct nuodb_resultset *rs, int *has_values, struct nuodb_value values[]);
-int nuodb_resultset_close(struct nuodb *db, struct nuodb_resultset **rs);
+/* Returned by nuodb_execute and nuodb_query for parameters which don't
+   match the placeholders of the statement in number. */
+#define NUODB_PARAMETER_COUNT_MISMATCH (-10001)
+
+struct nuodb_column_info {
+    int32_t nullable;  // 0: no nulls, 1: nullable, 2: unknown
+    int32_t length;    // length of a variable length type, otherwise -1
+    int32_t precision; // precision and scale of a decimal type, otherwise -1
+    int32_t scale;
+};
+
+CNUODB_API void nuodb_init(struct nuodb **db);
+CNUODB_API const char *nuodb_error(const struct nuodb *db);
+CNUODB_API const char *nuodb_sqlstate(const struct nuodb *db);
+CNUODB_API int nuodb_open(struct nuodb *db, const char *database, const char *username, const char *password, const char **props, int props_count);
+CNUODB_API int nuodb_close(struct nuodb **db);
+
+CNUODB_API int nuodb_autocommit(struct nuodb *db, int *state);
+CNUODB_API int nuodb_autocommit_set(struct nuodb *db, int state);
+CNUODB_API int nuodb_read_only(struct nuodb *db, int *state);
+CNUODB_API int nuodb_read_only_set(struct nuodb *db, int state);
+CNUODB_API int nuodb_commit(struct nuodb *db);
+CNUODB_API int nuodb_rollback(struct nuodb *db);
+CNUODB_API int nuodb_isolation(struct nuodb *db, int *level);
+CNUODB_API int nuodb_isolation_set(struct nuodb *db, int level);
+CNUODB_API int nuodb_reset(struct nuodb *db, int isolation, int read_only, int autocommit);
+CNUODB_API int nuodb_warning_count(struct nuodb *db);
+CNUODB_API void nuodb_warning(struct nuodb *db, int index, int *code, const char **message);
+CNUODB_API int nuodb_client_version(struct nuodb *db, const char **version);
+CNUODB_API int nuodb_server_version(struct nuodb *db, const char **version);
+CNUODB_API int nuodb_last_commit_info(struct nuodb *db, const char **info);
+CNUODB_API int nuodb_commit_info_set(struct nuodb *db, const char *info);
+CNUODB_API int nuodb_session_id(struct nuodb *db, int64_t *id);
+CNUODB_API int nuodb_execute(struct nuodb *db, const char *sql, struct nuodb_value parameters[], int parameter_count, int64_t *rows_affected, int64_t *last_insert_id, int64_t timeout_micro_seconds);
+CNUODB_API int nuodb_query(struct nuodb *db, const char *sql, struct nuodb_value parameters[], int parameter_count, int fetch_size, int max_rows, struct nuodb_statement **st, struct nuodb_resultset **rs, int *column_count, int64_t timeout_micro_seconds);
+
+CNUODB_API int nuodb_statement_prepare(struct nuodb *db, const char *sql, struct nuodb_statement **st, int *parameter_count);
+CNUODB_API int nuodb_statement_prepare_keys(struct nuodb *db, const char *sql, const char **columns, int column_count, struct nuodb_statement **st, int *parameter_count);
+CNUODB_API int nuodb_statement_prepare_call(struct nuodb *db, const char *sql, struct nuodb_statement **st, int *parameter_count);
+CNUODB_API int nuodb_statement_prepare_cursor(struct nuodb *db, const char *sql, int scrollable, int holdable, struct nuodb_statement **st, int *parameter_count);
+CNUODB_API int nuodb_statement_bind(struct nuodb *db, struct nuodb_statement *st, struct nuodb_value parameters[]);
+CNUODB_API int nuodb_statement_register_out(struct nuodb *db, struct nuodb_statement *st, int index);
+CNUODB_API int nuodb_statement_out_value(struct nuodb *db, struct nuodb_statement *st, int index, struct nuodb_value *value);
+CNUODB_API int nuodb_statement_execute(struct nuodb *db, struct nuodb_statement *st, int64_t *rows_affected, int64_t *last_insert_id);
+CNUODB_API int nuodb_statement_execute_batch(struct nuodb *db, struct nuodb_statement *st, struct nuodb_value parameters[], int row_count, int64_t rows_affected[]);
+CNUODB_API int nuodb_statement_query(struct nuodb *db, struct nuodb_statement *st, struct nuodb_resultset **rs, int *column_count);
+CNUODB_API int nuodb_statement_next_resultset(struct nuodb *db, struct nuodb_statement *st, struct nuodb_resultset **rs, int *column_count);
+CNUODB_API int nuodb_statement_close(struct nuodb *db, struct nuodb_statement **st);
+CNUODB_API int nuodb_statement_column_count(struct nuodb *db, struct nuodb_statement *st, int *column_count);
+CNUODB_API int nuodb_statement_column_names(struct nuodb *db, struct nuodb_statement *st, struct nuodb_value names[]);
+CNUODB_API int nuodb_statement_parameter_info(struct nuodb *db, struct nuodb_statement *st, struct nuodb_value types[], struct nuodb_column_info info[]);
+CNUODB_API int nuodb_statement_column_types(struct nuodb *db, struct nuodb_statement *st, struct nuodb_value types[], struct nuodb_column_info info[]);
+CNUODB_API int nuodb_statement_column_origins(struct nuodb *db, struct nuodb_statement *st, struct nuodb_value origins[]);
+CNUODB_API int nuodb_statement_set_query_micros(struct nuodb *db, struct nuodb_statement *st, int64_t timeout_micro_seconds);
+CNUODB_API void nuodb_statement_cancel(struct nuodb_statement *st);
+CNUODB_API void nuodb_cancel(struct nuodb *db);
+CNUODB_API void nuodb_cancel_reset(struct nuodb *db);
+CNUODB_API int nuodb_statement_set_fetch_size(struct nuodb *db, struct nuodb_statement *st, int fetch_size);
+CNUODB_API int nuodb_statement_set_max_rows(struct nuodb *db, struct nuodb_statement *st, int max_rows);
+
+CNUODB_API int nuodb_resultset_column_names(struct nuodb *db, struct nuodb_resultset *rs, struct nuodb_value names[]);
+CNUODB_API int nuodb_resultset_column_types(struct nuodb *db, struct nuodb_resultset *rs, struct nuodb_value types[]);
+CNUODB_API int nuodb_resultset_column_info(struct nuodb *db, struct nuodb_resultset *rs, struct nuodb_column_info info[]);
+CNUODB_API int nuodb_resultset_column_origins(struct nuodb *db, struct nuodb_resultset *rs, struct nuodb_value origins[]);
+CNUODB_API int nuodb_resultset_next(struct nuodb *db, struct nuodb_resultset *rs, int *has_values, struct nuodb_value values[], int stream_lobs);
+CNUODB_API int nuodb_resultset_next_batch(struct nuodb *db, struct nuodb_resultset *rs, int max_rows, struct nuodb_value values[], unsigned char *buffer, int64_t buffer_size, int *row_count, int *done);
+CNUODB_API int nuodb_resultset_next_columns(struct nuodb *db, struct nuodb_resultset *rs, int max_rows, int64_t values[], uint64_t valid[], int *row_count, int *done);
+CNUODB_API int nuodb_resultset_move(struct nuodb *db, struct nuodb_resultset *rs, int rows, int relative, int *row);
+CNUODB_API int nuodb_resultset_close(struct nuodb *db, struct nuodb_resultset **rs);
+CNUODB_API void nuodb_resultset_cancel(struct nuodb *db, struct nuodb_statement *st, struct nuodb_resultset **rs);
+
+CNUODB_API int nuodb_lob_length(struct nuodb *db, struct nuodb_lob *lob, enum nuodb_value_type vt, int64_t *length);
+CNUODB_API int nuodb_lob_read(struct nuodb *db, struct nuodb_lob *lob, enum nuodb_value_type vt, int64_t offset, unsigned char *buffer, int32_t length);
+CNUODB_API int nuodb_lob_create(struct nuodb *db, enum nuodb_value_type vt, struct nuodb_lob **lob);
+CNUODB_API int nuodb_lob_write(struct nuodb *db, struct nuodb_lob *lob, enum nuodb_value_type vt, int64_t offset, const unsigned char *buffer, int32_t length);
+CNUODB_API void nuodb_lob_release(struct nuodb_lob *lob, enum nuodb_value_type vt);
 
 #ifdef __cplusplus
 }
@@ -0,0 +1,64 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+// #include "cnuodb.h"
+import "C"
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+)
+
+// ErrColumnTooLarge is returned by Next for a row with a character or
+// binary value larger than the limit of WithMaxColumnBytes or of the
+// maxColumnBytes property, before the value is copied, so that a huge
+// BLOB selected by accident fails the query rather than exhausts the
+// memory of the program. The error wraps it with the column and the size.
+var ErrColumnTooLarge = errors.New("nuodb: column value exceeds the maximum size")
+
+// Truncated reports whether a value of the last row fetched by Next or
+// Peek was cut to the limit of WithMaxColumnBytes or of the maxColumnBytes
+// property, with truncation enabled. It is available on the *Rows of a raw
+// connection.
+func (rows *Rows) Truncated() bool {
+	return rows.truncated
+}
+
+// limitColumns refuses or cuts the values of a row fetched over the size
+// limit of rows.
+func (rows *Rows) limitColumns(values []C.struct_nuodb_value) error {
+	rows.truncated = false
+	for i := range values {
+		value := &values[i]
+		if value.vt != C.NUODB_TYPE_BYTES && value.vt != C.NUODB_TYPE_STRING {
+			continue
+		}
+		n := int(value.i32)
+		if n <= rows.columnLimit {
+			continue
+		}
+		if !rows.truncate {
+			return fmt.Errorf("%w: %s has %d bytes, more than %d", ErrColumnTooLarge, rows.columnNames[i], n, rows.columnLimit)
+		}
+		data := unsafe.Slice((*byte)(valueData(value)), n)
+		value.i32 = C.int32_t(cutLength(data, rows.columnLimit, value.vt == C.NUODB_TYPE_STRING))
+		rows.truncated = true
+	}
+	return nil
+}
+
+// cutLength returns the length of data cut to at most limit bytes, without
+// splitting a UTF-8 sequence of text.
+func cutLength(data []byte, limit int, text bool) int {
+	if len(data) <= limit {
+		return len(data)
+	}
+	n := limit
+	if text {
+		for n > 0 && data[n]&0xC0 == 0x80 {
+			n--
+		}
+	}
+	return n
+}
@@ -0,0 +1,75 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestCutLength(t *testing.T) {
+	for _, test := range []struct {
+		data     string
+		limit    int
+		text     bool
+		expected int
+	}{
+		{"abc", 5, true, 3},
+		{"abcdef", 4, false, 4},
+		{"abcdef", 4, true, 4},
+		{"aäb", 2, true, 1},
+		{"aäb", 2, false, 2},
+		{"aäb", 3, true, 3},
+		{"€uro", 2, true, 0},
+	} {
+		if n := cutLength([]byte(test.data), test.limit, test.text); n != test.expected {
+			t.Errorf("%q cut to %d: expected %d, got %d", test.data, test.limit, test.expected, n)
+		}
+	}
+}
+
+func TestColumnLimit(t *testing.T) {
+	c := &Conn{maxColumnSize: 100}
+	background := context.Background()
+	for _, test := range []struct {
+		ctx      context.Context
+		limit    int
+		truncate bool
+	}{
+		{background, 100, false},
+		{WithMaxColumnBytes(background, 10, true), 10, true},
+		{WithMaxColumnBytes(background, 0, false), 0, false},
+	} {
+		if limit, truncate := c.columnLimit(test.ctx); limit != test.limit || truncate != test.truncate {
+			t.Errorf("Expected %d, %v, got %d, %v", test.limit, test.truncate, limit, truncate)
+		}
+	}
+}
+
+func TestMaxColumnBytes(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	exec(t, db, "CREATE TABLE tests.FooBarHuge (id BIGINT, b BLOB, s VARCHAR(100))")
+	exec(t, db, "INSERT INTO tests.FooBarHuge VALUES (1, ?, 'aäb')", bytes.Repeat([]byte{1}, 1000))
+
+	rows, err := db.QueryContext(WithMaxColumnBytes(context.Background(), 100, false), "SELECT b FROM tests.FooBarHuge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows.Next() || !errors.Is(rows.Err(), ErrColumnTooLarge) {
+		t.Fatalf("Expected %v, got %v", ErrColumnTooLarge, rows.Err())
+	}
+	rows.Close()
+
+	var b []byte
+	var s string
+	ctx := WithMaxColumnBytes(context.Background(), 2, true)
+	if err := db.QueryRowContext(ctx, "SELECT b, s FROM tests.FooBarHuge").Scan(&b, &s); err != nil {
+		t.Fatal(err)
+	}
+	if len(b) != 2 || s != "a" {
+		t.Fatalf("Expected the values to be cut, got %v and %q", b, s)
+	}
+}
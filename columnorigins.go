@@ -0,0 +1,114 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+// #include "cnuodb.h"
+import "C"
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"unsafe"
+)
+
+// ColumnOrigin is the table column which a result column was selected from.
+// It is empty for a computed column, e.g. COUNT(*).
+type ColumnOrigin struct {
+	Schema string
+	Table  string
+	Column string // the name of the column in the table, not its label
+}
+
+// RowsColumnOrigins is implemented by the driver rows of a query, so that a
+// mapper can tell apart the result columns of a join which have the same
+// label, e.g. SELECT a.id, b.id:
+//
+//	err := conn.Raw(func(driverConn interface{}) error {
+//		rows, err := driverConn.(*nuodb.Conn).QueryContext(ctx, query, nil)
+//		if err != nil {
+//			return err
+//		}
+//		defer rows.Close()
+//		origins, err := rows.(nuodb.RowsColumnOrigins).ColumnOrigins()
+//		...
+//	})
+//
+// PreparedColumnOrigins returns the same without executing the query.
+type RowsColumnOrigins interface {
+	// ColumnOrigins returns the origins of the result columns, in the
+	// order of Columns.
+	ColumnOrigins() ([]ColumnOrigin, error)
+}
+
+var _ RowsColumnOrigins = (*Rows)(nil)
+
+// ColumnOrigins implements RowsColumnOrigins.
+func (rows *Rows) ColumnOrigins() ([]ColumnOrigin, error) {
+	if len(rows.columnNames) == 0 {
+		return nil, nil
+	}
+	c := rows.c
+	if c.db == nil {
+		return nil, errClosed
+	}
+	values := make([]C.struct_nuodb_value, 3*len(rows.columnNames))
+	if err := rows.cgo(func() C.int {
+		return C.nuodb_resultset_column_origins(c.db, rows.rs, (*C.struct_nuodb_value)(unsafe.Pointer(&values[0])))
+	}); err != nil {
+		return nil, err
+	}
+	return columnOrigins(values), nil
+}
+
+// ColumnOrigins returns the origins of the result columns of the prepared
+// statement from its metadata, without executing it. They are nil for a
+// statement which returns no rows.
+func (stmt *Stmt) ColumnOrigins() ([]ColumnOrigin, error) {
+	count, err := stmt.ColumnCount()
+	if err != nil || count == 0 {
+		return nil, err
+	}
+	c := stmt.c
+	values := make([]C.struct_nuodb_value, 3*count)
+	if err := stmt.cgo(func() C.int {
+		return C.nuodb_statement_column_origins(c.db, stmt.st, (*C.struct_nuodb_value)(unsafe.Pointer(&values[0])))
+	}); err != nil {
+		return nil, err
+	}
+	return columnOrigins(values), nil
+}
+
+// PreparedColumnOrigins prepares query on conn and returns the origins of
+// its result columns without executing it, like PreparedColumns.
+func PreparedColumnOrigins(ctx context.Context, conn *sql.Conn, query string) ([]ColumnOrigin, error) {
+	var origins []ColumnOrigin
+	err := conn.Raw(func(driverConn interface{}) error {
+		c, ok := driverConn.(*Conn)
+		if !ok {
+			return errors.New("nuodb: not a nuodb connection")
+		}
+		if ctx.Err() != nil {
+			return contextError(ctx)
+		}
+		ds, err := c.Prepare(query)
+		if err != nil {
+			return err
+		}
+		stmt := ds.(*Stmt)
+		defer stmt.Close()
+		origins, err = stmt.ColumnOrigins()
+		return err
+	})
+	return origins, err
+}
+
+// columnOrigins returns the origins stored by the C layer, three strings
+// per column.
+func columnOrigins(values []C.struct_nuodb_value) []ColumnOrigin {
+	names := columnLabels(values)
+	origins := make([]ColumnOrigin, len(names)/3)
+	for i := range origins {
+		origins[i] = ColumnOrigin{Schema: names[3*i], Table: names[3*i+1], Column: names[3*i+2]}
+	}
+	return origins
+}
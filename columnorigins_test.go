@@ -0,0 +1,60 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+)
+
+func TestColumnOrigins(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	exec(t, db, "CREATE TABLE FooBar (id BIGINT, name STRING)")
+	exec(t, db, "CREATE TABLE BarBaz (id BIGINT, foo BIGINT)")
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	query := "SELECT f.id, b.id, COUNT(*) AS n FROM FooBar f JOIN BarBaz b ON b.foo = f.id GROUP BY f.id, b.id"
+	check := func(origins []ColumnOrigin) {
+		t.Helper()
+		if len(origins) != 3 {
+			t.Fatalf("Expected 3 columns, got %+v", origins)
+		}
+		if o := origins[0]; o.Table != "FOOBAR" || o.Column != "ID" || o.Schema == "" {
+			t.Fatalf("Expected FOOBAR.ID, got %+v", o)
+		}
+		if o := origins[1]; o.Table != "BARBAZ" || o.Column != "ID" {
+			t.Fatalf("Expected BARBAZ.ID, got %+v", o)
+		}
+		if o := origins[2]; o.Table != "" {
+			t.Fatalf("Expected a computed column, got %+v", o)
+		}
+	}
+	origins, err := PreparedColumnOrigins(ctx, conn, query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(origins)
+	err = conn.Raw(func(driverConn interface{}) error {
+		rows, err := driverConn.(*Conn).QueryContext(ctx, query, []driver.NamedValue{})
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		origins, err := rows.(RowsColumnOrigins).ColumnOrigins()
+		if err != nil {
+			return err
+		}
+		check(origins)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
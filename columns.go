@@ -0,0 +1,170 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+// #include "cnuodb.h"
+import "C"
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"unsafe"
+)
+
+// ColumnKind is the representation of a column of a ColumnBatch.
+type ColumnKind int
+
+const (
+	ColumnInt64   ColumnKind = iota // integer types without scale
+	ColumnFloat64                   // FLOAT and DOUBLE
+	ColumnBool                      // BOOLEAN
+)
+
+// Column holds the values of a column of a ColumnBatch. Only the slice of
+// the kind of the column is set. The value of a NULL is zero.
+type Column struct {
+	Name    string
+	Kind    ColumnKind
+	Int64   []int64
+	Float64 []float64
+	Bool    []bool
+	Valid   []uint64 // validity bitmap; bit i%64 of Valid[i/64] is set if row i is not NULL
+}
+
+// Null reports whether the value of row i is NULL.
+func (c *Column) Null(i int) bool {
+	return c.Valid[i/64]&(1<<(uint(i)%64)) == 0
+}
+
+// ColumnBatch holds consecutive rows of a result in column-major form.
+type ColumnBatch struct {
+	Len     int // number of rows
+	Columns []Column
+}
+
+// DefaultColumnBatchRows is the number of rows of a ColumnBatch by default.
+const DefaultColumnBatchRows = 1024
+
+// QueryColumns runs query on conn and calls fn with its result rows in
+// batches of up to batchRows rows, DefaultColumnBatchRows if 0. The values
+// are decoded by the C layer directly into typed slices, without boxing
+// each of them into an interface, e.g. for feature extraction:
+//
+//	err := nuodb.QueryColumns(ctx, conn, "SELECT age, income FROM people", 0,
+//		func(b *nuodb.ColumnBatch) error {
+//			ages, incomes := b.Columns[0].Int64, b.Columns[1].Float64
+//			...
+//			return nil
+//		})
+//
+// All the columns must be integers without scale, floating point numbers or
+// booleans; decimals, strings and the other types are rejected, as are the
+// masked columns of a Connector with MaskRules. The batch and its slices
+// are reused, so they are only valid until fn returns. An error returned
+// by fn stops the query and is returned.
+func QueryColumns(ctx context.Context, conn *sql.Conn, query string, batchRows int,
+	fn func(*ColumnBatch) error, args ...interface{}) error {
+	if batchRows <= 0 {
+		batchRows = DefaultColumnBatchRows
+	}
+	named, err := namedValues(args)
+	if err != nil {
+		return err
+	}
+	return conn.Raw(func(driverConn interface{}) error {
+		c, ok := driverConn.(*Conn)
+		if !ok {
+			return errors.New("nuodb: not a nuodb connection")
+		}
+		dr, err := c.QueryContext(ctx, query, named)
+		if err != nil {
+			return err
+		}
+		rows := dr.(*Rows)
+		defer rows.Close()
+		return rows.fetchColumns(ctx, batchRows, fn)
+	})
+}
+
+func (rows *Rows) fetchColumns(ctx context.Context, batchRows int, fn func(*ColumnBatch) error) error {
+	c := rows.c
+	if rows.maskers != nil {
+		return errors.New("nuodb: masked columns can't be fetched as columns")
+	}
+	types, err := rows.columnTypes()
+	if err != nil {
+		return err
+	}
+	cc := len(types)
+	if cc == 0 {
+		return nil
+	}
+	batch := &ColumnBatch{Columns: make([]Column, cc)}
+	for i, t := range types {
+		col := &batch.Columns[i]
+		col.Name = rows.columnNames[i]
+		switch t.valueType {
+		case C.NUODB_TYPE_INT64:
+			col.Kind = ColumnInt64
+		case C.NUODB_TYPE_FLOAT64:
+			col.Kind = ColumnFloat64
+		case C.NUODB_TYPE_BOOL:
+			col.Kind = ColumnBool
+			col.Bool = make([]bool, batchRows)
+		default:
+			return fmt.Errorf("nuodb: column %s of type %s can't be fetched as a column", col.Name, t.databaseTypeName)
+		}
+	}
+	words := (batchRows + 63) / 64
+	values := make([]int64, cc*batchRows)
+	valid := make([]uint64, cc*words)
+	for {
+		if ctx.Err() != nil {
+			return contextError(ctx)
+		}
+		var count, done C.int
+		c.stats.call()
+		if err := rows.cgo(func() C.int {
+			return C.nuodb_resultset_next_columns(c.db, rows.rs, C.int(batchRows),
+				(*C.int64_t)(unsafe.Pointer(&values[0])), (*C.uint64_t)(unsafe.Pointer(&valid[0])), &count, &done)
+		}); err != nil {
+			return err
+		}
+		n := int(count)
+		exceeded := rows.maxRows > 0 && rows.count+n > rows.maxRows
+		if exceeded {
+			n = rows.maxRows - rows.count
+		}
+		rows.count += n
+		if n > 0 {
+			batch.Len = n
+			for i := range batch.Columns {
+				col := &batch.Columns[i]
+				column := values[i*batchRows : i*batchRows+n]
+				col.Valid = valid[i*words : i*words+(n+63)/64]
+				switch col.Kind {
+				case ColumnInt64:
+					col.Int64 = column
+				case ColumnFloat64:
+					col.Float64 = (*[1 << 27]float64)(unsafe.Pointer(&column[0]))[:n:n]
+				case ColumnBool:
+					col.Bool = col.Bool[:n]
+					for j, v := range column {
+						col.Bool[j] = v != 0
+					}
+				}
+			}
+			if err := fn(batch); err != nil {
+				return err
+			}
+		}
+		if exceeded {
+			return ErrMaxRows
+		}
+		if done != 0 {
+			rows.batch.done = true
+			return nil
+		}
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestColumnNull(t *testing.T) {
+	col := Column{Valid: []uint64{1<<0 | 1<<63, 1 << 1}}
+	for i, null := range map[int]bool{0: false, 1: true, 63: false, 64: true, 65: false} {
+		if col.Null(i) != null {
+			t.Errorf("Row %d: expected null %v", i, null)
+		}
+	}
+}
+
+func TestQueryColumns(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	ctx := context.Background()
+	exec(t, db, "CREATE TABLE FooBar (id BIGINT, score DOUBLE, active BOOLEAN, name STRING)")
+	const n = 150
+	for i := 0; i < n; i++ {
+		if i%10 == 3 {
+			exec(t, db, "INSERT INTO FooBar (id, score, active, name) VALUES (?, NULL, NULL, 'x')", i)
+			continue
+		}
+		exec(t, db, "INSERT INTO FooBar (id, score, active, name) VALUES (?, ?, ?, 'x')", i, float64(i)/2, i%2 == 0)
+	}
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	rows, batches := 0, 0
+	err = QueryColumns(ctx, conn, "SELECT id, score, active FROM FooBar WHERE id >= ? ORDER BY id", 64,
+		func(b *ColumnBatch) error {
+			batches++
+			id, score, active := &b.Columns[0], &b.Columns[1], &b.Columns[2]
+			if id.Kind != ColumnInt64 || score.Kind != ColumnFloat64 || active.Kind != ColumnBool {
+				t.Fatalf("Unexpected kinds %v, %v, %v", id.Kind, score.Kind, active.Kind)
+			}
+			for j := 0; j < b.Len; j++ {
+				i := rows + j
+				if id.Int64[j] != int64(i) || id.Null(j) {
+					t.Fatalf("Row %d: unexpected id %d", i, id.Int64[j])
+				}
+				if i%10 == 3 {
+					if !score.Null(j) || !active.Null(j) {
+						t.Fatalf("Row %d: expected NULLs", i)
+					}
+					continue
+				}
+				if score.Float64[j] != float64(i)/2 || active.Bool[j] != (i%2 == 0) {
+					t.Fatalf("Row %d: unexpected %v, %v", i, score.Float64[j], active.Bool[j])
+				}
+			}
+			rows += b.Len
+			return nil
+		}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows != n || batches != 3 {
+		t.Fatalf("Expected %d rows in 3 batches, got %d in %d", n, rows, batches)
+	}
+
+	if err := QueryColumns(ctx, conn, "SELECT id, name FROM FooBar", 0, func(*ColumnBatch) error { return nil }); err == nil {
+		t.Fatal("Expected a string column to be rejected")
+	}
+	stop := errors.New("stop")
+	if err := QueryColumns(ctx, conn, "SELECT id FROM FooBar", 10, func(*ColumnBatch) error { return stop }); err != stop {
+		t.Fatalf("Expected %v, got %v", stop, err)
+	}
+}
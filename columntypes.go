@@ -0,0 +1,158 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+// #include "cnuodb.h"
+import "C"
+import (
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"time"
+	"unsafe"
+)
+
+var _ driver.RowsColumnTypeDatabaseTypeName = (*Rows)(nil)
+var _ driver.RowsColumnTypeScanType = (*Rows)(nil)
+var _ driver.RowsColumnTypeNullable = (*Rows)(nil)
+var _ driver.RowsColumnTypeLength = (*Rows)(nil)
+var _ driver.RowsColumnTypePrecisionScale = (*Rows)(nil)
+
+type columnType struct {
+	databaseTypeName string
+	valueType        C.enum_nuodb_value_type
+	info             C.struct_nuodb_column_info
+}
+
+// columnTypes fetches the column types from the result set metadata on the
+// first use, unless cached by the statement.
+func (rows *Rows) columnTypes() ([]columnType, error) {
+	if rows.types != nil || len(rows.columnNames) == 0 {
+		return rows.types, nil
+	}
+	if rc := rows.columns; rc != nil && len(rc.types) == len(rows.columnNames) {
+		rows.types = rc.types
+		return rows.types, nil
+	}
+	c := rows.c
+	if c.db == nil {
+		return nil, errClosed
+	}
+	values := make([]C.struct_nuodb_value, len(rows.columnNames))
+	if err := rows.cgo(func() C.int {
+		return C.nuodb_resultset_column_types(c.db, rows.rs, (*C.struct_nuodb_value)(unsafe.Pointer(&values[0])))
+	}); err != nil {
+		return nil, err
+	}
+	info := make([]C.struct_nuodb_column_info, len(values))
+	if err := rows.cgo(func() C.int {
+		return C.nuodb_resultset_column_info(c.db, rows.rs, (*C.struct_nuodb_column_info)(unsafe.Pointer(&info[0])))
+	}); err != nil {
+		return nil, err
+	}
+	types := make([]columnType, len(values))
+	for i, value := range values {
+		types[i].info = info[i]
+		types[i].valueType = value.vt
+		if length := (C.int)(value.i32); length > 0 {
+			cstr := (*C.char)(valueData(&value))
+			types[i].databaseTypeName = strings.ToUpper(C.GoStringN(cstr, length))
+		}
+	}
+	rows.types = types
+	if rows.columns != nil {
+		rows.columns.types = types
+	}
+	return types, nil
+}
+
+func (rows *Rows) columnType(index int) columnType {
+	types, err := rows.columnTypes()
+	if err != nil || index >= len(types) {
+		return columnType{info: C.struct_nuodb_column_info{nullable: 2, length: -1, precision: -1, scale: -1}}
+	}
+	return types[index]
+}
+
+// characterType reports whether databaseTypeName is a type of character
+// strings, such as VARCHAR or CLOB.
+func characterType(databaseTypeName string) bool {
+	for _, s := range []string{"CHAR", "STRING", "TEXT", "CLOB"} {
+		if strings.Contains(databaseTypeName, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// ColumnTypeDatabaseTypeName implements driver.RowsColumnTypeDatabaseTypeName.
+func (rows *Rows) ColumnTypeDatabaseTypeName(index int) string {
+	return rows.columnType(index).databaseTypeName
+}
+
+var (
+	scanTypeInt64   = reflect.TypeOf(int64(0))
+	scanTypeFloat64 = reflect.TypeOf(float64(0))
+	scanTypeBool    = reflect.TypeOf(false)
+	scanTypeTime    = reflect.TypeOf(time.Time{})
+	scanTypeString  = reflect.TypeOf("")
+	scanTypeBytes   = reflect.TypeOf([]byte(nil))
+	scanTypeAny     = reflect.TypeOf((*interface{})(nil)).Elem()
+)
+
+// ColumnTypeScanType implements driver.RowsColumnTypeScanType.
+func (rows *Rows) ColumnTypeScanType(index int) reflect.Type {
+	return scanType(rows.columnType(index).valueType)
+}
+
+// scanType returns the type of the values fetched as vt.
+func scanType(vt C.enum_nuodb_value_type) reflect.Type {
+	switch vt {
+	case C.NUODB_TYPE_INT64:
+		return scanTypeInt64
+	case C.NUODB_TYPE_FLOAT64:
+		return scanTypeFloat64
+	case C.NUODB_TYPE_BOOL:
+		return scanTypeBool
+	case C.NUODB_TYPE_TIME, C.NUODB_TYPE_DATE, C.NUODB_TYPE_TIME_OF_DAY:
+		return scanTypeTime
+	case C.NUODB_TYPE_STRING:
+		return scanTypeString
+	case C.NUODB_TYPE_BYTES:
+		return scanTypeBytes
+	}
+	return scanTypeAny
+}
+
+// ColumnTypeNullable implements driver.RowsColumnTypeNullable.
+func (rows *Rows) ColumnTypeNullable(index int) (nullable, ok bool) {
+	switch rows.columnType(index).info.nullable {
+	case 0:
+		return false, true
+	case 1:
+		return true, true
+	}
+	return false, false
+}
+
+// ColumnTypeLength implements driver.RowsColumnTypeLength. It reports the
+// length of the variable length character and binary types.
+func (rows *Rows) ColumnTypeLength(index int) (length int64, ok bool) {
+	info := rows.columnType(index).info
+	if info.length < 0 {
+		return 0, false
+	}
+	return int64(info.length), true
+}
+
+// ColumnTypePrecisionScale implements driver.RowsColumnTypePrecisionScale.
+// It reports the precision and scale of the decimal types, and of the TIME
+// and TIMESTAMP types, whose scale is the number of the digits of the
+// fraction of a second they keep, e.g. 9 of a TIMESTAMP(9).
+func (rows *Rows) ColumnTypePrecisionScale(index int) (precision, scale int64, ok bool) {
+	info := rows.columnType(index).info
+	if info.precision < 0 {
+		return 0, 0, false
+	}
+	return int64(info.precision), int64(info.scale), true
+}
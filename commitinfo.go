@@ -0,0 +1,71 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+// #include "cnuodb.h"
+// #include <stdlib.h>
+import "C"
+import (
+	"context"
+	"unsafe"
+)
+
+// CommitInfo identifies a commit, so that a statement on another
+// connection, possibly served by another TE, can wait until the TE has
+// seen the commit. It is opaque; pass it on as is, e.g. in a session
+// cookie.
+type CommitInfo string
+
+// CommitInfo implements NuoConn. It returns the commit info of the last
+// transaction committed on the connection, also in autocommit mode, to
+// read its writes on other connections:
+//
+//	var info nuodb.CommitInfo
+//	err := conn.Raw(func(driverConn interface{}) (err error) {
+//		info, err = driverConn.(*nuodb.Conn).CommitInfo()
+//		return err
+//	})
+//	...
+//	rows, err := db.QueryContext(nuodb.WithCommitInfo(ctx, info), "SELECT ...")
+func (c *Conn) CommitInfo() (CommitInfo, error) {
+	if c == nil || c.db == nil {
+		return "", errUninitialized
+	}
+	var info *C.char
+	if err := c.cgo(func() C.int { return C.nuodb_last_commit_info(c.db, &info) }); err != nil {
+		return "", err
+	}
+	return CommitInfo(C.GoString(info)), nil
+}
+
+type commitInfoKey struct{}
+
+// WithCommitInfo returns a copy of ctx which carries info. Before a
+// statement is run with the returned context the driver passes info to the
+// connection, so that the statement observes the commit, e.g. to read the
+// writes of a previous request served by another connection of the pool.
+func WithCommitInfo(ctx context.Context, info CommitInfo) context.Context {
+	return context.WithValue(ctx, commitInfoKey{}, info)
+}
+
+func commitInfoFrom(ctx context.Context) CommitInfo {
+	info, _ := ctx.Value(commitInfoKey{}).(CommitInfo)
+	return info
+}
+
+// applyCommitInfo passes the commit info of ctx to the connection, unless
+// it has been passed already. The commit info stays in effect on the
+// connection, which is harmless, as an observed commit stays observed.
+func (c *Conn) applyCommitInfo(ctx context.Context) error {
+	info := commitInfoFrom(ctx)
+	if info == "" || info == c.commitInfo {
+		return nil
+	}
+	cinfo := C.CString(string(info))
+	defer C.free(unsafe.Pointer(cinfo))
+	if err := c.cgo(func() C.int { return C.nuodb_commit_info_set(c.db, cinfo) }); err != nil {
+		return err
+	}
+	c.commitInfo = info
+	return nil
+}
@@ -0,0 +1,62 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWithCommitInfo(t *testing.T) {
+	ctx := context.Background()
+	if info := commitInfoFrom(ctx); info != "" {
+		t.Fatalf("Expected no commit info, got %q", info)
+	}
+	if info := commitInfoFrom(WithCommitInfo(ctx, "1:42")); info != "1:42" {
+		t.Fatalf("Expected the commit info, got %q", info)
+	}
+	c := &Conn{commitInfo: "1:42"}
+	if err := c.applyCommitInfo(WithCommitInfo(ctx, "1:42")); err != nil {
+		t.Fatalf("Expected the applied commit info to be skipped, got %v", err)
+	}
+}
+
+func TestCommitInfo(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	exec(t, db, "CREATE TABLE tests.FooBar (id INTEGER)")
+	ctx := context.Background()
+
+	writer, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	if _, err := writer.ExecContext(ctx, "INSERT INTO tests.FooBar VALUES (1)"); err != nil {
+		t.Fatal(err)
+	}
+	var info CommitInfo
+	err = writer.Raw(func(driverConn interface{}) (err error) {
+		info, err = driverConn.(NuoConn).CommitInfo()
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info == "" {
+		t.Fatal("Expected commit info")
+	}
+
+	reader, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	var count int
+	if err := reader.QueryRowContext(WithCommitInfo(ctx, info), "SELECT COUNT(*) FROM tests.FooBar").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Fatalf("Expected the committed row, got %d rows", count)
+	}
+}
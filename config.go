@@ -0,0 +1,724 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tilinna/go-nuodb/internal/parse"
+)
+
+// Config is a parsed data source name. It can be used to configure
+// connections without building the url:
+//
+//	connector, err := nuodb.NewConnector(&nuodb.Config{
+//		Hosts:    []string{"localhost:48004"},
+//		Database: "tests",
+//		User:     "robinh",
+//		Password: "crossbow",
+//		Schema:   "abcd",
+//	})
+//	...
+//	db := sql.OpenDB(connector)
+type Config struct {
+	Hosts    []string // broker addresses as host[:port], tried in order
+	Database string
+	User     string
+	Password string
+	Schema   string         // default schema
+	Timezone *time.Location // default time zone; Local if nil
+	Locale   string         // locale of the session, e.g. "en_US"; see the locale property
+
+	// ClientInfo identifies the application in the NuoDB admin tools,
+	// instead of the program, host and driver version. See the clientInfo
+	// property.
+	ClientInfo string
+
+	TLS *TLSConfig // nil leaves the encryption to the client defaults
+
+	// Authentication selects how the server verifies the password of the
+	// user; the default is AuthPassword. See the authentication property.
+	Authentication AuthMethod
+
+	// LBQuery and LBTag select the Transaction Engines which the brokers
+	// connect the connections to, with a load balancer query, e.g.
+	// "round_robin(first(label(role analytics) any))", or the tag of a
+	// load balancer policy of the domain. WithLBQuery and WithLBTag
+	// override them for the connections of a call. See the LBQuery and
+	// LBTag properties.
+	LBQuery string
+	LBTag   string
+
+	// LBPolicy and LBRegion pick the Transaction Engine of a connection by
+	// the policy, among those whose region label is LBRegion if any of
+	// them is running, instead of an LBQuery. A region alone uses
+	// LBRoundRobin. See the lbPolicy and lbRegion properties.
+	LBPolicy LBPolicy
+	LBRegion string
+
+	// Properties are the other connection properties passed to NuoDB.
+	Properties map[string]string
+
+	MaxQueryTimeout   time.Duration // see the maxQueryTimeout property
+	DefaultTimeout    time.Duration // see the defaultTimeout property
+	MaxTxDuration     time.Duration // see the maxTxDuration property
+	KeepaliveInterval time.Duration // see the keepaliveInterval property
+	RetryAttempts     int           // see the retryAttempts property
+	RetryBackoff      time.Duration // see the retryBackoff property
+	MaxRows           int           // see the maxRows property
+	MaxColumnBytes    int           // see the maxColumnBytes property
+	RandomizeHosts    bool          // see the randomizeHosts property
+	SearchPath        []string      // see the searchPath property; the first schema is the default
+
+	EmptyStringAsNull bool // see the emptyStringAsNull property
+	NullStringAsEmpty bool // see the nullStringAsEmpty property
+	ReadOnly          bool // see the readOnly property
+	NoAutoCommit      bool // see the autocommit property; set for autocommit=false
+	TruncateColumns   bool // see the truncateColumns property
+	DeferClose        bool // see the deferClose property
+
+	// OnConnect lists the statements executed on every new connection, in
+	// order, e.g. SET statements initializing the session. See the
+	// onConnect property.
+	OnConnect []string
+
+	// Credentials supplies the user and password of every new connection
+	// instead of User and Password, e.g. to pick up rotated passwords. It
+	// has no data source name representation.
+	Credentials CredentialsProvider
+
+	// Masking masks the sensitive columns of the result rows of the
+	// connections, which are then unsuitable for anything but display. It
+	// has no data source name representation.
+	Masking *MaskRules
+
+	// Limiter caps the number of concurrently executing statements of the
+	// connections. It may be shared by several Connectors. It has no data
+	// source name representation.
+	Limiter *StatementLimiter
+
+	// Faults injects errors and latency into the statements and connects
+	// of the connections, for resilience testing only. It has no data
+	// source name representation.
+	Faults *FaultInjector
+
+	// Breaker fails the connects fast for a cooldown after repeated
+	// failed connects to the brokers. It has no data source name
+	// representation.
+	Breaker *CircuitBreaker
+
+	// ScanLocation is the time zone of the time.Time values scanned on
+	// the connections, e.g. time.UTC, rather than Timezone, the time zone
+	// of the session. WithLocation overrides it for a statement. It has no
+	// data source name representation.
+	ScanLocation *time.Location
+
+	// OnTxExpired is called after the driver rolled back a transaction
+	// which was open for longer than MaxTxDuration, or the duration of
+	// WithMaxTxDuration, e.g. to log the abandoned transaction. It is
+	// called on the goroutine of the timer or of the call which was in
+	// progress when the transaction expired, so it must not use the
+	// connection. It has no data source name representation.
+	OnTxExpired func(TxExpiredEvent)
+
+	// OnDrain is called once for a connection on which a statement failed
+	// with OperationKilled or IsShutdown, e.g. while its TE shuts down in
+	// a rolling restart, so that the application can shed load until the
+	// pool has connected to the other TEs. The connection is not reused by
+	// the pool, but stays usable for the work in progress unless the error
+	// was fatal. It is called on the goroutine of the failed call, so it
+	// must not use the connection. It has no data source name
+	// representation.
+	OnDrain func(DrainEvent)
+
+	// LongTxThreshold is the duration after which a transaction which is
+	// still open is reported to OnLongTx, or else logged at LevelWarn, with
+	// the stack of its Begin, e.g. to find the code paths which leave
+	// transactions open and keep the old record versions from being
+	// garbage collected. The transaction is left open; MaxTxDuration rolls
+	// it back. Zero disables the reports. It has no data source name
+	// representation.
+	LongTxThreshold time.Duration
+
+	// OnLongTx is called for each transaction still open after
+	// LongTxThreshold, on the goroutine of a timer, so it must not use the
+	// connection. It has no data source name representation.
+	OnLongTx func(LongTxEvent)
+
+	// MetadataTTL is how long the column types of the tables looked up by
+	// the helpers, e.g. LoadCSV, are cached for the connections of the
+	// Connector. A DDL statement executed on any of them, or a
+	// NoSuchTable or InvalidField error, drops the cache. Zero disables
+	// the cache. It has no data source name representation.
+	MetadataTTL time.Duration
+
+	// Hooks are called around the statements executed on the
+	// connections, if set. It has no data source name representation.
+	Hooks Hooks
+
+	// Logger receives the messages of the driver, if set, e.g. of the
+	// retried statements and of the connections broken by fatal errors.
+	// It has no data source name representation.
+	Logger Logger
+
+	// SlowQueryThreshold logs the statements which take at least as long
+	// to the Logger at LevelWarn, with their SQL, duration, number of rows
+	// and error code. The duration of a query includes fetching its rows,
+	// until the rows are closed. Zero disables the slow query log. It has
+	// no data source name representation.
+	SlowQueryThreshold time.Duration
+
+	// AnnotateErrors adds the SQL of the failed statement to the NuoDB
+	// errors of the statements, and with AnnotateSQLAndParams the types of
+	// its parameters, but not their values, so that the log line of an
+	// error tells which statement failed. It has no data source name
+	// representation.
+	AnnotateErrors ErrorAnnotation
+
+	// Reconnect selects the fatal connection errors on which database/sql
+	// reconnects and retries the call, ReconnectAlways by default. With
+	// ReconnectFirstUse, only a call failing on a connection on which no
+	// work was done is retried, so that the first query after the TE of
+	// an idle connection went away doesn't fail, yet a statement is never
+	// executed twice. It has no data source name representation.
+	Reconnect ReconnectPolicy
+
+	// LeakDetection reports the statements and result sets of the
+	// connections which are garbage collected without having been closed,
+	// with the stack which opened them, e.g. LeakDetectionPanic in tests.
+	// Recording the stacks slows the statements down, so it is off by
+	// default. It has no data source name representation.
+	LeakDetection LeakDetection
+
+	// Tracer starts the spans of the operations of the connections, if
+	// set. It has no data source name representation.
+	Tracer Tracer
+
+	// Metrics counts the operations of the connections, if set. It has no
+	// data source name representation.
+	Metrics *Metrics
+}
+
+// CredentialsProvider supplies the credentials of the connections opened
+// by a Connector. It is called for every new connection, possibly
+// concurrently, with the context of the connect.
+type CredentialsProvider interface {
+	Credentials(ctx context.Context) (user, password string, err error)
+}
+
+// TLSConfig configures the TLS encryption of the connection. The files
+// must exist.
+type TLSConfig struct {
+	TrustStore         string // file of the trusted certificates, trustStorePath
+	TrustStorePassword string // trustStorePassword
+	ClientCertificate  string // file of the client certificate, clientCertificatePath
+	ClientKey          string // file of its key, clientKeyPath
+	SkipVerifyHostname bool   // verifyHostname=false
+}
+
+// AuthMethod is a method of verifying the password of the user, see
+// Config.Authentication.
+type AuthMethod string
+
+const (
+	// AuthPassword verifies the password against the users of the
+	// database.
+	AuthPassword AuthMethod = parse.AuthPassword
+
+	// AuthLDAP has the server verify the password against the LDAP
+	// directory it is configured with, e.g. of the enterprise. The password
+	// is sent to the server, so it requires TLS. The client and the server
+	// must be NuoDB 4.0 or later.
+	AuthLDAP AuthMethod = parse.AuthLDAP
+
+	// AuthKerberos is not supported by the NuoDB client library, and is
+	// refused by the validation of a Config with an explanation.
+	AuthKerberos AuthMethod = parse.AuthKerberos
+)
+
+// Connection properties which are represented by the fields of Config.
+const (
+	propSchema     = "schema"
+	propTimezone   = "timezone"
+	propLocale     = parse.PropLocale
+	propClientInfo = "clientInfo"
+	propLBQuery    = parse.PropLBQuery
+	propLBTag      = "LBTag"
+)
+
+// LBPolicy is the load balancer policy of Config.LBPolicy.
+type LBPolicy string
+
+// The load balancer policies.
+const (
+	LBRandom     LBPolicy = parse.LBRandom     // a random TE for each connection
+	LBRoundRobin LBPolicy = parse.LBRoundRobin // the TEs in turn
+)
+
+// propClientProcessID is the connection property of the process id of the
+// client, set by default.
+const propClientProcessID = "clientProcessID"
+
+// ParseDSN parses a data source name into a Config. The environment
+// variables supply the defaults for the omitted parts, as with sql.Open.
+func ParseDSN(dsn string) (*Config, error) {
+	d, err := parse.ParseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &Config{
+		User:              d.Username,
+		Password:          d.Password,
+		MaxQueryTimeout:   d.MaxQueryTimeout,
+		DefaultTimeout:    d.DefaultTimeout,
+		MaxTxDuration:     d.MaxTxDuration,
+		KeepaliveInterval: d.KeepaliveInterval,
+		RetryAttempts:     d.RetryAttempts,
+		RetryBackoff:      d.RetryBackoff,
+		MaxRows:           d.MaxRows,
+		MaxColumnBytes:    d.MaxColumnBytes,
+	}
+	cfg.Database, cfg.Hosts = parse.SplitDatabase(d.Database)
+	cfg.RandomizeHosts = d.RandomizeHosts
+	cfg.EmptyStringAsNull = d.EmptyStringAsNull
+	cfg.NullStringAsEmpty = d.NullStringAsEmpty
+	cfg.ReadOnly = d.ReadOnly
+	cfg.NoAutoCommit = d.NoAutoCommit
+	cfg.TruncateColumns = d.TruncateColumns
+	cfg.DeferClose = d.DeferClose
+	cfg.SearchPath = d.SearchPath
+	cfg.OnConnect = d.OnConnect
+
+	props := make(map[string]string, len(d.Props))
+	for k, v := range d.Props {
+		props[k] = v
+	}
+	cfg.Schema = props[propSchema]
+	delete(props, propSchema)
+	cfg.Locale = props[propLocale]
+	delete(props, propLocale)
+	cfg.ClientInfo = props[propClientInfo]
+	delete(props, propClientInfo)
+	cfg.LBQuery = props[propLBQuery]
+	delete(props, propLBQuery)
+	// the policy has been translated by parse.ValidateLB
+	if policy, region, ok := parse.LBPolicy(cfg.LBQuery); ok {
+		cfg.LBPolicy, cfg.LBRegion, cfg.LBQuery = LBPolicy(policy), region, ""
+	}
+	cfg.LBTag = props[propLBTag]
+	delete(props, propLBTag)
+	cfg.Authentication = AuthMethod(props[parse.PropAuthentication])
+	delete(props, parse.PropAuthentication)
+	if _, ok := props[propTimezone]; ok {
+		cfg.Timezone = d.Location
+		delete(props, propTimezone)
+	}
+	// the TLS properties have been translated by parse.ValidateTLS
+	tls := &TLSConfig{
+		TrustStore:         props[parse.NuoTrustStore],
+		TrustStorePassword: props[parse.PropTrustStorePassword],
+		ClientCertificate:  props[parse.NuoClientCertificate],
+		ClientKey:          props[parse.NuoClientKey],
+		SkipVerifyHostname: props[parse.PropVerifyHostname] == "false",
+	}
+	if *tls != (TLSConfig{}) {
+		cfg.TLS = tls
+	}
+	for _, prop := range []string{parse.NuoTrustStore, parse.PropTrustStorePassword,
+		parse.NuoClientCertificate, parse.NuoClientKey, parse.PropVerifyHostname} {
+		delete(props, prop)
+	}
+	if len(props) > 0 {
+		cfg.Properties = props
+	}
+	return cfg, nil
+}
+
+// FormatDSN returns the data source name of the configuration.
+func (cfg *Config) FormatDSN() string {
+	u := &url.URL{
+		Scheme: "nuodb",
+		User:   url.UserPassword(cfg.User, cfg.Password),
+		Host:   strings.Join(cfg.Hosts, ","),
+		Path:   "/" + cfg.Database,
+	}
+	if cfg.Password == "" {
+		u.User = url.User(cfg.User)
+	}
+	query := make(url.Values)
+	for k, v := range cfg.props() {
+		query.Set(k, v)
+	}
+	if cfg.MaxQueryTimeout > 0 {
+		query.Set(parse.PropMaxQueryTimeout, cfg.MaxQueryTimeout.String())
+	}
+	if cfg.DefaultTimeout > 0 {
+		query.Set(parse.PropDefaultTimeout, cfg.DefaultTimeout.String())
+	}
+	if cfg.MaxTxDuration > 0 {
+		query.Set(parse.PropMaxTxDuration, cfg.MaxTxDuration.String())
+	}
+	if cfg.KeepaliveInterval > 0 {
+		query.Set(parse.PropKeepaliveInterval, cfg.KeepaliveInterval.String())
+	}
+	if cfg.RetryAttempts > 0 {
+		query.Set(parse.PropRetryAttempts, strconv.Itoa(cfg.RetryAttempts))
+	}
+	if cfg.MaxRows > 0 {
+		query.Set(parse.PropMaxRows, strconv.Itoa(cfg.MaxRows))
+	}
+	if cfg.MaxColumnBytes > 0 {
+		query.Set(parse.PropMaxColumnBytes, strconv.Itoa(cfg.MaxColumnBytes))
+	}
+	if cfg.RetryBackoff > 0 {
+		query.Set(parse.PropRetryBackoff, cfg.RetryBackoff.String())
+	}
+	if cfg.RandomizeHosts {
+		query.Set(parse.PropRandomizeHosts, "true")
+	}
+	if cfg.EmptyStringAsNull {
+		query.Set(parse.PropEmptyStringAsNull, "true")
+	}
+	if cfg.NullStringAsEmpty {
+		query.Set(parse.PropNullStringAsEmpty, "true")
+	}
+	if cfg.ReadOnly {
+		query.Set(parse.PropReadOnly, "true")
+	}
+	if cfg.NoAutoCommit {
+		query.Set(parse.PropAutoCommit, "false")
+	}
+	if cfg.TruncateColumns {
+		query.Set(parse.PropTruncateColumns, "true")
+	}
+	if cfg.DeferClose {
+		query.Set(parse.PropDeferClose, "true")
+	}
+	if len(cfg.SearchPath) > 0 {
+		query.Set(parse.PropSearchPath, strings.Join(cfg.SearchPath, ","))
+	}
+	if len(cfg.OnConnect) > 0 {
+		query.Set(parse.PropOnConnect, strings.Join(cfg.OnConnect, "; "))
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// props returns the connection properties, with the driver properties of
+// TLS.
+func (cfg *Config) props() map[string]string {
+	props := make(map[string]string, len(cfg.Properties)+5)
+	for k, v := range cfg.Properties {
+		props[k] = v
+	}
+	if cfg.Schema != "" {
+		props[propSchema] = cfg.Schema
+	}
+	if cfg.Timezone != nil {
+		props[propTimezone] = cfg.Timezone.String()
+	}
+	if cfg.Locale != "" {
+		props[propLocale] = cfg.Locale
+	}
+	if cfg.ClientInfo != "" {
+		props[propClientInfo] = cfg.ClientInfo
+	}
+	if cfg.LBQuery != "" {
+		props[propLBQuery] = cfg.LBQuery
+	}
+	if cfg.LBTag != "" {
+		props[propLBTag] = cfg.LBTag
+	}
+	if cfg.LBPolicy != "" {
+		props[parse.PropLBPolicy] = string(cfg.LBPolicy)
+	}
+	if cfg.LBRegion != "" {
+		props[parse.PropLBRegion] = cfg.LBRegion
+	}
+	if cfg.Authentication != "" {
+		props[parse.PropAuthentication] = string(cfg.Authentication)
+	}
+	if cfg.TLS != nil {
+		props[parse.PropTrustStorePath] = cfg.TLS.TrustStore
+		props[parse.PropTrustStorePassword] = cfg.TLS.TrustStorePassword
+		props[parse.PropClientCertificatePath] = cfg.TLS.ClientCertificate
+		props[parse.PropClientKeyPath] = cfg.TLS.ClientKey
+		if cfg.TLS.SkipVerifyHostname {
+			props[parse.PropVerifyHostname] = "false"
+		}
+	}
+	return props
+}
+
+// dsn validates the configuration. Unlike with ParseDSN, the environment
+// variables don't supply defaults to a Config.
+func (cfg *Config) dsn() (*parse.DSN, error) {
+	switch {
+	case len(cfg.Hosts) == 0:
+		return nil, errors.New("nuodb: invalid config: no hosts")
+	case cfg.Database == "":
+		return nil, errors.New("nuodb: invalid config: no database")
+	case cfg.User == "" && cfg.Credentials == nil:
+		return nil, errors.New("nuodb: invalid config: no user")
+	case cfg.MaxQueryTimeout < 0, cfg.DefaultTimeout < 0, cfg.MaxTxDuration < 0, cfg.RetryAttempts < 0,
+		cfg.RetryBackoff < 0, cfg.KeepaliveInterval < 0, cfg.MaxRows < 0,
+		cfg.MaxColumnBytes < 0, cfg.LongTxThreshold < 0:
+		return nil, errors.New("nuodb: invalid config: negative limits")
+	case cfg.AnnotateErrors < AnnotateNone || cfg.AnnotateErrors > AnnotateSQLAndParams:
+		return nil, fmt.Errorf("nuodb: invalid config: unknown error annotation %d", cfg.AnnotateErrors)
+	case cfg.Reconnect < ReconnectAlways || cfg.Reconnect > ReconnectNever:
+		return nil, fmt.Errorf("nuodb: invalid config: unknown reconnect policy %d", cfg.Reconnect)
+	case cfg.LeakDetection < LeakDetectionOff || cfg.LeakDetection > LeakDetectionPanic:
+		return nil, fmt.Errorf("nuodb: invalid config: unknown leak detection %d", cfg.LeakDetection)
+	}
+	locale, ok := parse.Locale(cfg.Locale)
+	if cfg.Locale != "" && !ok {
+		return nil, fmt.Errorf("nuodb: invalid config: invalid locale: %q", cfg.Locale)
+	}
+	hosts := make([]string, len(cfg.Hosts))
+	for i, host := range cfg.Hosts {
+		if host == "" || strings.ContainsAny(host, ",@/") {
+			return nil, fmt.Errorf("nuodb: invalid config: invalid host: %q", host)
+		}
+		var err error
+		if hosts[i], err = parse.NormalizeHosts(host); err != nil {
+			return nil, err
+		}
+	}
+	var searchPath []string
+	if len(cfg.SearchPath) > 0 {
+		if searchPath, ok = parse.SearchPath(strings.Join(cfg.SearchPath, ",")); !ok {
+			return nil, fmt.Errorf("nuodb: invalid config: invalid search path: %q", cfg.SearchPath)
+		}
+	}
+	d := &parse.DSN{
+		Database:          cfg.Database + "@" + strings.Join(hosts, ","),
+		Username:          cfg.User,
+		Password:          cfg.Password,
+		Location:          cfg.Timezone,
+		MaxQueryTimeout:   cfg.MaxQueryTimeout,
+		DefaultTimeout:    cfg.DefaultTimeout,
+		MaxTxDuration:     cfg.MaxTxDuration,
+		KeepaliveInterval: cfg.KeepaliveInterval,
+		RetryAttempts:     cfg.RetryAttempts,
+		RetryBackoff:      cfg.RetryBackoff,
+		MaxRows:           cfg.MaxRows,
+		MaxColumnBytes:    cfg.MaxColumnBytes,
+		RandomizeHosts:    cfg.RandomizeHosts,
+		SearchPath:        searchPath,
+
+		EmptyStringAsNull: cfg.EmptyStringAsNull,
+		NullStringAsEmpty: cfg.NullStringAsEmpty,
+		ReadOnly:          cfg.ReadOnly,
+		NoAutoCommit:      cfg.NoAutoCommit,
+		TruncateColumns:   cfg.TruncateColumns,
+		DeferClose:        cfg.DeferClose,
+	}
+	for _, sql := range cfg.OnConnect {
+		statements := parse.SplitStatements(sql)
+		if len(statements) != 1 {
+			return nil, fmt.Errorf("nuodb: invalid config: not a single onConnect statement: %q", sql)
+		}
+		d.OnConnect = append(d.OnConnect, statements[0])
+	}
+	if d.Location == nil {
+		d.Location = time.Local
+	}
+	var err error
+	if d.Props, err = parse.ValidateProps(cfg.props()); err != nil {
+		return nil, err
+	}
+	if err := parse.ValidateTLS(d.Props); err != nil {
+		return nil, err
+	}
+	if err := parse.ValidateAuth(d.Props); err != nil {
+		return nil, err
+	}
+	if err := parse.ValidateLB(d.Props); err != nil {
+		return nil, err
+	}
+	if err := parse.ValidateCompression(d.Props); err != nil {
+		return nil, err
+	}
+	if locale != "" {
+		d.Props[propLocale] = locale
+	}
+	if searchPath != nil {
+		if err := parse.SearchPathSchema(d.Props, searchPath); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// Connector implements driver.Connector for sql.OpenDB.
+type Connector struct {
+	dsn         *parse.DSN
+	credentials CredentialsProvider
+	masks       *MaskRules
+	limiter     *StatementLimiter
+	faults      *FaultInjector
+	breaker     *CircuitBreaker
+	scanLoc     *time.Location
+	onTxExpired func(TxExpiredEvent)
+	onDrain     func(DrainEvent)
+	longTx      time.Duration
+	onLongTx    func(LongTxEvent)
+	meta        *metadataCache
+	hooks       Hooks
+	logger      Logger
+	slow        time.Duration
+	annotate    ErrorAnnotation
+	reconnect   ReconnectPolicy
+	leaks       LeakDetection
+	tracer      Tracer
+	metrics     *Metrics
+	stats       connectorStats
+}
+
+var _ driver.Connector = (*Connector)(nil)
+
+// NewConnector returns a Connector which opens connections configured by
+// cfg. Later changes to cfg don't affect the Connector.
+func NewConnector(cfg *Config) (*Connector, error) {
+	d, err := cfg.dsn()
+	if err != nil {
+		return nil, err
+	}
+	return &Connector{dsn: d, credentials: cfg.Credentials, masks: cfg.Masking, limiter: cfg.Limiter,
+		faults: cfg.Faults, breaker: cfg.Breaker, scanLoc: cfg.ScanLocation, onTxExpired: cfg.OnTxExpired,
+		onDrain: cfg.OnDrain, longTx: cfg.LongTxThreshold, onLongTx: cfg.OnLongTx, meta: newMetadataCache(cfg.MetadataTTL), hooks: cfg.Hooks,
+		logger: cfg.Logger, slow: cfg.SlowQueryThreshold, annotate: cfg.AnnotateErrors, tracer: cfg.Tracer,
+		metrics: cfg.Metrics, reconnect: cfg.Reconnect, leaks: cfg.LeakDetection}, nil
+}
+
+// ConnectorFromEnv returns a Connector configured entirely by the
+// environment variables NUODB_USER, NUODB_PASSWORD, NUODB_DATABASE,
+// NUODB_BROKERS and NUODB_PROPS, e.g. of a container, so that no data source
+// name embeds the credentials:
+//
+//	connector, err := nuodb.ConnectorFromEnv()
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	db := sql.OpenDB(connector)
+//
+// It fails if the user, the database or its brokers are missing.
+func ConnectorFromEnv() (*Connector, error) {
+	cfg, err := ParseDSN("nuodb://")
+	if err != nil {
+		return nil, err
+	}
+	return NewConnector(cfg)
+}
+
+// open opens a connection configured by d, unless a connect fault is
+// injected.
+func (c *Connector) open(d *parse.DSN) (*Conn, error) {
+	if err := c.faults.injectConnect(); err != nil {
+		return nil, err
+	}
+	c.stats.call() // the open
+	return newConn(d)
+}
+
+// Connect opens a new connection. The context is only checked before
+// connecting, as opening the connection can't be interrupted.
+func (c *Connector) Connect(ctx context.Context) (_ driver.Conn, err error) {
+	database, _ := parse.SplitDatabase(c.dsn.Database)
+	var span Span
+	if c.tracer != nil {
+		_, span = c.tracer.Start(ctx, SpanConnect, []SpanAttribute{{"db.system", "nuodb"}, {"db.name", database}})
+		defer func() { endSpan(span, err) }()
+	}
+	d, err := c.connectDSN(ctx)
+	if err != nil {
+		return nil, err
+	}
+	probe, err := c.breaker.allow(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	conn, err := c.open(d)
+	if c.breaker.done(time.Now(), probe, err) && c.logger != nil {
+		c.logger.Log(LevelError, "circuit breaker opened", "cooldown", c.breaker.Cooldown)
+	}
+	if err != nil {
+		if c.logger != nil {
+			c.logger.Log(LevelWarn, "connect failed", errorKeyvals(err)...)
+		}
+		if c.metrics != nil {
+			c.metrics.failed(err)
+		}
+		return nil, err
+	}
+	conn.database = database
+	if c.tracer != nil {
+		conn.node, _ = conn.ConnectedNode() // unknown to the spans on error
+	}
+	conn.masks = c.masks
+	conn.limiter = c.limiter
+	conn.faults = c.faults
+	conn.scanLoc = c.scanLoc
+	conn.onTxExpired = c.onTxExpired
+	conn.onDrain = c.onDrain
+	conn.longTxThreshold = c.longTx
+	conn.onLongTx = c.onLongTx
+	conn.meta = c.meta
+	conn.hooks = c.hooks
+	conn.logger = c.logger
+	conn.slowThreshold = c.slow
+	conn.annotateErrors = c.annotate
+	conn.reconnect = c.reconnect
+	conn.route = lbRoute{d.Props[propLBQuery], d.Props[propLBTag]}
+	conn.defaultRoute = c.route()
+	conn.leaks = c.leaks
+	conn.used = false // by the setup of the connection
+	conn.tracer = c.tracer
+	conn.metrics = c.metrics
+	conn.metrics.opened()
+	conn.stats = &c.stats
+	conn.stats.opened()
+	conn.log(LevelDebug, "connection opened", "tls", conn.tls())
+	return conn, nil
+}
+
+// connectDSN returns the dsn of a new connection, with the credentials
+// from the CredentialsProvider, if any.
+func (c *Connector) connectDSN(ctx context.Context) (*parse.DSN, error) {
+	if ctx.Err() != nil {
+		return nil, contextError(ctx)
+	}
+	d := *c.dsn
+	d.Props = c.route().route(ctx).props(d.Props)
+	if c.credentials == nil {
+		return &d, nil
+	}
+	user, password, err := c.credentials.Credentials(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("nuodb: credentials: %w", err)
+	}
+	if user == "" {
+		return nil, errors.New("nuodb: credentials: no user")
+	}
+	d.Username, d.Password = user, password
+	return &d, nil
+}
+
+// route returns the load balancer route of the connections of c, unless a
+// context overrides it.
+func (c *Connector) route() lbRoute {
+	return lbRoute{c.dsn.Props[propLBQuery], c.dsn.Props[propLBTag]}
+}
+
+// Driver returns the driver which is registered as "nuodb".
+func (c *Connector) Driver() driver.Driver {
+	return &nuodbDriver{}
+}
@@ -0,0 +1,96 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import "sync/atomic"
+
+// ConnectorStats are the statistics of the driver internals of the
+// connections of a Connector, which complement sql.DBStats of the pool.
+type ConnectorStats struct {
+	ActiveConnections int64 // opened and not closed yet
+	Prepares          int64 // prepared statements
+
+	// The lookups of the table metadata cache of Config.MetadataTTL. The
+	// driver doesn't cache prepared statements, so this is its only cache.
+	CacheHits   int64
+	CacheMisses int64
+
+	CgoCalls       int64 // calls into the NuoDB client library which may reach the server
+	Retries        int64 // retries of conflicting statements
+	BadConnections int64 // connections discarded by the pool as broken
+}
+
+// CacheHitRate returns the share of the metadata cache lookups which hit,
+// or zero if there were none.
+func (s ConnectorStats) CacheHitRate() float64 {
+	if n := s.CacheHits + s.CacheMisses; n > 0 {
+		return float64(s.CacheHits) / float64(n)
+	}
+	return 0
+}
+
+// connectorStats counts ConnectorStats. A nil connectorStats, of a
+// connection opened without a Connector, counts nothing.
+type connectorStats struct {
+	active, prepares, hits, misses, calls, retries, bad int64
+}
+
+// Stats returns the statistics of the connections of the Connector.
+func (c *Connector) Stats() ConnectorStats {
+	s := &c.stats
+	return ConnectorStats{
+		ActiveConnections: atomic.LoadInt64(&s.active),
+		Prepares:          atomic.LoadInt64(&s.prepares),
+		CacheHits:         atomic.LoadInt64(&s.hits),
+		CacheMisses:       atomic.LoadInt64(&s.misses),
+		CgoCalls:          atomic.LoadInt64(&s.calls),
+		Retries:           atomic.LoadInt64(&s.retries),
+		BadConnections:    atomic.LoadInt64(&s.bad),
+	}
+}
+
+func (s *connectorStats) opened() {
+	if s != nil {
+		atomic.AddInt64(&s.active, 1)
+	}
+}
+
+func (s *connectorStats) closed() {
+	if s != nil {
+		atomic.AddInt64(&s.active, -1)
+	}
+}
+
+func (s *connectorStats) prepared() {
+	if s != nil {
+		atomic.AddInt64(&s.prepares, 1)
+	}
+}
+
+func (s *connectorStats) call() {
+	if s != nil {
+		atomic.AddInt64(&s.calls, 1)
+	}
+}
+
+func (s *connectorStats) retried() {
+	if s != nil {
+		atomic.AddInt64(&s.retries, 1)
+	}
+}
+
+func (s *connectorStats) discarded() {
+	if s != nil {
+		atomic.AddInt64(&s.bad, 1)
+	}
+}
+
+func (s *connectorStats) lookup(hit bool) {
+	switch {
+	case s == nil:
+	case hit:
+		atomic.AddInt64(&s.hits, 1)
+	default:
+		atomic.AddInt64(&s.misses, 1)
+	}
+}
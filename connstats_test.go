@@ -0,0 +1,70 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestConnectorStatsCount(t *testing.T) {
+	c := &Connector{}
+	s := &c.stats
+	s.opened()
+	s.opened()
+	s.closed()
+	s.prepared()
+	s.call()
+	s.retried()
+	s.discarded()
+	s.lookup(true)
+	s.lookup(true)
+	s.lookup(true)
+	s.lookup(false)
+	stats := c.Stats()
+	if stats != (ConnectorStats{ActiveConnections: 1, Prepares: 1, CacheHits: 3, CacheMisses: 1,
+		CgoCalls: 1, Retries: 1, BadConnections: 1}) {
+		t.Fatalf("Unexpected stats %+v", stats)
+	}
+	if r := stats.CacheHitRate(); r != 0.75 {
+		t.Fatalf("Expected a hit rate of 0.75, got %v", r)
+	}
+	if r := (ConnectorStats{}).CacheHitRate(); r != 0 {
+		t.Fatalf("Expected no hit rate, got %v", r)
+	}
+
+	// a connection without a Connector counts nothing
+	var none *connectorStats
+	none.opened()
+	none.call()
+	none.lookup(true)
+}
+
+func TestConnectorStats(t *testing.T) {
+	cfg, err := ParseDSN(default_dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	connector, err := NewConnector(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := sql.OpenDB(connector)
+	stmt, err := db.Prepare("SELECT 'abc' FROM DUAL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s string
+	if err := stmt.QueryRow().Scan(&s); err != nil {
+		t.Fatal(err)
+	}
+	stmt.Close()
+	stats := connector.Stats()
+	if stats.ActiveConnections != 1 || stats.Prepares != 1 || stats.CgoCalls < 3 {
+		t.Fatalf("Unexpected stats %+v", stats)
+	}
+	db.Close()
+	if n := connector.Stats().ActiveConnections; n != 0 {
+		t.Fatalf("Expected no active connections, got %d", n)
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// convertValue converts the Go types which the driver supports natively
+// into driver.Value types. It returns false for values which should be left
+// to the default conversion of database/sql, e.g. driver.Valuer types.
+func convertValue(v interface{}) (driver.Value, bool, error) {
+	switch v := v.(type) {
+	case nil, int64, float64, bool, []byte, string, time.Time, Date, TimeOfDay:
+		return v, true, nil
+	case JSON:
+		j, err := v.text()
+		return j, err == nil, err
+	case driver.Valuer:
+		return nil, false, nil
+	case time.Duration:
+		// NuoDB has no INTERVAL type; a duration is stored as BIGINT
+		// nanoseconds, which scan back into a time.Duration.
+		return int64(v), true, nil
+	case int:
+		return int64(v), true, nil
+	case int8:
+		return int64(v), true, nil
+	case int16:
+		return int64(v), true, nil
+	case int32:
+		return int64(v), true, nil
+	case uint:
+		return convertUint64(uint64(v)), true, nil
+	case uint8:
+		return int64(v), true, nil
+	case uint16:
+		return int64(v), true, nil
+	case uint32:
+		return int64(v), true, nil
+	case uint64:
+		return convertUint64(v), true, nil
+	case float32:
+		return float64(v), true, nil
+	case json.RawMessage:
+		return string(v), true, nil
+	case *big.Int:
+		if v == nil {
+			return nil, true, nil
+		}
+		return v.String(), true, nil
+	case *big.Rat:
+		if v == nil {
+			return nil, true, nil
+		}
+		d, err := ratDecimal(v)
+		return d, err == nil, err
+	case *big.Float:
+		if v == nil {
+			return nil, true, nil
+		}
+		return v.Text('f', -1), true, nil
+	}
+	if u, ok := uuidValue(v); ok {
+		return u, true, nil
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr {
+		// Like the default conversion of database/sql: a nil pointer is NULL,
+		// and another one is converted by the value it points to.
+		if rv.IsNil() {
+			return nil, true, nil
+		}
+		return convertValue(rv.Elem().Interface())
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Uint64 || rv.Kind() == reflect.Uint {
+		// Named unsigned types, which database/sql rejects above math.MaxInt64.
+		return convertUint64(rv.Uint()), true, nil
+	}
+	return nil, false, nil
+}
+
+// convertUint64 converts v into an int64, or into its decimal string if it
+// is above math.MaxInt64, which the server converts into the NUMERIC or
+// DECIMAL column, e.g. of the IDs using the full unsigned 64-bit range. An
+// overflowing BIGINT column fails the statement instead of wrapping v.
+func convertUint64(v uint64) driver.Value {
+	if v > math.MaxInt64 {
+		return strconv.FormatUint(v, 10)
+	}
+	return int64(v)
+}
@@ -0,0 +1,65 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"math"
+	"math/big"
+	"testing"
+	"time"
+)
+
+type testEnum int
+
+func (e testEnum) String() string { return "enum" }
+
+type testID uint64
+
+func TestCheckNamedValueConversion(t *testing.T) {
+	now := time.Now()
+	i := 8
+	tests := []struct {
+		in       interface{}
+		expected driver.Value
+	}{
+		{nil, nil},
+		{int64(-1), int64(-1)},
+		{int(-2), int64(-2)},
+		{int32(-3), int64(-3)},
+		{uint(4), int64(4)},
+		{uint8(5), int64(5)},
+		{uint64(math.MaxInt64), int64(math.MaxInt64)},
+		{uint64(math.MaxUint64), "18446744073709551615"},
+		{uint(math.MaxInt64 + 1), "9223372036854775808"},
+		{testID(math.MaxUint64), "18446744073709551615"},
+		{testID(7), int64(7)},
+		{float32(0.5), float64(0.5)},
+		{"str", "str"},
+		{now, now},
+		{1500 * time.Millisecond, int64(1500000000)},
+		{json.RawMessage(`{"a":1}`), `{"a":1}`},
+		{big.NewFloat(1.25), "1.25"},
+		{(*time.Time)(nil), nil},
+		{&now, now},
+		{&i, int64(8)},
+	}
+	c := &Conn{}
+	for _, test := range tests {
+		nv := &driver.NamedValue{Ordinal: 1, Value: test.in}
+		if err := c.CheckNamedValue(nv); err != nil {
+			t.Fatalf("%T: %s", test.in, err)
+		}
+		if nv.Value != test.expected {
+			t.Fatalf("%T: expected %#v, got %#v", test.in, test.expected, nv.Value)
+		}
+	}
+
+	for _, v := range []interface{}{testEnum(1), struct{}{}} {
+		nv := &driver.NamedValue{Ordinal: 1, Value: v}
+		if err := c.CheckNamedValue(nv); err != driver.ErrSkip {
+			t.Fatalf("%T: expected ErrSkip, got %v", v, err)
+		}
+	}
+}
@@ -0,0 +1,406 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CSVOptions configures LoadCSV.
+type CSVOptions struct {
+	// Comma is the field delimiter, ',' by default.
+	Comma rune
+
+	// Header tells that the first record names the columns to load.
+	// Otherwise the records hold all the columns of the table in order.
+	Header bool
+
+	// BatchSize is the number of rows inserted by a single statement, 100
+	// by default.
+	BatchSize int
+
+	// Skip is the number of data records to skip before loading, e.g. the
+	// CSVResult.Records of an interrupted load to resume it.
+	Skip int64
+
+	// MaxErrors is the number of failed records tolerated before the load
+	// is aborted. Zero aborts on the first failed record.
+	MaxErrors int
+}
+
+// CSVResult reports the progress of LoadCSV.
+type CSVResult struct {
+	Rows    int64           // rows inserted
+	Records int64           // data records processed, including the skipped and failed ones
+	Errors  []*CSVLineError // failed records
+}
+
+// CSVLineError is the error of a single record of a CSV input.
+type CSVLineError struct {
+	Line int64 // line number of the record; quoted fields may span lines
+	Err  error
+}
+
+func (e *CSVLineError) Error() string {
+	return fmt.Sprintf("nuodb: csv line %d: %s", e.Line, e.Err)
+}
+
+const defaultCSVBatchSize = 100
+
+// identifierRegexp matches the optionally schema qualified table and column
+// names accepted by the helpers, as they can't be passed as parameters.
+var identifierRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*(\.[A-Za-z_][A-Za-z0-9_$]*)?$`)
+
+// LoadCSV inserts the records read from r into table. The fields are
+// converted to the types of the table columns; an empty field is loaded as
+// NULL, except into character columns. The rows are inserted in batches
+// and the records of a failed batch are retried one by one, so that the
+// failed records can be reported by their line numbers.
+//
+// The returned CSVResult tells how far the load got also on error. Passing
+// its Records as CSVOptions.Skip resumes the load from the same input.
+func LoadCSV(ctx context.Context, db *sql.DB, table string, r io.Reader, opts CSVOptions) (*CSVResult, error) {
+	if !identifierRegexp.MatchString(table) {
+		return nil, fmt.Errorf("nuodb: invalid table name: %q", table)
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultCSVBatchSize
+	}
+	cr := csv.NewReader(r)
+	if opts.Comma != 0 {
+		cr.Comma = opts.Comma
+	}
+	cr.FieldsPerRecord = -1 // checked against the columns instead
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	l := &csvLoader{conn: conn, table: table, opts: opts, result: &CSVResult{}}
+	var names []string
+	if opts.Header {
+		if names, err = cr.Read(); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, fmt.Errorf("nuodb: csv header: %s", err)
+		}
+	}
+	if l.columns, err = csvColumns(ctx, conn, table, names); err != nil {
+		return nil, err
+	}
+	return l.result, l.load(ctx, cr)
+}
+
+type csvLoader struct {
+	conn    *sql.Conn
+	table   string
+	columns []csvColumn
+	opts    CSVOptions
+	result  *CSVResult
+	records int64 // records read, for line numbers
+	batch   []csvRecord
+}
+
+type csvRecord struct {
+	line   int64
+	values []interface{}
+}
+
+func (l *csvLoader) load(ctx context.Context, cr *csv.Reader) error {
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return l.flush(ctx)
+		}
+		if perr, ok := err.(*csv.ParseError); ok {
+			l.records++
+			if err := l.flush(ctx); err != nil {
+				return err
+			}
+			if l.skip() {
+				continue
+			}
+			if err := l.fail(int64(perr.StartLine), perr.Err); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			if ferr := l.flush(ctx); ferr != nil {
+				return ferr
+			}
+			return err
+		}
+		line := l.nextLine(cr)
+		if l.skip() {
+			continue
+		}
+		values, err := l.convert(record)
+		if err != nil {
+			if err := l.flush(ctx); err != nil {
+				return err
+			}
+			if err := l.fail(line, err); err != nil {
+				return err
+			}
+			continue
+		}
+		l.batch = append(l.batch, csvRecord{line: line, values: values})
+		if len(l.batch) >= l.opts.BatchSize {
+			if err := l.flush(ctx); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// nextLine returns the line number of the record just read. Before go1.17
+// there is no csv.Reader.FieldPos, so the line is counted by records.
+func (l *csvLoader) nextLine(cr *csv.Reader) int64 {
+	l.records++
+	if p, ok := interface{}(cr).(interface{ FieldPos(int) (int, int) }); ok {
+		line, _ := p.FieldPos(0)
+		return int64(line)
+	}
+	if l.opts.Header {
+		return l.records + 1
+	}
+	return l.records
+}
+
+// skip counts a processed record and reports whether it is skipped.
+func (l *csvLoader) skip() bool {
+	l.result.Records++
+	return l.result.Records <= l.opts.Skip
+}
+
+func (l *csvLoader) fail(line int64, err error) error {
+	lineErr := &CSVLineError{Line: line, Err: err}
+	l.result.Errors = append(l.result.Errors, lineErr)
+	if len(l.result.Errors) > l.opts.MaxErrors {
+		return lineErr
+	}
+	return nil
+}
+
+func (l *csvLoader) convert(record []string) ([]interface{}, error) {
+	if len(record) != len(l.columns) {
+		return nil, fmt.Errorf("expected %d fields, got %d", len(l.columns), len(record))
+	}
+	values := make([]interface{}, len(record))
+	for i, field := range record {
+		v, err := l.columns[i].convert(field)
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %s", l.columns[i].name, err)
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+// flush inserts the batched records. The records of a failed batch are
+// inserted one by one to find the failed ones. The records which are left
+// uninserted by an error are uncounted from the result, so that they are
+// loaded again when resuming.
+func (l *csvLoader) flush(ctx context.Context) error {
+	batch := l.batch
+	l.batch = l.batch[:0]
+	if len(batch) == 0 {
+		return nil
+	}
+	err := l.insert(ctx, batch)
+	switch {
+	case err == nil:
+		l.result.Rows += int64(len(batch))
+		return nil
+	case ctx.Err() != nil:
+		l.result.Records -= int64(len(batch))
+		return err
+	case len(batch) == 1:
+		return l.fail(batch[0].line, err)
+	}
+	for i := range batch {
+		if err := l.insert(ctx, batch[i:i+1]); err != nil {
+			if ctx.Err() != nil {
+				l.result.Records -= int64(len(batch) - i)
+				return err
+			}
+			if err := l.fail(batch[i].line, err); err != nil {
+				l.result.Records -= int64(len(batch) - i - 1)
+				return err
+			}
+			continue
+		}
+		l.result.Rows++
+	}
+	return nil
+}
+
+func (l *csvLoader) insert(ctx context.Context, batch []csvRecord) error {
+	row := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(l.columns)), ", ") + ")"
+	var sb strings.Builder
+	sb.WriteString("INSERT INTO ")
+	sb.WriteString(l.table)
+	sb.WriteString(" (")
+	for i, col := range l.columns {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(col.name)
+	}
+	sb.WriteString(") VALUES ")
+	args := make([]interface{}, 0, len(batch)*len(l.columns))
+	for i, record := range batch {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(row)
+		args = append(args, record.values...)
+	}
+	_, err := l.conn.ExecContext(ctx, sb.String(), args...)
+	return err
+}
+
+type csvKind int
+
+const (
+	csvString csvKind = iota // loaded as is, also when empty
+	csvText                  // other textual values, e.g. unknown types
+	csvInt
+	csvFloat
+	csvBool
+	csvTime
+	csvDecimal
+	csvBytes
+)
+
+// csvColumn is a table column which a CSV field is loaded into.
+type csvColumn struct {
+	name string
+	kind csvKind
+}
+
+// csvColumns introspects the types of the named columns, or of all the
+// columns of table if names is nil.
+func csvColumns(ctx context.Context, conn *sql.Conn, table string, names []string) ([]csvColumn, error) {
+	for _, name := range names {
+		if !identifierRegexp.MatchString(name) || strings.Contains(name, ".") {
+			return nil, fmt.Errorf("nuodb: invalid column name: %q", name)
+		}
+	}
+	var tcs []tableColumn
+	err := conn.Raw(func(driverConn interface{}) error {
+		c, ok := driverConn.(*Conn)
+		if !ok {
+			return errors.New("nuodb: not a nuodb connection")
+		}
+		var err error
+		tcs, err = c.tableColumns(ctx, table)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if names == nil {
+		columns := make([]csvColumn, len(tcs))
+		for i, tc := range tcs {
+			columns[i] = csvColumn{name: tc.name, kind: csvColumnKind(tc.scanType, tc.databaseTypeName)}
+		}
+		return columns, nil
+	}
+	columns := make([]csvColumn, len(names))
+	for i, name := range names {
+		tc, ok := findTableColumn(tcs, name)
+		if !ok {
+			return nil, fmt.Errorf("nuodb: table %s has no column %s", table, name)
+		}
+		columns[i] = csvColumn{name: name, kind: csvColumnKind(tc.scanType, tc.databaseTypeName)}
+	}
+	return columns, nil
+}
+
+// findTableColumn finds the column of an unquoted name, which NuoDB folds
+// to upper case.
+func findTableColumn(columns []tableColumn, name string) (tableColumn, bool) {
+	for _, tc := range columns {
+		if strings.EqualFold(tc.name, name) {
+			return tc, true
+		}
+	}
+	return tableColumn{}, false
+}
+
+func csvColumnKind(scanType reflect.Type, databaseTypeName string) csvKind {
+	switch scanType {
+	case scanTypeInt64:
+		return csvInt
+	case scanTypeFloat64:
+		return csvFloat
+	case scanTypeBool:
+		return csvBool
+	case scanTypeTime:
+		return csvTime
+	case scanTypeString:
+		return csvString
+	}
+	switch name := databaseTypeName; {
+	case characterType(name):
+		return csvString
+	case strings.Contains(name, "DECIMAL"), strings.Contains(name, "NUMERIC"):
+		return csvDecimal
+	case strings.Contains(name, "BINARY"), strings.Contains(name, "BLOB"):
+		return csvBytes
+	}
+	return csvText
+}
+
+// csvTimeLayouts are the accepted formats of date and time fields.
+var csvTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+	"15:04:05.999999999",
+}
+
+func (col csvColumn) convert(field string) (interface{}, error) {
+	if field == "" && col.kind != csvString {
+		return nil, nil
+	}
+	switch col.kind {
+	case csvInt:
+		return strconv.ParseInt(field, 10, 64)
+	case csvFloat:
+		return strconv.ParseFloat(field, 64)
+	case csvBool:
+		return strconv.ParseBool(field)
+	case csvTime:
+		for _, layout := range csvTimeLayouts {
+			if t, err := time.Parse(layout, field); err == nil {
+				return t, nil
+			}
+		}
+		return nil, fmt.Errorf("invalid time: %q", field)
+	case csvDecimal:
+		if _, ok := new(big.Rat).SetString(field); !ok {
+			return nil, fmt.Errorf("invalid decimal: %q", field)
+		}
+		return field, nil
+	case csvBytes:
+		return []byte(field), nil
+	}
+	return field, nil
+}
@@ -0,0 +1,111 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCSVColumnKind(t *testing.T) {
+	tests := []struct {
+		scanType reflect.Type
+		name     string
+		kind     csvKind
+	}{
+		{scanTypeInt64, "BIGINT", csvInt},
+		{scanTypeFloat64, "DOUBLE", csvFloat},
+		{scanTypeBool, "BOOLEAN", csvBool},
+		{scanTypeTime, "TIMESTAMP", csvTime},
+		{scanTypeString, "VARCHAR", csvString},
+		{scanTypeString, "STRING", csvString},
+		{scanTypeBytes, "DECIMAL", csvDecimal},
+		{scanTypeBytes, "BLOB", csvBytes},
+		{scanTypeAny, "ENUM", csvText},
+	}
+	for _, test := range tests {
+		if kind := csvColumnKind(test.scanType, test.name); kind != test.kind {
+			t.Errorf("%s: expected %d, got %d", test.name, test.kind, kind)
+		}
+	}
+}
+
+func TestCSVColumnConvert(t *testing.T) {
+	tests := []struct {
+		kind     csvKind
+		field    string
+		expected interface{}
+	}{
+		{csvInt, "42", int64(42)},
+		{csvInt, "", nil},
+		{csvFloat, "1.5", 1.5},
+		{csvBool, "true", true},
+		{csvTime, "2013-02-03", time.Date(2013, 2, 3, 0, 0, 0, 0, time.UTC)},
+		{csvTime, "2013-02-03 04:05:06.5", time.Date(2013, 2, 3, 4, 5, 6, 5e8, time.UTC)},
+		{csvDecimal, "12.345", "12.345"},
+		{csvString, "", ""},
+		{csvText, "", nil},
+		{csvBytes, "abc", []byte("abc")},
+	}
+	for _, test := range tests {
+		v, err := csvColumn{kind: test.kind}.convert(test.field)
+		if err != nil {
+			t.Fatalf("%q: %s", test.field, err)
+		}
+		if !reflect.DeepEqual(v, test.expected) {
+			t.Errorf("%q: expected %#v, got %#v", test.field, test.expected, v)
+		}
+	}
+	for _, test := range []struct {
+		kind  csvKind
+		field string
+	}{
+		{csvInt, "4.2"},
+		{csvBool, "maybe"},
+		{csvTime, "yesterday"},
+		{csvDecimal, "1e"},
+	} {
+		if _, err := (csvColumn{kind: test.kind}).convert(test.field); err == nil {
+			t.Errorf("%q: expected error", test.field)
+		}
+	}
+}
+
+func TestLoadCSV(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	exec(t, db, "CREATE TABLE tests.FooBarCSV (id BIGINT NOT NULL, name STRING, amount DECIMAL(10,2))")
+
+	input := "id,name,amount\n1,a,1.50\nx,b,2\n3,c,\n,d,4\n5,e,5\n"
+	result, err := LoadCSV(context.Background(), db, "tests.FooBarCSV", strings.NewReader(input),
+		CSVOptions{Header: true, BatchSize: 2, MaxErrors: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Rows != 3 || result.Records != 5 {
+		t.Fatalf("Expected 3 rows of 5 records, got %+v", result)
+	}
+	if len(result.Errors) != 2 || result.Errors[0].Line != 3 || result.Errors[1].Line != 5 {
+		t.Fatalf("Expected errors on lines 3 and 5, got %v", result.Errors)
+	}
+
+	// resume after the first two records
+	exec(t, db, "DELETE FROM tests.FooBarCSV WHERE id > 1")
+	result, err = LoadCSV(context.Background(), db, "tests.FooBarCSV", strings.NewReader(input),
+		CSVOptions{Header: true, Skip: 2, MaxErrors: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Rows != 2 || len(result.Errors) != 1 {
+		t.Fatalf("Expected 2 rows and 1 error, got %+v", result)
+	}
+
+	_, err = LoadCSV(context.Background(), db, "tests.FooBarCSV", strings.NewReader(input),
+		CSVOptions{Header: true})
+	if lineErr, ok := err.(*CSVLineError); !ok || lineErr.Line != 3 {
+		t.Fatalf("Expected an error on line 3, got %v", err)
+	}
+}
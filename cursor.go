@@ -0,0 +1,81 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+// #include "cnuodb.h"
+import "C"
+import (
+	"errors"
+)
+
+// Cursor selects the cursor of the result set of a single query, e.g. of a
+// report which pages back and forth through a large result:
+//
+//	rows, err := db.QueryContext(ctx, "SELECT * FROM orders ORDER BY id", nuodb.Cursor{Scrollable: true})
+//
+// A scrollable cursor is positioned with the Absolute and Relative methods
+// of the *Rows of a raw connection. A holdable cursor stays open across the
+// commit of the transaction, e.g. while the rows of a query are updated in
+// autocommit mode on the same connection. Such a query is prepared for the
+// cursor, and its rows are fetched one at a time.
+type Cursor struct {
+	Scrollable bool
+	Holdable   bool
+}
+
+func (cur Cursor) apply(o *callOptions) {
+	o.cursor = cur
+}
+
+var errNotScrollable = errors.New("nuodb: the rows have no scrollable cursor; query with nuodb.Cursor{Scrollable: true}")
+
+// Absolute positions a scrollable cursor on the given row, counted from 1,
+// so that the following Next returns the row after it. Row 0 is before the
+// first row, and a negative row counts from the end, -1 being the last row.
+// A row beyond the result makes Next return io.EOF.
+func (rows *Rows) Absolute(row int) error {
+	return rows.move(row, false)
+}
+
+// Relative moves a scrollable cursor by the given number of rows from the
+// last row returned by Next, backwards if negative, so that the following
+// Next returns the row after the new position, e.g. Relative(-2*n) pages
+// back after reading a page of n rows.
+func (rows *Rows) Relative(n int) error {
+	return rows.move(n, true)
+}
+
+// move positions the cursor of rows on the row, or by the rows if relative.
+func (rows *Rows) move(n int, relative bool) error {
+	if !rows.scrollable {
+		return errNotScrollable
+	}
+	c := rows.c
+	exit, err := c.enter()
+	if err != nil {
+		return err
+	}
+	defer exit()
+	var row C.int
+	c.stats.call()
+	if err := rows.cgo(func() C.int {
+		return C.nuodb_resultset_move(c.db, rows.rs, C.int(n), boolInt(relative), &row)
+	}); err != nil {
+		return err
+	}
+	rows.row++ // the lobs of the previous row are gone
+	rows.peeked, rows.peekErr = nil, nil
+	rows.count = int(row)
+	return nil
+}
+
+// prepareCursor prepares the query of stmt with the cursor, for the rows
+// of a single query.
+func (stmt *Stmt) prepareCursor(cursor Cursor) (*Stmt, error) {
+	cs, err := stmt.c.prepare(stmt.psql, "", cursor, nil)
+	if err != nil {
+		return nil, err
+	}
+	cs.sql = stmt.sql // for the statistics of the statement
+	return cs, nil
+}
@@ -0,0 +1,141 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"database/sql/driver"
+	"io"
+	"testing"
+)
+
+func TestCursorOption(t *testing.T) {
+	c := &Conn{}
+	cursor := Cursor{Scrollable: true, Holdable: true}
+	nv := &driver.NamedValue{Ordinal: 1, Value: cursor}
+	if err := c.CheckNamedValue(nv); err != driver.ErrRemoveArgument {
+		t.Fatalf("Expected ErrRemoveArgument, got %v", err)
+	}
+	if opts := c.takeOptions(); opts.cursor != cursor {
+		t.Fatalf("Expected the cursor to be set, got %+v", opts)
+	}
+}
+
+func TestCursorNotScrollable(t *testing.T) {
+	rows := &Rows{}
+	if err := rows.Absolute(1); err != errNotScrollable {
+		t.Fatalf("Expected %v, got %v", errNotScrollable, err)
+	}
+	if err := rows.Relative(-1); err != errNotScrollable {
+		t.Fatalf("Expected %v, got %v", errNotScrollable, err)
+	}
+}
+
+func TestScrollableCursor(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	exec(t, db, "CREATE TABLE tests.FooBarScroll (id BIGINT)")
+	for i := 1; i <= 5; i++ {
+		exec(t, db, "INSERT INTO tests.FooBarScroll VALUES (?)", i)
+	}
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	err = conn.Raw(func(driverConn interface{}) error {
+		c := driverConn.(*Conn)
+		if err := c.CheckNamedValue(&driver.NamedValue{Value: Cursor{Scrollable: true}}); err != driver.ErrRemoveArgument {
+			return err
+		}
+		dr, err := c.QueryContext(ctx, "SELECT id FROM tests.FooBarScroll ORDER BY id", nil)
+		if err != driver.ErrSkip {
+			t.Fatalf("Expected driver.ErrSkip, got %v, %v", dr, err)
+		}
+		ds, err := c.Prepare("SELECT id FROM tests.FooBarScroll ORDER BY id")
+		if err != nil {
+			return err
+		}
+		defer ds.Close()
+		dr, err = ds.(*Stmt).QueryContext(ctx, nil)
+		if err != nil {
+			return err
+		}
+		rows := dr.(*Rows)
+		defer rows.Close()
+		dest := make([]driver.Value, 1)
+		next := func(want int64) {
+			t.Helper()
+			if err := rows.Next(dest); err != nil {
+				t.Fatal(err)
+			}
+			if dest[0] != want {
+				t.Fatalf("Expected %d, got %v", want, dest[0])
+			}
+		}
+		next(1)
+		next(2)
+		next(3)
+		if err := rows.Relative(-2); err != nil {
+			return err
+		}
+		next(2)
+		if err := rows.Absolute(-2); err != nil {
+			return err
+		}
+		next(5)
+		if err := rows.Absolute(0); err != nil {
+			return err
+		}
+		next(1)
+		if err := rows.Absolute(10); err != nil {
+			return err
+		}
+		if err := rows.Next(dest); err != io.EOF {
+			t.Fatalf("Expected io.EOF, got %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHoldableCursor(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	exec(t, db, "CREATE TABLE tests.FooBarHold (id BIGINT, n BIGINT)")
+	for i := 1; i <= 3; i++ {
+		exec(t, db, "INSERT INTO tests.FooBarHold VALUES (?, 0)", i)
+	}
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	rows, err := conn.QueryContext(ctx, "SELECT id FROM tests.FooBarHold ORDER BY id", Cursor{Holdable: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+		if _, err := conn.ExecContext(ctx, "UPDATE tests.FooBarHold SET n = 1 WHERE id = ?", id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 3 {
+		t.Fatalf("Expected 3 rows across the commits, got %v", ids)
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"fmt"
+	"time"
+)
+
+// Date is the value of a DATE column: a calendar date without a time of day
+// or a time zone. Bind a Date to store the date as written, whatever the
+// time zone of the connection, and scan a DATE column into a Date to read
+// it as stored:
+//
+//	_, err := db.Exec("INSERT INTO people (name, born) VALUES (?, ?)", name, nuodb.Date{1969, time.July, 20})
+//
+// A time.Time bound to a DATE parameter is stored as its date in the time
+// zone of the connection. A DATE column scanned into a time.Time is the
+// midnight of the date in that time zone, or in the one of WithLocation.
+type Date struct {
+	Year  int
+	Month time.Month
+	Day   int
+}
+
+// DateOf returns the date of t in the time zone of t.
+func DateOf(t time.Time) Date {
+	year, month, day := t.Date()
+	return Date{year, month, day}
+}
+
+// ParseDate parses a date in the form 2006-01-02.
+func ParseDate(s string) (Date, error) {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return Date{}, fmt.Errorf("nuodb: invalid Date: %q", s)
+	}
+	return DateOf(t), nil
+}
+
+// In returns the midnight of d in loc.
+func (d Date) In(loc *time.Location) time.Time {
+	return time.Date(d.Year, d.Month, d.Day, 0, 0, 0, 0, loc)
+}
+
+// String returns d in the form 2006-01-02.
+func (d Date) String() string {
+	return fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day)
+}
+
+// Scan implements sql.Scanner.
+func (d *Date) Scan(src interface{}) error {
+	var err error
+	switch src := src.(type) {
+	case time.Time:
+		*d = DateOf(src)
+	case []byte:
+		*d, err = ParseDate(string(src))
+	case string:
+		*d, err = ParseDate(src)
+	default:
+		return fmt.Errorf("nuodb: cannot scan %T into Date", src)
+	}
+	return err
+}
+
+// TimeOfDay is the value of a TIME column: a time of day without a date or
+// a time zone. Bind a TimeOfDay to store the time as written, whatever the
+// time zone of the connection, and scan a TIME column into a TimeOfDay to
+// read it as stored.
+//
+// A time.Time bound to a TIME parameter is stored as its time of day in the
+// time zone of the connection. A TIME column scanned into a time.Time is
+// the time on January 1, 1970 in that time zone, or in the one of
+// WithLocation.
+type TimeOfDay struct {
+	Hour       int
+	Minute     int
+	Second     int
+	Nanosecond int
+}
+
+// TimeOfDayOf returns the time of day of t in the time zone of t.
+func TimeOfDayOf(t time.Time) TimeOfDay {
+	hour, minute, second := t.Clock()
+	return TimeOfDay{hour, minute, second, t.Nanosecond()}
+}
+
+// ParseTimeOfDay parses a time of day in the form 15:04:05, optionally
+// followed by a fraction of a second.
+func ParseTimeOfDay(s string) (TimeOfDay, error) {
+	t, err := time.Parse("15:04:05.999999999", s)
+	if err != nil {
+		return TimeOfDay{}, fmt.Errorf("nuodb: invalid TimeOfDay: %q", s)
+	}
+	return TimeOfDayOf(t), nil
+}
+
+// On returns the time of day t on the date d in loc.
+func (t TimeOfDay) On(d Date, loc *time.Location) time.Time {
+	return time.Date(d.Year, d.Month, d.Day, t.Hour, t.Minute, t.Second, t.Nanosecond, loc)
+}
+
+// String returns t in the form 15:04:05, followed by the fraction of a
+// second if it isn't zero.
+func (t TimeOfDay) String() string {
+	s := fmt.Sprintf("%02d:%02d:%02d", t.Hour, t.Minute, t.Second)
+	if t.Nanosecond != 0 {
+		s += t.On(epochDate, time.UTC).Format(".999999999")
+	}
+	return s
+}
+
+// Scan implements sql.Scanner.
+func (t *TimeOfDay) Scan(src interface{}) error {
+	var err error
+	switch src := src.(type) {
+	case time.Time:
+		*t = TimeOfDayOf(src)
+	case []byte:
+		*t, err = ParseTimeOfDay(string(src))
+	case string:
+		*t, err = ParseTimeOfDay(src)
+	default:
+		return fmt.Errorf("nuodb: cannot scan %T into TimeOfDay", src)
+	}
+	return err
+}
+
+// epochDate is the date of the times of day.
+var epochDate = Date{1970, time.January, 1}
+
+// dateValue returns the fetched DATE of the given seconds, whose date is
+// in the time zone of the session, as the midnight of the date in loc,
+// whatever time of day the seconds fall on.
+func dateValue(seconds int64, session, loc *time.Location) time.Time {
+	return DateOf(time.Unix(seconds, 0).In(session)).In(loc)
+}
+
+// timeOfDayValue returns the fetched TIME of the given seconds, whose time
+// of day is in the time zone of the session, as the time of day on the
+// epochDate in loc, whatever date the seconds fall on.
+func timeOfDayValue(seconds, nanos int64, session, loc *time.Location) time.Time {
+	return TimeOfDayOf(time.Unix(seconds, nanos).In(session)).On(epochDate, loc)
+}
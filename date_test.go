@@ -0,0 +1,192 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestDateScanAndString(t *testing.T) {
+	loc := time.FixedZone("UTC-8", -8*3600)
+	var d Date
+	for _, test := range []struct {
+		src      interface{}
+		expected Date
+	}{
+		{time.Date(2013, 2, 3, 23, 30, 0, 0, loc), Date{2013, time.February, 3}},
+		{[]byte("2013-02-03"), Date{2013, time.February, 3}},
+		{"0001-12-31", Date{1, time.December, 31}},
+	} {
+		if err := d.Scan(test.src); err != nil {
+			t.Fatal(err)
+		}
+		if d != test.expected {
+			t.Fatalf("Expected %s, got %s", test.expected, d)
+		}
+	}
+	for _, src := range []interface{}{"2013-02-30", "2013-02-03 04:05", int64(1)} {
+		if err := d.Scan(src); err == nil {
+			t.Fatalf("Expected an error scanning %v", src)
+		}
+	}
+	if s := (Date{2013, time.February, 3}).String(); s != "2013-02-03" {
+		t.Fatal(s)
+	}
+}
+
+func TestTimeOfDayScanAndString(t *testing.T) {
+	var tod TimeOfDay
+	for _, test := range []struct {
+		src      interface{}
+		expected TimeOfDay
+	}{
+		{time.Date(2013, 2, 3, 4, 5, 6, 7, time.UTC), TimeOfDay{4, 5, 6, 7}},
+		{[]byte("23:59:59"), TimeOfDay{23, 59, 59, 0}},
+		{"00:00:01.25", TimeOfDay{0, 0, 1, 250000000}},
+	} {
+		if err := tod.Scan(test.src); err != nil {
+			t.Fatal(err)
+		}
+		if tod != test.expected {
+			t.Fatalf("Expected %s, got %s", test.expected, tod)
+		}
+	}
+	for _, src := range []interface{}{"24:00:00", "4:05", 1.5} {
+		if err := tod.Scan(src); err == nil {
+			t.Fatalf("Expected an error scanning %v", src)
+		}
+	}
+	for tod, expected := range map[TimeOfDay]string{
+		{4, 5, 6, 0}:         "04:05:06",
+		{4, 5, 6, 250000000}: "04:05:06.25",
+		{23, 0, 0, 1}:        "23:00:00.000000001",
+	} {
+		if s := tod.String(); s != expected {
+			t.Fatalf("Expected %s, got %s", expected, s)
+		}
+	}
+}
+
+func TestDateAndTimeOfDayValues(t *testing.T) {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the seconds of a DATE or TIME which isn't at the midnight or on the
+	// epoch date in loc are cut to their date or time of day in loc
+	at := time.Date(2013, 7, 8, 22, 30, 15, 5, loc)
+	if d := dateValue(at.Unix(), loc, loc); !d.Equal(time.Date(2013, 7, 8, 0, 0, 0, 0, loc)) {
+		t.Fatal(d)
+	}
+	if tod := timeOfDayValue(at.Unix(), 5, loc, loc); !tod.Equal(time.Date(1970, 1, 1, 22, 30, 15, 5, loc)) {
+		t.Fatal(tod)
+	}
+	if d := DateOf(dateValue(Date{2013, 7, 8}.In(loc).Unix(), loc, loc)); d != (Date{2013, 7, 8}) {
+		t.Fatal(d)
+	}
+}
+
+func TestCheckNamedValueDate(t *testing.T) {
+	c := &Conn{}
+	for _, v := range []interface{}{Date{2013, 2, 3}, TimeOfDay{4, 5, 6, 0}} {
+		nv := &driver.NamedValue{Ordinal: 1, Value: v}
+		if err := c.CheckNamedValue(nv); err != nil {
+			t.Fatal(err)
+		}
+		if nv.Value != v {
+			t.Fatalf("Expected %#v to be kept, got %#v", v, nv.Value)
+		}
+	}
+}
+
+func TestDateAndTimeOfDay(t *testing.T) {
+	db := testConn(t) // in America/Los_Angeles
+	defer db.Close()
+	exec(t, db, "CREATE TABLE FooBar (id BIGINT, dat DATE, tim TIME)")
+
+	// written as is, although the midnight of the date in UTC is still the
+	// previous day in the time zone of the connection
+	date, tod := Date{2013, time.July, 8}, TimeOfDay{23, 30, 15, 0}
+	exec(t, db, "INSERT INTO FooBar VALUES (1, ?, ?)", date, tod)
+	// a time.Time is bound as the type of the column
+	at := time.Date(2013, 7, 8, 23, 30, 15, 0, time.UTC)
+	exec(t, db, "INSERT INTO FooBar VALUES (2, ?, ?)", at, at)
+
+	var d Date
+	var tm TimeOfDay
+	if err := db.QueryRow("SELECT dat, tim FROM FooBar WHERE id = 1").Scan(&d, &tm); err != nil {
+		t.Fatal(err)
+	}
+	if d != date || tm != tod {
+		t.Fatalf("Expected %s %s, got %s %s", date, tod, d, tm)
+	}
+	var dt, tt time.Time
+	if err := db.QueryRow("SELECT dat, tim FROM FooBar WHERE id = 2").Scan(&dt, &tt); err != nil {
+		t.Fatal(err)
+	}
+	local := at.In(dt.Location())
+	if !dt.Equal(DateOf(local).In(local.Location())) {
+		t.Fatalf("Expected the midnight of %s, got %s", local, dt)
+	}
+	if !tt.Equal(TimeOfDayOf(local).On(epochDate, local.Location())) {
+		t.Fatalf("Expected the time of day of %s, got %s", local, tt)
+	}
+}
+
+func TestTimestampPrecision(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	precisions := []int{0, 3, 6, 9}
+	for _, p := range precisions {
+		exec(t, db, fmt.Sprintf("CREATE TABLE Prec%d (id BIGINT, ts TIMESTAMP(%d), tim TIME(%d))", p, p, p))
+	}
+	at := time.Date(2013, 7, 8, 23, 59, 58, 123456789, time.UTC)
+	for i, tz := range []string{"UTC", "America/Los_Angeles", "Asia/Kolkata"} {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tzdb, err := sql.Open("nuodb", base_dsn+"?schema=tests&timezone="+tz)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer tzdb.Close()
+		for _, p := range precisions {
+			table := fmt.Sprintf("Prec%d", p)
+			exec(t, tzdb, "INSERT INTO "+table+" VALUES (?, ?, ?)", i, at, at)
+			rows := query(t, tzdb, "SELECT ts, tim FROM "+table+" WHERE id = ?", i)
+			types, err := rows.ColumnTypes()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, ct := range types {
+				if _, scale, ok := ct.DecimalSize(); !ok || scale != int64(p) {
+					t.Errorf("%s %s: expected the scale %d, got %d", table, ct.Name(), p, scale)
+				}
+			}
+			var ts time.Time
+			var tod TimeOfDay
+			if !rows.Next() {
+				t.Fatal("Expected a row")
+			}
+			if err := rows.Scan(&ts, &tod); err != nil {
+				t.Fatal(err)
+			}
+			rows.Close()
+			// the server keeps p digits of the fraction, truncated or rounded
+			unit := time.Duration(math.Pow10(9 - p))
+			truncated, rounded := at.Truncate(unit), at.Round(unit)
+			if !ts.Equal(truncated) && !ts.Equal(rounded) {
+				t.Errorf("%s in %s: expected %s, got %s", table, tz, truncated, ts)
+			}
+			if tod != TimeOfDayOf(truncated.In(loc)) && tod != TimeOfDayOf(rounded.In(loc)) {
+				t.Errorf("%s in %s: expected %s, got %s", table, tz, TimeOfDayOf(truncated.In(loc)), tod)
+			}
+		}
+	}
+}
@@ -0,0 +1,10 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+//go:build nuodb_debug
+// +build nuodb_debug
+
+package nuodb
+
+// debug enables additional safety checks which are too expensive for
+// production builds.
+const debug = true
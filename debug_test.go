@@ -0,0 +1,27 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+//go:build nuodb_debug
+// +build nuodb_debug
+
+package nuodb
+
+import (
+	"database/sql/driver"
+	"testing"
+	"time"
+)
+
+func TestCheckAfterFree(t *testing.T) {
+	cv, err := encodeValues([]driver.Value{"str", []byte{1, 2, 3}}, time.UTC, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cv.ptr() // the data is in the allocation
+	cv.free()
+	defer func() {
+		if r := recover(); r != "nuodb: parameter 1 data outside of its C allocation" {
+			t.Fatalf("Expected a panic for the freed data, got %v", r)
+		}
+	}()
+	cv.ptr()
+}
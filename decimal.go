@@ -0,0 +1,76 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math/big"
+	"strconv"
+)
+
+// Decimal is an exact DECIMAL or NUMERIC value in its decimal string form,
+// e.g. "3.1416". Unlike float64 it preserves the precision of the value
+// both ways: scan DECIMAL columns into a Decimal and bind it, or a *big.Int,
+// *big.Rat or string, as a NUMERIC parameter.
+type Decimal string
+
+var _ driver.Valuer = Decimal("")
+
+// Scan implements sql.Scanner.
+func (d *Decimal) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case []byte:
+		*d = Decimal(src)
+	case string:
+		*d = Decimal(src)
+	case int64:
+		*d = Decimal(strconv.FormatInt(src, 10))
+	case float64:
+		*d = Decimal(strconv.FormatFloat(src, 'f', -1, 64))
+	default:
+		return fmt.Errorf("nuodb: cannot scan %T into Decimal", src)
+	}
+	return nil
+}
+
+// Value implements driver.Valuer.
+func (d Decimal) Value() (driver.Value, error) {
+	if _, ok := new(big.Rat).SetString(string(d)); !ok {
+		return nil, fmt.Errorf("nuodb: invalid Decimal: %q", string(d))
+	}
+	return string(d), nil
+}
+
+// Rat returns d as a *big.Rat.
+func (d Decimal) Rat() (*big.Rat, error) {
+	r, ok := new(big.Rat).SetString(string(d))
+	if !ok {
+		return nil, fmt.Errorf("nuodb: invalid Decimal: %q", string(d))
+	}
+	return r, nil
+}
+
+// String returns d in its decimal string form.
+func (d Decimal) String() string {
+	return string(d)
+}
+
+// ratDecimal returns the exact decimal string form of r. It fails if r has
+// no finite decimal representation, e.g. 1/3.
+func ratDecimal(r *big.Rat) (string, error) {
+	// r is a finite decimal iff its denominator divides 10^n for some n,
+	// which is then the number of fractional digits. As the denominator is
+	// 2^a*5^b, n = max(a, b) can't exceed its bit length.
+	denom := r.Denom()
+	p := big.NewInt(1)
+	ten := big.NewInt(10)
+	mod := new(big.Int)
+	for n := 0; n <= denom.BitLen(); n++ {
+		if mod.Mod(p, denom).Sign() == 0 {
+			return r.FloatString(n), nil
+		}
+		p.Mul(p, ten)
+	}
+	return "", fmt.Errorf("nuodb: %s has no exact decimal representation", r.String())
+}
@@ -0,0 +1,92 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"database/sql/driver"
+	"math/big"
+	"testing"
+)
+
+func TestDecimalScanAndValue(t *testing.T) {
+	var d Decimal
+	for _, test := range []struct {
+		src      interface{}
+		expected Decimal
+	}{
+		{[]byte("3.1415926535897932384626433832795028841"), "3.1415926535897932384626433832795028841"},
+		{"-0.001", "-0.001"},
+		{int64(42), "42"},
+		{float64(0.5), "0.5"},
+	} {
+		if err := d.Scan(test.src); err != nil {
+			t.Fatal(err)
+		}
+		if d != test.expected {
+			t.Fatalf("Expected %s, got %s", test.expected, d)
+		}
+	}
+	if err := d.Scan(true); err == nil {
+		t.Fatal("Expected error")
+	}
+
+	v, err := Decimal("12.50").Value()
+	if err != nil || v != "12.50" {
+		t.Fatal(v, err)
+	}
+	if _, err := Decimal("12,50").Value(); err == nil {
+		t.Fatal("Expected invalid Decimal error")
+	}
+	r, err := Decimal("12.50").Rat()
+	if err != nil || r.Cmp(big.NewRat(25, 2)) != 0 {
+		t.Fatal(r, err)
+	}
+}
+
+func TestBigNumberConversion(t *testing.T) {
+	huge, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	tests := []struct {
+		in       interface{}
+		expected driver.Value
+	}{
+		{huge, "123456789012345678901234567890"},
+		{big.NewRat(5, 1), "5"},
+		{big.NewRat(-1, 8), "-0.125"},
+		{big.NewRat(7, 20), "0.35"},
+		{big.NewFloat(0.1), "0.1"},
+	}
+	c := &Conn{}
+	for _, test := range tests {
+		nv := &driver.NamedValue{Ordinal: 1, Value: test.in}
+		if err := c.CheckNamedValue(nv); err != nil {
+			t.Fatal(err)
+		}
+		if nv.Value != test.expected {
+			t.Fatalf("Expected %v, got %v", test.expected, nv.Value)
+		}
+	}
+	nv := &driver.NamedValue{Ordinal: 1, Value: big.NewRat(1, 3)}
+	if err := c.CheckNamedValue(nv); err == nil {
+		t.Fatal("Expected error for 1/3")
+	}
+}
+
+func TestDecimalRoundTrip(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	exec(t, db, "CREATE TABLE tests.FooBarDecimal (dec DECIMAL(38,30), num NUMBER)")
+
+	pi := Decimal("3.141592653589793238462643383279")
+	exec(t, db, "INSERT INTO tests.FooBarDecimal VALUES (?, ?)", pi, big.NewRat(-1, 8))
+
+	var dec, num Decimal
+	if err := db.QueryRow("SELECT dec, num FROM tests.FooBarDecimal").Scan(&dec, &num); err != nil {
+		t.Fatal(err)
+	}
+	if dec != pi {
+		t.Fatalf("Expected %s, got %s", pi, dec)
+	}
+	if r, err := num.Rat(); err != nil || r.Cmp(big.NewRat(-1, 8)) != 0 {
+		t.Fatalf("Expected -0.125, got %s", num)
+	}
+}
@@ -0,0 +1,236 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+// #include "cnuodb.h"
+import "C"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+	"unsafe"
+)
+
+// DirectConn is a connection used without database/sql, for the hot paths
+// where boxing every value into an interface and copying it is measurable:
+//
+//	conn, err := nuodb.Connect(ctx, cfg)
+//	defer conn.Close()
+//	rows, err := conn.Query(ctx, "SELECT id, name FROM users WHERE id > ?", 100)
+//	defer rows.Close()
+//	for rows.Next() {
+//		id, err := rows.ScanInt64(0)
+//		name, err := rows.ScanString(1)
+//		...
+//	}
+//	err = rows.Err()
+//
+// The values are decoded from the fetched rows only when scanned, into the
+// type asked for. A DirectConn is not pooled, retried or reconnected, and
+// like a driver connection it is not safe for concurrent use.
+type DirectConn struct {
+	c *Conn
+}
+
+// Connect opens a DirectConn configured by cfg, as a Connector of cfg
+// would.
+func Connect(ctx context.Context, cfg *Config) (*DirectConn, error) {
+	connector, err := NewConnector(cfg)
+	if err != nil {
+		return nil, err
+	}
+	c, err := connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &DirectConn{c: c.(*Conn)}, nil
+}
+
+// Close closes the connection.
+func (dc *DirectConn) Close() error {
+	return dc.c.Close()
+}
+
+// Exec executes a statement and returns the number of rows it affected.
+func (dc *DirectConn) Exec(ctx context.Context, sql string, args ...interface{}) (int64, error) {
+	named, err := namedValues(args)
+	if err != nil {
+		return 0, err
+	}
+	res, err := dc.c.ExecContext(ctx, sql, named)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// Query runs a query and returns its result rows. The query options of
+// ctx, e.g. WithMaxRows, apply to it, but the masked columns of MaskRules
+// can't be fetched directly.
+func (dc *DirectConn) Query(ctx context.Context, sql string, args ...interface{}) (*DirectRows, error) {
+	named, err := namedValues(args)
+	if err != nil {
+		return nil, err
+	}
+	dr, err := dc.c.QueryContext(ctx, sql, named)
+	if err != nil {
+		return nil, err
+	}
+	rows := dr.(*Rows)
+	if rows.maskers != nil {
+		rows.Close()
+		return nil, errors.New("nuodb: masked columns can't be fetched directly")
+	}
+	return &DirectRows{rows: rows}, nil
+}
+
+// DirectRows iterates the result rows of a DirectConn query. The Scan
+// methods decode a column of the current row, numbered from 0; a NULL is
+// scanned as the zero value, which Null tells apart.
+type DirectRows struct {
+	rows   *Rows
+	values []C.struct_nuodb_value // of the current row, valid until Next
+	err    error
+}
+
+// Columns returns the names of the columns.
+func (r *DirectRows) Columns() []string {
+	return r.rows.Columns()
+}
+
+// Next advances to the next row. It returns false after the last row or an
+// error, which Err returns.
+func (r *DirectRows) Next() bool {
+	r.values = nil
+	if r.err != nil {
+		return false
+	}
+	exit, err := r.rows.c.enter()
+	if err != nil {
+		r.err = err
+		return false
+	}
+	defer exit()
+	r.values, r.err = r.rows.advance()
+	return r.err == nil
+}
+
+// Err returns the error which ended the iteration, if any.
+func (r *DirectRows) Err() error {
+	if r.err == io.EOF {
+		return nil
+	}
+	return r.err
+}
+
+// Close closes the rows.
+func (r *DirectRows) Close() error {
+	r.values = nil
+	return r.rows.Close()
+}
+
+// value returns the value of column i of the current row.
+func (r *DirectRows) value(i int) (*C.struct_nuodb_value, error) {
+	if r.values == nil {
+		return nil, errors.New("nuodb: no current row")
+	}
+	if i < 0 || i >= len(r.values) {
+		return nil, fmt.Errorf("nuodb: column %d out of range", i)
+	}
+	return &r.values[i], nil
+}
+
+// mismatch returns the error of scanning column i as a Go type.
+func (r *DirectRows) mismatch(i int, goType string) error {
+	return fmt.Errorf("nuodb: column %s of type %s can't be scanned as %s",
+		r.rows.columnNames[i], r.rows.ColumnTypeDatabaseTypeName(i), goType)
+}
+
+// Null reports whether column i of the current row is NULL.
+func (r *DirectRows) Null(i int) bool {
+	v, err := r.value(i)
+	return err == nil && v.vt == C.NUODB_TYPE_NULL
+}
+
+// ScanInt64 returns column i, of an integer type without scale.
+func (r *DirectRows) ScanInt64(i int) (int64, error) {
+	v, err := r.value(i)
+	if err != nil {
+		return 0, err
+	}
+	switch v.vt {
+	case C.NUODB_TYPE_NULL:
+		return 0, nil
+	case C.NUODB_TYPE_INT64:
+		return int64(v.i64), nil
+	}
+	return 0, r.mismatch(i, "int64")
+}
+
+// ScanFloat64 returns column i, of a FLOAT or DOUBLE type.
+func (r *DirectRows) ScanFloat64(i int) (float64, error) {
+	v, err := r.value(i)
+	if err != nil {
+		return 0, err
+	}
+	switch v.vt {
+	case C.NUODB_TYPE_NULL:
+		return 0, nil
+	case C.NUODB_TYPE_FLOAT64:
+		return *(*float64)(unsafe.Pointer(&v.i64)), nil
+	}
+	return 0, r.mismatch(i, "float64")
+}
+
+// ScanBool returns column i, of the BOOLEAN type.
+func (r *DirectRows) ScanBool(i int) (bool, error) {
+	v, err := r.value(i)
+	if err != nil {
+		return false, err
+	}
+	switch v.vt {
+	case C.NUODB_TYPE_NULL:
+		return false, nil
+	case C.NUODB_TYPE_BOOL:
+		return v.i64 != 0, nil
+	}
+	return false, r.mismatch(i, "bool")
+}
+
+// ScanString returns column i, of a character or binary type, or a
+// DECIMAL, which the client library fetches as text.
+func (r *DirectRows) ScanString(i int) (string, error) {
+	v, err := r.value(i)
+	if err != nil {
+		return "", err
+	}
+	switch v.vt {
+	case C.NUODB_TYPE_NULL:
+		return "", nil
+	case C.NUODB_TYPE_STRING, C.NUODB_TYPE_BYTES:
+		return C.GoStringN((*C.char)(valueData(v)), C.int(v.i32)), nil
+	}
+	return "", r.mismatch(i, "string")
+}
+
+// ScanTime returns column i, of a TIMESTAMP, DATE or TIME type, in the
+// scan location of the query, as it would be scanned by database/sql.
+func (r *DirectRows) ScanTime(i int) (time.Time, error) {
+	v, err := r.value(i)
+	if err != nil {
+		return time.Time{}, err
+	}
+	switch v.vt {
+	case C.NUODB_TYPE_NULL:
+		return time.Time{}, nil
+	case C.NUODB_TYPE_TIME:
+		return time.Unix(int64(v.i64), int64(v.i32)).In(r.rows.loc), nil
+	case C.NUODB_TYPE_DATE:
+		return dateValue(int64(v.i64), r.rows.c.loc, r.rows.loc), nil
+	case C.NUODB_TYPE_TIME_OF_DAY:
+		return timeOfDayValue(int64(v.i64), int64(v.i32), r.rows.c.loc, r.rows.loc), nil
+	}
+	return time.Time{}, r.mismatch(i, "time.Time")
+}
@@ -0,0 +1,80 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestDirectRowsNoRow(t *testing.T) {
+	r := &DirectRows{err: io.EOF}
+	if r.Next() || r.Err() != nil {
+		t.Fatalf("Expected the end of the rows, got %v", r.Err())
+	}
+	if _, err := r.ScanInt64(0); err == nil {
+		t.Fatal("Expected no current row")
+	}
+	if r.Null(0) {
+		t.Fatal("Expected no NULL without a current row")
+	}
+	if _, err := Connect(context.Background(), &Config{}); err == nil {
+		t.Fatal("Expected an invalid config")
+	}
+}
+
+func TestDirectConn(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	exec(t, db, "CREATE TABLE FooBarDirect (id BIGINT, score DOUBLE, ok BOOLEAN, name STRING, price DECIMAL(10,2), at TIMESTAMP)")
+	at := time.Date(2013, 5, 6, 7, 8, 9, 0, time.UTC)
+	exec(t, db, "INSERT INTO FooBarDirect VALUES (1, 1.5, TRUE, 'a', 2.50, ?), (2, NULL, NULL, NULL, NULL, NULL)", at)
+
+	cfg, err := ParseDSN(default_dsn + "&schema=tests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	conn, err := Connect(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if n, err := conn.Exec(ctx, "UPDATE FooBarDirect SET score = score WHERE id = ?", 1); err != nil || n != 1 {
+		t.Fatalf("Expected 1 row, got %d: %v", n, err)
+	}
+	rows, err := conn.Query(ctx, "SELECT id, score, ok, name, price, at FROM FooBarDirect ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		t.Fatal(rows.Err())
+	}
+	id, _ := rows.ScanInt64(0)
+	score, _ := rows.ScanFloat64(1)
+	ok, _ := rows.ScanBool(2)
+	name, _ := rows.ScanString(3)
+	price, _ := rows.ScanString(4)
+	ts, err := rows.ScanTime(5)
+	if err != nil || id != 1 || score != 1.5 || !ok || name != "a" || price != "2.50" || !ts.Equal(at) {
+		t.Fatalf("Unexpected row %v %v %v %v %v %v: %v", id, score, ok, name, price, ts, err)
+	}
+	if _, err := rows.ScanInt64(3); err == nil {
+		t.Fatal("Expected a string not to scan as int64")
+	}
+	if !rows.Next() {
+		t.Fatal(rows.Err())
+	}
+	if !rows.Null(1) || rows.Null(0) {
+		t.Fatal("Expected NULLs but the id")
+	}
+	if name, err := rows.ScanString(3); err != nil || name != "" {
+		t.Fatalf("Expected an empty NULL, got %q: %v", name, err)
+	}
+	if rows.Next() || rows.Err() != nil {
+		t.Fatalf("Expected the end of the rows, got %v", rows.Err())
+	}
+}
@@ -0,0 +1,36 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+// DrainEvent describes a connection whose TE is shutting down, e.g. during
+// a rolling restart, see Config.OnDrain.
+type DrainEvent struct {
+	Conn    uint64    // local number of the connection
+	Session int64     // server side id of the connection; 0 if unknown
+	Code    ErrorCode // OperationKilled or IsShutdown
+	Err     error     // which signalled the shutdown
+	InTx    bool      // a transaction was open on the connection
+}
+
+// drainErrorCodes are the errors of a TE which is shutting down.
+var drainErrorCodes = map[ErrorCode]bool{
+	OperationKilled: true,
+	IsShutdown:      true,
+}
+
+// drain marks c, on which err signals that its TE is shutting down, as
+// draining, unless it already is. A draining connection stays usable, so
+// that an open transaction can still be committed or rolled back and the
+// result sets read, unless err is fatal, but the pool closes it instead of
+// reusing it. The onDrain callback of c is called once, on the goroutine of
+// the failed call.
+func (c *Conn) drain(err *Error) {
+	if c.draining {
+		return
+	}
+	c.draining = true
+	c.log(LevelWarn, "connection draining", "code", err.Code.Name(), "error", err.Message)
+	if c.onDrain != nil {
+		c.onDrain(DrainEvent{Conn: c.id, Session: c.sessionID, Code: err.Code, Err: err, InTx: c.inTransaction()})
+	}
+}
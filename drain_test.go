@@ -0,0 +1,42 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+)
+
+func TestDrain(t *testing.T) {
+	ctx := context.Background()
+	var events []DrainEvent
+	c := &Conn{id: 3, sessionID: 42, inTx: true, onDrain: func(e DrainEvent) { events = append(events, e) }}
+
+	f := NewFaultInjector(1)
+	f.Faults = []Fault{{Code: Deadlock, Rate: 1}}
+	if f.inject(ctx, c); c.draining || len(events) != 0 {
+		t.Fatal("Expected no drain on a deadlock")
+	}
+
+	f.Faults = []Fault{{Code: OperationKilled, Rate: 1}}
+	err := f.inject(ctx, c)
+	if !c.draining || c.bad || errors.Is(err, driver.ErrBadConn) {
+		t.Fatalf("Expected a usable draining connection, got %v", err)
+	}
+	if len(events) != 1 || events[0].Conn != 3 || events[0].Session != 42 ||
+		events[0].Code != OperationKilled || events[0].Err != err || !events[0].InTx {
+		t.Fatalf("Unexpected events %+v", events)
+	}
+
+	f.Faults = []Fault{{Code: IsShutdown, Rate: 1}}
+	if err := f.inject(ctx, c); !c.bad || len(events) != 1 {
+		t.Fatalf("Expected a single event and a broken connection, got %v", err)
+	}
+
+	c = &Conn{}
+	if f.inject(ctx, c); !c.draining {
+		t.Fatal("Expected a draining connection without a callback")
+	}
+}
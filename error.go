@@ -3,17 +3,135 @@
 package nuodb
 
 import (
+	"database/sql/driver"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
 )
 
 // Error is an error type which represents a single instance of a NuoDB error
 type Error struct {
-	Code    ErrorCode
-	Message string
+	Code     ErrorCode
+	Message  string
+	SQLState string // the five character SQLSTATE, if reported
+
+	// TimeoutLimit is set on a timeout error when the maxQueryTimeout of
+	// the connection cut the statement timeout of the context deadline.
+	TimeoutLimit time.Duration
+
+	// DefaultTimeout is set on a timeout error of a statement whose context
+	// had no deadline, when the defaultTimeout of the connection applied.
+	DefaultTimeout time.Duration
+
+	// Attempts is set when the statement was retried by the retryAttempts
+	// of the connection before giving up.
+	Attempts int
+
+	// Conn is the local number of the connection which reported the error
+	// and Session its server side id, GETCONNECTIONID(), if known. They
+	// correlate the errors of a pool with the NuoDB logs.
+	Conn    uint64
+	Session int64
+
+	// SQL is the statement which failed and Params the types of its
+	// parameters, without their values, e.g. "string(12)", if the
+	// AnnotateErrors of the Connector asks for them.
+	SQL    string
+	Params []string
+
+	noRetry bool // by the ReconnectPolicy of the connection
 }
 
 func (e *Error) Error() string {
-	return fmt.Sprintf("nuodb: %s", e.Message)
+	msg := "nuodb: " + e.Message
+	if e.TimeoutLimit > 0 {
+		msg += fmt.Sprintf(" (statement timeout limited to %s by maxQueryTimeout)", e.TimeoutLimit)
+	} else if e.DefaultTimeout > 0 {
+		msg += fmt.Sprintf(" (statement timeout of %s by defaultTimeout)", e.DefaultTimeout)
+	}
+	if e.Attempts > 1 {
+		msg += fmt.Sprintf(" (gave up after %d attempts)", e.Attempts)
+	}
+	msg += e.annotation()
+	if e.Session != 0 {
+		msg += fmt.Sprintf(" [conn %d, session %d]", e.Conn, e.Session)
+	} else if e.Conn != 0 {
+		msg += fmt.Sprintf(" [conn %d]", e.Conn)
+	}
+	return msg
+}
+
+// annotation returns the SQL and Params of the message, if any.
+func (e *Error) annotation() string {
+	var msg string
+	if e.SQL != "" {
+		msg += " [sql: " + abbreviateSQL(e.SQL) + "]"
+	}
+	if len(e.Params) > 0 {
+		msg += " [params: " + strings.Join(e.Params, ", ") + "]"
+	}
+	return msg
+}
+
+// Is reports whether the error matches driver.ErrBadConn, i.e. whether it
+// is a fatal connection error, such as a network error or a shut down TE,
+// which the ReconnectPolicy of the connection allows to retry. database/sql
+// then discards the connection and retries on a new one. Unless the policy
+// is ReconnectFirstUse, the statement which failed may have been executed
+// nevertheless.
+func (e *Error) Is(target error) bool {
+	return target == driver.ErrBadConn && fatalErrorCodes[e.Code] && !e.noRetry
+}
+
+// Retryable reports whether the error is due to contention with other
+// transactions, i.e. an UpdateConflict, a Deadlock or a LockTimeout, so
+// that the failed statement, or the transaction of a deadlock, can be run
+// again as is. Unlike Retry and retryAttempts, which don't retry a lock
+// timeout since the statement already waited for the lock, it reports the
+// lock timeouts too.
+func (e *Error) Retryable() bool {
+	return retryableErrorCodes[e.Code] || e.Code == LockTimeout
+}
+
+// Temporary reports whether the error may go away by itself, i.e. whether
+// it is Retryable, an OperationTimeout or a fatal connection error, after
+// which the statement can be run again on another connection. Errors in
+// the statement itself, such as a SyntaxError or a ConstraintError, are
+// not temporary.
+func (e *Error) Temporary() bool {
+	return e.Retryable() || e.Code == OperationTimeout || fatalErrorCodes[e.Code]
+}
+
+// Unwrap returns the sentinel error of the error code, if any, so that the
+// errors can be tested with errors.Is, e.g.
+//
+//	if errors.Is(err, nuodb.ErrUniqueViolation) {
+func (e *Error) Unwrap() error {
+	return sentinelErrors[e.Code]
+}
+
+// Sentinel errors which the NuoDB errors of the corresponding codes match
+// with errors.Is.
+var (
+	ErrUniqueViolation = errors.New("nuodb: unique violation")
+	ErrLockTimeout     = errors.New("nuodb: lock timeout")
+	ErrDeadlock        = errors.New("nuodb: deadlock")
+	ErrUpdateConflict  = errors.New("nuodb: update conflict")
+	ErrNoSuchTable     = errors.New("nuodb: no such table")
+	ErrTimeout         = errors.New("nuodb: operation timeout")
+	ErrConstraint      = errors.New("nuodb: constraint violation")
+)
+
+var sentinelErrors = map[ErrorCode]error{
+	UniqueDuplicate:  ErrUniqueViolation,
+	LockTimeout:      ErrLockTimeout,
+	Deadlock:         ErrDeadlock,
+	UpdateConflict:   ErrUpdateConflict,
+	NoSuchTable:      ErrNoSuchTable,
+	OperationTimeout: ErrTimeout,
+	ConstraintError:  ErrConstraint,
 }
 
 // ErrorCode represents an error defined by NuoDB
@@ -21,72 +139,203 @@ func (e *Error) Error() string {
 type ErrorCode int
 
 // Name returns a short name for the error code
-func (c *ErrorCode) Name() string {
-	name, ok := errorCodeNames[*c]
+func (c ErrorCode) Name() string {
+	name, ok := errorCodeNames[c]
 	if !ok {
 		return "UNKNOWN_ERROR"
 	}
 	return name
 }
 
+// NuoDB error codes.
+const (
+	SyntaxError                     ErrorCode = -1
+	FeatureNotYetImplemented        ErrorCode = -2
+	BugCheck                        ErrorCode = -3
+	CompileError                    ErrorCode = -4
+	RuntimeError                    ErrorCode = -5
+	OCSError                        ErrorCode = -6
+	NetworkError                    ErrorCode = -7
+	ConversionError                 ErrorCode = -8
+	TruncationError                 ErrorCode = -9
+	ConnectionError                 ErrorCode = -10
+	DDLError                        ErrorCode = -11
+	ApplicationError                ErrorCode = -12
+	SecurityError                   ErrorCode = -13
+	DatabaseCorruption              ErrorCode = -14
+	VersionError                    ErrorCode = -15
+	LicenseError                    ErrorCode = -16
+	InternalError                   ErrorCode = -17
+	DebugError                      ErrorCode = -18
+	LostBlob                        ErrorCode = -19
+	InconsistentBlob                ErrorCode = -20
+	DeletedBlob                     ErrorCode = -21
+	LogError                        ErrorCode = -22
+	DatabaseDamaged                 ErrorCode = -23
+	UpdateConflict                  ErrorCode = -24
+	NoSuchTable                     ErrorCode = -25
+	IndexOverflow                   ErrorCode = -26
+	UniqueDuplicate                 ErrorCode = -27
+	Deadlock                        ErrorCode = -29
+	OutOfMemoryError                ErrorCode = -30
+	OutOfRecordMemoryError          ErrorCode = -31
+	LockTimeout                     ErrorCode = -32
+	PlatformError                   ErrorCode = -36
+	NoSchema                        ErrorCode = -37
+	ConfigurationError              ErrorCode = -38
+	ReadOnlyError                   ErrorCode = -39
+	NoGeneratedKeys                 ErrorCode = -40
+	ThrownException                 ErrorCode = -41
+	InvalidTransactionIsolation     ErrorCode = -42
+	UnsupportedTransactionIsolation ErrorCode = -43
+	InvalidUTF8                     ErrorCode = -44
+	ConstraintError                 ErrorCode = -45
+	UpdateError                     ErrorCode = -46
+	I18NError                       ErrorCode = -47
+	OperationKilled                 ErrorCode = -48
+	InvalidStatement                ErrorCode = -49
+	IsShutdown                      ErrorCode = -50
+	InQuotedString                  ErrorCode = -51
+	BatchUpdateError                ErrorCode = -52
+	JavaError                       ErrorCode = -53
+	InvalidField                    ErrorCode = -54
+	InvalidIndexNull                ErrorCode = -55
+	InvalidOperation                ErrorCode = -56
+	InvalidStatistics               ErrorCode = -57
+	InvalidGenerator                ErrorCode = -58
+	OperationTimeout                ErrorCode = -59
+	NoSuchIndex                     ErrorCode = -60
+	NoSuchSequence                  ErrorCode = -61
+	XAERProto                       ErrorCode = -62
+	UnknownError                    ErrorCode = -63
+)
+
 var errorCodeNames = map[ErrorCode]string{
-	-1:  "SYNTAX_ERROR",
-	-2:  "FEATURE_NOT_YET_IMPLEMENTED",
-	-3:  "BUG_CHECK",
-	-4:  "COMPILE_ERROR",
-	-5:  "RUNTIME_ERROR",
-	-6:  "OCS_ERROR",
-	-7:  "NETWORK_ERROR",
-	-8:  "CONVERSION_ERROR",
-	-9:  "TRUNCATION_ERROR",
-	-10: "CONNECTION_ERROR",
-	-11: "DDL_ERROR",
-	-12: "APPLICATION_ERROR",
-	-13: "SECURITY_ERROR",
-	-14: "DATABASE_CORRUPTION",
-	-15: "VERSION_ERROR",
-	-16: "LICENSE_ERROR",
-	-17: "INTERNAL_ERROR",
-	-18: "DEBUG_ERROR",
-	-19: "LOST_BLOB",
-	-20: "INCONSISTENT_BLOB",
-	-21: "DELETED_BLOB",
-	-22: "LOG_ERROR",
-	-23: "DATABASE_DAMAGED",
-	-24: "UPDATE_CONFLICT",
-	-25: "NO_SUCH_TABLE",
-	-26: "INDEX_OVERFLOW",
-	-27: "UNIQUE_DUPLICATE",
-	-29: "DEADLOCK",
-	-30: "OUT_OF_MEMORY_ERROR",
-	-31: "OUT_OF_RECORD_MEMORY_ERROR",
-	-32: "LOCK_TIMEOUT",
-	-36: "PLATFORM_ERROR",
-	-37: "NO_SCHEMA",
-	-38: "CONFIGURATION_ERROR",
-	-39: "READ_ONLY_ERROR",
-	-40: "NO_GENERATED_KEYS",
-	-41: "THROWN_EXCEPTION",
-	-42: "INVALID_TRANSACTION_ISOLATION",
-	-43: "UNSUPPORTED_TRANSACTION_ISOLATION",
-	-44: "INVALID_UTF8",
-	-45: "CONSTRAINT_ERROR",
-	-46: "UPDATE_ERROR",
-	-47: "I18N_ERROR",
-	-48: "OPERATION_KILLED",
-	-49: "INVALID_STATEMENT",
-	-50: "IS_SHUTDOWN",
-	-51: "IN_QUOTED_STRING",
-	-52: "BATCH_UPDATE_ERROR",
-	-53: "JAVA_ERROR",
-	-54: "INVALID_FIELD",
-	-55: "INVALID_INDEX_NULL",
-	-56: "INVALID_OPERATION",
-	-57: "INVALID_STATISTICS",
-	-58: "INVALID_GENERATOR",
-	-59: "OPERATION_TIMEOUT",
-	-60: "NO_SUCH_INDEX",
-	-61: "NO_SUCH_SEQUENCE",
-	-62: "XAER_PROTO",
-	-63: "UNKNOWN_ERROR",
+	SyntaxError:                     "SYNTAX_ERROR",
+	FeatureNotYetImplemented:        "FEATURE_NOT_YET_IMPLEMENTED",
+	BugCheck:                        "BUG_CHECK",
+	CompileError:                    "COMPILE_ERROR",
+	RuntimeError:                    "RUNTIME_ERROR",
+	OCSError:                        "OCS_ERROR",
+	NetworkError:                    "NETWORK_ERROR",
+	ConversionError:                 "CONVERSION_ERROR",
+	TruncationError:                 "TRUNCATION_ERROR",
+	ConnectionError:                 "CONNECTION_ERROR",
+	DDLError:                        "DDL_ERROR",
+	ApplicationError:                "APPLICATION_ERROR",
+	SecurityError:                   "SECURITY_ERROR",
+	DatabaseCorruption:              "DATABASE_CORRUPTION",
+	VersionError:                    "VERSION_ERROR",
+	LicenseError:                    "LICENSE_ERROR",
+	InternalError:                   "INTERNAL_ERROR",
+	DebugError:                      "DEBUG_ERROR",
+	LostBlob:                        "LOST_BLOB",
+	InconsistentBlob:                "INCONSISTENT_BLOB",
+	DeletedBlob:                     "DELETED_BLOB",
+	LogError:                        "LOG_ERROR",
+	DatabaseDamaged:                 "DATABASE_DAMAGED",
+	UpdateConflict:                  "UPDATE_CONFLICT",
+	NoSuchTable:                     "NO_SUCH_TABLE",
+	IndexOverflow:                   "INDEX_OVERFLOW",
+	UniqueDuplicate:                 "UNIQUE_DUPLICATE",
+	Deadlock:                        "DEADLOCK",
+	OutOfMemoryError:                "OUT_OF_MEMORY_ERROR",
+	OutOfRecordMemoryError:          "OUT_OF_RECORD_MEMORY_ERROR",
+	LockTimeout:                     "LOCK_TIMEOUT",
+	PlatformError:                   "PLATFORM_ERROR",
+	NoSchema:                        "NO_SCHEMA",
+	ConfigurationError:              "CONFIGURATION_ERROR",
+	ReadOnlyError:                   "READ_ONLY_ERROR",
+	NoGeneratedKeys:                 "NO_GENERATED_KEYS",
+	ThrownException:                 "THROWN_EXCEPTION",
+	InvalidTransactionIsolation:     "INVALID_TRANSACTION_ISOLATION",
+	UnsupportedTransactionIsolation: "UNSUPPORTED_TRANSACTION_ISOLATION",
+	InvalidUTF8:                     "INVALID_UTF8",
+	ConstraintError:                 "CONSTRAINT_ERROR",
+	UpdateError:                     "UPDATE_ERROR",
+	I18NError:                       "I18N_ERROR",
+	OperationKilled:                 "OPERATION_KILLED",
+	InvalidStatement:                "INVALID_STATEMENT",
+	IsShutdown:                      "IS_SHUTDOWN",
+	InQuotedString:                  "IN_QUOTED_STRING",
+	BatchUpdateError:                "BATCH_UPDATE_ERROR",
+	JavaError:                       "JAVA_ERROR",
+	InvalidField:                    "INVALID_FIELD",
+	InvalidIndexNull:                "INVALID_INDEX_NULL",
+	InvalidOperation:                "INVALID_OPERATION",
+	InvalidStatistics:               "INVALID_STATISTICS",
+	InvalidGenerator:                "INVALID_GENERATOR",
+	OperationTimeout:                "OPERATION_TIMEOUT",
+	NoSuchIndex:                     "NO_SUCH_INDEX",
+	NoSuchSequence:                  "NO_SUCH_SEQUENCE",
+	XAERProto:                       "XAER_PROTO",
+	UnknownError:                    "UNKNOWN_ERROR",
+}
+
+// fatalErrorCodes are the errors after which a connection is unusable.
+var fatalErrorCodes = map[ErrorCode]bool{
+	NetworkError:    true,
+	ConnectionError: true,
+	IsShutdown:      true,
+}
+
+// ErrorAnnotation selects what the NuoDB errors of the statements report
+// about the statement which failed, see Config.AnnotateErrors.
+type ErrorAnnotation int
+
+const (
+	AnnotateNone         ErrorAnnotation = iota
+	AnnotateSQL                          // Error.SQL
+	AnnotateSQLAndParams                 // Error.SQL and Error.Params
+)
+
+// annotateError adds the statement sql, and the types of its parameters,
+// to a NuoDB error, as the annotateErrors of the connection asks.
+func (c *Conn) annotateError(sql string, args []driver.NamedValue, err *error) {
+	var nerr *Error
+	if c.annotateErrors == AnnotateNone || *err == nil || !errors.As(*err, &nerr) || nerr.SQL != "" {
+		return
+	}
+	nerr.SQL = sql
+	if c.annotateErrors == AnnotateSQLAndParams {
+		for _, arg := range args {
+			nerr.Params = append(nerr.Params, paramType(arg))
+		}
+	}
+	if *err != error(nerr) {
+		// the message of a wrapping error doesn't change with nerr
+		*err = fmt.Errorf("%w%s", *err, nerr.annotation())
+	}
+}
+
+// paramType returns the type of a parameter, with the length of a string or
+// bytes, but not its value.
+func paramType(arg driver.NamedValue) string {
+	t := captureParam(arg, true).Type
+	switch v := arg.Value.(type) {
+	case string:
+		t = fmt.Sprintf("%s(%d)", t, len(v))
+	case []byte:
+		t = fmt.Sprintf("%s(%d)", t, len(v))
+	}
+	if arg.Name != "" {
+		t = arg.Name + " " + t
+	}
+	return t
+}
+
+// maxErrorSQL is the length of the SQL shown in an error message.
+const maxErrorSQL = 200
+
+// abbreviateSQL returns sql on a single line, cut to maxErrorSQL bytes.
+func abbreviateSQL(sql string) string {
+	sql = strings.Join(strings.Fields(sql), " ")
+	if len(sql) <= maxErrorSQL {
+		return sql
+	}
+	cut := maxErrorSQL
+	for cut > 0 && !utf8.RuneStart(sql[cut]) {
+		cut--
+	}
+	return sql[:cut] + "..."
 }
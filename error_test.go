@@ -3,7 +3,17 @@
 package nuodb
 
 import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
+	"unicode/utf8"
 )
 
 func TestErrorString(t *testing.T) {
@@ -40,3 +50,167 @@ func TestErrorCodeName(t *testing.T) {
 		t.Fatalf("Expected 'UNKNOWN_ERROR', got '%s'", err.Code.Name())
 	}
 }
+
+func TestErrorCodeConstants(t *testing.T) {
+	code := ErrorCode(-24)
+	if code != UpdateConflict {
+		t.Fatalf("Expected UpdateConflict, got %d", code)
+	}
+	if code.Name() != "UPDATE_CONFLICT" {
+		t.Fatalf("Expected 'UPDATE_CONFLICT', got '%s'", code.Name())
+	}
+}
+
+func TestErrorCodeNames(t *testing.T) {
+	// every ErrorCode constant of error.go has a name of its own
+	f, err := parser.ParseFile(token.NewFileSet(), "error.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	constants := 0
+	names := map[string]string{}
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			spec := spec.(*ast.ValueSpec)
+			if typ, ok := spec.Type.(*ast.Ident); !ok || typ.Name != "ErrorCode" {
+				continue
+			}
+			neg := spec.Values[0].(*ast.UnaryExpr)
+			code, err := strconv.Atoi(neg.X.(*ast.BasicLit).Value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			constant := spec.Names[0].Name
+			name, ok := errorCodeNames[ErrorCode(-code)]
+			if !ok {
+				t.Errorf("%s: no name", constant)
+			} else if other, ok := names[name]; ok {
+				t.Errorf("%s: name %s of %s too", constant, name, other)
+			}
+			names[name] = constant
+			constants++
+		}
+	}
+	if constants != len(errorCodeNames) {
+		t.Fatalf("Expected %d names of the codes, got %d", constants, len(errorCodeNames))
+	}
+}
+
+func TestErrorTimeoutLimit(t *testing.T) {
+	err := &Error{
+		Code:         OperationTimeout,
+		Message:      "Statement timed out",
+		TimeoutLimit: 2 * time.Minute,
+	}
+	expected := "nuodb: Statement timed out (statement timeout limited to 2m0s by maxQueryTimeout)"
+	if err.Error() != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, err.Error())
+	}
+
+	err = &Error{Code: OperationTimeout, Message: "Statement timed out", DefaultTimeout: 30 * time.Second}
+	expected = "nuodb: Statement timed out (statement timeout of 30s by defaultTimeout)"
+	if err.Error() != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, err.Error())
+	}
+}
+
+func TestErrorConnectionID(t *testing.T) {
+	err := &Error{Code: SyntaxError, Message: "Some sort of error", Conn: 12, Session: 3456}
+	if expected := "nuodb: Some sort of error [conn 12, session 3456]"; err.Error() != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, err.Error())
+	}
+	err.Session = 0
+	if expected := "nuodb: Some sort of error [conn 12]"; err.Error() != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, err.Error())
+	}
+}
+
+func TestErrorIsBadConn(t *testing.T) {
+	for _, code := range []ErrorCode{NetworkError, ConnectionError, IsShutdown} {
+		err := fmt.Errorf("bind: %w", &Error{Code: code, Message: "Gone"})
+		if !errors.Is(err, driver.ErrBadConn) {
+			t.Errorf("%s: expected driver.ErrBadConn", code.Name())
+		}
+		var nerr *Error
+		if !errors.As(err, &nerr) || nerr.Code != code {
+			t.Errorf("%s: expected the underlying error to be kept", code.Name())
+		}
+	}
+	if errors.Is(&Error{Code: SyntaxError}, driver.ErrBadConn) {
+		t.Error("Expected a syntax error not to be driver.ErrBadConn")
+	}
+}
+
+func TestErrorSentinels(t *testing.T) {
+	err := fmt.Errorf("insert: %w", &Error{Code: UniqueDuplicate, Message: "duplicate value", SQLState: "23000"})
+	if !errors.Is(err, ErrUniqueViolation) {
+		t.Fatal("Expected ErrUniqueViolation")
+	}
+	if errors.Is(err, ErrDeadlock) || errors.Is(err, driver.ErrBadConn) {
+		t.Fatal("Expected no other sentinels to match")
+	}
+	var nerr *Error
+	if !errors.As(err, &nerr) || nerr.SQLState != "23000" {
+		t.Fatalf("Expected the SQLState to be kept, got %+v", nerr)
+	}
+	if (&Error{Code: SyntaxError}).Unwrap() != nil {
+		t.Fatal("Expected no sentinel for a syntax error")
+	}
+}
+
+func TestErrorRetryable(t *testing.T) {
+	for _, tc := range []struct {
+		code                 ErrorCode
+		retryable, temporary bool
+	}{
+		{UpdateConflict, true, true},
+		{Deadlock, true, true},
+		{LockTimeout, true, true},
+		{OperationTimeout, false, true},
+		{NetworkError, false, true},
+		{ConnectionError, false, true},
+		{IsShutdown, false, true},
+		{SyntaxError, false, false},
+		{UniqueDuplicate, false, false},
+		{ConstraintError, false, false},
+		{NoSuchTable, false, false},
+	} {
+		err := &Error{Code: tc.code}
+		if err.Retryable() != tc.retryable || err.Temporary() != tc.temporary {
+			t.Errorf("%s: expected retryable %v and temporary %v, got %v and %v",
+				tc.code.Name(), tc.retryable, tc.temporary, err.Retryable(), err.Temporary())
+		}
+	}
+}
+
+func TestErrorAnnotation(t *testing.T) {
+	args := []driver.NamedValue{{Ordinal: 1, Value: int64(7)}, {Ordinal: 2, Value: "secret"}, {Ordinal: 3}}
+	annotated := func(annotation ErrorAnnotation, err error) error {
+		c := &Conn{annotateErrors: annotation}
+		c.annotateError("INSERT INTO t\n  VALUES (?, ?, ?)", args, &err)
+		return err
+	}
+	err := annotated(AnnotateNone, &Error{Code: UniqueDuplicate, Message: "duplicate value in unique index"})
+	if expected := "nuodb: duplicate value in unique index"; err.Error() != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, err.Error())
+	}
+	err = annotated(AnnotateSQL, &Error{Code: UniqueDuplicate, Message: "duplicate value in unique index"})
+	if expected := "nuodb: duplicate value in unique index [sql: INSERT INTO t VALUES (?, ?, ?)]"; err.Error() != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, err.Error())
+	}
+	err = annotated(AnnotateSQLAndParams, fmt.Errorf("bind: %w", &Error{Code: UniqueDuplicate, Message: "duplicate"}))
+	expected := "bind: nuodb: duplicate [sql: INSERT INTO t VALUES (?, ?, ?)] [params: int64, string(6), null]"
+	if err.Error() != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, err.Error())
+	}
+	if err := annotated(AnnotateSQL, errClosed); err != errClosed {
+		t.Fatalf("Expected the other errors to be kept, got %v", err)
+	}
+	if s := abbreviateSQL(strings.Repeat("é", maxErrorSQL)); len(s) > maxErrorSQL+3 || !utf8.ValidString(s) {
+		t.Fatalf("Unexpected abbreviation %q", s)
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/tilinna/go-nuodb/internal/parse"
+)
+
+// Queryer runs queries; it is implemented by *sql.DB, *sql.Conn and
+// *sql.Tx.
+type Queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// Exists reports whether query returns any rows, e.g. instead of the
+// wasteful SELECT COUNT(*) ... > 0:
+//
+//	found, err := nuodb.Exists(ctx, db, "SELECT * FROM orders WHERE customer = ?", id)
+//
+// A SELECT is rewritten to SELECT 1 FROM DUAL WHERE EXISTS (query), so that
+// the server stops at the first matching row. Other queries, e.g. WITH ...
+// SELECT, are run as they are. Either way a single row is fetched and the
+// rows are closed right after it.
+func Exists(ctx context.Context, db Queryer, query string, args ...interface{}) (bool, error) {
+	sql, err := existsSQL(query)
+	if err != nil {
+		return false, err
+	}
+	rows, err := db.QueryContext(ctx, sql, append(args[:len(args):len(args)], FetchSize(1))...)
+	if err != nil {
+		return false, err
+	}
+	found := rows.Next()
+	if err := rows.Close(); err != nil {
+		return false, err
+	}
+	if err := rows.Err(); err != nil {
+		return false, err
+	}
+	return found, nil
+}
+
+// existsSQL rewrites query for Exists.
+func existsSQL(query string) (string, error) {
+	query = strings.TrimRight(strings.TrimSpace(query), ";")
+	if parse.Classify(query) != parse.KindQuery {
+		return "", fmt.Errorf("nuodb: not a query: %s", query)
+	}
+	if parse.LeadingKeyword(query) != "SELECT" {
+		return query, nil
+	}
+	return "SELECT 1 FROM DUAL WHERE EXISTS (" + query + "\n)", nil // after a trailing comment
+}
@@ -0,0 +1,49 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"testing"
+)
+
+func TestExistsSQL(t *testing.T) {
+	tests := []struct {
+		query, sql string
+	}{
+		{"SELECT * FROM t WHERE id = ?;", "SELECT 1 FROM DUAL WHERE EXISTS (SELECT * FROM t WHERE id = ?\n)"},
+		{"select id from t -- by id", "SELECT 1 FROM DUAL WHERE EXISTS (select id from t -- by id\n)"},
+		{"WITH a AS (SELECT 1 FROM DUAL) SELECT * FROM a", "WITH a AS (SELECT 1 FROM DUAL) SELECT * FROM a"},
+		{"VALUES (1)", "VALUES (1)"},
+	}
+	for _, test := range tests {
+		if sql, err := existsSQL(test.query); err != nil || sql != test.sql {
+			t.Errorf("%q: expected %q, got %q (%v)", test.query, test.sql, sql, err)
+		}
+	}
+	if _, err := existsSQL("DELETE FROM t"); err == nil {
+		t.Fatal("Expected an error for a statement which isn't a query")
+	}
+}
+
+func TestExists(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	exec(t, db, "CREATE TABLE tests.FooBarExists (id BIGINT)")
+	exec(t, db, "INSERT INTO tests.FooBarExists (id) VALUES (1), (2), (3)")
+
+	ctx := context.Background()
+	for id, expected := range map[int]bool{2: true, 4: false} {
+		found, err := Exists(ctx, db, "SELECT * FROM tests.FooBarExists WHERE id = ?", id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if found != expected {
+			t.Fatalf("%d: expected %v, got %v", id, expected, found)
+		}
+	}
+	found, err := Exists(ctx, db, "WITH t AS (SELECT id FROM tests.FooBarExists) SELECT * FROM t WHERE id > 1")
+	if err != nil || !found {
+		t.Fatalf("Expected rows of the WITH query, got %v (%v)", found, err)
+	}
+}
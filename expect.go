@@ -0,0 +1,49 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Execer executes statements; it is implemented by *sql.DB, *sql.Conn and
+// *sql.Tx.
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// UnexpectedRowCountError is returned by ExecExpect when a statement
+// affected another number of rows than expected.
+type UnexpectedRowCountError struct {
+	SQL      string
+	Expected int64
+	Actual   int64
+}
+
+func (e *UnexpectedRowCountError) Error() string {
+	return fmt.Sprintf("nuodb: expected %d affected rows, got %d: %s", e.Expected, e.Actual, e.SQL)
+}
+
+// ExecExpect executes sql and checks that it affected expectedRows rows,
+// e.g. that an UPDATE or a DELETE by key hit exactly one row:
+//
+//	_, err := nuodb.ExecExpect(ctx, tx, "UPDATE accounts SET balance = ? WHERE id = ?", 1, balance, id)
+//
+// On a mismatch the result is returned with an *UnexpectedRowCountError.
+// Within a transaction, the caller should then roll it back.
+func ExecExpect(ctx context.Context, db Execer, sql string, expectedRows int64, args ...interface{}) (sql.Result, error) {
+	result, err := db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rows != expectedRows {
+		return result, &UnexpectedRowCountError{SQL: sql, Expected: expectedRows, Actual: rows}
+	}
+	return result, nil
+}
@@ -0,0 +1,51 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+)
+
+type rowsAffectedExecer int64
+
+func (e rowsAffectedExecer) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return driver.RowsAffected(e), nil
+}
+
+func TestExecExpect(t *testing.T) {
+	ctx := context.Background()
+	if _, err := ExecExpect(ctx, rowsAffectedExecer(1), "DELETE FROM t WHERE id = ?", 1, 7); err != nil {
+		t.Fatal(err)
+	}
+	result, err := ExecExpect(ctx, rowsAffectedExecer(0), "DELETE FROM t WHERE id = ?", 1, 7)
+	var rerr *UnexpectedRowCountError
+	if !errors.As(err, &rerr) || rerr.Expected != 1 || rerr.Actual != 0 {
+		t.Fatalf("Expected an UnexpectedRowCountError, got %v", err)
+	}
+	if result == nil {
+		t.Fatal("Expected the result with the error")
+	}
+	if want := "nuodb: expected 1 affected rows, got 0: DELETE FROM t WHERE id = ?"; err.Error() != want {
+		t.Fatalf("Expected %q, got %q", want, err.Error())
+	}
+}
+
+func TestExecExpectUpdate(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	ctx := context.Background()
+	exec(t, db, "CREATE TABLE FooBar (id INTEGER PRIMARY KEY, name STRING)")
+	exec(t, db, "INSERT INTO FooBar VALUES (1, 'a'), (2, 'b')")
+	if _, err := ExecExpect(ctx, db, "UPDATE FooBar SET name = ? WHERE id = ?", 1, "c", 1); err != nil {
+		t.Fatal(err)
+	}
+	_, err := ExecExpect(ctx, db, "UPDATE FooBar SET name = ? WHERE id = ?", 1, "c", 3)
+	var rerr *UnexpectedRowCountError
+	if !errors.As(err, &rerr) || rerr.Actual != 0 {
+		t.Fatalf("Expected an UnexpectedRowCountError, got %v", err)
+	}
+}
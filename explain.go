@@ -0,0 +1,184 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Plan is the query plan of a statement, as explained by NuoDB.
+type Plan struct {
+	Text  string      // as returned by EXPLAIN
+	Nodes []*PlanNode // at the top of the plan, usually one
+}
+
+// PlanNode is an operator of a Plan, e.g.
+//
+//	Table scan HOCKEY.PLAYERS (cost: 21.0, resultRows: 21.0)
+//
+// is a node whose Operator is "Table scan", Detail "HOCKEY.PLAYERS", Cost
+// 21 and Rows 21. The children of a node are indented under it in the
+// text, and feed it with their rows.
+type PlanNode struct {
+	Operator  string            // e.g. "Project", "Table scan" or "Index scan"
+	Detail    string            // the rest of the line, e.g. the table or the columns
+	Index     string            // of an index operator, if any
+	Cost      float64           // estimated cost; 0 if not given
+	Rows      float64           // estimated result rows; 0 if not given
+	Estimates map[string]string // all the estimates in parentheses, by name
+	Children  []*PlanNode
+}
+
+// planOperators are the multi-word operators of the NuoDB plans, which are
+// otherwise the first word of a line.
+var planOperators = []string{
+	"table scan", "index scan", "bitmap scan", "bitmap index", "record fetch",
+	"index only scan", "nested loop join", "hash join", "merge join",
+	"hash grouping", "sort grouping", "group by", "order by", "distinct by",
+}
+
+// Explain returns the plan of query, explained by NuoDB, so that e.g. a
+// test can check that a query uses an index:
+//
+//	plan, err := nuodb.Explain(ctx, db, "SELECT * FROM orders WHERE customer = ?", id)
+//	...
+//	if !plan.UsesIndex("ORDERS_CUSTOMER_IDX") {
+//		t.Error("orders by customer: a table scan")
+//	}
+//
+// The operators and the estimates are as NuoDB explains them, which may
+// change between NuoDB versions.
+func Explain(ctx context.Context, db Queryer, query string, args ...interface{}) (*Plan, error) {
+	rows, err := db.QueryContext(ctx, "EXPLAIN "+query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var lines []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, errors.New("nuodb: no plan")
+	}
+	return parsePlan(strings.Join(lines, "\n")), nil
+}
+
+// UsesIndex reports whether an operator of the plan uses the named index.
+func (p *Plan) UsesIndex(name string) bool {
+	for _, index := range p.Indexes() {
+		if strings.EqualFold(index, name) || strings.HasSuffix(strings.ToUpper(index), "."+strings.ToUpper(name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Indexes returns the indexes used by the operators of the plan, in the
+// order of the text.
+func (p *Plan) Indexes() []string {
+	var indexes []string
+	p.Walk(func(n *PlanNode) {
+		if n.Index != "" {
+			indexes = append(indexes, n.Index)
+		}
+	})
+	return indexes
+}
+
+// Walk calls fn for each node of the plan, in the order of the text.
+func (p *Plan) Walk(fn func(*PlanNode)) {
+	var walk func([]*PlanNode)
+	walk = func(nodes []*PlanNode) {
+		for _, n := range nodes {
+			fn(n)
+			walk(n.Children)
+		}
+	}
+	walk(p.Nodes)
+}
+
+// parsePlan parses the text of EXPLAIN into a tree by the indentation of
+// its lines.
+func parsePlan(text string) *Plan {
+	p := &Plan{Text: text}
+	type level struct {
+		indent int
+		node   *PlanNode
+	}
+	var stack []level
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimLeft(line, " \t")
+		if strings.TrimSpace(trimmed) == "" {
+			continue
+		}
+		indent := len(line) - len(trimmed)
+		n := parsePlanNode(strings.TrimSpace(trimmed))
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			p.Nodes = append(p.Nodes, n)
+		} else {
+			parent := stack[len(stack)-1].node
+			parent.Children = append(parent.Children, n)
+		}
+		stack = append(stack, level{indent, n})
+	}
+	return p
+}
+
+// parsePlanNode parses a line of a plan.
+func parsePlanNode(line string) *PlanNode {
+	n := &PlanNode{}
+	if i := strings.LastIndex(line, "("); i >= 0 && strings.HasSuffix(line, ")") {
+		if estimates, ok := parseEstimates(line[i+1 : len(line)-1]); ok {
+			n.Estimates = estimates
+			n.Cost, _ = strconv.ParseFloat(estimates["cost"], 64)
+			n.Rows, _ = strconv.ParseFloat(estimates["resultRows"], 64)
+			line = strings.TrimSpace(line[:i])
+		}
+	}
+	n.Operator = line
+	if i := strings.IndexByte(line, ' '); i > 0 {
+		n.Operator = line[:i]
+	}
+	lower := strings.ToLower(line)
+	for _, op := range planOperators {
+		if strings.HasPrefix(lower, op) && (len(line) == len(op) || line[len(op)] == ' ') {
+			n.Operator = line[:len(op)]
+			break
+		}
+	}
+	n.Detail = strings.TrimSpace(line[len(n.Operator):])
+	if strings.Contains(lower[:len(n.Operator)], "index") || strings.HasPrefix(lower, "bitmap") {
+		if fields := strings.Fields(n.Detail); len(fields) > 0 {
+			n.Index = strings.TrimRight(fields[0], ",")
+		}
+	}
+	return n
+}
+
+// parseEstimates parses the estimates of a plan line, e.g.
+// "cost: 16.9, resultRows: 5.3".
+func parseEstimates(s string) (map[string]string, bool) {
+	estimates := make(map[string]string)
+	for _, field := range strings.Split(s, ",") {
+		kv := strings.SplitN(field, ":", 2)
+		if len(kv) != 2 {
+			return nil, false
+		}
+		estimates[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	return estimates, true
+}
@@ -0,0 +1,71 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+const testPlan = ` Sort (cost: 1052.8, resultRows: 10.5)
+   Project HOCKEY.NUMBER, HOCKEY.NAME
+     Filter HOCKEY.POSITION = 'Goalie'
+       Record fetch HOCKEY.HOCKEY (cost: 16.9, resultRows: 5.3)
+         Bitmap index HOCKEY..POSITION_IDX (cost: 2.1, resultRows: 5.3)
+   Table scan HOCKEY.TEAMS (cost: 0.0*21.0, resultRows: 21.0)
+ Hash join`
+
+func TestParsePlan(t *testing.T) {
+	p := parsePlan(testPlan)
+	if len(p.Nodes) != 2 || p.Nodes[1].Operator != "Hash join" || p.Nodes[1].Detail != "" {
+		t.Fatalf("Expected two roots, got %d", len(p.Nodes))
+	}
+	sort := p.Nodes[0]
+	if sort.Operator != "Sort" || sort.Detail != "" || sort.Cost != 1052.8 || sort.Rows != 10.5 || len(sort.Children) != 2 {
+		t.Fatalf("Unexpected root %+v", sort)
+	}
+	project := sort.Children[0]
+	if project.Operator != "Project" || project.Detail != "HOCKEY.NUMBER, HOCKEY.NAME" || project.Estimates != nil {
+		t.Fatalf("Unexpected project %+v", project)
+	}
+	fetch := project.Children[0].Children[0]
+	if fetch.Operator != "Record fetch" || fetch.Detail != "HOCKEY.HOCKEY" || fetch.Index != "" {
+		t.Fatalf("Unexpected fetch %+v", fetch)
+	}
+	bitmap := fetch.Children[0]
+	if bitmap.Operator != "Bitmap index" || bitmap.Index != "HOCKEY..POSITION_IDX" || bitmap.Cost != 2.1 {
+		t.Fatalf("Unexpected index %+v", bitmap)
+	}
+	scan := sort.Children[1]
+	if scan.Operator != "Table scan" || scan.Cost != 0 || scan.Estimates["cost"] != "0.0*21.0" || scan.Rows != 21 {
+		t.Fatalf("Unexpected scan %+v", scan)
+	}
+
+	if indexes := p.Indexes(); !reflect.DeepEqual(indexes, []string{"HOCKEY..POSITION_IDX"}) {
+		t.Fatalf("Unexpected indexes %q", indexes)
+	}
+	if !p.UsesIndex("position_idx") || p.UsesIndex("NUMBER_IDX") {
+		t.Fatal("Unexpected index use")
+	}
+	var operators []string
+	p.Walk(func(n *PlanNode) { operators = append(operators, n.Operator) })
+	expected := []string{"Sort", "Project", "Filter", "Record fetch", "Bitmap index", "Table scan", "Hash join"}
+	if !reflect.DeepEqual(operators, expected) {
+		t.Fatalf("Expected %q, got %q", expected, operators)
+	}
+}
+
+func TestExplain(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	exec(t, db, "CREATE TABLE tests.FooBarExplain (id BIGINT, name STRING)")
+	exec(t, db, "CREATE INDEX FooBarExplainName ON tests.FooBarExplain (name)")
+	plan, err := Explain(context.Background(), db, "SELECT id FROM tests.FooBarExplain WHERE name = ?", "x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Nodes) == 0 || !plan.UsesIndex("FooBarExplainName") {
+		t.Fatalf("Expected the index to be used:\n%s", plan.Text)
+	}
+}
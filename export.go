@@ -0,0 +1,149 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"time"
+)
+
+// CopyToCSV runs query on a connection of db and writes its result rows to
+// w as CSV, after a header record of the column names, and returns the
+// number of rows written:
+//
+//	n, err := nuodb.CopyToCSV(ctx, f, db, "SELECT * FROM orders WHERE day = ?", day)
+//
+// The rows are read from the driver as they are fetched, in batches,
+// without scanning them into Go values. A NULL is written as an empty field,
+// a time in the format of time.RFC3339Nano and bytes as is, so that LoadCSV
+// loads the file back. On error, the rows written before it are flushed to
+// w and counted.
+func CopyToCSV(ctx context.Context, w io.Writer, db *sql.DB, query string, args ...interface{}) (int64, error) {
+	var n int64
+	err := exportRows(ctx, db, query, args, func(rows *Rows) error {
+		cw := csv.NewWriter(w)
+		columns := rows.Columns()
+		if err := cw.Write(columns); err != nil {
+			return err
+		}
+		dest := make([]driver.Value, len(columns))
+		record := make([]string, len(columns))
+		for {
+			err := rows.Next(dest)
+			if err == io.EOF {
+				break
+			}
+			if err == nil {
+				err = csvFields(dest, record)
+			}
+			if err == nil {
+				err = cw.Write(record)
+			}
+			if err != nil {
+				cw.Flush()
+				return err
+			}
+			n++
+		}
+		cw.Flush()
+		return cw.Error()
+	})
+	return n, err
+}
+
+// CopyToColumns runs query on a connection of db and calls fn with its
+// result rows in batches of up to batchRows rows, like QueryColumns, e.g. to
+// build the record batches of Apache Arrow. The values of a Column and its
+// validity bitmap have the layout of the buffers of an Arrow array on a
+// little-endian machine, so they can be appended to its builders or copied
+// into its buffers as a whole.
+func CopyToColumns(ctx context.Context, db *sql.DB, query string, batchRows int,
+	fn func(*ColumnBatch) error, args ...interface{}) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return QueryColumns(ctx, conn, query, batchRows, fn, args...)
+}
+
+// exportRows runs query on a connection of db and calls fn with its driver
+// rows, which are closed when fn returns.
+func exportRows(ctx context.Context, db *sql.DB, query string, args []interface{}, fn func(*Rows) error) error {
+	named, err := namedValues(args)
+	if err != nil {
+		return err
+	}
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return conn.Raw(func(driverConn interface{}) error {
+		c, ok := driverConn.(*Conn)
+		if !ok {
+			return errors.New("nuodb: not a nuodb connection")
+		}
+		dr, err := c.QueryContext(ctx, query, named)
+		if err != nil {
+			return err
+		}
+		rows := dr.(*Rows)
+		defer rows.Close()
+		return fn(rows)
+	})
+}
+
+// namedValues converts the arguments of a helper to the parameters of a
+// statement.
+func namedValues(args []interface{}) ([]driver.NamedValue, error) {
+	named := make([]driver.NamedValue, len(args))
+	for i, arg := range args {
+		v, err := batchValue(arg)
+		if err != nil {
+			return nil, fmt.Errorf("nuodb: parameter %d: %s", i+1, err)
+		}
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
+	}
+	return named, nil
+}
+
+// csvFields formats the values of a row as the fields of a CSV record.
+func csvFields(values []driver.Value, record []string) error {
+	for i, v := range values {
+		switch v := v.(type) {
+		case nil:
+			record[i] = ""
+		case int64:
+			record[i] = strconv.FormatInt(v, 10)
+		case float64:
+			record[i] = strconv.FormatFloat(v, 'g', -1, 64)
+		case bool:
+			record[i] = strconv.FormatBool(v)
+		case string:
+			record[i] = v
+		case []byte:
+			record[i] = string(v)
+		case time.Time:
+			record[i] = v.Format(time.RFC3339Nano)
+		case io.Reader:
+			// a streamed lob
+			b, err := ioutil.ReadAll(v)
+			if err != nil {
+				return err
+			}
+			record[i] = string(b)
+		default:
+			record[i] = fmt.Sprint(v)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCSVFields(t *testing.T) {
+	when := time.Date(2013, 5, 6, 7, 8, 9, 10, time.UTC)
+	values := []driver.Value{nil, int64(-3), 1.5, true, "a,b", []byte("xy"), when, strings.NewReader("lob")}
+	record := make([]string, len(values))
+	if err := csvFields(values, record); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"", "-3", "1.5", "true", "a,b", "xy", "2013-05-06T07:08:09.00000001Z", "lob"}
+	for i := range expected {
+		if record[i] != expected[i] {
+			t.Errorf("Field %d: expected %q, got %q", i, expected[i], record[i])
+		}
+	}
+	for i, field := range record[:len(record)-1] {
+		kind := []csvKind{csvInt, csvInt, csvFloat, csvBool, csvString, csvBytes, csvTime}[i]
+		v, err := csvColumn{kind: kind}.convert(field)
+		if err != nil {
+			t.Fatalf("Field %d: %s", i, err)
+		}
+		if b, ok := v.([]byte); ok {
+			v = string(b)
+		}
+		if expected := values[i]; expected != nil && !equalValue(v, expected) {
+			t.Errorf("Field %d: expected %v to load back, got %v", i, expected, v)
+		}
+	}
+}
+
+func equalValue(a, b driver.Value) bool {
+	if t, ok := a.(time.Time); ok {
+		return t.Equal(b.(time.Time))
+	}
+	if bs, ok := b.([]byte); ok {
+		b = string(bs)
+	}
+	return a == b
+}
+
+func TestCopyToCSV(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	exec(t, db, "CREATE TABLE FooBarExport (id BIGINT, name STRING, score DOUBLE)")
+	exec(t, db, "INSERT INTO FooBarExport VALUES (1, 'a,b', 1.5), (2, NULL, NULL), (3, 'c', 3)")
+
+	var buf bytes.Buffer
+	n, err := CopyToCSV(context.Background(), &buf, db, "SELECT id, name, score FROM FooBarExport WHERE id > ? ORDER BY id", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "ID,NAME,SCORE\n1,\"a,b\",1.5\n2,,\n3,c,3\n"
+	if n != 3 || buf.String() != expected {
+		t.Fatalf("Expected 3 rows %q, got %d %q", expected, n, buf.String())
+	}
+
+	var rows int
+	err = CopyToColumns(context.Background(), db, "SELECT id, score FROM FooBarExport", 2, func(b *ColumnBatch) error {
+		rows += b.Len
+		return nil
+	})
+	if err != nil || rows != 3 {
+		t.Fatalf("Expected 3 rows, got %d: %v", rows, err)
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// FaultInjector injects NuoDB errors and latency into the statements and
+// connects of the Connectors configured with it, to chaos test the retry
+// and failover logic of an application against the errors the driver
+// really returns:
+//
+//	faults := nuodb.NewFaultInjector(1)
+//	faults.Faults = []nuodb.Fault{{Code: nuodb.Deadlock, Rate: 0.01}, {Code: nuodb.NetworkError, Rate: 0.001}}
+//	faults.LatencyRate, faults.MaxLatency = 0.05, 200*time.Millisecond
+//	cfg.Faults = faults
+//
+// An injected error takes the place of the statement, which isn't executed.
+// It is an *Error of the code, which the driver treats as a real one: a
+// Deadlock is retried by the retryAttempts of the connection and after a
+// NetworkError the connection is discarded. Set the fields before the
+// Connector is used; FaultInjector is otherwise safe for concurrent use.
+type FaultInjector struct {
+	Faults      []Fault       // errors injected into the statements
+	LatencyRate float64       // probability of delaying a statement
+	MaxLatency  time.Duration // of a uniformly random delay
+	ConnectRate float64       // probability of failing a connect with a ConnectionError
+
+	mu    sync.Mutex
+	rand  *rand.Rand
+	stats FaultStats
+}
+
+// Fault is an error injected into statements with the probability Rate.
+type Fault struct {
+	Code ErrorCode // e.g. Deadlock, LockTimeout or NetworkError
+	Rate float64
+}
+
+// FaultStats are the statistics of a FaultInjector.
+type FaultStats struct {
+	Statements    int64 // statements seen
+	Errors        int64 // errors injected into them
+	Delays        int64 // statements delayed
+	Connects      int64 // connects seen
+	ConnectErrors int64 // connects failed
+}
+
+// NewFaultInjector returns a FaultInjector which draws its faults from a
+// source seeded with seed, so that a run can be repeated.
+func NewFaultInjector(seed int64) *FaultInjector {
+	return &FaultInjector{rand: rand.New(rand.NewSource(seed))}
+}
+
+// Stats returns the statistics of the injector.
+func (f *FaultInjector) Stats() FaultStats {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.stats
+}
+
+// inject delays a statement on c and returns the error injected into it,
+// if any. A nil injector doesn't inject.
+func (f *FaultInjector) inject(ctx context.Context, c *Conn) error {
+	if f == nil {
+		return nil
+	}
+	delay, code := f.draw()
+	if delay > 0 {
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return contextError(ctx)
+		case <-t.C:
+		}
+	}
+	if code == 0 {
+		return nil
+	}
+	err := &Error{Code: code, Message: injectedMessage(code), Conn: c.id, Session: c.sessionID}
+	if fatalErrorCodes[code] {
+		c.fatal(err, !c.used)
+	}
+	if drainErrorCodes[code] {
+		c.drain(err)
+	}
+	return err
+}
+
+// draw draws the delay and the error code of a statement.
+func (f *FaultInjector) draw() (delay time.Duration, code ErrorCode) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stats.Statements++
+	r := f.random()
+	if f.MaxLatency > 0 && r.Float64() < f.LatencyRate {
+		delay = time.Duration(r.Int63n(int64(f.MaxLatency)) + 1)
+		f.stats.Delays++
+	}
+	// one draw for all the faults, so that their rates add up
+	p := r.Float64()
+	for _, fault := range f.Faults {
+		if p < fault.Rate {
+			f.stats.Errors++
+			return delay, fault.Code
+		}
+		p -= fault.Rate
+	}
+	return delay, 0
+}
+
+// injectConnect returns the error injected into a connect, if any.
+func (f *FaultInjector) injectConnect() error {
+	if f == nil {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stats.Connects++
+	if f.random().Float64() >= f.ConnectRate {
+		return nil
+	}
+	f.stats.ConnectErrors++
+	return &Error{Code: ConnectionError, Message: injectedMessage(ConnectionError)}
+}
+
+// random returns the source of the faults. f.mu must be held.
+func (f *FaultInjector) random() *rand.Rand {
+	if f.rand == nil {
+		f.rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	return f.rand
+}
+
+func injectedMessage(code ErrorCode) string {
+	return "injected fault: " + code.Name()
+}
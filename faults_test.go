@@ -0,0 +1,84 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFaultInjector(t *testing.T) {
+	ctx := context.Background()
+	var none *FaultInjector
+	if err := none.inject(ctx, &Conn{}); err != nil {
+		t.Fatal(err)
+	}
+
+	f := NewFaultInjector(1)
+	f.Faults = []Fault{{Code: Deadlock, Rate: 1}}
+	c := &Conn{id: 7}
+	err := f.inject(ctx, c)
+	if !errors.Is(err, ErrDeadlock) || c.bad {
+		t.Fatalf("Expected a deadlock, got %v", err)
+	}
+	if nerr := err.(*Error); nerr.Conn != 7 || nerr.Message != "injected fault: DEADLOCK" {
+		t.Fatalf("Unexpected error %+v", nerr)
+	}
+
+	f.Faults = []Fault{{Code: NetworkError, Rate: 1}}
+	if err := f.inject(ctx, c); !errors.Is(err, driver.ErrBadConn) || !c.bad {
+		t.Fatalf("Expected a bad connection, got %v", err)
+	}
+
+	f.Faults = []Fault{{Code: Deadlock, Rate: 0.5}, {Code: LockTimeout, Rate: 0.5}}
+	codes := make(map[ErrorCode]int)
+	for i := 0; i < 1000; i++ {
+		var nerr *Error
+		if err := f.inject(ctx, &Conn{}); !errors.As(err, &nerr) {
+			t.Fatalf("Expected an error, got %v", err)
+		}
+		codes[nerr.Code]++
+	}
+	if codes[Deadlock] < 400 || codes[LockTimeout] < 400 {
+		t.Fatalf("Unexpected distribution %v", codes)
+	}
+
+	f.Faults = nil
+	f.LatencyRate, f.MaxLatency = 1, time.Hour
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := f.inject(timeout, &Conn{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected %v, got %v", context.DeadlineExceeded, err)
+	}
+
+	f.ConnectRate = 1
+	if err := f.injectConnect(); !errors.Is(err, driver.ErrBadConn) {
+		t.Fatalf("Expected a connection error, got %v", err)
+	}
+	expected := FaultStats{Statements: 1003, Errors: 1002, Delays: 1, Connects: 1, ConnectErrors: 1}
+	if s := f.Stats(); s != expected {
+		t.Fatalf("Expected %+v, got %+v", expected, s)
+	}
+}
+
+func TestFaultInjectorSeed(t *testing.T) {
+	draws := func() []ErrorCode {
+		f := NewFaultInjector(42)
+		f.Faults = []Fault{{Code: Deadlock, Rate: 0.3}}
+		var codes []ErrorCode
+		for i := 0; i < 20; i++ {
+			_, code := f.draw()
+			codes = append(codes, code)
+		}
+		return codes
+	}
+	a, b := draws(), draws()
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("Expected the same draws, got %v and %v", a, b)
+		}
+	}
+}
@@ -1,3 +1,3 @@
 module github.com/tilinna/go-nuodb
 
-go 1.13
+go 1.17
@@ -0,0 +1,104 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+// #include "cnuodb.h"
+import "C"
+import "errors"
+
+// The client library isn't safe for concurrent use of a connection, yet
+// database/sql may close a Stmt or Rows in one goroutine, e.g. when a
+// transaction ends, while another goroutine still uses it or the other
+// Stmts and Rows of the connection. So the calls into the client library on
+// a connection hold its callMu, and check that the connection, the
+// statement or the result set they use is still open, so that such a race
+// fails with an error instead of crashing in the client library. Only
+// nuodb_statement_cancel runs without callMu, as it interrupts the call
+// holding it.
+
+var (
+	errStmtClosed = errors.New("nuodb: statement is closed")
+	errRowsClosed = errors.New("nuodb: rows are closed")
+)
+
+// lock locks the calls into the client library on c until the returned
+// function is called. It fails with errClosed, unlocked, if c is closed.
+// It notes whether the call is the first since the pool handed c out, for
+// the ReconnectPolicy.
+func (c *Conn) lock() (func(), error) {
+	c.callMu.Lock()
+	if c.db == nil {
+		c.callMu.Unlock()
+		return nil, errClosed
+	}
+	c.firstCall, c.used = !c.used, true
+	return c.callMu.Unlock, nil
+}
+
+// cgo calls fn, a call into the client library on c, holding the lock of
+// the calls, and returns the error of the call if it fails.
+func (c *Conn) cgo(fn func() C.int) error {
+	unlock, err := c.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if rc := fn(); rc != 0 {
+		return c.lastError(rc)
+	}
+	return nil
+}
+
+// lock locks the calls on the connection of stmt, failing if either is
+// closed.
+func (stmt *Stmt) lock() (func(), error) {
+	unlock, err := stmt.c.lock()
+	if err != nil {
+		return nil, err
+	}
+	if stmt.st == nil {
+		unlock()
+		return nil, errStmtClosed
+	}
+	return unlock, nil
+}
+
+// cgo calls fn, a call on stmt, like Conn.cgo.
+func (stmt *Stmt) cgo(fn func() C.int) error {
+	unlock, err := stmt.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if rc := fn(); rc != 0 {
+		return stmt.c.lastError(rc)
+	}
+	return nil
+}
+
+// lock locks the calls on the connection of rows, failing if either is
+// closed.
+func (rows *Rows) lock() (func(), error) {
+	unlock, err := rows.c.lock()
+	if err != nil {
+		return nil, err
+	}
+	if rows.closed || rows.rs == nil {
+		unlock()
+		return nil, errRowsClosed
+	}
+	return unlock, nil
+}
+
+// cgo calls fn, a call on the result set of rows, like Conn.cgo.
+func (rows *Rows) cgo(fn func() C.int) error {
+	unlock, err := rows.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if rc := fn(); rc != 0 {
+		return rows.c.lastError(rc)
+	}
+	return nil
+}
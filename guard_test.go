@@ -0,0 +1,72 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestLockClosed(t *testing.T) {
+	c := &Conn{}
+	if _, err := c.lock(); err != errClosed {
+		t.Fatalf("Expected errClosed, got %v", err)
+	}
+	if _, err := (&Stmt{c: c}).lock(); err != errClosed {
+		t.Fatalf("Expected errClosed of the statement, got %v", err)
+	}
+	if _, err := (&Rows{c: c}).lock(); err != errClosed {
+		t.Fatalf("Expected errClosed of the rows, got %v", err)
+	}
+	// the lock is released by the failures
+	c.callMu.Lock()
+	c.callMu.Unlock()
+}
+
+func TestConcurrentStmtClose(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	err = conn.Raw(func(driverConn interface{}) error {
+		c := driverConn.(*Conn)
+		for i := 0; i < 20; i++ {
+			ds, err := c.Prepare("SELECT * FROM SYSTEM.FIELDS")
+			if err != nil {
+				return err
+			}
+			stmt := ds.(*Stmt)
+			dr, err := stmt.queryContext(ctx, nil)
+			if err != nil {
+				return err
+			}
+			rows := dr.(*Rows)
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				dest := make([]driver.Value, len(rows.Columns()))
+				for rows.Next(dest) == nil {
+				}
+			}()
+			stmt.Close()
+			wg.Wait()
+			rows.Close()
+			if _, err := stmt.execQuery(ctx, nil); !errors.Is(err, errStmtClosed) {
+				return fmt.Errorf("expected errStmtClosed, got %v", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
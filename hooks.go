@@ -0,0 +1,90 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"database/sql/driver"
+	"time"
+)
+
+// HookEvent describes a statement passed to the Hooks of a Connector.
+type HookEvent struct {
+	Conn    uint64              // local number of the connection
+	Session int64               // server side id of the connection; 0 if unknown
+	SQL     string              // as passed to the driver, before named parameters are replaced
+	Args    []driver.NamedValue // converted parameters; not to be modified
+	TLS     bool                // the connection is encrypted; see NuoConn.Security
+
+	// Duration and Err are set for AfterQuery, AfterExec and OnError.
+	Duration time.Duration
+	Err      error
+}
+
+// Hooks are called around the statements executed on the connections of a
+// Connector, e.g. to audit them, to record metrics or to trace them, without
+// wrapping every call site:
+//
+//	type auditHooks struct{ nuodb.NopHooks }
+//
+//	func (auditHooks) AfterExec(ctx context.Context, e *nuodb.HookEvent) {
+//		log.Printf("conn %d: %s took %s", e.Conn, e.SQL, e.Duration)
+//	}
+//
+//	cfg.Hooks = auditHooks{}
+//
+// BeforeQuery and BeforeExec are called before the statement is executed.
+// The context they return is passed to the other hooks of the same
+// statement, e.g. to carry a span. Either AfterQuery or AfterExec is called
+// when the statement succeeds, OnError when it fails. The duration of a
+// query covers its execution but not the fetching of its rows. Batches and
+// transaction boundaries are not passed to the hooks.
+//
+// The hooks are called on the goroutine executing the statement, so they
+// must not use its connection, and they must be safe for concurrent use by
+// the connections. Embed NopHooks to implement only some of the methods.
+type Hooks interface {
+	BeforeQuery(ctx context.Context, e *HookEvent) context.Context
+	AfterQuery(ctx context.Context, e *HookEvent)
+	BeforeExec(ctx context.Context, e *HookEvent) context.Context
+	AfterExec(ctx context.Context, e *HookEvent)
+	OnError(ctx context.Context, e *HookEvent)
+}
+
+// NopHooks implements Hooks with methods which do nothing.
+type NopHooks struct{}
+
+var _ Hooks = NopHooks{}
+
+func (NopHooks) BeforeQuery(ctx context.Context, e *HookEvent) context.Context { return ctx }
+func (NopHooks) AfterQuery(ctx context.Context, e *HookEvent)                  {}
+func (NopHooks) BeforeExec(ctx context.Context, e *HookEvent) context.Context  { return ctx }
+func (NopHooks) AfterExec(ctx context.Context, e *HookEvent)                   {}
+func (NopHooks) OnError(ctx context.Context, e *HookEvent)                     {}
+
+// beforeHooks calls the before hook of a query or an exec of sql on c, if
+// c has hooks, and returns the function which calls the after hooks with
+// the error of the statement.
+func (c *Conn) beforeHooks(ctx context.Context, query bool, sql string, args []driver.NamedValue) func(err *error) {
+	if c.hooks == nil {
+		return func(*error) {}
+	}
+	e := &HookEvent{Conn: c.id, Session: c.sessionID, SQL: sql, Args: args, TLS: c.tls()}
+	if query {
+		ctx = c.hooks.BeforeQuery(ctx, e)
+	} else {
+		ctx = c.hooks.BeforeExec(ctx, e)
+	}
+	start := time.Now()
+	return func(err *error) {
+		e.Duration, e.Err = time.Since(start), *err
+		switch {
+		case *err != nil:
+			c.hooks.OnError(ctx, e)
+		case query:
+			c.hooks.AfterQuery(ctx, e)
+		default:
+			c.hooks.AfterExec(ctx, e)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+type hookKey struct{}
+
+// recordingHooks records the calls of the hooks.
+type recordingHooks struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (h *recordingHooks) record(ctx context.Context, call string, e *HookEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if ctx.Value(hookKey{}) != nil {
+		call += " (ctx)"
+	}
+	if e.Err != nil {
+		call += " " + e.Err.Error()
+	}
+	h.calls = append(h.calls, fmt.Sprintf("%s %s %d", call, e.SQL, len(e.Args)))
+}
+
+func (h *recordingHooks) recorded() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), h.calls...)
+}
+
+func (h *recordingHooks) BeforeQuery(ctx context.Context, e *HookEvent) context.Context {
+	h.record(ctx, "before query", e)
+	return context.WithValue(ctx, hookKey{}, true)
+}
+
+func (h *recordingHooks) AfterQuery(ctx context.Context, e *HookEvent) {
+	h.record(ctx, "after query", e)
+}
+
+func (h *recordingHooks) BeforeExec(ctx context.Context, e *HookEvent) context.Context {
+	h.record(ctx, "before exec", e)
+	return context.WithValue(ctx, hookKey{}, true)
+}
+
+func (h *recordingHooks) AfterExec(ctx context.Context, e *HookEvent) {
+	h.record(ctx, "after exec", e)
+}
+
+func (h *recordingHooks) OnError(ctx context.Context, e *HookEvent) {
+	h.record(ctx, "error", e)
+}
+
+func TestBeforeHooks(t *testing.T) {
+	h := &recordingHooks{}
+	c := &Conn{hooks: h}
+	args := []driver.NamedValue{{Ordinal: 1, Value: int64(1)}}
+	var err error
+	c.beforeHooks(context.Background(), true, "SELECT ?", args)(&err)
+	c.beforeHooks(context.Background(), false, "DELETE FROM t", nil)(&err)
+	err = errors.New("failed")
+	c.beforeHooks(context.Background(), false, "DELET FROM t", nil)(&err)
+	expected := []string{
+		"before query SELECT ? 1", "after query (ctx) SELECT ? 1",
+		"before exec DELETE FROM t 0", "after exec (ctx) DELETE FROM t 0",
+		"before exec DELET FROM t 0", "error (ctx) failed DELET FROM t 0",
+	}
+	if calls := h.recorded(); !reflect.DeepEqual(calls, expected) {
+		t.Fatalf("Expected %q, got %q", expected, calls)
+	}
+
+	c.hooks = nil
+	c.beforeHooks(context.Background(), true, "SELECT 1", nil)(&err)
+}
+
+func TestHooks(t *testing.T) {
+	db := testConn(t)
+	exec(t, db, "CREATE TABLE FooBar (id BIGINT)")
+	db.Close()
+	cfg, err := ParseDSN(default_dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Schema = "tests"
+	h := &recordingHooks{}
+	cfg.Hooks = h
+	connector, err := NewConnector(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db = sql.OpenDB(connector)
+	defer db.Close()
+
+	if _, err := db.Exec("INSERT INTO FooBar VALUES (?)", 1); err != nil {
+		t.Fatal(err)
+	}
+	var id int64
+	if err := db.QueryRow("SELECT id FROM FooBar").Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("INSERT INTO NoSuchTable VALUES (1)"); err == nil {
+		t.Fatal("Expected an error")
+	}
+	calls := h.recorded()
+	if len(calls) != 6 {
+		t.Fatalf("Expected 6 calls, got %q", calls)
+	}
+	for i, prefix := range []string{"before exec", "after exec (ctx)", "before query", "after query (ctx)",
+		"before exec", "error (ctx)"} {
+		if len(calls[i]) < len(prefix) || calls[i][:len(prefix)] != prefix {
+			t.Fatalf("Expected %q, got %q", prefix, calls[i])
+		}
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+)
+
+// IDAllocator hands out unique int64 IDs, e.g. the keys of the rows of a
+// high-throughput inserter, from blocks reserved from a NuoDB sequence.
+// Each value of the sequence reserves the block of blockSize IDs starting
+// at value*blockSize, so a block costs a single round trip, and the IDs of
+// a block are handed out locally:
+//
+//	ids, err := nuodb.NewIDAllocator(db, "app.order_ids", 1000)
+//	...
+//	id, err := ids.Next(ctx)
+//
+// The IDs of a block which isn't used up, e.g. when the process exits, are
+// never handed out, so the IDs have gaps, and the IDs of the allocators of
+// different processes interleave. All the users of the sequence must
+// allocate with the same block size, and nothing else may use the values
+// of the sequence as IDs. IDAllocator is safe for concurrent use; when a
+// block is used up, one caller reserves the next one while the others wait
+// for it.
+type IDAllocator struct {
+	sequence  string
+	blockSize int64
+	nextValue func(ctx context.Context) (int64, error) // of the sequence
+
+	sem       chan struct{} // held while allocating
+	next, end int64         // IDs left in the current block
+}
+
+// NewIDAllocator returns an IDAllocator reserving blocks of blockSize IDs
+// from sequence, an existing sequence of db.
+func NewIDAllocator(db *sql.DB, sequence string, blockSize int64) (*IDAllocator, error) {
+	if !identifierRegexp.MatchString(sequence) {
+		return nil, fmt.Errorf("nuodb: invalid sequence name: %q", sequence)
+	}
+	if blockSize <= 0 {
+		return nil, fmt.Errorf("nuodb: invalid block size: %d", blockSize)
+	}
+	query := "SELECT NEXT VALUE FOR " + sequence + " FROM DUAL"
+	return newIDAllocator(sequence, blockSize, func(ctx context.Context) (int64, error) {
+		var v int64
+		err := db.QueryRowContext(ctx, query).Scan(&v)
+		return v, err
+	}), nil
+}
+
+func newIDAllocator(sequence string, blockSize int64, nextValue func(ctx context.Context) (int64, error)) *IDAllocator {
+	return &IDAllocator{sequence: sequence, blockSize: blockSize, nextValue: nextValue,
+		sem: make(chan struct{}, 1)}
+}
+
+// Next returns the next ID, reserving a new block if the current one is
+// used up. The context bounds waiting for and reserving a block. An error
+// reserving a block leaves the allocator usable; the next call tries again.
+func (a *IDAllocator) Next(ctx context.Context) (int64, error) {
+	select {
+	case a.sem <- struct{}{}:
+	case <-ctx.Done():
+		return 0, contextError(ctx)
+	}
+	defer func() { <-a.sem }()
+	if a.next == a.end {
+		v, err := a.nextValue(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("nuodb: reserving IDs from %s: %w", a.sequence, err)
+		}
+		start, end, err := a.block(v)
+		if err != nil {
+			return 0, err
+		}
+		a.next, a.end = start, end
+	}
+	id := a.next
+	a.next++
+	return id, nil
+}
+
+// block returns the IDs of the block reserved by the sequence value v.
+func (a *IDAllocator) block(v int64) (start, end int64, err error) {
+	if v < 0 || v >= math.MaxInt64/a.blockSize {
+		return 0, 0, fmt.Errorf("nuodb: sequence %s value %d is out of the range of blocks of %d IDs",
+			a.sequence, v, a.blockSize)
+	}
+	return v * a.blockSize, (v + 1) * a.blockSize, nil
+}
@@ -0,0 +1,109 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"testing"
+)
+
+func TestIDAllocator(t *testing.T) {
+	var mu sync.Mutex
+	var reserved int64
+	fail := true
+	a := newIDAllocator("ids", 10, func(ctx context.Context) (int64, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if fail {
+			fail = false
+			return 0, errors.New("unavailable")
+		}
+		reserved++
+		return reserved, nil
+	})
+	ctx := context.Background()
+	if _, err := a.Next(ctx); err == nil {
+		t.Fatal("Expected the error reserving a block")
+	}
+
+	var wg sync.WaitGroup
+	ids := make(chan int64, 100)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 25; j++ {
+				id, err := a.Next(ctx)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				ids <- id
+			}
+		}()
+	}
+	wg.Wait()
+	close(ids)
+	seen := make(map[int64]bool)
+	for id := range ids {
+		if seen[id] || id < 10 || id >= 110 {
+			t.Fatalf("Unexpected id %d", id)
+		}
+		seen[id] = true
+	}
+	if len(seen) != 100 || reserved != 10 {
+		t.Fatalf("Expected 100 ids from 10 blocks, got %d from %d", len(seen), reserved)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	a.sem <- struct{}{} // held by another caller
+	if _, err := a.Next(cancelled); err == nil {
+		t.Fatal("Expected the context error")
+	}
+	<-a.sem
+}
+
+func TestIDAllocatorBlocks(t *testing.T) {
+	a := newIDAllocator("ids", 1000, nil)
+	if start, end, err := a.block(3); err != nil || start != 3000 || end != 4000 {
+		t.Fatalf("Unexpected block %d-%d: %v", start, end, err)
+	}
+	for _, v := range []int64{-1, math.MaxInt64 / 1000} {
+		if _, _, err := a.block(v); err == nil {
+			t.Fatalf("%d: expected an error", v)
+		}
+	}
+	if _, err := NewIDAllocator(nil, "ids; DROP", 10); err == nil {
+		t.Fatal("Expected an invalid sequence name")
+	}
+	if _, err := NewIDAllocator(nil, "ids", 0); err == nil {
+		t.Fatal("Expected an invalid block size")
+	}
+}
+
+func TestIDAllocatorSequence(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	exec(t, db, "CREATE SEQUENCE tests.FooBarIDs")
+	a, err := NewIDAllocator(db, "tests.FooBarIDs", 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := a.Next(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := int64(1); i < 150; i++ {
+		id, err := a.Next(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i < 100 && id != first+i || i >= 100 && id <= first+99 {
+			t.Fatalf("Unexpected id %d after %d", id, first)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+// #include "cnuodb.h"
+// #include <stdlib.h>
+import "C"
+import (
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// keepalivePingTimeout bounds a keepalive ping.
+const keepalivePingTimeout = 10 * time.Second
+
+// idleKeeper pings a connection which is idle in the pool every keepalive
+// interval, so that a firewall or a load balancer doesn't silently drop it
+// and its next user doesn't fail. database/sql calls IsValid when it
+// returns a connection to the pool, which starts the pings, and
+// ResetSession before it reuses the connection, which stops them, as does
+// any call of the driver on the connection. A ping holds mu, so the calls
+// wait for a ping in progress rather than run concurrently with it.
+type idleKeeper struct {
+	mu    sync.Mutex
+	timer *time.Timer
+	idle  bool // in the pool, so pinged
+}
+
+// startKeepalive starts the pings of c, which has been returned to the
+// pool.
+func (c *Conn) startKeepalive() {
+	k := &c.keeper
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.idle = true
+	if k.timer == nil {
+		k.timer = time.AfterFunc(c.keepaliveInterval, c.keepalivePing)
+	} else {
+		k.timer.Reset(c.keepaliveInterval)
+	}
+}
+
+// stopKeepalive stops the pings of c, which is being used, waiting for a
+// ping in progress.
+func (c *Conn) stopKeepalive() {
+	k := &c.keeper
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.idle {
+		k.idle = false
+		k.timer.Stop()
+	}
+}
+
+// keepalivePing pings c, if it is still idle, and schedules the next ping.
+// A connection whose ping fails is marked bad, so that the pool discards it
+// instead of handing it out.
+func (c *Conn) keepalivePing() {
+	k := &c.keeper
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if !k.idle || c.db == nil || c.bad {
+		return
+	}
+	if err := c.ping(); err != nil {
+		c.bad = true
+		c.log(LevelWarn, "keepalive ping failed", errorKeyvals(err)...)
+		k.idle = false
+		return
+	}
+	k.timer.Reset(c.keepaliveInterval)
+}
+
+// ping runs a trivial query on c without the hooks, the tracing, the
+// metrics and the logging of the statements.
+func (c *Conn) ping() error {
+	csql := C.CString("SELECT 1 FROM DUAL")
+	defer C.free(unsafe.Pointer(csql))
+	var st *C.struct_nuodb_statement
+	var rs *C.struct_nuodb_resultset
+	var columnCount C.int
+	unlock, err := c.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	c.stats.call()
+	if rc := C.nuodb_query(c.db, csql, nil, 0, 1, 0, &st, &rs, &columnCount,
+		C.int64_t(keepalivePingTimeout/time.Microsecond)); rc != 0 {
+		return c.lastError(rc)
+	}
+	C.nuodb_resultset_close(c.db, &rs)
+	C.nuodb_statement_close(c.db, &st)
+	return nil
+}
@@ -0,0 +1,63 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestIdleKeeper(t *testing.T) {
+	c := &Conn{keepaliveInterval: time.Hour}
+	c.startKeepalive()
+	if !c.keeper.idle {
+		t.Fatal("Expected the pings to start")
+	}
+	c.stopKeepalive()
+	if c.keeper.idle {
+		t.Fatal("Expected the pings to stop")
+	}
+	// a stale timer doesn't ping a connection in use
+	c.keepalivePing()
+
+	c.startKeepalive()
+	if _, err := c.enter(); err != nil {
+		t.Fatal(err)
+	}
+	if c.keeper.idle {
+		t.Fatal("Expected a call of the driver to stop the pings")
+	}
+	if c.IsValid() || c.keeper.idle {
+		t.Fatal("Expected a closed connection to be invalid and not pinged")
+	}
+}
+
+func TestKeepaliveInterval(t *testing.T) {
+	cfg, err := ParseDSN(default_dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.KeepaliveInterval = 50 * time.Millisecond
+	connector, err := NewConnector(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := sql.OpenDB(connector)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	ctx := context.Background()
+	var first, second int64
+	if err := db.QueryRowContext(ctx, "SELECT GETCONNECTIONID() FROM DUAL").Scan(&first); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(200 * time.Millisecond) // pinged while idle
+	if err := db.QueryRowContext(ctx, "SELECT GETCONNECTIONID() FROM DUAL").Scan(&second); err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Fatalf("Expected the pinged connection to be reused, got sessions %d and %d", first, second)
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+
+	"github.com/tilinna/go-nuodb/internal/parse"
+)
+
+// valueList is a slice bound to a single placeholder, e.g. of IN (?),
+// which the driver expands into a placeholder for each element.
+type valueList []driver.Value
+
+// listValue converts the elements of a slice, other than a []byte or a
+// driver.Valuer, into a valueList. An empty slice is rejected, as IN ()
+// is not valid SQL.
+func listValue(nv *driver.NamedValue) (valueList, bool, error) {
+	rv := reflect.ValueOf(nv.Value)
+	if rv.Kind() != reflect.Slice || rv.Type().Elem().Kind() == reflect.Uint8 {
+		return nil, false, nil
+	}
+	if _, ok := nv.Value.(driver.Valuer); ok {
+		return nil, false, nil
+	}
+	if rv.Len() == 0 {
+		return nil, false, fmt.Errorf("nuodb: empty slice for parameter %s", parameterName(nv))
+	}
+	list := make(valueList, rv.Len())
+	for i := range list {
+		e := rv.Index(i).Interface()
+		v, ok, err := convertValue(e)
+		if !ok && err == nil {
+			v, err = driver.DefaultParameterConverter.ConvertValue(e)
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("nuodb: element %d of parameter %s: %s", i, parameterName(nv), err)
+		}
+		list[i] = v
+	}
+	return list, true, nil
+}
+
+func parameterName(nv *driver.NamedValue) string {
+	if nv.Name != "" {
+		return ":" + nv.Name
+	}
+	return fmt.Sprint(nv.Ordinal)
+}
+
+func hasLists(values []driver.Value) bool {
+	for _, v := range values {
+		if _, ok := v.(valueList); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// expandLists expands the placeholders of the lists of values in sql, and
+// the lists into their elements.
+func expandLists(sql string, values []driver.Value) (string, []driver.Value) {
+	if !hasLists(values) {
+		return sql, values
+	}
+	counts := make([]int, len(values))
+	var expanded []driver.Value
+	for i, v := range values {
+		if list, ok := v.(valueList); ok {
+			counts[i] = len(list)
+			expanded = append(expanded, list...)
+		} else {
+			counts[i] = 1
+			expanded = append(expanded, v)
+		}
+	}
+	return parse.ExpandPlaceholders(sql, counts), expanded
+}
+
+// listStatement prepares the statement again with the placeholders of the
+// lists of values expanded, and returns it with the expanded values. The
+// caller closes the statement. It is prepared with the cursor of the query
+// and the generated key columns of stmt.
+func (stmt *Stmt) listStatement(values []driver.Value) (*Stmt, []driver.Value, error) {
+	sql, values := expandLists(stmt.psql, values)
+	ls, err := stmt.c.prepare(sql, "", stmt.c.opts.cursor, stmt.keys)
+	if err != nil {
+		return nil, nil, err
+	}
+	ls.sql = stmt.sql // for the statistics of the statement
+	return ls, values, nil
+}
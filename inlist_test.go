@@ -0,0 +1,107 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"testing"
+)
+
+func TestCheckNamedValueList(t *testing.T) {
+	c := &Conn{}
+	for _, test := range []struct {
+		in       interface{}
+		expected driver.Value
+	}{
+		{[]int64{1, 2, 3}, valueList{int64(1), int64(2), int64(3)}},
+		{[]int{4}, valueList{int64(4)}},
+		{[]string{"a", "b"}, valueList{"a", "b"}},
+		{[]testEnum{5}, valueList{int64(5)}},
+		{[]interface{}{int32(6), "c", nil}, valueList{int64(6), "c", nil}},
+		{[]byte("bytes"), []byte("bytes")},
+	} {
+		nv := &driver.NamedValue{Ordinal: 1, Value: test.in}
+		if err := c.CheckNamedValue(nv); err != nil {
+			t.Fatalf("%T: %s", test.in, err)
+		}
+		if !reflect.DeepEqual(nv.Value, test.expected) {
+			t.Fatalf("%T: expected %#v, got %#v", test.in, test.expected, nv.Value)
+		}
+	}
+	for _, v := range []interface{}{[]int64{}, []struct{}{{}}} {
+		nv := &driver.NamedValue{Ordinal: 1, Value: v}
+		if err := c.CheckNamedValue(nv); err == nil || err == driver.ErrSkip {
+			t.Fatalf("%T: expected an error, got %v", v, err)
+		}
+	}
+}
+
+func TestExpandLists(t *testing.T) {
+	sql, values := expandLists("SELECT * FROM t WHERE a = ? AND id IN (?) AND b = '?'",
+		[]driver.Value{"x", valueList{int64(1), int64(2), int64(3)}})
+	if expected := "SELECT * FROM t WHERE a = ? AND id IN (?, ?, ?) AND b = '?'"; sql != expected {
+		t.Fatalf("Expected %q, got %q", expected, sql)
+	}
+	if expected := []driver.Value{"x", int64(1), int64(2), int64(3)}; !reflect.DeepEqual(values, expected) {
+		t.Fatalf("Expected %#v, got %#v", expected, values)
+	}
+	in := []driver.Value{int64(1)}
+	if sql, values := expandLists("SELECT ?", in); sql != "SELECT ?" || !reflect.DeepEqual(values, in) {
+		t.Fatalf("Expected no expansion, got %q %#v", sql, values)
+	}
+}
+
+func TestInList(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	exec(t, db, "CREATE TABLE FooBar (id BIGINT, str STRING)")
+	exec(t, db, "INSERT INTO FooBar VALUES (1, 'a'), (2, 'b'), (3, 'c'), (4, 'd')")
+
+	ids := func(rows *sql.Rows) []int64 {
+		defer rows.Close()
+		var ids []int64
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				t.Fatal(err)
+			}
+			ids = append(ids, id)
+		}
+		if err := rows.Err(); err != nil {
+			t.Fatal(err)
+		}
+		return ids
+	}
+	expected := []int64{2, 4}
+	if got := ids(query(t, db, "SELECT id FROM FooBar WHERE id IN (?) AND str <> ? ORDER BY id", []int64{1, 2, 4}, "a")); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected %v, got %v", expected, got)
+	}
+
+	stmt, err := db.Prepare("SELECT id FROM FooBar WHERE str IN (:strs) ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stmt.Close()
+	for _, strs := range [][]string{{"b", "d"}, {"d", "b", "x"}} {
+		rows, err := stmt.Query(sql.Named("strs", strs))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := ids(rows); !reflect.DeepEqual(got, expected) {
+			t.Fatalf("Expected %v, got %v", expected, got)
+		}
+	}
+
+	res, err := db.Exec("DELETE FROM FooBar WHERE id IN (?)", []int{1, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := res.RowsAffected(); n != 2 {
+		t.Fatalf("Expected 2 rows deleted, got %d", n)
+	}
+	if _, err := db.Exec("DELETE FROM FooBar WHERE id IN (?)", []int{}); err == nil {
+		t.Fatal("Expected an error for an empty list")
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package parse
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PropAuthentication is the connection property which selects how the
+// server verifies the password of the user, one of the Auth methods. It is
+// validated by ValidateAuth.
+const PropAuthentication = "authentication"
+
+// Authentication methods of PropAuthentication.
+const (
+	AuthPassword = "password" // against the users of the database, the default
+	AuthLDAP     = "ldap"     // against an LDAP directory, by the server
+	AuthKerberos = "kerberos" // not supported by the NuoDB client
+)
+
+// ValidateAuth validates the authentication property of props, after
+// ValidateTLS, and normalizes it in place. The default method is removed,
+// so that it isn't passed to the NuoDB client. LDAP requires TLS, as the
+// password is sent to the server to verify it against the directory.
+func ValidateAuth(props map[string]string) error {
+	v, ok := props[PropAuthentication]
+	if !ok {
+		return nil
+	}
+	switch method := strings.ToLower(v); method {
+	case AuthPassword:
+		delete(props, PropAuthentication)
+	case AuthLDAP:
+		if _, ok := props[NuoTrustStore]; !ok {
+			return fmt.Errorf("nuodb: %s=%s requires %s, as the password is sent to the server", PropAuthentication, method, PropTrustStorePath)
+		}
+		props[PropAuthentication] = method
+	case AuthKerberos:
+		return fmt.Errorf("nuodb: %s=%s is not supported by the NuoDB client library; use %s or %s",
+			PropAuthentication, method, AuthPassword, AuthLDAP)
+	default:
+		return fmt.Errorf("nuodb: invalid %s: %s", PropAuthentication, v)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package parse
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateAuth(t *testing.T) {
+	tests := []struct {
+		props    map[string]string
+		expected map[string]string
+	}{
+		{
+			map[string]string{"schema": "abcd"},
+			map[string]string{"schema": "abcd"},
+		},
+		{
+			map[string]string{PropAuthentication: "Password"},
+			map[string]string{},
+		},
+		{
+			map[string]string{PropAuthentication: "LDAP", NuoTrustStore: "ca.pem"},
+			map[string]string{PropAuthentication: AuthLDAP, NuoTrustStore: "ca.pem"},
+		},
+	}
+	for _, test := range tests {
+		if err := ValidateAuth(test.props); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(test.props, test.expected) {
+			t.Errorf("Expected %v, got %v", test.expected, test.props)
+		}
+	}
+
+	for _, test := range []struct {
+		props    map[string]string
+		expected string
+	}{
+		{map[string]string{PropAuthentication: AuthLDAP}, "requires trustStorePath"},
+		{map[string]string{PropAuthentication: AuthKerberos, NuoTrustStore: "ca.pem"}, "not supported"},
+		{map[string]string{PropAuthentication: "sso"}, "invalid authentication"},
+	} {
+		if err := ValidateAuth(test.props); err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("Expected an error containing %q for %v, got %v", test.expected, test.props, err)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package parse
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// PropCompression is the property which would enable the network
+// compression of the NuoDB client, which the client library doesn't have.
+// It is validated by ValidateCompression.
+const PropCompression = "compression"
+
+// ValidateCompression refuses the compression property of props which
+// enables the compression, as the NuoDB client library doesn't support it,
+// rather than pass it to NuoDB without effect. compression=false is
+// removed.
+func ValidateCompression(props map[string]string) error {
+	v, ok := props[PropCompression]
+	if !ok {
+		return nil
+	}
+	compress, err := strconv.ParseBool(v)
+	if err != nil {
+		return fmt.Errorf("nuodb: invalid %s: %s", PropCompression, v)
+	}
+	if compress {
+		return fmt.Errorf("nuodb: %s=%s is not supported by the NuoDB client library, which has no network compression",
+			PropCompression, v)
+	}
+	delete(props, PropCompression)
+	return nil
+}
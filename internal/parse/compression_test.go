@@ -0,0 +1,25 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package parse
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateCompression(t *testing.T) {
+	props := map[string]string{PropCompression: "false"}
+	if err := ValidateCompression(props); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := props[PropCompression]; ok {
+		t.Fatal("Expected compression=false to be removed")
+	}
+	err := ValidateCompression(map[string]string{PropCompression: "true"})
+	if err == nil || !strings.Contains(err.Error(), "not supported by the NuoDB client library") {
+		t.Fatalf("Expected an error for an unsupported compression, got %v", err)
+	}
+	if err := ValidateCompression(map[string]string{PropCompression: "zlib"}); err == nil {
+		t.Fatal("Expected an error for an invalid compression")
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package parse
+
+import (
+	"strings"
+)
+
+// Fingerprint normalizes sql so that statements which differ only by their
+// literal values, comments, whitespace or keyword case map to the same
+// string. String and numeric literals are replaced with ?, e.g.
+//
+//	select *  from t where id = 42 and name='x'
+//
+// becomes
+//
+//	SELECT * FROM T WHERE ID = ? AND NAME = ?
+//
+// Quoted identifiers are kept as is.
+func Fingerprint(sql string) string {
+	var b strings.Builder
+	b.Grow(len(sql))
+	noSpace := true // no space before the next token
+	emit := func(token string) {
+		switch token {
+		case ",", ")", ".", ";":
+		default:
+			if !noSpace {
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteString(token)
+		noSpace = token == "(" || token == "."
+	}
+	for i := 0; i < len(sql); {
+		ch := sql[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r' || ch == '\f':
+			i++
+		case ch == '-' && i+1 < len(sql) && sql[i+1] == '-':
+			for i < len(sql) && sql[i] != '\n' {
+				i++
+			}
+		case ch == '/' && i+1 < len(sql) && sql[i+1] == '*':
+			if end := strings.Index(sql[i+2:], "*/"); end < 0 {
+				i = len(sql)
+			} else {
+				i += end + 4
+			}
+		case ch == '\'':
+			i = skipQuoted(sql, i, '\'')
+			emit("?")
+		case ch == '"' || ch == '`':
+			j := skipQuoted(sql, i, ch)
+			emit(sql[i:j])
+			i = j
+		case isDigit(ch) || (ch == '.' && i+1 < len(sql) && isDigit(sql[i+1])):
+			i = skipNumber(sql, i)
+			emit("?")
+		case isIdentifierChar(ch):
+			j := i
+			for j < len(sql) && isIdentifierChar(sql[j]) {
+				j++
+			}
+			emit(strings.ToUpper(sql[i:j]))
+			i = j
+		default:
+			emit(sql[i : i+1])
+			i++
+		}
+	}
+	return b.String()
+}
+
+// skipQuoted returns the index after the quoted string starting at i. A
+// doubled quote character within the string is an escaped quote.
+func skipQuoted(sql string, i int, quote byte) int {
+	for i++; i < len(sql); i++ {
+		if sql[i] == quote {
+			if i+1 < len(sql) && sql[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(sql)
+}
+
+func skipNumber(sql string, i int) int {
+	for i < len(sql) && (isDigit(sql[i]) || sql[i] == '.') {
+		i++
+	}
+	if i < len(sql) && (sql[i] == 'e' || sql[i] == 'E') {
+		j := i + 1
+		if j < len(sql) && (sql[j] == '+' || sql[j] == '-') {
+			j++
+		}
+		if j < len(sql) && isDigit(sql[j]) {
+			for i = j; i < len(sql) && isDigit(sql[i]); i++ {
+			}
+		}
+	}
+	return i
+}
+
+func isDigit(ch byte) bool {
+	return '0' <= ch && ch <= '9'
+}
+
+func isIdentifierChar(ch byte) bool {
+	return ch == '_' || ch == '$' || isDigit(ch) ||
+		('a' <= ch && ch <= 'z') || ('A' <= ch && ch <= 'Z') || ch >= 0x80
+}
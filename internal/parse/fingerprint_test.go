@@ -0,0 +1,24 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package parse
+
+import (
+	"testing"
+)
+
+func TestFingerprint(t *testing.T) {
+	tests := []struct{ sql, expected string }{
+		{"select *  from t where id = 42 and name='x'", "SELECT * FROM T WHERE ID = ? AND NAME = ?"},
+		{"SELECT * FROM T WHERE ID=7 AND NAME = 'it''s'", "SELECT * FROM T WHERE ID = ? AND NAME = ?"},
+		{"SELECT a.b, c FROM s.t", "SELECT A.B, C FROM S.T"},
+		{"SELECT \"Quoted\" FROM t -- comment\nWHERE x IN (1, 2.5, .5, 1e10)", "SELECT \"Quoted\" FROM T WHERE X IN (?, ?, ?, ?)"},
+		{"INSERT INTO t1 /* c */ VALUES (?, ?);", "INSERT INTO T1 VALUES (?, ?);"},
+		{"  \n", ""},
+		{"SELECT 'unterminated", "SELECT ?"},
+	}
+	for _, test := range tests {
+		if fp := Fingerprint(test.sql); fp != test.expected {
+			t.Errorf("%q: expected %q, got %q", test.sql, test.expected, fp)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package parse
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PropLBQuery is the connection property of NuoDB which selects the
+// Transaction Engines of a connection with a load balancer query.
+const PropLBQuery = "LBQuery"
+
+// Driver properties of a load balancer policy, which ValidateLB translates
+// into an LBQuery.
+const (
+	PropLBPolicy = "lbPolicy" // one of the LB policies
+	PropLBRegion = "lbRegion" // the value of the region label of the preferred TEs
+)
+
+// Load balancer policies of PropLBPolicy.
+const (
+	LBRandom     = "random"      // a random TE for each connection
+	LBRoundRobin = "round_robin" // the TEs in turn
+)
+
+// ValidateLB validates the load balancer policy of props and translates it
+// in place into an LBQuery, which picks a TE by the policy among those whose
+// region label is the lbRegion, if any of them is running, e.g.
+// "round_robin(first(label(region eu-west) any))". A region without a
+// policy uses LBRoundRobin. A policy can't be combined with an LBQuery.
+func ValidateLB(props map[string]string) error {
+	policy, hasPolicy := props[PropLBPolicy]
+	region, hasRegion := props[PropLBRegion]
+	if !hasPolicy && !hasRegion {
+		return nil
+	}
+	delete(props, PropLBPolicy)
+	delete(props, PropLBRegion)
+	if _, ok := props[PropLBQuery]; ok {
+		return fmt.Errorf("nuodb: %s and %s can't be combined with %s", PropLBPolicy, PropLBRegion, PropLBQuery)
+	}
+	switch p := strings.ToLower(policy); p {
+	case "":
+		policy = LBRoundRobin
+	case LBRandom, LBRoundRobin:
+		policy = p
+	default:
+		return fmt.Errorf("nuodb: invalid %s: %s; the policies are %s and %s", PropLBPolicy, policy, LBRandom, LBRoundRobin)
+	}
+	if hasRegion && !validLabel(region) {
+		return fmt.Errorf("nuodb: invalid %s: %s; a region has letters, digits, '-', '_' and '.'", PropLBRegion, region)
+	}
+	props[PropLBQuery] = LBQuery(policy, region)
+	return nil
+}
+
+// LBQuery returns the load balancer query of policy, preferring the TEs of
+// region unless it is empty.
+func LBQuery(policy, region string) string {
+	if region == "" {
+		return policy + "(any)"
+	}
+	return policy + "(first(label(region " + region + ") any))"
+}
+
+// LBPolicy returns the policy and the region of query, if it is a query
+// returned by LBQuery.
+func LBPolicy(query string) (policy, region string, ok bool) {
+	for _, policy := range []string{LBRandom, LBRoundRobin} {
+		if query == LBQuery(policy, "") {
+			return policy, "", true
+		}
+		prefix, suffix := policy+"(first(label(region ", ") any))"
+		if strings.HasPrefix(query, prefix) && strings.HasSuffix(query, suffix) && len(query) > len(prefix)+len(suffix) {
+			if region := query[len(prefix) : len(query)-len(suffix)]; validLabel(region) {
+				return policy, region, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// validLabel reports whether s is a valid value of a label of a TE.
+func validLabel(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || isDigit(c) || c == '-' || c == '_' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
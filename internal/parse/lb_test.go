@@ -0,0 +1,69 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package parse
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateLB(t *testing.T) {
+	tests := []struct {
+		props    map[string]string
+		expected map[string]string
+	}{
+		{
+			map[string]string{PropLBQuery: "first(any)"},
+			map[string]string{PropLBQuery: "first(any)"},
+		},
+		{
+			map[string]string{PropLBPolicy: "Random"},
+			map[string]string{PropLBQuery: "random(any)"},
+		},
+		{
+			map[string]string{PropLBPolicy: LBRoundRobin, PropLBRegion: "eu-west.1"},
+			map[string]string{PropLBQuery: "round_robin(first(label(region eu-west.1) any))"},
+		},
+		{
+			map[string]string{PropLBRegion: "us_east"},
+			map[string]string{PropLBQuery: "round_robin(first(label(region us_east) any))"},
+		},
+	}
+	for _, test := range tests {
+		if err := ValidateLB(test.props); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(test.props, test.expected) {
+			t.Errorf("Expected %v, got %v", test.expected, test.props)
+		}
+	}
+
+	for _, test := range []struct {
+		props    map[string]string
+		expected string
+	}{
+		{map[string]string{PropLBPolicy: "roundrobin"}, "the policies are random and round_robin"},
+		{map[string]string{PropLBRegion: "eu west"}, "invalid lbRegion"},
+		{map[string]string{PropLBRegion: "eu) any) (x"}, "invalid lbRegion"},
+		{map[string]string{PropLBPolicy: LBRandom, PropLBQuery: "first(any)"}, "can't be combined with LBQuery"},
+	} {
+		if err := ValidateLB(test.props); err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("Expected an error containing %q for %v, got %v", test.expected, test.props, err)
+		}
+	}
+}
+
+func TestLBPolicy(t *testing.T) {
+	for _, test := range []struct{ policy, region string }{{LBRandom, ""}, {LBRoundRobin, "eu-west"}} {
+		policy, region, ok := LBPolicy(LBQuery(test.policy, test.region))
+		if !ok || policy != test.policy || region != test.region {
+			t.Errorf("Expected %s %s, got %s %s", test.policy, test.region, policy, region)
+		}
+	}
+	for _, query := range []string{"", "first(any)", "random(first(label(region ) any))", "round_robin(first(label(zone a) any))"} {
+		if _, _, ok := LBPolicy(query); ok {
+			t.Errorf("%q: expected no policy", query)
+		}
+	}
+}
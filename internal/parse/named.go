@@ -0,0 +1,111 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package parse
+
+import (
+	"strings"
+)
+
+// NamedParameters rewrites the :name and @name placeholders of sql to ?
+// markers and returns the names of the placeholders in order. A name which
+// is used several times appears once for each placeholder. The names are
+// nil if sql has no named placeholders, in which case sql is returned as
+// is. Placeholders within string literals, quoted identifiers and comments
+// are ignored, as are :: casts.
+func NamedParameters(sql string) (string, []string) {
+	var b strings.Builder
+	var names []string
+	last := 0 // start of the sql not yet copied to b
+	for i := 0; i < len(sql); {
+		ch := sql[i]
+		switch {
+		case ch == '-' && i+1 < len(sql) && sql[i+1] == '-':
+			for i < len(sql) && sql[i] != '\n' {
+				i++
+			}
+		case ch == '/' && i+1 < len(sql) && sql[i+1] == '*':
+			if end := strings.Index(sql[i+2:], "*/"); end < 0 {
+				i = len(sql)
+			} else {
+				i += end + 4
+			}
+		case ch == '\'' || ch == '"' || ch == '`':
+			i = skipQuoted(sql, i, ch)
+		case ch == ':' && i+1 < len(sql) && sql[i+1] == ':':
+			i += 2
+		case (ch == ':' || ch == '@') && (i == 0 || !isIdentifierChar(sql[i-1])) &&
+			i+1 < len(sql) && isIdentifierChar(sql[i+1]) && !isDigit(sql[i+1]):
+			j := i + 1
+			for j < len(sql) && isIdentifierChar(sql[j]) {
+				j++
+			}
+			if names == nil {
+				b.Grow(len(sql))
+			}
+			b.WriteString(sql[last:i])
+			b.WriteByte('?')
+			names = append(names, sql[i+1:j])
+			i, last = j, j
+		case isIdentifierChar(ch):
+			for i < len(sql) && isIdentifierChar(sql[i]) {
+				i++
+			}
+		default:
+			i++
+		}
+	}
+	if names == nil {
+		return sql, nil
+	}
+	b.WriteString(sql[last:])
+	return b.String(), names
+}
+
+// ExpandPlaceholders rewrites the i-th ? marker of sql into counts[i]
+// comma separated markers, e.g. for the elements of a list bound to IN (?).
+// The markers within string literals, quoted identifiers and comments are
+// ignored. The markers beyond counts are left as they are, as is sql if no
+// count differs from 1. The counts must be positive.
+func ExpandPlaceholders(sql string, counts []int) string {
+	expand := false
+	for _, n := range counts {
+		expand = expand || n != 1
+	}
+	if !expand {
+		return sql
+	}
+	var b strings.Builder
+	b.Grow(len(sql) + 3*len(counts))
+	last := 0 // start of the sql not yet copied to b
+	k := 0    // index of the next marker
+	for i := 0; i < len(sql); {
+		ch := sql[i]
+		switch {
+		case ch == '-' && i+1 < len(sql) && sql[i+1] == '-':
+			for i < len(sql) && sql[i] != '\n' {
+				i++
+			}
+		case ch == '/' && i+1 < len(sql) && sql[i+1] == '*':
+			if end := strings.Index(sql[i+2:], "*/"); end < 0 {
+				i = len(sql)
+			} else {
+				i += end + 4
+			}
+		case ch == '\'' || ch == '"' || ch == '`':
+			i = skipQuoted(sql, i, ch)
+		case ch == '?':
+			if k < len(counts) && counts[k] != 1 {
+				b.WriteString(sql[last:i])
+				b.WriteString(strings.Repeat("?, ", counts[k]-1))
+				b.WriteByte('?')
+				last = i + 1
+			}
+			k++
+			i++
+		default:
+			i++
+		}
+	}
+	b.WriteString(sql[last:])
+	return b.String()
+}
@@ -0,0 +1,48 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package parse
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNamedParameters(t *testing.T) {
+	tests := []struct {
+		sql      string
+		expected string
+		names    []string
+	}{
+		{"SELECT * FROM t WHERE id = ?", "SELECT * FROM t WHERE id = ?", nil},
+		{"SELECT * FROM t WHERE id = :id", "SELECT * FROM t WHERE id = ?", []string{"id"}},
+		{"UPDATE t SET a = @a, b = :b WHERE a = @a", "UPDATE t SET a = ?, b = ? WHERE a = ?", []string{"a", "b", "a"}},
+		{"SELECT ':x', \":y\", x::STRING FROM t -- :z\n WHERE y=:y /* @w */",
+			"SELECT ':x', \":y\", x::STRING FROM t -- :z\n WHERE y=? /* @w */", []string{"y"}},
+		{"SELECT a:b, :1 FROM t", "SELECT a:b, :1 FROM t", nil},
+	}
+	for _, test := range tests {
+		sql, names := NamedParameters(test.sql)
+		if sql != test.expected || !reflect.DeepEqual(names, test.names) {
+			t.Errorf("%q: expected %q %v, got %q %v", test.sql, test.expected, test.names, sql, names)
+		}
+	}
+}
+
+func TestExpandPlaceholders(t *testing.T) {
+	for _, test := range []struct {
+		sql      string
+		counts   []int
+		expected string
+	}{
+		{"SELECT * FROM t WHERE id IN (?)", []int{3}, "SELECT * FROM t WHERE id IN (?, ?, ?)"},
+		{"SELECT * FROM t WHERE a = ? AND id IN (?)", []int{1, 2}, "SELECT * FROM t WHERE a = ? AND id IN (?, ?)"},
+		{"SELECT * FROM t WHERE a = ? AND id IN (?)", []int{1, 1}, "SELECT * FROM t WHERE a = ? AND id IN (?)"},
+		{"SELECT '?', \"?\" /* ? */ FROM t -- ?\nWHERE id IN (?)", []int{2},
+			"SELECT '?', \"?\" /* ? */ FROM t -- ?\nWHERE id IN (?, ?)"},
+		{"SELECT * FROM t WHERE id IN (?) OR a = ?", []int{2}, "SELECT * FROM t WHERE id IN (?, ?) OR a = ?"},
+	} {
+		if sql := ExpandPlaceholders(test.sql, test.counts); sql != test.expected {
+			t.Errorf("%q %v: expected %q, got %q", test.sql, test.counts, test.expected, sql)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package parse
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PropSearchPath is the driver property of DSN.SearchPath, a comma
+// separated list of schemas.
+const PropSearchPath = "searchPath"
+
+// SearchPath splits a searchPath property into its schemas.
+func SearchPath(v string) ([]string, bool) {
+	var schemas []string
+	for _, schema := range strings.Split(v, ",") {
+		schema = strings.TrimSpace(schema)
+		if schema == "" || !isIdentifier(schema) {
+			return nil, false
+		}
+		schemas = append(schemas, schema)
+	}
+	return schemas, true
+}
+
+// SearchPathSchema makes the first schema of searchPath the default schema
+// of props. A different schema property is an error.
+func SearchPathSchema(props map[string]string, searchPath []string) error {
+	schema, ok := props["schema"]
+	if ok && !strings.EqualFold(schema, searchPath[0]) {
+		return fmt.Errorf("nuodb: schema %s is not the first schema of %s", schema, PropSearchPath)
+	}
+	props["schema"] = searchPath[0]
+	return nil
+}
+
+func isIdentifier(s string) bool {
+	if s[0] >= '0' && s[0] <= '9' {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isWordByte(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// clauseKeywords end the table list of a FROM clause.
+var clauseKeywords = map[string]bool{
+	"WHERE": true, "GROUP": true, "ORDER": true, "HAVING": true, "LIMIT": true,
+	"OFFSET": true, "FETCH": true, "FOR": true, "UNION": true, "EXCEPT": true,
+	"INTERSECT": true, "ON": true, "USING": true, "SET": true, "VALUES": true,
+	"SELECT": true, "RETURNING": true,
+}
+
+// QualifyTables qualifies the unquoted, unqualified table names of a query
+// or a DML statement with the schema returned by resolve, if any. The table
+// names are those following FROM, JOIN, INTO and UPDATE, and those listed
+// after a comma in a FROM clause. The names of the common table expressions
+// of a WITH statement are left as they are. Other statements are returned
+// as is.
+func QualifyTables(sql string, resolve func(table string) (schema string, ok bool)) string {
+	if kind := Classify(sql); kind != KindQuery && kind != KindDML {
+		return sql
+	}
+	ctes := cteNames(sql)
+	var b strings.Builder
+	groups := []group{{query: true}} // by parenthesis depth
+	expect := false                  // the next word is a table name
+	prev := byte(0)                  // the previous significant character
+	lastWord := ""                   // the previous word, in upper case
+	rewritten := false
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case isSpace(c) || strings.HasPrefix(sql[i:], "--") || strings.HasPrefix(sql[i:], "/*"):
+			j := len(sql) - len(skipSpace(sql[i:]))
+			b.WriteString(sql[i:j])
+			i = j
+			continue
+		case c == '\'' || c == '"' || c == '`':
+			j := skipQuoted(sql, i, c)
+			b.WriteString(sql[i:j])
+			i = j
+			expect = false
+		case c == '(':
+			groups = append(groups, group{start: true})
+			expect = false
+			b.WriteByte(c)
+			i++
+		case c == ')':
+			if len(groups) > 1 {
+				groups = groups[:len(groups)-1]
+			}
+			b.WriteByte(c)
+			i++
+		case c == ',':
+			expect = groups[len(groups)-1].from
+			b.WriteByte(c)
+			i++
+		case isWordByte(c):
+			j := i
+			for j < len(sql) && isWordByte(sql[j]) {
+				j++
+			}
+			word := sql[i:j]
+			upper := strings.ToUpper(word)
+			g := &groups[len(groups)-1]
+			if g.start {
+				// a subquery rather than e.g. the arguments of EXTRACT(YEAR FROM d)
+				g.query = upper == "SELECT" || upper == "WITH"
+				g.start = false
+			}
+			if expect {
+				expect = false
+				next := skipSpace(sql[j:])
+				// a table function, unless the columns of INSERT INTO follow
+				function := strings.HasPrefix(next, "(") && lastWord != "INTO"
+				if prev != '.' && !strings.HasPrefix(next, ".") && !function &&
+					!ctes[upper] && !keyword(word) {
+					if schema, ok := resolve(word); ok {
+						b.WriteString(schema)
+						b.WriteByte('.')
+						rewritten = true
+					}
+				}
+			} else {
+				switch {
+				case !g.query:
+				case upper == "FROM":
+					g.from = true
+					expect = true
+				case upper == "JOIN" || upper == "INTO" || upper == "UPDATE" && lastWord != "FOR":
+					expect = true
+				case clauseKeywords[upper]:
+					g.from = false
+				}
+			}
+			lastWord = upper
+			b.WriteString(word)
+			i = j
+			c = word[len(word)-1]
+		default:
+			expect = false
+			b.WriteByte(c)
+			i++
+		}
+		groups[len(groups)-1].start = groups[len(groups)-1].start && c == '('
+		prev = c
+	}
+	if !rewritten {
+		return sql
+	}
+	return b.String()
+}
+
+// group is the state of a parenthesized part of a statement.
+type group struct {
+	start bool // no word has been seen in the group yet
+	query bool // the group is a query, or the statement itself
+	from  bool // in the table list of a FROM clause
+}
+
+// keyword reports whether word is a keyword which may follow FROM, JOIN,
+// INTO or UPDATE in place of a table name.
+func keyword(word string) bool {
+	switch strings.ToUpper(word) {
+	case "SELECT", "LATERAL", "ONLY", "DUAL":
+		return true
+	}
+	return false
+}
+
+// cteNames returns the upper case names of the common table expressions of
+// a WITH statement.
+func cteNames(sql string) map[string]bool {
+	word, rest := leadingWord(sql)
+	if !strings.EqualFold(word, "WITH") {
+		return nil
+	}
+	names := make(map[string]bool)
+	expect := true // the next word is a name
+	depth := 0
+	for {
+		rest = skipSpace(rest)
+		if rest == "" {
+			return names
+		}
+		switch c := rest[0]; {
+		case c == '(':
+			depth++
+			rest = rest[1:]
+		case c == ')':
+			depth--
+			rest = rest[1:]
+		case c == ',' && depth == 0:
+			expect = true
+			rest = rest[1:]
+		case c == '\'' || c == '"' || c == '`':
+			rest = rest[skipQuoted(rest, 0, c):]
+			expect = false
+		case isWordByte(c):
+			word, rest = leadingWord(rest)
+			if depth == 0 {
+				if kind := keywordKind(word); kind == KindQuery || kind == KindDML {
+					return names
+				}
+				if expect && !strings.EqualFold(word, "RECURSIVE") {
+					names[strings.ToUpper(word)] = true
+					expect = false
+				}
+			}
+		default:
+			rest = rest[1:]
+		}
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package parse
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestQualifyTables(t *testing.T) {
+	tables := map[string]string{"ORDERS": "legacy", "ITEMS": "public", "D": "public"}
+	resolve := func(table string) (string, bool) {
+		schema, ok := tables[strings.ToUpper(table)]
+		return schema, ok
+	}
+	tests := []struct {
+		sql, expected string
+	}{
+		{"SELECT * FROM orders", "SELECT * FROM legacy.orders"},
+		{"SELECT * FROM users", "SELECT * FROM users"},
+		{"select o.id from Orders o, items i where o.id = i.order_id",
+			"select o.id from legacy.Orders o, public.items i where o.id = i.order_id"},
+		{"SELECT * FROM orders JOIN items ON orders.id = items.order_id",
+			"SELECT * FROM legacy.orders JOIN public.items ON orders.id = items.order_id"},
+		{"SELECT * FROM app.orders", "SELECT * FROM app.orders"},
+		{`SELECT * FROM "orders"`, `SELECT * FROM "orders"`},
+		{"INSERT INTO orders (id, items) VALUES (1, 2)", "INSERT INTO legacy.orders (id, items) VALUES (1, 2)"},
+		{"UPDATE items SET n = 1", "UPDATE public.items SET n = 1"},
+		{"DELETE FROM /* old */ orders WHERE id = ?", "DELETE FROM /* old */ legacy.orders WHERE id = ?"},
+		{"SELECT * FROM (SELECT id FROM orders) x, items", "SELECT * FROM (SELECT id FROM legacy.orders) x, public.items"},
+		{"SELECT EXTRACT(YEAR FROM d), TRIM(' ' FROM d) FROM items", "SELECT EXTRACT(YEAR FROM d), TRIM(' ' FROM d) FROM public.items"},
+		{"SELECT * FROM items WHERE name = 'FROM orders'", "SELECT * FROM public.items WHERE name = 'FROM orders'"},
+		{"SELECT * FROM items FOR UPDATE", "SELECT * FROM public.items FOR UPDATE"},
+		{"WITH orders AS (SELECT * FROM items) SELECT * FROM orders", "WITH orders AS (SELECT * FROM public.items) SELECT * FROM orders"},
+		{"SELECT * FROM orders(1)", "SELECT * FROM orders(1)"},
+		{"CREATE TABLE orders (id INTEGER)", "CREATE TABLE orders (id INTEGER)"},
+		{"SELECT 1 FROM DUAL", "SELECT 1 FROM DUAL"},
+	}
+	for _, test := range tests {
+		if sql := QualifyTables(test.sql, resolve); sql != test.expected {
+			t.Errorf("%q: expected %q, got %q", test.sql, test.expected, sql)
+		}
+	}
+}
+
+func TestSearchPath(t *testing.T) {
+	if schemas, ok := SearchPath("app, public,legacy"); !ok || !reflect.DeepEqual(schemas, []string{"app", "public", "legacy"}) {
+		t.Fatalf("Unexpected %q", schemas)
+	}
+	for _, v := range []string{"", "app,", "app;drop", "1app", `"app"`} {
+		if _, ok := SearchPath(v); ok {
+			t.Errorf("%q: expected an invalid search path", v)
+		}
+	}
+}
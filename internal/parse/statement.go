@@ -0,0 +1,265 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package parse
+
+import (
+	"strings"
+)
+
+// The statement classification runs on every Exec and Prepare, so it scans
+// the leading keywords by hand instead of matching regular expressions.
+
+// Kind is the kind of a statement.
+type Kind int
+
+const (
+	KindOther   Kind = iota // DDL and the statements not listed below
+	KindQuery               // SELECT, EXPLAIN, VALUES or WITH ... SELECT
+	KindDML                 // DELETE, INSERT, REPLACE, TRUNCATE, UPDATE or WITH ... DML
+	KindCall                // CALL or EXECUTE of a stored procedure
+	KindSession             // SET or USE
+)
+
+var keywordKinds = []struct {
+	keyword string
+	kind    Kind
+}{
+	{"SELECT", KindQuery},
+	{"INSERT", KindDML},
+	{"UPDATE", KindDML},
+	{"DELETE", KindDML},
+	{"CALL", KindCall},
+	{"SET", KindSession},
+	{"EXPLAIN", KindQuery},
+	{"REPLACE", KindDML},
+	{"TRUNCATE", KindDML},
+	{"EXECUTE", KindCall},
+	{"USE", KindSession},
+	{"VALUES", KindQuery},
+}
+
+// ddlKeywords lead the DDL statements, which affect no rows.
+var ddlKeywords = []string{"CREATE", "DROP", "ALTER", "GRANT", "REVOKE", "RENAME", "ANALYZE"}
+
+// Classify returns the kind of sql by its leading keyword. The leading
+// whitespace, comments and opening parentheses, e.g. of a union of
+// parenthesized queries, are skipped. The kind of a WITH statement is the
+// kind of the statement following its common table expressions.
+func Classify(sql string) Kind {
+	word, rest := leadingWord(skipParens(sql))
+	if strings.EqualFold(word, "WITH") {
+		return classifyWith(rest)
+	}
+	return keywordKind(word)
+}
+
+// LeadingKeyword returns the leading keyword of sql in upper case,
+// skipped over like by Classify, e.g. SELECT or WITH.
+func LeadingKeyword(sql string) string {
+	word, _ := leadingWord(skipParens(sql))
+	return strings.ToUpper(word)
+}
+
+func keywordKind(word string) Kind {
+	for _, k := range keywordKinds {
+		if strings.EqualFold(word, k.keyword) {
+			return k.kind
+		}
+	}
+	return KindOther
+}
+
+// classifyWith returns the kind of the statement which follows the common
+// table expressions of a WITH statement, skipping their parenthesized
+// definitions and any quoted names.
+func classifyWith(sql string) Kind {
+	depth := 0
+	for {
+		sql = skipSpace(sql)
+		if sql == "" {
+			return KindOther
+		}
+		switch c := sql[0]; {
+		case c == '(':
+			depth++
+			sql = sql[1:]
+		case c == ')':
+			depth--
+			sql = sql[1:]
+		case c == '\'' || c == '"' || c == '`':
+			sql = sql[skipQuoted(sql, 0, c):]
+		case isWordByte(c):
+			var word string
+			word, sql = leadingWord(sql)
+			if depth == 0 {
+				if kind := keywordKind(word); kind == KindQuery || kind == KindDML {
+					return kind
+				}
+			}
+		default:
+			sql = sql[1:]
+		}
+	}
+}
+
+// DDLStatement reports whether sql is a DDL or a session statement, i.e.
+// a statement which does not affect rows. Statements which aren't known to
+// be DDL, e.g. the vendor specific ones, are not, so that the rows they
+// affect are still reported.
+func DDLStatement(sql string) bool {
+	switch Classify(sql) {
+	case KindSession:
+		return true
+	case KindOther:
+		word, _ := leadingWord(sql)
+		for _, keyword := range ddlKeywords {
+			if strings.EqualFold(word, keyword) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SchemaStatement reports whether sql may change the current schema of
+// the session.
+func SchemaStatement(sql string) bool {
+	word, rest := leadingWord(sql)
+	if strings.EqualFold(word, "USE") {
+		return true
+	}
+	if !strings.EqualFold(word, "SET") {
+		return false
+	}
+	word, _ = leadingWord(rest)
+	return strings.EqualFold(word, "SCHEMA")
+}
+
+// CallStatement reports whether sql calls a stored procedure, i.e. whether
+// it may have output parameters.
+func CallStatement(sql string) bool {
+	return Classify(sql) == KindCall
+}
+
+// SplitStatements splits a script into its statements, which are separated
+// by semicolons outside of quotes, comments and the bodies of the stored
+// procedures, functions and triggers, which run from AS to END_PROCEDURE,
+// END_FUNCTION or END_TRIGGER. The statements are trimmed and the empty
+// ones are dropped.
+func SplitStatements(script string) []string {
+	var statements []string
+	add := func(s string) {
+		if s = strings.TrimSpace(s); s != "" && skipSpace(s) != "" {
+			statements = append(statements, s)
+		}
+	}
+	start := 0
+	end := "" // keyword ending the routine body the script is in, if any
+	for i := 0; i < len(script); {
+		switch c := script[i]; {
+		case c == '\'' || c == '"' || c == '`':
+			i = skipQuoted(script, i, c)
+		case strings.HasPrefix(script[i:], "--") || strings.HasPrefix(script[i:], "/*"):
+			i = len(script) - len(skipSpace(script[i:]))
+		case c == ';' && end == "":
+			add(script[start:i])
+			i++
+			start = i
+		case isWordByte(c):
+			j := i
+			for j < len(script) && isWordByte(script[j]) {
+				j++
+			}
+			word := script[i:j]
+			if end == "" && strings.EqualFold(word, "AS") {
+				end = routineEnd(script[start:i])
+			} else if end != "" && strings.EqualFold(word, end) {
+				end = ""
+			}
+			i = j
+		default:
+			i++
+		}
+	}
+	add(script[start:])
+	return statements
+}
+
+// routineEnd returns the keyword which ends the body of the routine created
+// by the statement beginning with head, or "" if it creates no routine.
+func routineEnd(head string) string {
+	word, rest := leadingWord(head)
+	if !strings.EqualFold(word, "CREATE") {
+		return ""
+	}
+	word, rest = leadingWord(rest)
+	if strings.EqualFold(word, "OR") {
+		if word, rest = leadingWord(rest); !strings.EqualFold(word, "REPLACE") {
+			return ""
+		}
+		word, _ = leadingWord(rest)
+	}
+	for _, routine := range []string{"PROCEDURE", "FUNCTION", "TRIGGER"} {
+		if strings.EqualFold(word, routine) {
+			return "END_" + routine
+		}
+	}
+	return ""
+}
+
+// leadingWord returns the first word of sql after the whitespace and the
+// comments, and the rest of sql. A keyword must be followed by whitespace,
+// a comment or a delimiter, so the word includes the identifier characters
+// which may follow the letters, e.g. "SELECTED" or "CALL2".
+func leadingWord(sql string) (word, rest string) {
+	sql = skipSpace(sql)
+	i := 0
+	for i < len(sql) && isWordByte(sql[i]) {
+		i++
+	}
+	return sql[:i], sql[i:]
+}
+
+// skipParens skips the leading whitespace, comments and opening
+// parentheses of sql.
+func skipParens(sql string) string {
+	for sql = skipSpace(sql); strings.HasPrefix(sql, "("); {
+		sql = skipSpace(sql[1:])
+	}
+	return sql
+}
+
+// skipSpace skips the leading whitespace and the "--" and "/* */"
+// comments of sql.
+func skipSpace(sql string) string {
+	for {
+		switch {
+		case sql == "":
+			return sql
+		case isSpace(sql[0]):
+			sql = sql[1:]
+		case strings.HasPrefix(sql, "--"):
+			i := strings.IndexByte(sql, '\n')
+			if i < 0 {
+				return ""
+			}
+			sql = sql[i+1:]
+		case strings.HasPrefix(sql, "/*"):
+			i := strings.Index(sql[2:], "*/")
+			if i < 0 {
+				return ""
+			}
+			sql = sql[i+4:]
+		default:
+			return sql
+		}
+	}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'
+}
+
+func isWordByte(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '_' || c == '$'
+}
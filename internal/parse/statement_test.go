@@ -0,0 +1,179 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package parse
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDDLStatement(t *testing.T) {
+	tests := []struct {
+		sql string
+		ddl bool
+	}{
+		{"CREATE TABLE t (id integer)", true},
+		{"  \t  \nCREAte\t  \nTABLE FooBar (id integer)", true},
+		{"DROP TABLE t", true},
+		{"USE tests", true},
+		{"SELECT 1 FROM DUAL", false},
+		{"select 1 from dual", false},
+		{" \nINSERT INTO t VALUES (1)", false},
+		{"UPDATE t SET id = 1", false},
+		{"DELETE FROM t", false},
+		{"REPLACE INTO t VALUES (1)", false},
+		{"TRUNCATE TABLE t", false},
+		{"EXPLAIN SELECT 1 FROM DUAL", false},
+		{"SELECTED", false},
+		{"", false},
+		{"/* hint */ SELECT 1 FROM DUAL", false},
+		{"-- comment\nINSERT INTO t VALUES (1)", false},
+		{"/* a */ /* b */\n-- c\nupdate t SET id = 1", false},
+		{"SELECT/* hint */1 FROM DUAL", false},
+		{"/* SELECT */ CREATE TABLE t (id integer)", true},
+		{"-- SELECT 1 FROM DUAL", false},
+		{"/* unterminated SELECT", false},
+		{"WITH t AS (SELECT 1 FROM DUAL) SELECT * FROM t", false},
+		{"WITH old AS (SELECT id FROM t) DELETE FROM t WHERE id IN (SELECT id FROM old)", false},
+		{"CALL tests.proc(?)", false},
+		{"VALUES (1), (2)", false},
+		{"(SELECT 1 FROM DUAL) UNION (SELECT 2 FROM DUAL)", false},
+		{"/* purge */ DELETE FROM t", false},
+		{"START TRANSACTION", false},
+		{"ALTER TABLE t ADD name STRING", true},
+		{"GRANT SELECT ON t TO app", true},
+		{"SET ISOLATION LEVEL READ COMMITTED", true},
+	}
+	for _, test := range tests {
+		if ddl := DDLStatement(test.sql); ddl != test.ddl {
+			t.Errorf("%q: expected %v, got %v", test.sql, test.ddl, ddl)
+		}
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		sql  string
+		kind Kind
+	}{
+		{"CREATE TABLE t (id integer)", KindOther},
+		{"SELECT 1 FROM DUAL", KindQuery},
+		{"/* hint */ select 1 FROM DUAL", KindQuery},
+		{"EXPLAIN SELECT 1 FROM DUAL", KindQuery},
+		{"INSERT INTO t VALUES (1)", KindDML},
+		{"-- purge\nTRUNCATE TABLE t", KindDML},
+		{"CALL proc(?)", KindCall},
+		{"execute proc", KindCall},
+		{"SET SCHEMA tests", KindSession},
+		{"USE tests", KindSession},
+		{"WITH t AS (SELECT 1 FROM DUAL) SELECT * FROM t", KindQuery},
+		{"with recursive t (n) as (select 1 from dual union all select n + 1 from t where n < 3) select n from t", KindQuery},
+		{"WITH a AS (SELECT 1 FROM DUAL), b AS (SELECT 2 FROM DUAL)\nSELECT * FROM a, b", KindQuery},
+		{"WITH old AS (SELECT id FROM t WHERE name = 'a) update') DELETE FROM t WHERE id IN (SELECT id FROM old)", KindDML},
+		{"WITH \"select\" AS (SELECT 1 FROM DUAL) INSERT INTO t SELECT * FROM \"select\"", KindDML},
+		{"WITH t AS (SELECT 1 FROM DUAL", KindOther},
+		{"WITHDRAW", KindOther},
+		{"VALUES (1), (2)", KindQuery},
+		{" ( /* a */ (SELECT 1 FROM DUAL)) UNION SELECT 2 FROM DUAL", KindQuery},
+		{"", KindOther},
+	}
+	for _, test := range tests {
+		if kind := Classify(test.sql); kind != test.kind {
+			t.Errorf("%q: expected %v, got %v", test.sql, test.kind, kind)
+		}
+	}
+}
+
+func TestLeadingKeyword(t *testing.T) {
+	for sql, expected := range map[string]string{
+		"select 1 FROM DUAL":                             "SELECT",
+		"/* hint */ (SELECT 1 FROM DUAL) UNION SELECT 2": "SELECT",
+		"WITH t AS (SELECT 1 FROM DUAL) SELECT * FROM t": "WITH",
+		"": "",
+	} {
+		if keyword := LeadingKeyword(sql); keyword != expected {
+			t.Errorf("%q: expected %q, got %q", sql, expected, keyword)
+		}
+	}
+}
+
+func TestSchemaStatement(t *testing.T) {
+	tests := []struct {
+		sql    string
+		schema bool
+	}{
+		{"USE tests", true},
+		{"  use\ttests", true},
+		{"SET SCHEMA tests", true},
+		{"set  schema tests", true},
+		{"SET ISOLATION LEVEL READ COMMITTED", false},
+		{"SELECT * FROM users", false},
+		{"USERS", false},
+		{"/* switch */ USE tests", true},
+		{"SET /* the */ SCHEMA tests", true},
+		{"SET SCHEMATA", false},
+	}
+	for _, test := range tests {
+		if schema := SchemaStatement(test.sql); schema != test.schema {
+			t.Errorf("%q: expected %v, got %v", test.sql, test.schema, schema)
+		}
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	for script, expected := range map[string][]string{
+		"":                             nil,
+		" ; ;":                         nil,
+		"USE app":                      {"USE app"},
+		"USE app; SET TIMEZONE 'UTC';": {"USE app", "SET TIMEZONE 'UTC'"},
+		"SET x = 'a;b'; USE \"s;t\"":   {"SET x = 'a;b'", "USE \"s;t\""},
+		"USE app -- why; not\n; USE b": {"USE app -- why; not", "USE b"},
+		"/* first; */ USE app; /* */":  {"/* first; */ USE app"},
+		"SET x = 'it''s'; USE app":     {"SET x = 'it''s'", "USE app"},
+		"CREATE PROCEDURE p() AS VAR n INTEGER; n = 1; END_PROCEDURE; CALL p()": {
+			"CREATE PROCEDURE p() AS VAR n INTEGER; n = 1; END_PROCEDURE", "CALL p()"},
+		"create or replace trigger t for x before insert as new.a = 1; end_trigger;\nSELECT 1 FROM DUAL": {
+			"create or replace trigger t for x before insert as new.a = 1; end_trigger", "SELECT 1 FROM DUAL"},
+		"CREATE FUNCTION f(a INTEGER) RETURNS INTEGER LANGUAGE JAVA EXTERNAL 'j:F.f'; SELECT a AS b FROM t; USE app": {
+			"CREATE FUNCTION f(a INTEGER) RETURNS INTEGER LANGUAGE JAVA EXTERNAL 'j:F.f'", "SELECT a AS b FROM t", "USE app"},
+		"CREATE VIEW v AS SELECT 1 FROM DUAL; USE app": {"CREATE VIEW v AS SELECT 1 FROM DUAL", "USE app"},
+	} {
+		if statements := SplitStatements(script); !reflect.DeepEqual(statements, expected) {
+			t.Errorf("%q: expected %q, got %q", script, expected, statements)
+		}
+	}
+}
+
+func TestCallStatement(t *testing.T) {
+	tests := []struct {
+		sql  string
+		call bool
+	}{
+		{"CALL tests.proc(?, ?)", true},
+		{" \n execute\tproc(?)", true},
+		{"SELECT * FROM calls", false},
+		{"CALLS", false},
+		{"-- out params\nCALL proc(?)", true},
+	}
+	for _, test := range tests {
+		if call := CallStatement(test.sql); call != test.call {
+			t.Errorf("%q: expected %v, got %v", test.sql, test.call, call)
+		}
+	}
+}
+
+var benchmarkStatements = []string{
+	"SELECT id, name FROM users WHERE id = ?",
+	"  INSERT INTO events (id, payload) VALUES (?, ?)",
+	"/* hint */ UPDATE users SET name = ? WHERE id = ?",
+	"CREATE TABLE t (id INTEGER)",
+}
+
+func BenchmarkClassifyStatement(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		sql := benchmarkStatements[i%len(benchmarkStatements)]
+		DDLStatement(sql)
+		SchemaStatement(sql)
+		CallStatement(sql)
+	}
+}
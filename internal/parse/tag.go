@@ -0,0 +1,37 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package parse
+
+import (
+	"strings"
+)
+
+// MaxTagLength is the length to which AppendTag cuts a tag.
+const MaxTagLength = 200
+
+// AppendTag appends tag to sql as a /* tag */ comment on a line of its own,
+// after any trailing semicolon is removed, so that a trailing -- comment
+// doesn't swallow it. The characters of tag other than ASCII letters,
+// digits and " -_.,:;=/@#()[]+" are replaced with _, so that the tag can't
+// end the comment. sql is returned as is for an empty tag.
+func AppendTag(sql, tag string) string {
+	tag = strings.TrimSpace(tag)
+	if len(tag) > MaxTagLength {
+		tag = tag[:MaxTagLength]
+	}
+	if tag == "" {
+		return sql
+	}
+	b := []byte(tag)
+	for i, ch := range b {
+		if !isTagChar(ch) {
+			b[i] = '_'
+		}
+	}
+	return strings.TrimRight(sql, "; \t\r\n") + "\n/* " + string(b) + " */"
+}
+
+func isTagChar(ch byte) bool {
+	return ('a' <= ch && ch <= 'z') || ('A' <= ch && ch <= 'Z') || isDigit(ch) ||
+		strings.IndexByte(" -_.,:;=/@#()[]+", ch) >= 0
+}
@@ -0,0 +1,33 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package parse
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAppendTag(t *testing.T) {
+	tests := []struct {
+		sql      string
+		tag      string
+		expected string
+	}{
+		{"SELECT 1", "", "SELECT 1"},
+		{"SELECT 1", "  ", "SELECT 1"},
+		{"SELECT 1", "checkout-service:order-create", "SELECT 1\n/* checkout-service:order-create */"},
+		{"SELECT 1;\n", "a", "SELECT 1\n/* a */"},
+		{"SELECT 1 -- one", "a", "SELECT 1 -- one\n/* a */"},
+		{"SELECT 1", "x */ DROP TABLE t /*", "SELECT 1\n/* x _/ DROP TABLE t /_ */"},
+		{"SELECT 1", "a\nb?'c'", "SELECT 1\n/* a_b__c_ */"},
+		{"SELECT 1", "café", "SELECT 1\n/* caf__ */"},
+	}
+	for _, test := range tests {
+		if sql := AppendTag(test.sql, test.tag); sql != test.expected {
+			t.Errorf("%q %q: expected %q, got %q", test.sql, test.tag, test.expected, sql)
+		}
+	}
+	if sql := AppendTag("SELECT 1", strings.Repeat("a", 2*MaxTagLength)); len(sql) != len("SELECT 1\n/*  */")+MaxTagLength {
+		t.Errorf("Expected the tag to be cut, got %q", sql)
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package parse
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// Driver properties which configure TLS. They are validated by ValidateTLS
+// and translated into the connection properties of the NuoDB client.
+const (
+	PropTrustStorePath        = "trustStorePath"        // trusted certificates
+	PropTrustStorePassword    = "trustStorePassword"    // passed as is
+	PropClientCertificatePath = "clientCertificatePath" // client certificate
+	PropClientKeyPath         = "clientKeyPath"         // key of the client certificate
+	PropVerifyHostname        = "verifyHostname"        // boolean, passed as is
+)
+
+// Connection properties of the NuoDB client which the TLS files are passed
+// as.
+const (
+	NuoTrustStore        = "trustStore"
+	NuoClientCertificate = "clientCertificate"
+	NuoClientKey         = "clientKey"
+)
+
+// tlsFiles maps the file properties to the NuoDB connection properties.
+var tlsFiles = []struct{ prop, nuo string }{
+	{PropTrustStorePath, NuoTrustStore},
+	{PropClientCertificatePath, NuoClientCertificate},
+	{PropClientKeyPath, NuoClientKey},
+}
+
+// ValidateTLS validates the TLS properties of props and translates them
+// into the connection properties of the NuoDB client in place. The files
+// must exist, so that a missing file is reported before connecting. The
+// trustStore property of the client is accepted as an alias of
+// trustStorePath.
+func ValidateTLS(props map[string]string) error {
+	if path, ok := props[NuoTrustStore]; ok {
+		if _, dup := props[PropTrustStorePath]; dup {
+			return fmt.Errorf("nuodb: both %s and %s given", NuoTrustStore, PropTrustStorePath)
+		}
+		delete(props, NuoTrustStore)
+		props[PropTrustStorePath] = path
+	}
+	for _, f := range tlsFiles {
+		path, ok := props[f.prop]
+		if !ok {
+			continue
+		}
+		if err := checkFile(path); err != nil {
+			return fmt.Errorf("nuodb: invalid %s: %s", f.prop, err)
+		}
+		delete(props, f.prop)
+		props[f.nuo] = path
+	}
+	if _, ok := props[NuoTrustStore]; !ok {
+		if _, ok := props[PropTrustStorePassword]; ok {
+			return fmt.Errorf("nuodb: %s requires %s", PropTrustStorePassword, PropTrustStorePath)
+		}
+	}
+	_, cert := props[NuoClientCertificate]
+	_, key := props[NuoClientKey]
+	if cert != key {
+		return fmt.Errorf("nuodb: %s and %s must be given together", PropClientCertificatePath, PropClientKeyPath)
+	}
+	if v, ok := props[PropVerifyHostname]; ok {
+		verify, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("nuodb: invalid %s: %s", PropVerifyHostname, v)
+		}
+		props[PropVerifyHostname] = strconv.FormatBool(verify)
+	}
+	return nil
+}
+
+func checkFile(path string) error {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if fi.IsDir() {
+		return fmt.Errorf("%s is a directory", path)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package parse
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestValidateTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nuodb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ca, cert, key := filepath.Join(dir, "ca.pem"), filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	for _, name := range []string{ca, cert, key} {
+		if err := ioutil.WriteFile(name, nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	missing := filepath.Join(dir, "missing.pem")
+
+	tests := []struct {
+		props    map[string]string
+		expected map[string]string
+	}{
+		{
+			map[string]string{"schema": "abcd"},
+			map[string]string{"schema": "abcd"},
+		},
+		{
+			map[string]string{PropTrustStorePath: ca, PropTrustStorePassword: "secret", PropVerifyHostname: "0"},
+			map[string]string{NuoTrustStore: ca, PropTrustStorePassword: "secret", PropVerifyHostname: "false"},
+		},
+		{
+			map[string]string{NuoTrustStore: ca, PropClientCertificatePath: cert, PropClientKeyPath: key},
+			map[string]string{NuoTrustStore: ca, NuoClientCertificate: cert, NuoClientKey: key},
+		},
+	}
+	for _, test := range tests {
+		if err := ValidateTLS(test.props); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(test.props, test.expected) {
+			t.Errorf("Expected %v, got %v", test.expected, test.props)
+		}
+	}
+
+	for _, props := range []map[string]string{
+		{PropTrustStorePath: missing},
+		{PropTrustStorePath: dir},
+		{NuoTrustStore: missing},
+		{NuoTrustStore: ca, PropTrustStorePath: ca},
+		{PropTrustStorePassword: "secret"},
+		{PropClientCertificatePath: cert},
+		{PropClientKeyPath: key},
+		{PropClientCertificatePath: cert, PropClientKeyPath: missing},
+		{PropVerifyHostname: "maybe"},
+	} {
+		if err := ValidateTLS(props); err == nil {
+			t.Errorf("Expected error for %v", props)
+		}
+	}
+}
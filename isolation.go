@@ -0,0 +1,62 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/tilinna/go-nuodb/internal/parse"
+)
+
+// nuodbLevels offsets the NuoDB isolation levels from the standard ones.
+const nuodbLevels = 1 << 16
+
+// The NuoDB transaction isolation levels, for sql.TxOptions.Isolation,
+// besides the standard levels which BeginTx maps to them:
+//
+//	tx, err := db.BeginTx(ctx, &sql.TxOptions{Isolation: nuodb.LevelConsistentRead})
+//
+// CONSISTENT READ, the NuoDB default, reads a snapshot of the database as
+// of the start of the transaction. WRITE COMMITTED reads the latest
+// committed versions in the updates and deletes.
+const (
+	LevelReadCommitted  = sql.IsolationLevel(nuodbLevels + 2)
+	LevelWriteCommitted = sql.IsolationLevel(nuodbLevels + 5)
+	LevelConsistentRead = sql.IsolationLevel(nuodbLevels + 7)
+	LevelSerializable   = sql.IsolationLevel(nuodbLevels + 8)
+)
+
+// standardLevels are the isolation properties of the standard isolation
+// levels which NuoDB supports.
+var standardLevels = map[sql.IsolationLevel]string{
+	sql.LevelReadCommitted:  "read_committed",
+	sql.LevelWriteCommitted: "write_committed",
+	sql.LevelRepeatableRead: "consistent_read",
+	sql.LevelSnapshot:       "consistent_read",
+	sql.LevelSerializable:   "serializable",
+}
+
+// isolationNames are the NuoDB isolation levels in SQL.
+var isolationNames = map[int]string{
+	2: "READ COMMITTED",
+	5: "WRITE COMMITTED",
+	7: "CONSISTENT READ",
+	8: "SERIALIZABLE",
+}
+
+// isolationLevel returns the NuoDB isolation level of l, or 0 for the
+// default level of the connection.
+func isolationLevel(l sql.IsolationLevel) (int, error) {
+	if l == sql.LevelDefault {
+		return 0, nil
+	}
+	v, ok := standardLevels[l]
+	if !ok {
+		v = fmt.Sprint(int(l - nuodbLevels))
+	}
+	if level, ok := parse.IsolationLevel(v); ok {
+		return level, nil
+	}
+	return 0, fmt.Errorf("nuodb: unsupported isolation level %v", l)
+}
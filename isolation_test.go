@@ -0,0 +1,39 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestIsolationLevel(t *testing.T) {
+	for _, test := range []struct {
+		level    sql.IsolationLevel
+		expected int
+	}{
+		{sql.LevelDefault, 0},
+		{sql.LevelReadCommitted, 2},
+		{sql.LevelWriteCommitted, 5},
+		{sql.LevelRepeatableRead, 7},
+		{sql.LevelSnapshot, 7},
+		{sql.LevelSerializable, 8},
+		{LevelReadCommitted, 2},
+		{LevelWriteCommitted, 5},
+		{LevelConsistentRead, 7},
+		{LevelSerializable, 8},
+	} {
+		level, err := isolationLevel(test.level)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if level != test.expected {
+			t.Errorf("%v: expected %d, got %d", test.level, test.expected, level)
+		}
+	}
+	for _, level := range []sql.IsolationLevel{sql.LevelReadUncommitted, sql.LevelLinearizable, LevelReadCommitted - 1} {
+		if _, err := isolationLevel(level); err == nil {
+			t.Errorf("%v: expected an error", level)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// JSON stores a Go value as a JSON document in a STRING or CLOB column.
+// Bound, V is marshalled with encoding/json, and a value which can't be
+// marshalled fails the statement before it is sent. Scanned, the document
+// is unmarshalled into V, which must then be a pointer:
+//
+//	_, err := db.Exec("UPDATE users SET settings = ? WHERE id = ?", nuodb.JSON{V: settings}, id)
+//	err = db.QueryRow("SELECT settings FROM users WHERE id = ?", id).Scan(&nuodb.JSON{V: &settings})
+//
+// A nil V is bound as NULL, and NULL is scanned as the JSON null, which
+// sets a pointer, map or slice V points to to nil.
+type JSON struct {
+	V interface{}
+}
+
+var _ driver.Valuer = JSON{}
+
+// Value implements driver.Valuer. The driver binds a JSON without calling
+// it.
+func (j JSON) Value() (driver.Value, error) {
+	return j.text()
+}
+
+// text returns the marshalled V, or nil for a nil V.
+func (j JSON) text() (driver.Value, error) {
+	if j.V == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(j.V)
+	if err != nil {
+		return nil, fmt.Errorf("nuodb: invalid JSON value: %w", err)
+	}
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner.
+func (j *JSON) Scan(src interface{}) error {
+	var b []byte
+	switch src := src.(type) {
+	case nil:
+		b = []byte("null")
+	case []byte:
+		b = src
+	case string:
+		b = []byte(src)
+	default:
+		return fmt.Errorf("nuodb: cannot scan %T into JSON", src)
+	}
+	if err := json.Unmarshal(b, j.V); err != nil {
+		return fmt.Errorf("nuodb: invalid JSON: %w", err)
+	}
+	return nil
+}
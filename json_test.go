@@ -0,0 +1,86 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"testing"
+)
+
+type jsonSettings struct {
+	Theme string   `json:"theme"`
+	Tags  []string `json:"tags,omitempty"`
+}
+
+func TestCheckNamedValueJSON(t *testing.T) {
+	c := &Conn{}
+	for _, test := range []struct {
+		v        JSON
+		expected driver.Value
+	}{
+		{JSON{jsonSettings{Theme: "dark"}}, `{"theme":"dark"}`},
+		{JSON{map[string]int{"a": 1}}, `{"a":1}`},
+		{JSON{"text"}, `"text"`},
+		{JSON{}, nil},
+	} {
+		nv := &driver.NamedValue{Ordinal: 1, Value: test.v}
+		if err := c.CheckNamedValue(nv); err != nil {
+			t.Fatal(err)
+		}
+		if nv.Value != test.expected {
+			t.Fatalf("Expected %#v, got %#v", test.expected, nv.Value)
+		}
+	}
+	nv := &driver.NamedValue{Ordinal: 1, Value: JSON{make(chan int)}}
+	if err := c.CheckNamedValue(nv); err == nil {
+		t.Fatal("Expected an error binding a value which can't be marshalled")
+	}
+}
+
+func TestJSONScan(t *testing.T) {
+	var settings jsonSettings
+	j := JSON{&settings}
+	if err := j.Scan([]byte(`{"theme":"dark","tags":["a","b"]}`)); err != nil {
+		t.Fatal(err)
+	}
+	if expected := (jsonSettings{"dark", []string{"a", "b"}}); !reflect.DeepEqual(settings, expected) {
+		t.Fatalf("Expected %+v, got %+v", expected, settings)
+	}
+	var m map[string]int
+	j = JSON{&m}
+	if err := j.Scan(`{"a":1}`); err != nil || m["a"] != 1 {
+		t.Fatalf("Expected a:1, got %v (%v)", m, err)
+	}
+	if err := j.Scan(nil); err != nil || m != nil {
+		t.Fatalf("Expected NULL to clear the map, got %v (%v)", m, err)
+	}
+	for _, src := range []interface{}{"{", int64(1)} {
+		if err := j.Scan(src); err == nil {
+			t.Fatalf("Expected an error scanning %v", src)
+		}
+	}
+	if err := (&JSON{settings}).Scan(`{}`); err == nil {
+		t.Fatal("Expected an error scanning into a non-pointer")
+	}
+}
+
+func TestJSON(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	exec(t, db, "CREATE TABLE FooBar (id BIGINT, doc STRING)")
+	settings := jsonSettings{Theme: "dark", Tags: []string{"beta"}}
+	exec(t, db, "INSERT INTO FooBar VALUES (1, ?), (2, ?)", JSON{settings}, JSON{})
+
+	var scanned jsonSettings
+	if err := db.QueryRow("SELECT doc FROM FooBar WHERE id = 1").Scan(&JSON{&scanned}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(scanned, settings) {
+		t.Fatalf("Expected %+v, got %+v", settings, scanned)
+	}
+	var ptr *jsonSettings
+	if err := db.QueryRow("SELECT doc FROM FooBar WHERE id = 2").Scan(&JSON{&ptr}); err != nil || ptr != nil {
+		t.Fatalf("Expected NULL, got %v (%v)", ptr, err)
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// TxKeepalive configures KeepTxAlive.
+type TxKeepalive struct {
+	Interval    time.Duration // between the pings, 1 minute by default
+	MaxDuration time.Duration // after which the pings stop, 10 minutes by default
+	Warn        func(error)   // called when the pings stop early, if not nil
+}
+
+// Limits of a TxKeepalive. A longer MaxDuration is cut to
+// MaxTxKeepaliveDuration and a shorter Interval raised to
+// MinTxKeepaliveInterval.
+const (
+	MaxTxKeepaliveDuration = time.Hour
+	MinTxKeepaliveInterval = time.Second
+
+	defaultTxKeepaliveInterval    = time.Minute
+	defaultTxKeepaliveMaxDuration = 10 * time.Minute
+)
+
+// ErrTxKeepaliveExpired is passed to TxKeepalive.Warn when the pings of a
+// transaction stop at MaxDuration.
+var ErrTxKeepaliveExpired = errors.New("nuodb: transaction keepalive expired")
+
+// KeepTxAlive pings the server on tx with a harmless query every Interval,
+// so that a transaction which pauses between its statements, e.g. for user
+// input, isn't terminated by the idle transaction timeout of the server:
+//
+//	stop := nuodb.KeepTxAlive(ctx, tx, nuodb.TxKeepalive{Interval: 30 * time.Second})
+//	defer stop()
+//
+// An open transaction holds its locks and keeps the old record versions
+// from being garbage collected, so keep the pauses short. The pings stop
+// when stop is called, ctx is done, tx is committed or rolled back, a ping
+// fails or MaxDuration has passed; the last two are reported to Warn. stop
+// waits until the pings have stopped.
+func KeepTxAlive(ctx context.Context, tx *sql.Tx, k TxKeepalive) (stop func()) {
+	k = k.withDefaults()
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := keepTxAlive(ctx, tx, k); err != nil && k.Warn != nil {
+			k.Warn(err)
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+func (k TxKeepalive) withDefaults() TxKeepalive {
+	switch {
+	case k.Interval == 0:
+		k.Interval = defaultTxKeepaliveInterval
+	case k.Interval < MinTxKeepaliveInterval:
+		k.Interval = MinTxKeepaliveInterval
+	}
+	switch {
+	case k.MaxDuration == 0:
+		k.MaxDuration = defaultTxKeepaliveMaxDuration
+	case k.MaxDuration > MaxTxKeepaliveDuration:
+		k.MaxDuration = MaxTxKeepaliveDuration
+	}
+	return k
+}
+
+// keepTxAlive pings until ctx is done, tx is done or MaxDuration passes.
+func keepTxAlive(ctx context.Context, tx *sql.Tx, k TxKeepalive) error {
+	ticker := time.NewTicker(k.Interval)
+	defer ticker.Stop()
+	expired := time.NewTimer(k.MaxDuration)
+	defer expired.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-expired.C:
+			return ErrTxKeepaliveExpired
+		case <-ticker.C:
+		}
+		var one int
+		err := tx.QueryRowContext(ctx, "SELECT 1 FROM DUAL").Scan(&one)
+		switch {
+		case err == sql.ErrTxDone || ctx.Err() != nil:
+			return nil
+		case err != nil:
+			return err
+		}
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTxKeepaliveDefaults(t *testing.T) {
+	for _, test := range []struct {
+		k, expected TxKeepalive
+	}{
+		{TxKeepalive{}, TxKeepalive{Interval: time.Minute, MaxDuration: 10 * time.Minute}},
+		{TxKeepalive{Interval: time.Millisecond, MaxDuration: 24 * time.Hour},
+			TxKeepalive{Interval: time.Second, MaxDuration: time.Hour}},
+		{TxKeepalive{Interval: 30 * time.Second, MaxDuration: 5 * time.Minute},
+			TxKeepalive{Interval: 30 * time.Second, MaxDuration: 5 * time.Minute}},
+	} {
+		if k := test.k.withDefaults(); k.Interval != test.expected.Interval || k.MaxDuration != test.expected.MaxDuration {
+			t.Errorf("%+v: expected %+v, got %+v", test.k, test.expected, k)
+		}
+	}
+}
+
+func TestKeepTxAlive(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	exec(t, db, "CREATE TABLE FooBar (id INTEGER)")
+	ctx := context.Background()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Exec("INSERT INTO FooBar VALUES (1)"); err != nil {
+		t.Fatal(err)
+	}
+	warnings := make(chan error, 1)
+	stop := KeepTxAlive(ctx, tx, TxKeepalive{
+		Interval:    time.Second,
+		MaxDuration: 2500 * time.Millisecond,
+		Warn:        func(err error) { warnings <- err },
+	})
+	if err := <-warnings; err != ErrTxKeepaliveExpired {
+		t.Fatalf("Expected %v, got %v", ErrTxKeepaliveExpired, err)
+	}
+	stop()
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the pings stop silently with the transaction
+	tx, err = db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop = KeepTxAlive(ctx, tx, TxKeepalive{Interval: time.Second, Warn: func(err error) { warnings <- err }})
+	tx.Rollback()
+	time.Sleep(1500 * time.Millisecond)
+	stop()
+	select {
+	case err := <-warnings:
+		t.Fatalf("Unexpected warning %v", err)
+	default:
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+)
+
+// lbRoute selects the Transaction Engines of a connection with the LBQuery
+// and LBTag connection properties, either or both of which may be empty.
+type lbRoute struct {
+	query, tag string
+}
+
+// lbOverride is the lbRoute of a context, whose fields override those of
+// the Connector if they are set.
+type lbOverride struct {
+	query, tag       string
+	hasQuery, hasTag bool
+}
+
+type lbOverrideKey struct{}
+
+func lbOverrideFrom(ctx context.Context) lbOverride {
+	o, _ := ctx.Value(lbOverrideKey{}).(lbOverride)
+	return o
+}
+
+// WithLBQuery returns a copy of ctx with which the connections are opened
+// to the Transaction Engines of the load balancer query q instead of those
+// of the LBQuery of the Connector, e.g. to steer the analytics queries of a
+// pool to dedicated TEs while its OLTP statements stay on the others:
+//
+//	ctx = nuodb.WithLBQuery(ctx, "round_robin(first(label(role analytics) any))")
+//	rows, err := db.QueryContext(ctx, report)
+//
+// The pool hands out a connection of another route only to be discarded,
+// and connects a new one, so mixing the routes on one pool replaces its
+// connections. A *sql.Conn or a *sql.Tx stays on the TE of its connection.
+// The empty query removes the LBQuery of the Connector.
+func WithLBQuery(ctx context.Context, q string) context.Context {
+	o := lbOverrideFrom(ctx)
+	o.query, o.hasQuery = q, true
+	return context.WithValue(ctx, lbOverrideKey{}, o)
+}
+
+// WithLBTag returns a copy of ctx with which the connections are opened to
+// the Transaction Engines of the load balancer policy tagged tag, instead
+// of the LBTag of the Connector, like WithLBQuery.
+func WithLBTag(ctx context.Context, tag string) context.Context {
+	o := lbOverrideFrom(ctx)
+	o.tag, o.hasTag = tag, true
+	return context.WithValue(ctx, lbOverrideKey{}, o)
+}
+
+// route returns the route of the connections of ctx, that of r unless
+// ctx overrides it.
+func (r lbRoute) route(ctx context.Context) lbRoute {
+	o := lbOverrideFrom(ctx)
+	if o.hasQuery {
+		r.query = o.query
+	}
+	if o.hasTag {
+		r.tag = o.tag
+	}
+	return r
+}
+
+// props returns props with the properties of r, copied if they differ.
+func (r lbRoute) props(props map[string]string) map[string]string {
+	if props[propLBQuery] == r.query && props[propLBTag] == r.tag {
+		return props
+	}
+	routed := make(map[string]string, len(props)+2)
+	for k, v := range props {
+		routed[k] = v
+	}
+	delete(routed, propLBQuery)
+	delete(routed, propLBTag)
+	if r.query != "" {
+		routed[propLBQuery] = r.query
+	}
+	if r.tag != "" {
+		routed[propLBTag] = r.tag
+	}
+	return routed
+}
@@ -0,0 +1,46 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"testing"
+)
+
+func TestLBRoute(t *testing.T) {
+	connector, err := NewConnector(&Config{Hosts: []string{"localhost"}, Database: "tests", User: "robinh",
+		LBQuery: "round_robin(any)", Properties: map[string]string{"a": "b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	background := context.Background()
+	analytics := WithLBQuery(background, "first(label(role analytics))")
+	for _, test := range []struct {
+		ctx        context.Context
+		query, tag string
+	}{
+		{background, "round_robin(any)", ""},
+		{analytics, "first(label(role analytics))", ""},
+		{WithLBTag(analytics, "reports"), "first(label(role analytics))", "reports"},
+		{WithLBQuery(analytics, ""), "", ""},
+	} {
+		d, err := connector.connectDSN(test.ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		query, hasQuery := d.Props[propLBQuery]
+		tag, hasTag := d.Props[propLBTag]
+		if query != test.query || hasQuery != (test.query != "") || tag != test.tag || hasTag != (test.tag != "") || d.Props["a"] != "b" {
+			t.Errorf("Expected %q %q, got %v", test.query, test.tag, d.Props)
+		}
+	}
+	if connector.dsn.Props[propLBQuery] != "round_robin(any)" {
+		t.Fatalf("Expected the properties of the connector to stay, got %v", connector.dsn.Props)
+	}
+
+	// ResetSession discards a pooled connection of another route
+	route := connector.route()
+	if route.route(analytics) == route || route.route(background) != route {
+		t.Fatal("Expected only the analytics route to differ")
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"fmt"
+	"log"
+	"runtime"
+	"strings"
+)
+
+// LeakDetection selects what is done when a Stmt or a Rows of a connection
+// is garbage collected without having been closed, see
+// Config.LeakDetection. A leaked statement or result set pins its memory
+// in the client library until the connection is closed.
+type LeakDetection int
+
+const (
+	LeakDetectionOff LeakDetection = iota
+
+	// LeakDetectionLog logs the leak at LevelError, with the SQL and the
+	// stack of the prepare or the query which opened the statement or the
+	// result set, to the Logger or, without one, to the standard logger.
+	LeakDetectionLog
+
+	// LeakDetectionPanic panics with the same message instead, e.g. in
+	// tests, so that a leak can't go unnoticed. The panic is raised on the
+	// goroutine of the finalizers, so it crashes the program.
+	LeakDetectionPanic
+)
+
+// maxLeakStackDepth is the number of frames recorded of a stack.
+const maxLeakStackDepth = 32
+
+// trackLeak records the stack of the caller, which opened v, a *Stmt or a
+// *Rows, with sql, and makes the garbage collection of v report a leak,
+// unless untrackLeak is called first. The stack is only formatted for a
+// leak.
+func (c *Conn) trackLeak(v interface{}, sql string) {
+	if c.leaks == LeakDetectionOff {
+		return
+	}
+	pcs := make([]uintptr, maxLeakStackDepth)
+	pcs = pcs[:runtime.Callers(2, pcs)]
+	kind := strings.TrimPrefix(fmt.Sprintf("%T", v), "*nuodb.")
+	mode, logger, id, session := c.leaks, c.logger, c.id, c.sessionID // not c, which may be gone too
+	runtime.SetFinalizer(v, func(interface{}) {
+		stack := formatStack(pcs)
+		if mode == LeakDetectionPanic {
+			panic(fmt.Sprintf("nuodb: %s garbage collected without Close: %s\nopened at:\n%s", kind, sql, stack))
+		}
+		msg := kind + " garbage collected without Close"
+		if logger == nil {
+			log.Printf("nuodb %s: %s: %s\nopened at:\n%s", LevelError, msg, sql, stack)
+			return
+		}
+		logger.Log(LevelError, msg, "conn", id, "session", session, "sql", sql, "stack", stack)
+	})
+}
+
+// untrackLeak stops the leak detection of v, which is being closed.
+func (c *Conn) untrackLeak(v interface{}) {
+	if c.leaks != LeakDetectionOff {
+		runtime.SetFinalizer(v, nil)
+	}
+}
+
+// formatStack formats the frames of pcs like a stack trace of a panic.
+func formatStack(pcs []uintptr) string {
+	var b strings.Builder
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		fmt.Fprintf(&b, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		if !more {
+			return b.String()
+		}
+	}
+}
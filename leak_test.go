@@ -0,0 +1,46 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// collect runs the garbage collector until the finalizers have logged to l,
+// or a second has passed.
+func collect(l *recordingLogger) []string {
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		runtime.GC()
+		if messages := l.recorded(); len(messages) > 0 {
+			return messages
+		}
+	}
+	return nil
+}
+
+func openLeaked(c *Conn) {
+	c.trackLeak(&Stmt{c: c}, "SELECT 1 FROM DUAL")
+}
+
+func TestLeakDetection(t *testing.T) {
+	l := &recordingLogger{}
+	c := &Conn{leaks: LeakDetectionLog, logger: l}
+	openLeaked(c)
+	messages := collect(l)
+	if len(messages) != 1 || !strings.Contains(messages[0], "Stmt garbage collected without Close") ||
+		!strings.Contains(messages[0], "SELECT 1 FROM DUAL") || !strings.Contains(messages[0], "openLeaked") {
+		t.Fatalf("Expected the leak with its statement and stack, got %q", messages)
+	}
+
+	l = &recordingLogger{}
+	c.logger = l
+	rows := &Rows{c: c}
+	c.trackLeak(rows, "SELECT 2 FROM DUAL")
+	c.untrackLeak(rows)
+	if messages := collect(l); len(messages) != 0 {
+		t.Fatalf("Expected no leak of closed rows, got %q", messages)
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// StatementLimiter caps the number of statements executing concurrently on
+// the connections of the Connectors configured with it, independently of
+// the size of their pools. It protects the transaction engines from a
+// thundering herd of statements of oversized pools:
+//
+//	limiter := nuodb.NewStatementLimiter(32)
+//	cfg.Limiter = limiter
+//
+// A statement waits for its turn until its context is done. The limit
+// covers the execution of a statement, but not the fetching of its result
+// rows, so open rows don't block other statements. StatementLimiter is safe
+// for concurrent use.
+type StatementLimiter struct {
+	slots chan struct{}
+
+	mu    sync.Mutex
+	stats LimiterStats
+}
+
+// LimiterStats are the statistics of a StatementLimiter.
+type LimiterStats struct {
+	MaxConcurrent int // the limit
+
+	InUse   int // statements executing now
+	Waiting int // statements waiting now
+
+	WaitCount    int64         // statements which had to wait
+	WaitDuration time.Duration // total time waited
+	MaxWait      time.Duration // longest wait
+	Canceled     int64         // statements whose context was done while waiting
+}
+
+// NewStatementLimiter returns a StatementLimiter which lets max statements
+// execute at a time.
+func NewStatementLimiter(max int) *StatementLimiter {
+	if max < 1 {
+		max = 1
+	}
+	return &StatementLimiter{
+		slots: make(chan struct{}, max),
+		stats: LimiterStats{MaxConcurrent: max},
+	}
+}
+
+// Stats returns the statistics of the limiter.
+func (l *StatementLimiter) Stats() LimiterStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.stats
+}
+
+// acquire waits for a slot to execute a statement. A nil limiter doesn't
+// limit.
+func (l *StatementLimiter) acquire(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	select {
+	case l.slots <- struct{}{}:
+		l.mu.Lock()
+		l.stats.InUse++
+		l.mu.Unlock()
+		return nil
+	default:
+	}
+	l.mu.Lock()
+	l.stats.Waiting++
+	l.mu.Unlock()
+	start := time.Now()
+	var err error
+	select {
+	case l.slots <- struct{}{}:
+	case <-ctx.Done():
+		err = contextError(ctx)
+	}
+	wait := time.Since(start)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.stats.Waiting--
+	l.stats.WaitCount++
+	l.stats.WaitDuration += wait
+	if wait > l.stats.MaxWait {
+		l.stats.MaxWait = wait
+	}
+	if err != nil {
+		l.stats.Canceled++
+		return err
+	}
+	l.stats.InUse++
+	return nil
+}
+
+// release frees the slot of an executed statement.
+func (l *StatementLimiter) release() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	l.stats.InUse--
+	l.mu.Unlock()
+	<-l.slots
+}
@@ -0,0 +1,51 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStatementLimiter(t *testing.T) {
+	l := NewStatementLimiter(1)
+	ctx := context.Background()
+	if err := l.acquire(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if s := l.Stats(); s.InUse != 1 || s.WaitCount != 0 {
+		t.Fatalf("Unexpected stats %+v", s)
+	}
+
+	acquired := make(chan error)
+	go func() { acquired <- l.acquire(ctx) }()
+	for l.Stats().Waiting == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	l.release()
+	if err := <-acquired; err != nil {
+		t.Fatal(err)
+	}
+	if s := l.Stats(); s.InUse != 1 || s.Waiting != 0 || s.WaitCount != 1 || s.MaxWait < 10*time.Millisecond {
+		t.Fatalf("Unexpected stats %+v", s)
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := l.acquire(timeout); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected %v, got %v", context.DeadlineExceeded, err)
+	}
+	l.release()
+	if s := l.Stats(); s.InUse != 0 || s.Canceled != 1 || s.WaitCount != 2 {
+		t.Fatalf("Unexpected stats %+v", s)
+	}
+
+	var nilLimiter *StatementLimiter
+	if err := nilLimiter.acquire(ctx); err != nil {
+		t.Fatal(err)
+	}
+	nilLimiter.release()
+}
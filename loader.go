@@ -0,0 +1,181 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DefaultLoadBatchSize is the number of rows a Loader inserts per batch by
+// default.
+const DefaultLoadBatchSize = 500
+
+// Loader inserts a stream of rows into a table in batches:
+//
+//	loader, err := nuodb.NewLoader(db, "events", []string{"id", "payload"})
+//	...
+//	rows := make(chan []interface{}, 1000)
+//	go func() {
+//		defer close(rows)
+//		for _, e := range events {
+//			rows <- []interface{}{e.ID, e.Payload}
+//		}
+//	}()
+//	result, err := loader.Load(ctx, rows)
+//
+// Load reads the rows only as fast as the database accepts the batches, so
+// the producer blocks on a full channel instead of buffering without bound.
+type Loader struct {
+	// BatchSize is the number of rows inserted per round trip;
+	// DefaultLoadBatchSize if 0.
+	BatchSize int
+
+	db      *sql.DB
+	query   string
+	columns int
+}
+
+// LoadResult is the result of a Load.
+type LoadResult struct {
+	Rows   int64        // inserted rows
+	Failed []*LoadError // rejected rows, in the order received
+}
+
+// LoadError is the error of a row which a Loader couldn't insert.
+type LoadError struct {
+	Row int64 // index of the row in the order received
+	Err error
+}
+
+func (e *LoadError) Error() string {
+	return fmt.Sprintf("nuodb: load row %d: %s", e.Row, e.Err)
+}
+
+func (e *LoadError) Unwrap() error {
+	return e.Err
+}
+
+// NewLoader returns a Loader which inserts rows into the columns of table.
+// The table and the column names are used in the statement as is, so they
+// may be qualified or quoted.
+func NewLoader(db *sql.DB, table string, columns []string) (*Loader, error) {
+	if table == "" {
+		return nil, errors.New("nuodb: loader: no table")
+	}
+	if len(columns) == 0 {
+		return nil, errors.New("nuodb: loader: no columns")
+	}
+	for _, column := range columns {
+		if column == "" {
+			return nil, errors.New("nuodb: loader: empty column name")
+		}
+	}
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(columns, ", "),
+		strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", "))
+	return &Loader{db: db, query: query, columns: len(columns)}, nil
+}
+
+// Load inserts the rows received from rows until it is closed. Each row
+// holds the values of the columns. The rows which can't be converted or
+// inserted are reported in the LoadResult and the loading continues. The
+// error is returned, with the result so far, if a batch couldn't be
+// executed at all or ctx is done; the rows of that batch are not counted
+// as inserted or failed. Outside a transaction, each batch commits on its
+// own.
+func (l *Loader) Load(ctx context.Context, rows <-chan []interface{}) (*LoadResult, error) {
+	batchSize := l.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultLoadBatchSize
+	}
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	result := &LoadResult{}
+	err = conn.Raw(func(driverConn interface{}) error {
+		c, ok := driverConn.(*Conn)
+		if !ok {
+			return errors.New("nuodb: not a nuodb connection")
+		}
+		ds, err := c.Prepare(l.query)
+		if err != nil {
+			return err
+		}
+		defer ds.Close()
+		return l.load(ctx, ds.(*Stmt), rows, batchSize, result)
+	})
+	return result, err
+}
+
+func (l *Loader) load(ctx context.Context, stmt *Stmt, rows <-chan []interface{}, batchSize int, result *LoadResult) error {
+	var (
+		batch   = make([][]driver.Value, 0, batchSize)
+		indexes = make([]int64, 0, batchSize) // of the rows of batch
+		next    int64
+	)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		r, err := stmt.ExecBatch(ctx, batch)
+		if err != nil {
+			return err
+		}
+		for i, err := range r.Errors {
+			if err != nil {
+				result.Failed = append(result.Failed, &LoadError{Row: indexes[i], Err: err})
+			} else {
+				result.Rows++
+			}
+		}
+		batch, indexes = batch[:0], indexes[:0]
+		return nil
+	}
+	for {
+		var row []interface{}
+		var ok bool
+		select {
+		case row, ok = <-rows:
+		case <-ctx.Done():
+			return contextError(ctx)
+		}
+		if !ok {
+			return flush()
+		}
+		index := next
+		next++
+		values, err := l.values(row)
+		if err != nil {
+			result.Failed = append(result.Failed, &LoadError{Row: index, Err: err})
+			continue
+		}
+		batch = append(batch, values)
+		indexes = append(indexes, index)
+		if len(batch) == batchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// values converts a row into the parameters of the insert.
+func (l *Loader) values(row []interface{}) ([]driver.Value, error) {
+	if len(row) != l.columns {
+		return nil, fmt.Errorf("expected %d values, got %d", l.columns, len(row))
+	}
+	values := make([]driver.Value, len(row))
+	for i, v := range row {
+		var err error
+		if values[i], err = batchValue(v); err != nil {
+			return nil, fmt.Errorf("column %d: %s", i+1, err)
+		}
+	}
+	return values, nil
+}
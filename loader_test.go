@@ -0,0 +1,77 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"testing"
+)
+
+func TestNewLoader(t *testing.T) {
+	l, err := NewLoader(nil, "tests.FooBar", []string{"id", "name"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "INSERT INTO tests.FooBar (id, name) VALUES (?, ?)"; l.query != expected {
+		t.Fatalf("Expected %q, got %q", expected, l.query)
+	}
+	for _, columns := range [][]string{nil, {"id", ""}} {
+		if _, err := NewLoader(nil, "FooBar", columns); err == nil {
+			t.Errorf("Expected an error for %q", columns)
+		}
+	}
+	if _, err := NewLoader(nil, "", []string{"id"}); err == nil {
+		t.Error("Expected an error for no table")
+	}
+
+	if _, err := l.values([]interface{}{1}); err == nil {
+		t.Error("Expected an error for a missing value")
+	}
+	if _, err := l.values([]interface{}{1, struct{}{}}); err == nil {
+		t.Error("Expected an error for an unsupported type")
+	}
+	if values, err := l.values([]interface{}{int32(1), "a"}); err != nil || values[0] != int64(1) || values[1] != "a" {
+		t.Errorf("Unexpected values %v (%v)", values, err)
+	}
+}
+
+func TestLoader(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	exec(t, db, "CREATE TABLE FooBar (id INTEGER PRIMARY KEY, name STRING)")
+	l, err := NewLoader(db, "FooBar", []string{"id", "name"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.BatchSize = 100
+
+	rows := make(chan []interface{})
+	go func() {
+		defer close(rows)
+		for i := 0; i < 1050; i++ {
+			rows <- []interface{}{i, "name"}
+		}
+		rows <- []interface{}{1}         // missing value
+		rows <- []interface{}{0, "name"} // duplicate key
+	}()
+	result, err := l.Load(context.Background(), rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Rows != 1050 {
+		t.Fatalf("Expected 1050 rows, got %d", result.Rows)
+	}
+	if len(result.Failed) != 2 || result.Failed[0].Row != 1050 || result.Failed[1].Row != 1051 {
+		t.Fatalf("Unexpected failures: %v", result.Failed)
+	}
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM FooBar").Scan(&count); err != nil || count != 1050 {
+		t.Fatalf("Expected 1050 rows, got %d (%v)", count, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := l.Load(ctx, make(chan []interface{})); err == nil {
+		t.Fatal("Expected a cancelled load")
+	}
+}
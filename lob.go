@@ -0,0 +1,203 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+// #include "cnuodb.h"
+import "C"
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"unsafe"
+)
+
+// StreamLobs makes a query return its BLOB and CLOB values as *Lob instead
+// of reading them into memory at once, e.g.
+//
+//	rows, err := db.Query("SELECT data FROM files WHERE id = ?", nuodb.StreamLobs(true), id)
+//	...
+//	var lob *nuodb.Lob
+//	err = rows.Scan(&lob)
+//	_, err = io.Copy(w, lob)
+type StreamLobs bool
+
+func (s StreamLobs) apply(o *callOptions) {
+	o.streamLobs = bool(s)
+}
+
+// Lob streams a BLOB or CLOB value of a row in chunks. A Lob is only valid
+// until the next call to Next or Close of the rows it was fetched with.
+type Lob struct {
+	rows *Rows
+	row  uint64 // the row the lob belongs to
+	lob  *C.struct_nuodb_lob
+	vt   C.enum_nuodb_value_type
+
+	offset int64
+	length int64 // -1 until fetched
+}
+
+var errLobInvalid = errors.New("nuodb: lob is no longer valid")
+
+func newLob(rows *Rows, value C.struct_nuodb_value) *Lob {
+	return &Lob{
+		rows:   rows,
+		row:    rows.row,
+		lob:    (*C.struct_nuodb_lob)(valueData(&value)),
+		vt:     value.vt,
+		length: -1,
+	}
+}
+
+func (l *Lob) valid() bool {
+	return l.rows.rs != nil && l.rows.c.db != nil && l.rows.row == l.row
+}
+
+// Len returns the length of the value in bytes, or in characters for a CLOB.
+func (l *Lob) Len() (int64, error) {
+	if l.length >= 0 {
+		return l.length, nil
+	}
+	if !l.valid() {
+		return 0, errLobInvalid
+	}
+	c := l.rows.c
+	exit, err := c.enter()
+	if err != nil {
+		return 0, err
+	}
+	defer exit()
+	var length C.int64_t
+	c.stats.call()
+	if err := l.rows.cgo(func() C.int { return C.nuodb_lob_length(c.db, l.lob, l.vt, &length) }); err != nil {
+		return 0, err
+	}
+	l.length = int64(length)
+	return l.length, nil
+}
+
+// Read implements io.Reader.
+func (l *Lob) Read(p []byte) (int, error) {
+	length, err := l.Len()
+	if err != nil {
+		return 0, err
+	}
+	if l.offset >= length {
+		return 0, io.EOF
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if !l.valid() {
+		return 0, errLobInvalid
+	}
+	n := int64(len(p))
+	if remaining := length - l.offset; n > remaining {
+		n = remaining
+	}
+	if n > maxLobChunk {
+		n = maxLobChunk
+	}
+	c := l.rows.c
+	exit, err := c.enter()
+	if err != nil {
+		return 0, err
+	}
+	defer exit()
+	c.stats.call()
+	if err := l.rows.cgo(func() C.int {
+		return C.nuodb_lob_read(c.db, l.lob, l.vt, C.int64_t(l.offset), (*C.uchar)(unsafe.Pointer(&p[0])), C.int32_t(n))
+	}); err != nil {
+		return 0, err
+	}
+	l.offset += n
+	return int(n), nil
+}
+
+// maxLobChunk limits a single read to the range of int32.
+const maxLobChunk = 1<<31 - 1
+
+// LobSource binds a parameter from a reader, which is written to the server
+// in chunks instead of being read into memory at once. Text binds the value
+// as a CLOB instead of a BLOB. A plain io.Reader argument is bound as a
+// BLOB, e.g.
+//
+//	f, err := os.Open("image.png")
+//	...
+//	_, err = db.Exec("INSERT INTO files (data) VALUES (?)", f)
+//
+// Readers can't be used with EncodeParams, as they can be read only once.
+type LobSource struct {
+	Reader io.Reader
+	Text   bool
+}
+
+// lobChunkSize is the size of the chunks written from a LobSource.
+const lobChunkSize = 64 << 10
+
+// lobParam is a LOB which has been written from a LobSource for binding.
+type lobParam struct {
+	lob *C.struct_nuodb_lob
+	vt  C.enum_nuodb_value_type
+}
+
+// writeLobs writes the LobSource arguments to the server and replaces them
+// with the written LOBs. The LOBs must be released with releaseLobs once the
+// statement has been executed.
+func (c *Conn) writeLobs(args []driver.Value) ([]*lobParam, error) {
+	var lobs []*lobParam
+	for i, v := range args {
+		src, ok := v.(LobSource)
+		if !ok {
+			continue
+		}
+		p, err := c.writeLob(src)
+		if err != nil {
+			releaseLobs(lobs)
+			return nil, fmt.Errorf("nuodb: parameter %d: %s", i+1, err)
+		}
+		lobs = append(lobs, p)
+		args[i] = p
+	}
+	return lobs, nil
+}
+
+func (c *Conn) writeLob(src LobSource) (*lobParam, error) {
+	p := &lobParam{vt: C.NUODB_TYPE_BLOB}
+	if src.Text {
+		p.vt = C.NUODB_TYPE_CLOB
+	}
+	c.stats.call()
+	if err := c.cgo(func() C.int { return C.nuodb_lob_create(c.db, p.vt, &p.lob) }); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, lobChunkSize)
+	var offset int64
+	for {
+		n, err := io.ReadFull(src.Reader, buf)
+		if n > 0 {
+			c.stats.call()
+			if err := c.cgo(func() C.int {
+				return C.nuodb_lob_write(c.db, p.lob, p.vt, C.int64_t(offset), (*C.uchar)(unsafe.Pointer(&buf[0])), C.int32_t(n))
+			}); err != nil {
+				releaseLobs([]*lobParam{p})
+				return nil, err
+			}
+			offset += int64(n)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return p, nil
+		}
+		if err != nil {
+			releaseLobs([]*lobParam{p})
+			return nil, err
+		}
+	}
+}
+
+func releaseLobs(lobs []*lobParam) {
+	for _, p := range lobs {
+		C.nuodb_lob_release(p.lob, p.vt)
+	}
+}
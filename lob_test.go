@@ -0,0 +1,121 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestStreamLobsOption(t *testing.T) {
+	c := &Conn{}
+	nv := &driver.NamedValue{Ordinal: 1, Value: StreamLobs(true)}
+	if err := c.CheckNamedValue(nv); err != driver.ErrRemoveArgument {
+		t.Fatalf("Expected ErrRemoveArgument, got %v", err)
+	}
+	if opts := c.takeOptions(); !opts.streamLobs {
+		t.Fatalf("Expected streamLobs to be set, got %+v", opts)
+	}
+}
+
+func TestStreamLobs(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	exec(t, db, "CREATE TABLE tests.FooBarLob (id BIGINT, b BLOB, c CLOB)")
+	blob := bytes.Repeat([]byte{0, 1, 2, 3}, 100000)
+	clob := strings.Repeat("abcd", 100000)
+	exec(t, db, "INSERT INTO tests.FooBarLob VALUES (1, ?, ?)", blob, clob)
+
+	rows := query(t, db, "SELECT b, c FROM tests.FooBarLob", StreamLobs(true))
+	defer rows.Close()
+	if !rows.Next() {
+		t.Fatal("Expected a row", rows.Err())
+	}
+	var b, c *Lob
+	if err := rows.Scan(&b, &c); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := b.Len(); err != nil || n != int64(len(blob)) {
+		t.Fatalf("Expected length %d, got %d (%v)", len(blob), n, err)
+	}
+	got, err := ioutil.ReadAll(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, blob) {
+		t.Fatal("Streamed BLOB differs")
+	}
+	got, err = ioutil.ReadAll(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != clob {
+		t.Fatal("Streamed CLOB differs")
+	}
+	if rows.Next() {
+		t.Fatal("Expected only one row")
+	}
+	if _, err := b.Read(make([]byte, 1)); err != errLobInvalid {
+		t.Fatalf("Expected %v, got %v", errLobInvalid, err)
+	}
+}
+
+func TestCheckNamedValueReader(t *testing.T) {
+	c := &Conn{}
+	r := strings.NewReader("abc")
+	nv := &driver.NamedValue{Ordinal: 1, Value: r}
+	if err := c.CheckNamedValue(nv); err != nil {
+		t.Fatal(err)
+	}
+	if nv.Value != (LobSource{Reader: r}) {
+		t.Fatalf("Expected a LobSource, got %#v", nv.Value)
+	}
+	src := LobSource{Reader: r, Text: true}
+	nv = &driver.NamedValue{Ordinal: 1, Value: src}
+	if err := c.CheckNamedValue(nv); err != nil || nv.Value != src {
+		t.Fatalf("Expected the LobSource to be kept, got %#v, %v", nv.Value, err)
+	}
+}
+
+func TestBindLobSource(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	exec(t, db, "CREATE TABLE tests.FooBarLobSource (id BIGINT, b BLOB, c CLOB)")
+	blob := bytes.Repeat([]byte{0, 1, 2, 3}, 100000)
+	clob := strings.Repeat("abcd", 100000)
+	exec(t, db, "INSERT INTO tests.FooBarLobSource VALUES (1, ?, ?)",
+		bytes.NewReader(blob), LobSource{Reader: strings.NewReader(clob), Text: true})
+
+	stmt, err := db.Prepare("INSERT INTO tests.FooBarLobSource VALUES (?, ?, ?)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stmt.Close()
+	if _, err = stmt.Exec(2, bytes.NewReader(blob), LobSource{Reader: strings.NewReader(clob), Text: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	rows := query(t, db, "SELECT b, c FROM tests.FooBarLobSource ORDER BY id")
+	defer rows.Close()
+	n := 0
+	for rows.Next() {
+		var b []byte
+		var c string
+		if err := rows.Scan(&b, &c); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(b, blob) || c != clob {
+			t.Fatalf("Row %d differs from the bound readers", n+1)
+		}
+		n++
+	}
+	if rows.Err() != nil {
+		t.Fatal(rows.Err())
+	}
+	if n != 2 {
+		t.Fatalf("Expected 2 rows, got %d", n)
+	}
+}
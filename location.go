@@ -0,0 +1,65 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"time"
+)
+
+type locationKey struct{}
+
+// WithLocation returns a copy of ctx which carries loc. The time.Time
+// values scanned from the rows and the output parameters of the statements
+// run with the returned context are in loc rather than in the time zone of
+// the connection, e.g. in the time zone of the tenant of a request:
+//
+//	rows, err := db.QueryContext(nuodb.WithLocation(ctx, time.UTC), "SELECT created FROM orders")
+//
+// A TIMESTAMP is the same instant in loc. A DATE is the midnight of its
+// date in loc and a TIME its time of day in loc, the date and the time of
+// day being those in the time zone of the connection.
+func WithLocation(ctx context.Context, loc *time.Location) context.Context {
+	return context.WithValue(ctx, locationKey{}, loc)
+}
+
+// scanLocation returns the time zone of the time.Time values scanned by
+// a statement run with ctx.
+func (c *Conn) scanLocation(ctx context.Context) *time.Location {
+	if loc, _ := ctx.Value(locationKey{}).(*time.Location); loc != nil {
+		return loc
+	}
+	if c.scanLoc != nil {
+		return c.scanLoc
+	}
+	return c.loc
+}
+
+// propSessionTimeZone is the connection property of the time zone of the
+// server session.
+const propSessionTimeZone = "TimeZone"
+
+// withSessionTimeZone returns props with the time zone of the server
+// session set to loc, the time zone of the timezone property, so that the
+// server evaluates the dates and times of the statements, e.g. NOW() or
+// CAST(ts AS DATE), in the time zone the driver binds and scans them in.
+// The local time zone and a time.FixedZone have no name known to the
+// server, so they leave the server default, as does a TimeZone property
+// set explicitly.
+func withSessionTimeZone(props map[string]string, loc *time.Location) map[string]string {
+	if loc == nil || loc == time.Local {
+		return props
+	}
+	if _, ok := props[propSessionTimeZone]; ok {
+		return props
+	}
+	if _, err := time.LoadLocation(loc.String()); err != nil || loc.String() == "Local" {
+		return props
+	}
+	with := make(map[string]string, len(props)+1)
+	for k, v := range props {
+		with[k] = v
+	}
+	with[propSessionTimeZone] = loc.String()
+	return with
+}
@@ -0,0 +1,89 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestScanLocation(t *testing.T) {
+	session := time.FixedZone("UTC-8", -8*3600)
+	tenant := time.FixedZone("UTC+9", 9*3600)
+	c := &Conn{loc: session}
+	ctx := context.Background()
+	if loc := c.scanLocation(ctx); loc != session {
+		t.Fatalf("Expected the session time zone, got %s", loc)
+	}
+	c.scanLoc = time.UTC
+	if loc := c.scanLocation(ctx); loc != time.UTC {
+		t.Fatalf("Expected the ScanLocation, got %s", loc)
+	}
+	if loc := c.scanLocation(WithLocation(ctx, tenant)); loc != tenant {
+		t.Fatalf("Expected the location of the context, got %s", loc)
+	}
+
+	// the date and the time of day are those of the session, in the scan
+	// location
+	at := time.Date(2013, 7, 8, 22, 30, 0, 0, session) // 2013-07-09 in tenant
+	if d := dateValue(at.Unix(), session, tenant); !d.Equal(time.Date(2013, 7, 8, 0, 0, 0, 0, tenant)) {
+		t.Fatal(d)
+	}
+	if tod := timeOfDayValue(at.Unix(), 0, session, tenant); !tod.Equal(time.Date(1970, 1, 1, 22, 30, 0, 0, tenant)) {
+		t.Fatal(tod)
+	}
+}
+
+func TestWithLocation(t *testing.T) {
+	db := testConn(t) // in America/Los_Angeles
+	defer db.Close()
+	exec(t, db, "CREATE TABLE FooBar (ts TIMESTAMP, dat DATE)")
+	at := time.Date(2013, 7, 8, 22, 30, 0, 0, time.UTC)
+	exec(t, db, "INSERT INTO FooBar VALUES (?, ?)", at, Date{2013, time.July, 8})
+
+	var ts, dat time.Time
+	ctx := WithLocation(context.Background(), time.UTC)
+	if err := db.QueryRowContext(ctx, "SELECT ts, dat FROM FooBar").Scan(&ts, &dat); err != nil {
+		t.Fatal(err)
+	}
+	if ts.Location() != time.UTC || !ts.Equal(at) {
+		t.Fatalf("Expected %s, got %s", at, ts)
+	}
+	if expected := time.Date(2013, 7, 8, 0, 0, 0, 0, time.UTC); !dat.Equal(expected) {
+		t.Fatalf("Expected %s, got %s", expected, dat)
+	}
+	if err := db.QueryRow("SELECT ts FROM FooBar").Scan(&ts); err != nil {
+		t.Fatal(err)
+	}
+	if ts.Location() == time.UTC {
+		t.Fatal("Expected the time zone of the connection without WithLocation")
+	}
+}
+
+func TestWithSessionTimeZone(t *testing.T) {
+	la, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Skip(err)
+	}
+	props := map[string]string{"schema": "tests"}
+	with := withSessionTimeZone(props, la)
+	if with[propSessionTimeZone] != "America/Los_Angeles" || with["schema"] != "tests" {
+		t.Fatalf("Unexpected %v", with)
+	}
+	if _, ok := props[propSessionTimeZone]; ok {
+		t.Fatal("Expected the properties not to change")
+	}
+	if with := withSessionTimeZone(props, time.UTC); with[propSessionTimeZone] != "UTC" {
+		t.Fatalf("Unexpected %v", with)
+	}
+	for _, loc := range []*time.Location{nil, time.Local, time.FixedZone("UTC-8", -8*3600)} {
+		if with := withSessionTimeZone(props, loc); len(with) != 1 {
+			t.Errorf("%v: expected the server default, got %v", loc, with)
+		}
+	}
+	explicit := map[string]string{propSessionTimeZone: "Europe/Helsinki"}
+	if with := withSessionTimeZone(explicit, la); with[propSessionTimeZone] != "Europe/Helsinki" {
+		t.Fatalf("Expected the explicit TimeZone, got %v", with)
+	}
+}
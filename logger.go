@@ -0,0 +1,134 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// LogLevel is the severity of a message of a Logger.
+type LogLevel int
+
+// Levels of the messages of a Logger.
+const (
+	LevelDebug LogLevel = iota // connections opened and closed
+	LevelInfo                  // retries of conflicting statements
+	LevelWarn                  // slow statements, expired transactions, failed connects
+	LevelError                 // connections broken by fatal errors
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	}
+	return fmt.Sprintf("LogLevel(%d)", int(l))
+}
+
+// Logger receives the messages of the driver, with the context of a
+// message as alternating keys and values, e.g. "conn", uint64(3). The
+// messages of a connection have the keys conn and session, its local
+// number and server side id. A Logger must be safe for concurrent use by
+// the connections.
+type Logger interface {
+	Log(level LogLevel, msg string, keyvals ...interface{})
+}
+
+// NewStdLogger returns a Logger which writes the messages of level and
+// above to l, e.g. log.Default(), as a line of the level, the message and
+// the key=value pairs.
+func NewStdLogger(l *log.Logger, level LogLevel) Logger {
+	return &stdLogger{l: l, level: level}
+}
+
+type stdLogger struct {
+	l     *log.Logger
+	level LogLevel
+}
+
+func (s *stdLogger) Log(level LogLevel, msg string, keyvals ...interface{}) {
+	if level < s.level {
+		return
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "nuodb %s: %s", level, msg)
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		fmt.Fprintf(&b, " %v=%q", keyvals[i], fmt.Sprint(keyvals[i+1]))
+	}
+	s.l.Output(2, b.String())
+}
+
+// log logs a message of c, if c has a Logger.
+func (c *Conn) log(level LogLevel, msg string, keyvals ...interface{}) {
+	if c.logger == nil {
+		return
+	}
+	c.logger.Log(level, msg, append([]interface{}{"conn", c.id, "session", c.sessionID}, keyvals...)...)
+}
+
+// errorKeyvals returns the keys and values describing err: the name of the
+// code of a NuoDB error, or the message of another error.
+func errorKeyvals(err error) []interface{} {
+	var nerr *Error
+	if errors.As(err, &nerr) {
+		return []interface{}{"code", nerr.Code.Name(), "error", nerr.Message}
+	}
+	return []interface{}{"error", err.Error()}
+}
+
+// slow reports whether a statement which took d is logged as slow.
+func (c *Conn) slow(d time.Duration) bool {
+	return c.logger != nil && c.slowThreshold > 0 && d >= c.slowThreshold
+}
+
+// logSlow logs sql as a slow statement, if it took longer than the
+// threshold, with the number of rows it affected or fetched.
+func (c *Conn) logSlow(sql string, d time.Duration, rows int64, err error) {
+	if !c.slow(d) {
+		return
+	}
+	keyvals := []interface{}{"sql", sql, "duration", d, "rows", rows}
+	if err != nil {
+		keyvals = append(keyvals, errorKeyvals(err)...)
+	}
+	c.log(LevelWarn, "slow statement", keyvals...)
+}
+
+// logSlowExec logs an exec of sql which started at start, if it was slow.
+func (c *Conn) logSlowExec(sql string, start time.Time, result *driver.Result, err *error) {
+	if c.logger == nil || *err == driver.ErrSkip {
+		return
+	}
+	var n int64
+	if *result != nil {
+		n, _ = (*result).RowsAffected()
+	}
+	c.logSlow(sql, time.Since(start), n, *err)
+}
+
+// logSlowQuery logs a failed query of sql which started at start, if it
+// was slow. The rows of a query are logged when closed, to include the
+// time spent fetching them.
+func (c *Conn) logSlowQuery(sql string, start time.Time, rows *driver.Rows, err *error) {
+	if c.logger == nil || *err == driver.ErrSkip {
+		return
+	}
+	if *err != nil {
+		c.logSlow(sql, time.Since(start), 0, *err)
+		return
+	}
+	if r, ok := (*rows).(*Rows); ok {
+		r.sql, r.started = sql, start
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingLogger records the messages logged.
+type recordingLogger struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (l *recordingLogger) Log(level LogLevel, msg string, keyvals ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.messages = append(l.messages, fmt.Sprint(level, " ", msg, " ", keyvals))
+}
+
+func (l *recordingLogger) recorded() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.messages...)
+}
+
+func TestStdLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewStdLogger(log.New(&buf, "", 0), LevelInfo)
+	l.Log(LevelDebug, "connection opened", "conn", uint64(1))
+	l.Log(LevelWarn, "slow statement", "conn", uint64(1), "sql", "SELECT 'a'", "duration", time.Second)
+	expected := "nuodb warn: slow statement conn=\"1\" sql=\"SELECT 'a'\" duration=\"1s\"\n"
+	if buf.String() != expected {
+		t.Fatalf("Expected %q, got %q", expected, buf.String())
+	}
+	if s := LogLevel(7).String(); s != "LogLevel(7)" {
+		t.Fatalf("Unexpected level %q", s)
+	}
+}
+
+func TestSlowQueryLog(t *testing.T) {
+	l := &recordingLogger{}
+	c := &Conn{id: 2, logger: l, slowThreshold: time.Minute}
+	start := time.Now().Add(-time.Hour)
+	var result driver.Result = &Result{rowsAffected: 3}
+	var err error
+	c.logSlowExec("UPDATE t SET a = 1", start, &result, &err)
+	c.logSlowExec("UPDATE t SET a = 2", time.Now(), &result, &err) // fast
+
+	result, err = nil, &Error{Code: LockTimeout, Message: "timed out"}
+	c.logSlowExec("UPDATE t SET a = 3", start, &result, &err)
+	err = driver.ErrSkip
+	c.logSlowExec("UPDATE t SET a = 4", start, &result, &err)
+
+	var rows driver.Rows = &Rows{c: c, row: 5}
+	err = nil
+	c.logSlowQuery("SELECT * FROM t", start, &rows, &err)
+	if err := rows.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var messages []string
+	for _, m := range l.recorded() {
+		messages = append(messages, m[:strings.Index(m, " duration")]+m[strings.Index(m, " rows"):])
+	}
+	expected := []string{
+		"warn slow statement [conn 2 session 0 sql UPDATE t SET a = 1 rows 3]",
+		"warn slow statement [conn 2 session 0 sql UPDATE t SET a = 3 rows 0 code LOCK_TIMEOUT error timed out]",
+		"warn slow statement [conn 2 session 0 sql SELECT * FROM t rows 5]",
+	}
+	if !reflect.DeepEqual(messages, expected) {
+		t.Fatalf("Expected %q, got %q", expected, messages)
+	}
+}
+
+func TestLogger(t *testing.T) {
+	cfg, err := ParseDSN(default_dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := &recordingLogger{}
+	cfg.Logger = l
+	cfg.SlowQueryThreshold = time.Nanosecond
+	connector, err := NewConnector(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := sql.OpenDB(connector)
+	if _, err := db.Exec("SELECT 1 FROM DUAL"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("SELEC 1"); err == nil {
+		t.Fatal("Expected an error")
+	}
+	db.Close()
+	messages := l.recorded()
+	if len(messages) != 4 {
+		t.Fatalf("Expected 4 messages, got %q", messages)
+	}
+	for i, prefix := range []string{"debug connection opened", "warn slow statement", "warn slow statement",
+		"debug connection closed"} {
+		if !strings.HasPrefix(messages[i], prefix) {
+			t.Fatalf("Expected %q, got %q", prefix, messages[i])
+		}
+	}
+	if !strings.Contains(messages[2], "code SYNTAX_ERROR") {
+		t.Fatalf("Expected the error code, got %q", messages[2])
+	}
+}
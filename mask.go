@@ -0,0 +1,88 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"database/sql/driver"
+	"regexp"
+	"sync"
+)
+
+// Masker replaces the value of a sensitive column. It is not called for
+// NULL values.
+type Masker func(v driver.Value) driver.Value
+
+// Redact is a Masker which replaces any value with the string "REDACTED".
+// Scanning it into other than a string fails, rather than revealing the
+// value.
+func Redact(driver.Value) driver.Value {
+	return "REDACTED"
+}
+
+// MaskRules masks the values of sensitive columns, e.g. on a connector of a
+// debug console which must not display personal data:
+//
+//	masks := nuodb.NewMaskRules()
+//	masks.Column(`(?i)^(email|phone|ssn)$`, nuodb.Redact)
+//	cfg.Masking = masks
+//
+// The rules apply to the result rows of the connections of a Connector
+// configured with them; other connections don't mask. Lobs which are
+// streamed with StreamLobs are masked as whole values. MaskRules is safe
+// for concurrent use.
+type MaskRules struct {
+	mu    sync.RWMutex
+	rules []maskRule
+}
+
+type maskRule struct {
+	column *regexp.Regexp
+	masker Masker
+}
+
+// NewMaskRules returns empty MaskRules.
+func NewMaskRules() *MaskRules {
+	return &MaskRules{}
+}
+
+// Column registers masker for the result columns whose name matches the
+// regular expression pattern. The first matching rule applies. Rules which
+// are registered later apply to the queries executed after that.
+func (m *MaskRules) Column(pattern string, masker Masker) error {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rules = append(m.rules, maskRule{column: re, masker: masker})
+	return nil
+}
+
+// maskers returns the maskers of columns, nil if no column is masked.
+func (m *MaskRules) maskers(columns []string) []Masker {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var maskers []Masker
+	for i, column := range columns {
+		for _, rule := range m.rules {
+			if rule.column.MatchString(column) {
+				if maskers == nil {
+					maskers = make([]Masker, len(columns))
+				}
+				maskers[i] = rule.masker
+				break
+			}
+		}
+	}
+	return maskers
+}
+
+// mask masks the values of a row in place.
+func mask(maskers []Masker, dest []driver.Value) {
+	for i, masker := range maskers {
+		if masker != nil && dest[i] != nil {
+			dest[i] = masker(dest[i])
+		}
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMaskRules(t *testing.T) {
+	m := NewMaskRules()
+	if err := m.Column(`(?i)^(email|phone)$`, Redact); err != nil {
+		t.Fatal(err)
+	}
+	last4 := func(v driver.Value) driver.Value {
+		s, _ := v.(string)
+		if len(s) > 4 {
+			return strings.Repeat("*", len(s)-4) + s[len(s)-4:]
+		}
+		return s
+	}
+	if err := m.Column(`(?i)card`, last4); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Column(`(`, Redact); err == nil {
+		t.Fatal("Expected an invalid pattern")
+	}
+
+	if maskers := m.maskers([]string{"ID", "NAME"}); maskers != nil {
+		t.Fatalf("Expected no maskers, got %d", len(maskers))
+	}
+	maskers := m.maskers([]string{"ID", "EMAIL", "CARD_NUMBER", "PHONE"})
+	row := []driver.Value{int64(1), "a@example.com", "1234567812345678", nil}
+	mask(maskers, row)
+	expected := []driver.Value{int64(1), "REDACTED", "************5678", nil}
+	if !reflect.DeepEqual(row, expected) {
+		t.Fatalf("Expected %v, got %v", expected, row)
+	}
+}
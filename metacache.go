@@ -0,0 +1,123 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tilinna/go-nuodb/internal/parse"
+)
+
+// tableColumn is the metadata of a column of a table.
+type tableColumn struct {
+	name             string
+	scanType         reflect.Type
+	databaseTypeName string
+}
+
+// metadataCache caches the columns of the tables looked up by the helpers,
+// e.g. LoadCSV, for the connections of a Connector. An entry expires after
+// the TTL; all the entries are dropped by a DDL statement executed on any
+// of the connections, and by a NoSuchTable or InvalidField error, which
+// tells that the table was changed elsewhere. A nil cache caches nothing.
+type metadataCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu     sync.Mutex
+	tables map[string]metadataEntry // by upper case qualified table name
+}
+
+type metadataEntry struct {
+	columns []tableColumn
+	expires time.Time
+}
+
+func newMetadataCache(ttl time.Duration) *metadataCache {
+	if ttl <= 0 {
+		return nil
+	}
+	return &metadataCache{ttl: ttl, now: time.Now, tables: make(map[string]metadataEntry)}
+}
+
+func (m *metadataCache) get(table string) ([]tableColumn, bool) {
+	if m == nil {
+		return nil, false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := strings.ToUpper(table)
+	e, ok := m.tables[key]
+	if !ok {
+		return nil, false
+	}
+	if !m.now().Before(e.expires) {
+		delete(m.tables, key)
+		return nil, false
+	}
+	return e.columns, true
+}
+
+func (m *metadataCache) put(table string, columns []tableColumn) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.tables[strings.ToUpper(table)] = metadataEntry{columns: columns, expires: m.now().Add(m.ttl)}
+	m.mu.Unlock()
+}
+
+func (m *metadataCache) invalidate() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.tables = make(map[string]metadataEntry)
+	m.mu.Unlock()
+}
+
+// schemaChange reports whether sql is a DDL statement which may change a
+// table, unlike SET or USE.
+func schemaChange(sql string) bool {
+	return parse.Classify(sql) == parse.KindOther && parse.DDLStatement(sql)
+}
+
+// InvalidateMetadata drops the table metadata cached for the connections
+// of the Connector, e.g. after the schema was changed by another client
+// of the database.
+func (c *Connector) InvalidateMetadata() {
+	c.meta.invalidate()
+}
+
+// tableColumns returns the columns of table, from the metadata cache if
+// the name is schema qualified. An unqualified name depends on the schema
+// of the connection, so it is always looked up.
+func (c *Conn) tableColumns(ctx context.Context, table string) ([]tableColumn, error) {
+	qualified := strings.Contains(table, ".")
+	if qualified && c.meta != nil {
+		columns, ok := c.meta.get(table)
+		c.stats.lookup(ok)
+		if ok {
+			return columns, nil
+		}
+	}
+	dr, err := c.QueryContext(ctx, "SELECT * FROM "+table+" WHERE 1 = 0", nil)
+	if err != nil {
+		return nil, err
+	}
+	rows := dr.(*Rows)
+	defer rows.Close()
+	columns := make([]tableColumn, len(rows.columnNames))
+	for i, name := range rows.columnNames {
+		columns[i] = tableColumn{name: name, scanType: rows.ColumnTypeScanType(i),
+			databaseTypeName: rows.ColumnTypeDatabaseTypeName(i)}
+	}
+	if qualified {
+		c.meta.put(table, columns)
+	}
+	return columns, nil
+}
@@ -0,0 +1,92 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetadataCache(t *testing.T) {
+	now := time.Unix(0, 0)
+	m := newMetadataCache(time.Minute)
+	m.now = func() time.Time { return now }
+	columns := []tableColumn{{name: "ID", scanType: scanTypeInt64, databaseTypeName: "BIGINT"}}
+	m.put("app.Users", columns)
+	if got, ok := m.get("APP.USERS"); !ok || !reflect.DeepEqual(got, columns) {
+		t.Fatalf("Expected the cached columns, got %v %v", got, ok)
+	}
+	now = now.Add(time.Minute)
+	if _, ok := m.get("app.users"); ok {
+		t.Fatal("Expected the entry to expire")
+	}
+	m.put("app.users", columns)
+	m.invalidate()
+	if _, ok := m.get("app.users"); ok {
+		t.Fatal("Expected the entry to be invalidated")
+	}
+
+	disabled := newMetadataCache(0)
+	disabled.put("app.users", columns)
+	if _, ok := disabled.get("app.users"); ok {
+		t.Fatal("Expected nothing to be cached")
+	}
+}
+
+func TestSchemaChange(t *testing.T) {
+	for sql, expected := range map[string]bool{
+		"CREATE TABLE t (id INTEGER)":    true,
+		"alter table t add column x int": true,
+		"DROP TABLE t":                   true,
+		"USE app":                        false,
+		"SET SCHEMA app":                 false,
+		"SELECT * FROM t":                false,
+		"INSERT INTO t VALUES (1)":       false,
+	} {
+		if schemaChange(sql) != expected {
+			t.Errorf("%q: expected %v", sql, expected)
+		}
+	}
+}
+
+func TestMetadataTTL(t *testing.T) {
+	db := testConn(t)
+	exec(t, db, "CREATE TABLE FooBar (id BIGINT)")
+	db.Close()
+	cfg, err := ParseDSN(default_dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.MetadataTTL = time.Hour
+	connector, err := NewConnector(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db = sql.OpenDB(connector)
+	defer db.Close()
+	ctx := context.Background()
+
+	if _, err := LoadCSV(ctx, db, "tests.FooBar", strings.NewReader("1\n"), CSVOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := connector.meta.get("tests.FooBar"); !ok {
+		t.Fatal("Expected the columns to be cached")
+	}
+	exec(t, db, "ALTER TABLE tests.FooBar ADD COLUMN name STRING")
+	if _, ok := connector.meta.get("tests.FooBar"); ok {
+		t.Fatal("Expected the DDL statement to drop the cache")
+	}
+	if _, err := LoadCSV(ctx, db, "tests.FooBar", strings.NewReader("2,b\n"), CSVOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("SELECT * FROM tests.NoSuchTable"); err == nil {
+		t.Fatal("Expected an error")
+	}
+	if _, ok := connector.meta.get("tests.FooBar"); ok {
+		t.Fatal("Expected the NoSuchTable error to drop the cache")
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"database/sql/driver"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LatencyBuckets are the upper bounds of the buckets of the latency
+// histograms of Metrics, the default buckets of Prometheus.
+var LatencyBuckets = [...]time.Duration{
+	5 * time.Millisecond, 10 * time.Millisecond, 25 * time.Millisecond, 50 * time.Millisecond,
+	100 * time.Millisecond, 250 * time.Millisecond, 500 * time.Millisecond,
+	time.Second, 2500 * time.Millisecond, 5 * time.Second, 10 * time.Second,
+}
+
+// Metrics counts the operations of the connections of a Connector, for
+// exporting to a monitoring system. Set it as Config.Metrics, and read it
+// with Snapshot, e.g. from a Prometheus collector:
+//
+//	func (c collector) Collect(ch chan<- prometheus.Metric) {
+//		s := c.metrics.Snapshot()
+//		ch <- prometheus.MustNewConstMetric(connsOpened, prometheus.CounterValue,
+//			float64(s.ConnectionsOpened))
+//		ch <- prometheus.MustNewConstHistogram(queryLatency, uint64(s.Queries.Count),
+//			s.Queries.Sum.Seconds(), buckets(s.Queries))
+//		for code, n := range s.Errors {
+//			ch <- prometheus.MustNewConstMetric(errorsTotal, prometheus.CounterValue,
+//				float64(n), code)
+//		}
+//		...
+//	}
+//
+// The zero Metrics is ready for use. Metrics is safe for concurrent use,
+// and may be shared by Connectors.
+type Metrics struct {
+	connsOpened, connsClosed int64
+	prepares, execs, queries histogram
+	rowsFetched              int64
+	bytesSent, bytesReceived int64
+	mu                       sync.Mutex
+	errors                   map[string]int64 // by error code name
+}
+
+// MetricsSnapshot holds the values of Metrics at a point in time. The
+// counters only grow.
+type MetricsSnapshot struct {
+	ConnectionsOpened int64
+	ConnectionsClosed int64
+
+	// The latencies of the prepares and of the execs and queries, which
+	// include the execs of batches and cover the execution, but not the
+	// fetching of the result rows.
+	Prepares Histogram
+	Execs    Histogram
+	Queries  Histogram
+
+	RowsFetched   int64
+	BytesSent     int64 // of the string and byte slice parameters
+	BytesReceived int64 // of the string and byte slice values of the rows
+
+	// Errors counts the failed connects, prepares, execs and queries by
+	// the name of their ErrorCode, e.g. UPDATE_CONFLICT, or OTHER for the
+	// errors which aren't NuoDB errors.
+	Errors map[string]int64
+}
+
+// Histogram is a latency histogram of Metrics.
+type Histogram struct {
+	Count   int64
+	Sum     time.Duration
+	Buckets []int64 // cumulative counts of the latencies up to LatencyBuckets
+}
+
+// Snapshot returns the current values of m.
+func (m *Metrics) Snapshot() MetricsSnapshot {
+	s := MetricsSnapshot{
+		ConnectionsOpened: atomic.LoadInt64(&m.connsOpened),
+		ConnectionsClosed: atomic.LoadInt64(&m.connsClosed),
+		Prepares:          m.prepares.snapshot(),
+		Execs:             m.execs.snapshot(),
+		Queries:           m.queries.snapshot(),
+		RowsFetched:       atomic.LoadInt64(&m.rowsFetched),
+		BytesSent:         atomic.LoadInt64(&m.bytesSent),
+		BytesReceived:     atomic.LoadInt64(&m.bytesReceived),
+	}
+	m.mu.Lock()
+	s.Errors = make(map[string]int64, len(m.errors))
+	for code, n := range m.errors {
+		s.Errors[code] = n
+	}
+	m.mu.Unlock()
+	return s
+}
+
+// histogram counts latencies in the buckets of LatencyBuckets.
+type histogram struct {
+	count, sum int64
+	buckets    [len(LatencyBuckets) + 1]int64 // not cumulative; the last one is +Inf
+}
+
+func (h *histogram) observe(d time.Duration) {
+	i := 0
+	for i < len(LatencyBuckets) && d > LatencyBuckets[i] {
+		i++
+	}
+	atomic.AddInt64(&h.buckets[i], 1)
+	atomic.AddInt64(&h.sum, int64(d))
+	atomic.AddInt64(&h.count, 1)
+}
+
+func (h *histogram) snapshot() Histogram {
+	s := Histogram{Count: atomic.LoadInt64(&h.count), Sum: time.Duration(atomic.LoadInt64(&h.sum)),
+		Buckets: make([]int64, len(LatencyBuckets))}
+	var n int64
+	for i := range s.Buckets {
+		n += atomic.LoadInt64(&h.buckets[i])
+		s.Buckets[i] = n
+	}
+	return s
+}
+
+// The operations of Metrics with a latency histogram.
+const (
+	metricPrepare = iota
+	metricExec
+	metricQuery
+)
+
+// observe records an operation which started at start, if m isn't nil.
+func (m *Metrics) observe(op int, start time.Time, err *error) {
+	if m == nil || *err == driver.ErrSkip {
+		return
+	}
+	d := time.Since(start)
+	switch op {
+	case metricPrepare:
+		m.prepares.observe(d)
+	case metricExec:
+		m.execs.observe(d)
+	case metricQuery:
+		m.queries.observe(d)
+	}
+	if *err != nil {
+		m.failed(*err)
+	}
+}
+
+// failed counts err by the name of its error code.
+func (m *Metrics) failed(err error) {
+	code := "OTHER"
+	var nerr *Error
+	if errors.As(err, &nerr) {
+		code = nerr.Code.Name()
+	}
+	m.mu.Lock()
+	if m.errors == nil {
+		m.errors = make(map[string]int64)
+	}
+	m.errors[code]++
+	m.mu.Unlock()
+}
+
+func (m *Metrics) opened() {
+	if m != nil {
+		atomic.AddInt64(&m.connsOpened, 1)
+	}
+}
+
+func (m *Metrics) closed() {
+	if m != nil {
+		atomic.AddInt64(&m.connsClosed, 1)
+	}
+}
+
+func (m *Metrics) sent(n int) {
+	if m != nil && n > 0 {
+		atomic.AddInt64(&m.bytesSent, int64(n))
+	}
+}
+
+func (m *Metrics) fetched(bytes int) {
+	if m != nil {
+		atomic.AddInt64(&m.rowsFetched, 1)
+		atomic.AddInt64(&m.bytesReceived, int64(bytes))
+	}
+}
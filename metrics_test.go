@@ -0,0 +1,93 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMetricsObserve(t *testing.T) {
+	m := &Metrics{}
+	start := time.Now()
+	var err error
+	m.observe(metricQuery, start.Add(-20*time.Millisecond), &err)
+	m.observe(metricQuery, start.Add(-time.Minute), &err)
+	err = &Error{Code: UpdateConflict, Message: "update conflict"}
+	m.observe(metricExec, start, &err)
+	err = errors.New("nuodb: parameter 1: unsupported type struct {}")
+	m.observe(metricExec, start, &err)
+	err = driver.ErrSkip
+	m.observe(metricPrepare, start, &err)
+	m.opened()
+	m.closed()
+	m.sent(5)
+	m.fetched(3)
+	m.fetched(0)
+
+	s := m.Snapshot()
+	if s.Queries.Count != 2 || s.Queries.Sum < time.Minute {
+		t.Fatalf("Unexpected query latencies %+v", s.Queries)
+	}
+	// 20ms is in the bucket of 25ms, 1 minute in none
+	if s.Queries.Buckets[1] != 0 || s.Queries.Buckets[2] != 1 || s.Queries.Buckets[len(LatencyBuckets)-1] != 1 {
+		t.Fatalf("Unexpected buckets %v", s.Queries.Buckets)
+	}
+	if s.Execs.Count != 2 || s.Prepares.Count != 0 {
+		t.Fatalf("Expected 2 execs and no prepares, got %d and %d", s.Execs.Count, s.Prepares.Count)
+	}
+	if s.Errors["UPDATE_CONFLICT"] != 1 || s.Errors["OTHER"] != 1 || len(s.Errors) != 2 {
+		t.Fatalf("Unexpected errors %v", s.Errors)
+	}
+	if s.ConnectionsOpened != 1 || s.ConnectionsClosed != 1 || s.BytesSent != 5 ||
+		s.RowsFetched != 2 || s.BytesReceived != 3 {
+		t.Fatalf("Unexpected counters %+v", s)
+	}
+
+	// the snapshot is a copy
+	s.Errors["OTHER"] = 7
+	if m.Snapshot().Errors["OTHER"] != 1 {
+		t.Fatal("Expected the errors to be copied")
+	}
+
+	// a connection without Metrics counts nothing
+	var none *Metrics
+	none.observe(metricExec, start, &err)
+	none.opened()
+	none.sent(1)
+	none.fetched(1)
+}
+
+func TestMetrics(t *testing.T) {
+	cfg, err := ParseDSN(default_dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Metrics = &Metrics{}
+	connector, err := NewConnector(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := sql.OpenDB(connector)
+	var s string
+	if err := db.QueryRow("SELECT 'abc' FROM DUAL").Scan(&s); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("SELECT * FROM no_such_table"); err == nil {
+		t.Fatal("Expected an error")
+	}
+	db.Close()
+	m := cfg.Metrics.Snapshot()
+	if m.ConnectionsOpened != 1 || m.ConnectionsClosed != 1 {
+		t.Fatalf("Expected a connection opened and closed, got %+v", m)
+	}
+	if m.Queries.Count != 1 || m.RowsFetched != 1 || m.BytesReceived != 3 {
+		t.Fatalf("Unexpected query metrics %+v", m)
+	}
+	if m.Errors["NO_SUCH_TABLE"] != 1 {
+		t.Fatalf("Expected the error counted by its code, got %v", m.Errors)
+	}
+}
@@ -0,0 +1,14 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+//go:build !go1.20
+// +build !go1.20
+
+package nuodb
+
+import "context"
+
+// contextError returns the error of a done ctx. Before go1.20 there are no
+// cancellation causes.
+func contextError(ctx context.Context) error {
+	return ctx.Err()
+}
@@ -0,0 +1,32 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Node is a NuoDB engine process, as listed in SYSTEM.NODES.
+type Node struct {
+	ID      int
+	Address string
+	Port    int
+}
+
+// TxNode returns the Transaction Engine which serves tx.
+//
+// A NuoDB connection is bound to the single TE which the broker assigned it
+// to, and a sql.Tx runs all its statements on one connection, so every
+// statement of a transaction runs on the same TE. No option is needed to
+// pin them. TxNode makes the pinning observable, e.g. to tell which TEs
+// served the transactions involved in an anomaly between nodes.
+func TxNode(ctx context.Context, tx *sql.Tx) (*Node, error) {
+	n := &Node{}
+	err := tx.QueryRowContext(ctx, `SELECT ID, ADDRESS, PORT FROM SYSTEM.NODES
+		WHERE ID = GETNODEID()`).Scan(&n.ID, &n.Address, &n.Port)
+	if err != nil {
+		return nil, err
+	}
+	return n, nil
+}
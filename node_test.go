@@ -0,0 +1,36 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTxNode(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	first, err := TxNode(ctx, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.ID <= 0 || first.Address == "" || first.Port <= 0 {
+		t.Fatalf("Unexpected node: %+v", first)
+	}
+	if _, err := tx.ExecContext(ctx, "SELECT 1 FROM DUAL"); err != nil {
+		t.Fatal(err)
+	}
+	second, err := TxNode(ctx, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *second != *first {
+		t.Fatalf("Expected the transaction to stay on %+v, got %+v", first, second)
+	}
+}
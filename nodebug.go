@@ -0,0 +1,8 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+//go:build !nuodb_debug
+// +build !nuodb_debug
+
+package nuodb
+
+const debug = false
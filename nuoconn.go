@@ -0,0 +1,150 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+// #include "cnuodb.h"
+import "C"
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+)
+
+// NuoConn is the driver connection of sql.Conn.Raw, through which tooling
+// can inspect a pooled connection, e.g. to tell which TE and server
+// version serve it:
+//
+//	err := conn.Raw(func(driverConn interface{}) error {
+//		nc, ok := driverConn.(nuodb.NuoConn)
+//		if !ok {
+//			return errors.New("not a nuodb connection")
+//		}
+//		node, err := nc.ConnectedNode()
+//		...
+//	})
+//
+// The methods must only be called within Raw, like those of the
+// connection.
+type NuoConn interface {
+	// ServerVersion returns the version of the NuoDB server.
+	ServerVersion() (Version, error)
+
+	// ClientVersion returns the version of the NuoDB client library.
+	ClientVersion() Version
+
+	// ConnectionID returns the server side id of the connection,
+	// GETCONNECTIONID(), or 0 if unknown.
+	ConnectionID() int64
+
+	// ConnectedNode returns the Transaction Engine which the connection
+	// is bound to.
+	ConnectedNode() (*Node, error)
+
+	// CommitInfo returns the commit info of the last commit on the
+	// connection, for WithCommitInfo.
+	CommitInfo() (CommitInfo, error)
+
+	// AutoCommit reports whether the connection is in autocommit mode,
+	// i.e. outside of a transaction.
+	AutoCommit() (bool, error)
+
+	// Properties returns a copy of the connection properties passed to
+	// NuoDB, without the passwords.
+	Properties() map[string]string
+
+	// Security describes the TLS and the certificates the connection was
+	// opened with.
+	Security() (*SecurityInfo, error)
+
+	// Warnings returns the warnings of the last statement executed on the
+	// connection.
+	Warnings() []Warning
+
+	// SetSessionProperty changes a property of the session until the
+	// connection is returned to the pool.
+	SetSessionProperty(ctx context.Context, name, value string) error
+
+	// GetSessionProperty returns the current value of a property of the
+	// session.
+	GetSessionProperty(ctx context.Context, name string) (string, error)
+}
+
+var _ NuoConn = (*Conn)(nil)
+
+// ServerVersion implements NuoConn.
+func (c *Conn) ServerVersion() (Version, error) {
+	if c == nil || c.db == nil {
+		return Version{}, errUninitialized
+	}
+	var version *C.char
+	if err := c.cgo(func() C.int { return C.nuodb_server_version(c.db, &version) }); err != nil {
+		return Version{}, err
+	}
+	return ParseVersion(C.GoString(version)), nil
+}
+
+// ClientVersion implements NuoConn.
+func (c *Conn) ClientVersion() Version {
+	return c.clientVersion
+}
+
+// ConnectionID implements NuoConn.
+func (c *Conn) ConnectionID() int64 {
+	return c.sessionID
+}
+
+// ConnectedNode implements NuoConn.
+func (c *Conn) ConnectedNode() (*Node, error) {
+	if c == nil || c.db == nil {
+		return nil, errUninitialized
+	}
+	rows, err := c.QueryContext(context.Background(), `SELECT ID, ADDRESS, PORT FROM SYSTEM.NODES
+		WHERE ID = GETNODEID()`, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	dest := make([]driver.Value, 3)
+	if err := rows.Next(dest); err == io.EOF {
+		return nil, errors.New("nuodb: the connected node is not listed in SYSTEM.NODES")
+	} else if err != nil {
+		return nil, err
+	}
+	id, _ := dest[0].(int64)
+	port, _ := dest[2].(int64)
+	return &Node{ID: int(id), Address: valueString(dest[1]), Port: int(port)}, nil
+}
+
+// AutoCommit implements NuoConn.
+func (c *Conn) AutoCommit() (bool, error) {
+	if c == nil || c.txc == nil {
+		return false, errUninitialized
+	}
+	if c.inTx {
+		return false, nil // suspended by START TRANSACTION
+	}
+	return c.txc.autoCommit()
+}
+
+// Properties implements NuoConn.
+func (c *Conn) Properties() map[string]string {
+	props := make(map[string]string, len(c.props))
+	for k, v := range c.props {
+		props[k] = v
+	}
+	return props
+}
+
+// publicProps returns a copy of props without the passwords.
+func publicProps(props map[string]string) map[string]string {
+	public := make(map[string]string, len(props))
+	for k, v := range props {
+		if strings.Contains(strings.ToLower(k), "password") { // e.g. trustStorePassword
+			continue
+		}
+		public[k] = v
+	}
+	return public
+}
@@ -0,0 +1,64 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestPublicProps(t *testing.T) {
+	props := map[string]string{"schema": "tests", "trustStorePassword": "secret", "clientInfo": "app"}
+	expected := map[string]string{"schema": "tests", "clientInfo": "app"}
+	if public := publicProps(props); !reflect.DeepEqual(public, expected) {
+		t.Fatalf("Expected %v, got %v", expected, public)
+	}
+}
+
+func TestNuoConn(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	err = conn.Raw(func(driverConn interface{}) error {
+		nc, ok := driverConn.(NuoConn)
+		if !ok {
+			t.Fatalf("Unexpected connection %T", driverConn)
+		}
+		v, err := nc.ServerVersion()
+		if err != nil {
+			return err
+		}
+		if !v.Known() {
+			t.Errorf("Unknown server version %s", v)
+		}
+		if nc.ConnectionID() == 0 {
+			t.Error("Expected a connection id")
+		}
+		node, err := nc.ConnectedNode()
+		if err != nil {
+			return err
+		}
+		if node.ID == 0 || node.Address == "" {
+			t.Errorf("Unexpected node %+v", node)
+		}
+		if autoCommit, err := nc.AutoCommit(); err != nil || !autoCommit {
+			t.Errorf("Expected autocommit, got %v (%v)", autoCommit, err)
+		}
+		if warnings := nc.Warnings(); warnings != nil {
+			t.Errorf("Unexpected warnings %v", warnings)
+		}
+		if props := nc.Properties(); props["timezone"] != "America/Los_Angeles" {
+			t.Errorf("Unexpected properties %v", props)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
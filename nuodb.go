@@ -2,8 +2,6 @@
 
 package nuodb
 
-// #cgo CPPFLAGS: -I/opt/nuodb/include
-// #cgo LDFLAGS: -L. -lcnuodb -L/opt/nuodb/lib64/ -lNuoRemote
 // #include "cnuodb.h"
 // #include <stdlib.h>
 import "C"
@@ -14,31 +12,137 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"net/url"
-	"path"
-	"regexp"
+	"math/rand"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 	"unsafe"
+
+	"github.com/tilinna/go-nuodb/internal/parse"
 )
 
 type nuodbDriver struct{}
 
+var _ driver.DriverContext = (*nuodbDriver)(nil)
+
 type Conn struct {
-	db  *C.struct_nuodb
-	loc *time.Location
+	db        *C.struct_nuodb
+	callMu    sync.Mutex     // held by the calls into the client library on db, see guard.go
+	txc       txControl      // transaction calls, nil once closed
+	loc       *time.Location // time zone of the session
+	scanLoc   *time.Location // of the scanned times, if not loc
+	opts      callOptions    // per-call options collected by CheckNamedValue
+	schema    string         // default schema from the dsn
+	isolation C.int          // transaction isolation level after open
+	readOnly  bool           // the session is read-only, by the readOnly property
+
+	schemaChanged bool   // a statement may have changed the current schema
+	ddlCount      uint64 // of the DDL statements, invalidating the result columns of the statements
+	roleAssumed   bool   // AssumeRole may have changed the enabled roles
+	bad           bool   // a fatal error has occurred on the connection
+	draining      bool   // the TE of the connection is shutting down, see drain.go
+
+	sessionContext SessionContext // currently applied session context
+	commitInfo     CommitInfo     // last applied commit info of WithCommitInfo
+
+	id        uint64            // local number of the connection, for logs
+	sessionID int64             // server side id of the connection, GETCONNECTIONID(); 0 if unknown
+	props     map[string]string // connection properties, without the passwords
+
+	clientVersion   Version       // version of the NuoDB client library
+	maxQueryTimeout time.Duration // limit for context derived statement timeouts
+	timeoutLimit    time.Duration // maxQueryTimeout, if it cut the current statement timeout
+	defaultTimeout  time.Duration // timeout of the operations without a context deadline
+	timeoutDefault  time.Duration // defaultTimeout, if it is the current statement timeout
+
+	maxRows       int           // of a result set, by the maxRows property; 0 if unlimited
+	maxColumnSize int           // of a value, by the maxColumnBytes property; 0 if unlimited
+	truncateLarge bool          // cut the values over maxColumnSize, by the truncateColumns property
+	retryAttempts int           // attempts of a conflicting statement in autocommit mode
+	retryBackoff  time.Duration // delay before the first retry
+	inTx          bool          // a transaction is open, so statements are not retried
+	manualCommit  bool          // autocommit is off for the session, by the autocommit property
+	uncommitted   bool          // statements with manualCommit wait for a COMMIT or ROLLBACK
+	noAutoCommit  bool          // autocommit turned off by SetSessionProperty until the session is reset
+	deferClose    bool          // queue the closes of the statements and result sets, by the deferClose property
+	closes        closeQueue    // of the statements and result sets, with deferClose
+
+	maxTxDuration time.Duration        // after which a transaction is rolled back; 0 if unlimited
+	tx            *Tx                  // the open transaction, if any
+	watch         *txWatch             // of the open transaction, if it has a maximum duration or a cancellable context
+	onTxExpired   func(TxExpiredEvent) // called after an expired transaction is rolled back, if set
+	onDrain       func(DrainEvent)     // called when the connection starts draining, if set
+
+	longTxThreshold time.Duration     // after which an open transaction is reported; 0 if never
+	onLongTx        func(LongTxEvent) // called to report a long transaction, if set
+
+	keepaliveInterval time.Duration // of the pings while idle in the pool; 0 if not pinged
+	keeper            idleKeeper
+
+	masks   *MaskRules        // applied to the result rows, if any
+	limiter *StatementLimiter // of the concurrently executing statements, if any
+	faults  *FaultInjector    // of the statements, if any
+	meta    *metadataCache    // of the Connector, if any
+	hooks   Hooks             // of the Connector, if any
+
+	logger        Logger        // of the Connector, if any
+	slowThreshold time.Duration // of the statements logged as slow; 0 if none
+
+	annotateErrors ErrorAnnotation // of the Connector
+
+	reconnect ReconnectPolicy // of the Connector
+	leaks     LeakDetection   // of the Connector
+	used      bool            // called since the pool handed the connection out
+	firstCall bool            // the call in progress is the first such
+
+	route        lbRoute // of the TEs of the connection, by LBQuery and LBTag
+	defaultRoute lbRoute // of the Connector, which the context of a call may override
+
+	tracer   Tracer // of the Connector, if any
+	database string // name of the database, for the spans
+	node     *Node  // connected TE, for the spans; nil if unknown
+
+	metrics *Metrics        // of the Connector, if any
+	stats   *connectorStats // of the Connector, if any
+
+	searchPath []string          // schemas of the unqualified table names, if any
+	tables     map[string]string // schemas of the tables of searchPath by upper case name; nil if unknown
+
+	emptyStringAsNull bool // the empty string parameters are bound as NULL
+	nullStringAsEmpty bool // the NULLs of the character columns are scanned as empty strings
 }
 
 type Stmt struct {
 	c              *Conn
+	sql            string
 	st             *C.struct_nuodb_statement
 	parameterCount C.int
 	ddlStatement   bool
+	schemaChange   bool           // a DDL statement which may change a table
+	lobs           []*lobParam    // bound lobs, released on the next bind or close
+	call           bool           // a stored procedure call, which may have outs
+	names          []string       // names of the placeholders, if named
+	psql           string         // prepared sql, with ? markers
+	outs           []outParam     // bound output parameters
+	columns        *resultColumns // of the result sets, cached by the first query
+	cursor         Cursor         // of the result sets, if not the default
+	keys           []string       // columns of the generated keys, if named
 }
 
+var _ interface {
+	driver.Conn
+	driver.ExecerContext
+	driver.QueryerContext
+	driver.SessionResetter
+	driver.Validator
+	driver.ConnPrepareContext
+} = (*Conn)(nil)
+
 var _ interface {
 	driver.Stmt
 	driver.StmtQueryContext
-	// driver.StmtExecContext
+	driver.StmtExecContext
 } = (*Stmt)(nil)
 
 type Result struct {
@@ -48,69 +152,147 @@ type Result struct {
 
 type Rows struct {
 	c           *Conn
+	loc         *time.Location            // of the scanned times
+	st          *C.struct_nuodb_statement // owned statement of a direct query, if any
 	rs          *C.struct_nuodb_resultset
+	query       *C.struct_nuodb_statement // producing rs, cancelled by Close if rows are left unread
 	rowValues   []C.struct_nuodb_value
 	columnNames []string
+	types       []columnType   // fetched on demand
+	columns     *resultColumns // cache of the statement, if any
+	streamLobs  bool
+	scrollable  bool                      // by the Cursor option, so fetched one row at a time
+	row         uint64                    // number of the current row, for invalidating lobs
+	call        *C.struct_nuodb_statement // statement of a procedure call, for the next result sets
+	maskers     []Masker                  // of the columns, if any is masked
+	progress    *progressState            // of the FetchProgress option, if any
+	batch       rowBatch                  // rows fetched ahead, unless lobs are streamed
+	buffer      *rowBuffer                // of the byte values with ReuseBuffers, pooled
+	peeked      []driver.Value            // the next row, held by Peek
+	sql         string                    // of the query, if it may be logged as slow
+	started     time.Time                 // of the query, if it may be logged as slow
+	span        Span                      // of the iteration over the rows, if traced
+	peekErr     error                     // of fetching the next row by Peek
+	emptyNulls  []bool                    // the character columns, with nullStringAsEmpty
+	maxRows     int                       // limit of the rows of each result set; 0 if unlimited
+	count       int                       // rows returned of the current result set, for maxRows
+	columnLimit int                       // size limit of the values; 0 if unlimited
+	truncate    bool                      // cut the values over columnLimit instead of failing
+	truncated   bool                      // a value of the last row fetched was cut
+	closed      bool                      // by Close, which may race with the other calls
 }
 
-type Tx struct {
-	c          *Conn
-	autoCommit C.int
+// rowBatch holds the rows fetched ahead of Rows.Next, to cut the number of
+// cgo calls.
+type rowBatch struct {
+	values      []C.struct_nuodb_value // count rows of column count values
+	buffer      unsafe.Pointer         // C allocation of the data of the byte values
+	count, next int
+	done        bool  // the result set is exhausted
+	err         error // of fetching the rows after count
 }
 
-var errUninitialized = errors.New("nuodb: uninitialized connection")
-var errClosed = errors.New("nuodb: connection is closed")
+func (b *rowBatch) free() {
+	if b.buffer != nil {
+		C.free(b.buffer)
+		b.buffer = nil
+		b.values, b.count, b.next = nil, 0, 0
+	}
+}
 
-var dmlStatementRegexp = regexp.MustCompile(`^\s*(?i:DELETE|EXPLAIN|INSERT|REPLACE|SELECT|TRUNCATE|UPDATE)\s+`)
+// Rows fetched per cgo call and the size of the buffer for their data. A
+// row whose data doesn't fit in the buffer ends the batch.
+const (
+	fetchBatchRows   = 64
+	fetchBatchBuffer = 64 << 10
+)
 
-func ddlStatement(sql string) bool {
-	return !dmlStatementRegexp.MatchString(sql)
+type Tx struct {
+	c              *Conn
+	sessionContext SessionContext // applied when the Tx began, again after its rollback
 }
 
+var errUninitialized = errors.New("nuodb: uninitialized connection")
+var errClosed = errors.New("nuodb: connection is closed")
+var errUncommitted = errors.New("nuodb: uncommitted statements with autocommit=false, COMMIT or ROLLBACK them before beginning a transaction")
+
 func init() {
 	sql.Register("nuodb", &nuodbDriver{})
 }
 
-func (d *nuodbDriver) Open(dsn string) (conn driver.Conn, err error) {
-	var url *url.URL
-	if url, err = url.Parse(dsn); err == nil {
-		if url.Scheme == "nuodb" && url.User != nil {
-			database := fmt.Sprintf("%s@%s", path.Base(url.Path), url.Host)
-			username := url.User.Username()
-			password, _ := url.User.Password()
+func (d *nuodbDriver) Open(dsn string) (driver.Conn, error) {
+	parsed, err := parse.ParseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return newConn(parsed)
+}
 
-			query := url.Query()
-			props := make(map[string]string, len(query))
-			for key := range query {
-				props[key] = query.Get(key) // Get the first value for the key
-			}
+// OpenConnector implements driver.DriverContext. The data source name is
+// parsed once, rather than whenever a connection is opened.
+func (d *nuodbDriver) OpenConnector(dsn string) (driver.Connector, error) {
+	parsed, err := parse.ParseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &Connector{dsn: parsed}, nil
+}
 
-			conn, err = newConn(database, username, password, props)
-		} else {
-			err = fmt.Errorf("nuodb: invalid dsn: %s", dsn)
-		}
+// newConn opens a connection through the first broker of dsn which is
+// reachable. The brokers are tried in the listed order, or in a random
+// order with the randomizeHosts property, so that a broker being down, e.g.
+// during a rolling upgrade, doesn't fail the connection. Only network and
+// connection errors fail over to the next broker.
+func newConn(dsn *parse.DSN) (*Conn, error) {
+	name, hosts := parse.SplitDatabase(dsn.Database)
+	if dsn.RandomizeHosts {
+		rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+		rnd.Shuffle(len(hosts), func(i, j int) { hosts[i], hosts[j] = hosts[j], hosts[i] })
 	}
-	return
+	return failover(hosts, func(host string) (*Conn, error) {
+		return openConn(dsn, name+"@"+host)
+	})
 }
 
-func newConn(database, username, password string, props map[string]string) (*Conn, error) {
-	location := props["timezone"]
-	if location == "" {
-		location = "Local"
+// failover calls open with the hosts in order until a connection is opened
+// or it fails with an error which isn't a fatal connection error.
+func failover(hosts []string, open func(host string) (*Conn, error)) (*Conn, error) {
+	var err error
+	for _, host := range hosts {
+		var c *Conn
+		if c, err = open(host); err == nil || !errors.Is(err, driver.ErrBadConn) {
+			return c, err
+		}
 	}
-	loc, err := time.LoadLocation(location)
-	if err != nil {
-		return nil, fmt.Errorf("nuodb: %s", err)
+	if len(hosts) > 1 {
+		return nil, fmt.Errorf("nuodb: no broker of %s is reachable: %w", strings.Join(hosts, ","), err)
 	}
-	c := &Conn{loc: loc}
+	return nil, err
+}
+
+// connCounter numbers the connections.
+var connCounter uint64
+
+// openConn opens a connection to database, a name@broker_address.
+func openConn(dsn *parse.DSN, database string) (*Conn, error) {
+	c := &Conn{loc: dsn.Location, schema: dsn.Props["schema"], maxQueryTimeout: dsn.MaxQueryTimeout,
+		retryAttempts: dsn.RetryAttempts, retryBackoff: dsn.RetryBackoff, id: atomic.AddUint64(&connCounter, 1),
+		searchPath: dsn.SearchPath, defaultTimeout: dsn.DefaultTimeout,
+		maxTxDuration: dsn.MaxTxDuration, keepaliveInterval: dsn.KeepaliveInterval,
+		emptyStringAsNull: dsn.EmptyStringAsNull,
+		nullStringAsEmpty: dsn.NullStringAsEmpty, readOnly: dsn.ReadOnly, manualCommit: dsn.NoAutoCommit, maxRows: dsn.MaxRows,
+		maxColumnSize: dsn.MaxColumnBytes, truncateLarge: dsn.TruncateColumns, deferClose: dsn.DeferClose}
+	c.txc = clientTx{c}
 	C.nuodb_init(&c.db)
 	cdatabase := C.CString(database)
 	defer C.free(unsafe.Pointer(cdatabase))
-	cusername := C.CString(username)
+	cusername := C.CString(dsn.Username)
 	defer C.free(unsafe.Pointer(cusername))
-	cpassword := C.CString(password)
+	cpassword := C.CString(dsn.Password)
 	defer C.free(unsafe.Pointer(cpassword))
 
+	props := withSessionTimeZone(clientProps(dsn.Props), dsn.Location)
+	c.props = publicProps(props)
 	cprops := make([]*C.char, 2*len(props))
 	i := 0
 	for k, v := range props {
@@ -128,276 +310,1343 @@ func newConn(database, username, password string, props map[string]string) (*Con
 	if len(cprops) > 0 {
 		cpropsPtr = (**C.char)(unsafe.Pointer(&cprops[0]))
 	}
+	ldap := dsn.Props[parse.PropAuthentication] == parse.AuthLDAP
 	if rc := C.nuodb_open(c.db, cdatabase, cusername, cpassword, cpropsPtr, C.int(len(cprops))); rc != 0 {
+		lastError := c.lastError(rc)
+		C.nuodb_close(&c.db)
+		if ldap {
+			return nil, fmt.Errorf("nuodb: LDAP login of %s failed; it requires NuoDB 4.0 or later and "+
+				"a server configured with an LDAP directory: %w", dsn.Username, lastError)
+		}
+		return nil, lastError
+	}
+	if rc := C.nuodb_isolation(c.db, &c.isolation); rc != 0 {
+		lastError := c.lastError(rc)
+		C.nuodb_close(&c.db)
+		return nil, lastError
+	}
+	if c.readOnly {
+		if err := c.txc.setReadOnly(true); err != nil {
+			C.nuodb_close(&c.db)
+			return nil, err
+		}
+	}
+	if c.manualCommit {
+		if rc := C.nuodb_autocommit_set(c.db, 0); rc != 0 {
+			lastError := c.lastError(rc)
+			C.nuodb_close(&c.db)
+			return nil, lastError
+		}
+	}
+	var version *C.char
+	if rc := C.nuodb_client_version(c.db, &version); rc != 0 {
 		lastError := c.lastError(rc)
 		C.nuodb_close(&c.db)
 		return nil, lastError
 	}
+	c.clientVersion = ParseVersion(C.GoString(version))
+	if ldap {
+		if err := c.require(featureLDAP); err != nil {
+			C.nuodb_close(&c.db)
+			return nil, err
+		}
+	}
+	var sessionID C.int64_t
+	if rc := C.nuodb_session_id(c.db, &sessionID); rc == 0 {
+		c.sessionID = int64(sessionID)
+	}
+	if err := c.verifySession(dsn); err != nil {
+		C.nuodb_close(&c.db)
+		return nil, err
+	}
+	if err := c.initSession(dsn.OnConnect); err != nil {
+		C.nuodb_close(&c.db)
+		return nil, err
+	}
+	if err := c.loadSearchPathTables(); err != nil {
+		C.nuodb_close(&c.db)
+		return nil, err
+	}
 	return c, nil
 }
 
+// verifySession checks that the isolation and schema properties of dsn took
+// effect, so that a connection which NuoDB opened regardless of them isn't
+// handed to the pool. The timezone is applied by the driver, and passed to
+// the server as the TimeZone of the session.
+func (c *Conn) verifySession(dsn *parse.DSN) error {
+	if v, ok := dsn.Props[parse.PropIsolation]; ok {
+		level, ok := parse.IsolationLevel(v)
+		if !ok {
+			return fmt.Errorf("nuodb: invalid %s: %s", parse.PropIsolation, v)
+		}
+		if c.isolation != C.int(level) {
+			return fmt.Errorf("nuodb: %s %s didn't take effect, the level is %d", parse.PropIsolation, v, c.isolation)
+		}
+	}
+	if c.schema == "" {
+		return nil
+	}
+	schema, err := c.currentSchema()
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(schema, strings.Trim(c.schema, `"`)) {
+		return fmt.Errorf("nuodb: schema %s didn't take effect, the schema is %s", c.schema, schema)
+	}
+	return nil
+}
+
+// initSession executes the onConnect statements of a new connection. The
+// session they leave is the one which ResetSession restores: a schema they
+// change to becomes the default schema, and an isolation level they set
+// the default isolation.
+func (c *Conn) initSession(statements []string) error {
+	if len(statements) == 0 {
+		return nil
+	}
+	for i, sql := range statements {
+		if _, err := c.exec(context.Background(), sql, nil); err != nil {
+			return fmt.Errorf("nuodb: %s statement %d: %w", parse.PropOnConnect, i+1, err)
+		}
+	}
+	if rc := C.nuodb_isolation(c.db, &c.isolation); rc != 0 {
+		return c.lastError(rc)
+	}
+	if c.schemaChanged {
+		schema, err := c.currentSchema()
+		if err != nil {
+			return err
+		}
+		c.schema = quoteIdentifier(schema)
+		c.schemaChanged = false
+	}
+	return nil
+}
+
+// currentSchema returns the current schema of the session.
+func (c *Conn) currentSchema() (string, error) {
+	rows, err := c.QueryContext(context.Background(), "SELECT CURRENT_SCHEMA FROM DUAL", nil)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+	dest := make([]driver.Value, 1)
+	if err := rows.Next(dest); err != nil {
+		return "", err
+	}
+	return valueString(dest[0]), nil
+}
+
+func valueString(v driver.Value) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	}
+	return ""
+}
+
 func (c *Conn) lastError(sqlCode C.int) error {
 	if c == nil || c.db == nil {
 		return errUninitialized
 	}
-	return &Error{
-		Code:    ErrorCode(sqlCode),
-		Message: C.GoString(C.nuodb_error(c.db)),
+	if sqlCode == C.NUODB_PARAMETER_COUNT_MISMATCH {
+		return fmt.Errorf("nuodb: %s", C.GoString(C.nuodb_error(c.db)))
 	}
+	err := &Error{
+		Code:     ErrorCode(sqlCode),
+		Message:  C.GoString(C.nuodb_error(c.db)),
+		SQLState: C.GoString(C.nuodb_sqlstate(c.db)),
+		Conn:     c.id,
+		Session:  c.sessionID,
+	}
+	if fatalErrorCodes[err.Code] {
+		c.fatal(err, c.firstCall)
+		c.log(LevelError, "connection broken", "code", err.Code.Name(), "error", err.Message)
+	}
+	if drainErrorCodes[err.Code] {
+		c.drain(err)
+	}
+	if err.Code == NoSuchTable || err.Code == InvalidField {
+		c.meta.invalidate() // the table was changed elsewhere
+		c.ddlCount++
+	}
+	if err.Code == OperationTimeout {
+		err.TimeoutLimit = c.timeoutLimit
+		err.DefaultTimeout = c.timeoutDefault
+	}
+	return err
 }
 
-func (c *Conn) Prepare(sql string) (driver.Stmt, error) {
+// IsValid implements driver.Validator. A connection which has seen a fatal
+// error, e.g. a network error or a shut down TE, or which is draining, is
+// not reused by the pool.
+// It is called when the connection is returned to the pool, so it starts
+// the pings of the keepaliveInterval property.
+func (c *Conn) IsValid() bool {
 	if c == nil || c.db == nil {
-		return nil, errUninitialized
+		return false
 	}
-	csql := C.CString(sql)
-	defer C.free(unsafe.Pointer(csql))
-	stmt := &Stmt{c: c}
-	if rc := C.nuodb_statement_prepare(c.db, csql, &stmt.st, &stmt.parameterCount); rc != 0 {
-		return nil, c.lastError(rc)
+	if c.bad || c.draining {
+		c.stats.discarded()
+		return false
 	}
-	stmt.ddlStatement = ddlStatement(sql)
-	return stmt, nil
+	if c.keepaliveInterval > 0 {
+		c.startKeepalive()
+	}
+	return true
 }
 
-func (c *Conn) Begin() (driver.Tx, error) {
+// PrepareContext implements driver.ConnPrepareContext, so that the span of
+// a Tracer has the context of the caller. Preparing a statement can't be
+// interrupted, so the context is only checked before preparing.
+func (c *Conn) PrepareContext(ctx context.Context, sql string) (_ driver.Stmt, err error) {
 	if c == nil || c.db == nil {
 		return nil, errUninitialized
 	}
-	tx := &Tx{c: c}
-	// TODO: should use "START TRANSACTION"
-	if rc1 := C.nuodb_autocommit(c.db, &tx.autoCommit); rc1 != 0 {
-		return nil, c.lastError(rc1)
-	} else if rc2 := C.nuodb_autocommit_set(c.db, 0); rc2 != 0 {
-		return nil, c.lastError(rc2)
+	if ctx.Err() != nil {
+		return nil, contextError(ctx)
 	}
-	return tx, nil
+	defer c.traceStatement(ctx, SpanPrepare, sql)(&err)
+	defer c.annotateError(sql, nil, &err)
+	o := queryOptionsFrom(ctx)
+	return c.prepare(sql, o.tag, Cursor{}, o.generatedKeys)
 }
 
-func (c *Conn) Exec(sql string, args []driver.Value) (driver.Result, error) {
-	if len(args) > 0 {
-		return nil, driver.ErrSkip
+func (c *Conn) Prepare(sql string) (driver.Stmt, error) {
+	if c == nil || c.db == nil {
+		return nil, errUninitialized
 	}
-	return c.ExecContext(context.Background(), sql, nil)
+	return c.prepare(sql, "", Cursor{}, nil)
 }
 
-func (c *Conn) ExecContext(ctx context.Context, sql string, args []driver.NamedValue) (driver.Result, error) {
-	if len(args) > 0 {
-		return nil, driver.ErrSkip
-	}
-	csql := C.CString(sql)
-	defer C.free(unsafe.Pointer(csql))
-	result := &Result{}
-
-	uSec, err := getMicrosecondsUntilDeadline(ctx)
+// prepare prepares sql, tagged with tag, with the cursor of its result
+// sets, unless it is a procedure call. The generated keys of the statement
+// are the keys columns, unless there are none.
+func (c *Conn) prepare(sql, tag string, cursor Cursor, keys []string) (_ *Stmt, err error) {
+	exit, err := c.enter()
 	if err != nil {
 		return nil, err
 	}
-
-	if rc := C.nuodb_execute(c.db, csql, &result.rowsAffected, &result.lastInsertId, uSec); rc != 0 {
-		return nil, c.lastError(rc)
+	defer exit()
+	if c.bad {
+		return nil, driver.ErrBadConn
 	}
-	if result.rowsAffected == 0 && ddlStatement(sql) {
-		return driver.ResultNoRows, nil
+	defer c.metrics.observe(metricPrepare, time.Now(), &err)
+	stmt := &Stmt{c: c, sql: sql, call: parse.CallStatement(sql), cursor: cursor, keys: keys}
+	psql, names := parse.NamedParameters(c.qualify(sql))
+	psql = parse.AppendTag(psql, tag)
+	stmt.names, stmt.psql = names, psql
+	if err := stmt.prepareHandle(&stmt.st); err != nil {
+		return nil, err
 	}
-	return result, nil
+	stmt.ddlStatement = parse.DDLStatement(sql)
+	stmt.schemaChange = schemaChange(sql)
+	if parse.SchemaStatement(sql) {
+		c.schemaChanged = true
+	}
+	c.stats.prepared()
+	c.trackLeak(stmt, sql)
+	return stmt, nil
 }
 
-func (c *Conn) Close() error {
-	if c != nil && c.db != nil {
-		if rc := C.nuodb_close(&c.db); rc != 0 {
-			// can't use lastError here
-			return fmt.Errorf("nuodb: conn close failed: %d", rc)
-		}
+// prepareHandle prepares the psql of stmt, with its cursor and generated
+// keys, into st.
+func (stmt *Stmt) prepareHandle(st **C.struct_nuodb_statement) error {
+	c := stmt.c
+	csql := C.CString(stmt.psql)
+	defer C.free(unsafe.Pointer(csql))
+	ckeys := make([]*C.char, len(stmt.keys))
+	for i, key := range stmt.keys {
+		ckeys[i] = C.CString(key)
+		defer C.free(unsafe.Pointer(ckeys[i]))
 	}
-	return nil
+	c.stats.call()
+	return c.cgo(func() C.int {
+		if stmt.call {
+			// a callable statement for the output parameters
+			return C.nuodb_statement_prepare_call(c.db, csql, st, &stmt.parameterCount)
+		}
+		if cursor := stmt.cursor; cursor != (Cursor{}) {
+			return C.nuodb_statement_prepare_cursor(c.db, csql, boolInt(cursor.Scrollable), boolInt(cursor.Holdable),
+				st, &stmt.parameterCount)
+		}
+		if len(ckeys) > 0 {
+			return C.nuodb_statement_prepare_keys(c.db, csql, &ckeys[0], C.int(len(ckeys)),
+				st, &stmt.parameterCount)
+		}
+		return C.nuodb_statement_prepare(c.db, csql, st, &stmt.parameterCount)
+	})
 }
 
-func (stmt *Stmt) NumInput() int {
-	return int(stmt.parameterCount)
+func (c *Conn) Begin() (driver.Tx, error) {
+	return c.begin(context.Background(), c.maxTxDuration, 0, false)
 }
 
-func (stmt *Stmt) bind(args []driver.Value) error {
-	c := stmt.c
-	parameterCount := int(stmt.parameterCount)
-	if parameterCount == 0 || len(args) == 0 {
-		return nil
+// begin begins a transaction which is rolled back after d, unless d is 0,
+// or when ctx is cancelled. The transaction has the isolation level, unless
+// it is 0 for the level of the connection, and may be readOnly, with START
+// TRANSACTION, so that the session is left as it is.
+func (c *Conn) begin(ctx context.Context, d time.Duration, level int, readOnly bool) (_ driver.Tx, err error) {
+	if c == nil || c.txc == nil {
+		return nil, errUninitialized
 	}
-	parameters := make([]C.struct_nuodb_value, parameterCount)
-	for i, v := range args {
-		if i >= parameterCount {
-			break // go1.0.3 allowed extra args; ignore
-		}
-		var vt C.enum_nuodb_value_type
-		var i32 C.int32_t
-		var i64 C.int64_t
-		switch v := v.(type) {
-		case int64:
-			vt = C.NUODB_TYPE_INT64
-			i64 = C.int64_t(v)
-		case float64:
-			vt = C.NUODB_TYPE_FLOAT64
-			i64 = *(*C.int64_t)(unsafe.Pointer(&v))
-		case bool:
-			vt = C.NUODB_TYPE_BOOL
-			if v {
-				i64 = 1
-			} else {
-				i64 = 0
-			}
-		case string:
-			vt = C.NUODB_TYPE_STRING
-			b := []byte(v)
-			args[i] = b // ensure the b is not GC'ed before the _bind
-			i32 = C.int32_t(len(v))
-			if len(b) > 0 {
-				i64 = C.int64_t(uintptr(unsafe.Pointer(&b[0])))
-			}
-		case []byte:
-			vt = C.NUODB_TYPE_BYTES
-			i32 = C.int32_t(len(v))
-			if len(v) > 0 {
-				i64 = C.int64_t(uintptr(unsafe.Pointer(&v[0])))
-			}
-		case time.Time:
-			vt = C.NUODB_TYPE_TIME
-			i32 = C.int32_t(v.Nanosecond())
-			i64 = C.int64_t(v.Unix()) // seconds
-		default:
-			vt = C.NUODB_TYPE_NULL
-		}
-		parameters[i].i64 = i64
-		parameters[i].i32 = i32
-		parameters[i].vt = vt
+	if c.bad {
+		return nil, driver.ErrBadConn
 	}
-	if rc := C.nuodb_statement_bind(c.db, stmt.st,
-		(*C.struct_nuodb_value)(unsafe.Pointer(&parameters[0]))); rc != 0 {
-		return c.lastError(rc)
+	if c.uncommitted {
+		return nil, errUncommitted
 	}
-	return nil
+	defer captureStatement(c, CaptureBegin, "", nil, time.Now(), &err)
+	if C.int(level) == c.isolation {
+		level = 0 // the level of the connection
+	}
+	if err = c.txc.begin(level, readOnly && !c.readOnly); err != nil {
+		return nil, err
+	}
+	tx := &Tx{c: c, sessionContext: c.sessionContext}
+	c.inTx = true
+	c.tx = tx
+	if d > 0 || ctx.Done() != nil || c.longTxThreshold > 0 {
+		c.watchTx(ctx, d)
+	}
+	return tx, nil
 }
 
-func (stmt *Stmt) Exec(args []driver.Value) (driver.Result, error) {
-	return stmt.execQuery(context.Background(), args)
+func (c *Conn) Exec(sql string, args []driver.Value) (driver.Result, error) {
+	return c.ExecContext(context.Background(), sql, valuesToNamedValues(args))
 }
 
-func (stmt *Stmt) ExecQuery(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
-	values, err := namedValuesToValues(args)
+// ExecContext executes sql in a single call into the client library, which
+// binds any parameters too. A statement with parameters is still prepared
+// on the server by the call, so it saves the cgo calls of a separate
+// prepare, bind and close rather than a round trip. The statement is
+// cancelled when ctx is.
+func (c *Conn) ExecContext(ctx context.Context, sql string, args []driver.NamedValue) (res driver.Result, err error) {
+	if c == nil || c.db == nil {
+		return nil, errUninitialized
+	}
+	exit, err := c.enter()
 	if err != nil {
 		return nil, err
 	}
-
-	return stmt.execQuery(ctx, values)
+	defer exit()
+	if c.bad {
+		return nil, driver.ErrBadConn
+	}
+	if hasOuts(args) {
+		return nil, driver.ErrSkip // prepared as a callable statement instead
+	}
+	if len(queryOptionsFrom(ctx).generatedKeys) > 0 {
+		return nil, driver.ErrSkip // prepared with the columns of the keys
+	}
+	defer observeStatement(sql, time.Now(), &err)
+	defer c.metrics.observe(metricExec, time.Now(), &err)
+	defer captureStatement(c, CaptureExec, sql, args, time.Now(), &err)
+	defer c.logSlowExec(sql, time.Now(), &res, &err)
+	defer c.beforeHooks(ctx, false, sql, args)(&err)
+	defer c.traceStatement(ctx, SpanExec, sql)(&err)
+	defer c.annotateError(sql, args, &err)
+	defer c.trackUncommitted(sql, &err)
+	c.takeOptions()
+	if err := c.applyContext(ctx); err != nil {
+		return nil, err
+	}
+	restore, err := c.enterReadOnly(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+	defer restore()
+	return c.exec(ctx, sql, args)
 }
 
-func (stmt *Stmt) execQuery(ctx context.Context, args []driver.Value) (driver.Result, error) {
-	var err error
-	c := stmt.c
-	if c.db == nil {
-		return nil, errClosed
+func (c *Conn) exec(ctx context.Context, sql string, args []driver.NamedValue) (driver.Result, error) {
+	if parse.SchemaStatement(sql) {
+		c.schemaChanged = true
 	}
-	if err = stmt.bind(args); err != nil {
-		return nil, fmt.Errorf("bind: %s", err)
+	sql, names := namedParameters(c.qualify(sql), args)
+	values, err := namedValuesToValues(args, names)
+	if err != nil {
+		return nil, err
+	}
+	sql, values = expandLists(sql, values)
+	lobs, err := c.writeLobs(values)
+	if err != nil {
+		return nil, err
 	}
-	if err = stmt.addTimeoutFromContext(ctx); err != nil {
+	defer releaseLobs(lobs)
+	parameters, err := c.encodeValues(values)
+	if err != nil {
 		return nil, err
 	}
+	defer parameters.free()
+	csql := C.CString(tag(ctx, sql))
+	defer C.free(unsafe.Pointer(csql))
 	result := &Result{}
-	if rc := C.nuodb_statement_execute(c.db, stmt.st, &result.rowsAffected, &result.lastInsertId); rc != 0 {
-		return nil, c.lastError(rc)
+
+	err = c.retry(ctx, func() error {
+		uSec, err := c.queryTimeout(ctx)
+		if err != nil {
+			return err
+		}
+		unlock, err := c.lock()
+		if err != nil {
+			return err
+		}
+		defer unlock()
+		cancelled := c.watchCancel(ctx)
+		c.stats.call()
+		rc := C.nuodb_execute(c.db, csql, parameters.ptr(), C.int(len(parameters.values)),
+			&result.rowsAffected, &result.lastInsertId, uSec)
+		if cancelled() && rc != 0 {
+			return contextError(ctx)
+		}
+		if rc != 0 {
+			return c.lastError(rc)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	if result.rowsAffected == 0 && stmt.ddlStatement {
-		return driver.ResultNoRows, err
+	if schemaChange(sql) {
+		c.meta.invalidate()
+		c.ddlCount++
 	}
-	return result, err
+	if result.rowsAffected == 0 && parse.DDLStatement(sql) {
+		return driver.ResultNoRows, nil
+	}
+	return result, nil
 }
 
-func (stmt *Stmt) Query(args []driver.Value) (driver.Rows, error) {
-	return stmt.queryContext(context.Background(), args)
+func (c *Conn) Query(sql string, args []driver.Value) (driver.Rows, error) {
+	return c.QueryContext(context.Background(), sql, valuesToNamedValues(args))
 }
 
-func (stmt *Stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
-	values, err := namedValuesToValues(args)
+// QueryContext prepares, binds and executes sql in a single call into the
+// client library, like ExecContext. The statement is closed together with
+// the returned rows, and cancelled when ctx is. For a statement which
+// returns no result set, e.g. an INSERT, the rows are the keys generated by
+// the statement, one row per inserted row.
+func (c *Conn) QueryContext(ctx context.Context, sql string, args []driver.NamedValue) (rs driver.Rows, err error) {
+	if c == nil || c.db == nil {
+		return nil, errUninitialized
+	}
+	exit, err := c.enter()
 	if err != nil {
 		return nil, err
 	}
-	return stmt.queryContext(ctx, values)
-}
-
-func (stmt *Stmt) queryContext(ctx context.Context, args []driver.Value) (driver.Rows, error) {
-	var err error
-	c := stmt.c
-	if c.db == nil {
-		return nil, errClosed
+	defer exit()
+	if c.bad {
+		return nil, driver.ErrBadConn
 	}
-	if err = stmt.bind(args); err != nil {
-		return nil, fmt.Errorf("bind: %s", err)
+	if hasOuts(args) {
+		return nil, driver.ErrSkip // prepared as a callable statement instead
 	}
-	if err = stmt.addTimeoutFromContext(ctx); err != nil {
+	if len(queryOptionsFrom(ctx).generatedKeys) > 0 {
+		return nil, driver.ErrSkip // prepared with the columns of the keys
+	}
+	if c.opts.cursor != (Cursor{}) {
+		return nil, driver.ErrSkip // prepared with the cursor
+	}
+	defer observeStatement(sql, time.Now(), &err)
+	defer c.metrics.observe(metricQuery, time.Now(), &err)
+	defer captureStatement(c, CaptureQuery, sql, args, time.Now(), &err)
+	defer c.logSlowQuery(sql, time.Now(), &rs, &err)
+	defer c.beforeHooks(ctx, true, sql, args)(&err)
+	defer c.traceQuery(ctx, sql)(&rs, &err)
+	defer c.annotateError(sql, args, &err)
+	defer c.trackUncommitted(sql, &err)
+	opts := c.takeOptions()
+	if err := c.applyContext(ctx); err != nil {
+		return nil, err
+	}
+	restore, err := c.enterReadOnly(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+	defer restore()
+	sql, names := namedParameters(c.qualify(sql), args)
+	values, err := namedValuesToValues(args, names)
+	if err != nil {
 		return nil, err
 	}
-	rows := &Rows{c: c}
+	sql, values = expandLists(sql, values)
+	lobs, err := c.writeLobs(values)
+	if err != nil {
+		return nil, err
+	}
+	defer releaseLobs(lobs)
+	parameters, err := c.encodeValues(values)
+	if err != nil {
+		return nil, err
+	}
+	defer parameters.free()
+	csql := C.CString(tag(ctx, sql))
+	defer C.free(unsafe.Pointer(csql))
+
+	rows := &Rows{c: c, loc: c.scanLocation(ctx), streamLobs: opts.streamLobs, progress: newProgressState(opts.progress)}
+	if opts.reuseBuffers {
+		rows.buffer = getRowBuffer()
+	}
+	var fetchSize int
+	rows.maxRows, fetchSize = c.rowLimit(ctx, opts.fetchSize)
+	rows.columnLimit, rows.truncate = c.columnLimit(ctx)
 	var columnCount C.int
-	if rc := C.nuodb_statement_query(c.db, stmt.st, &rows.rs, &columnCount); rc != 0 {
-		return nil, c.lastError(rc)
-	}
-	if columnCount > 0 {
-		cc := int(columnCount)
-		rows.rowValues = make([]C.struct_nuodb_value, cc)
-		if rc := C.nuodb_resultset_column_names(c.db, rows.rs,
-			(*C.struct_nuodb_value)(unsafe.Pointer(&rows.rowValues[0]))); rc != 0 {
-			return nil, c.lastError(rc)
-		}
-		rows.columnNames = make([]string, cc)
-		for i, value := range rows.rowValues {
-			if length := (C.int)(value.i32); length > 0 {
-				cstr := (*C.char)(unsafe.Pointer(uintptr(value.i64)))
-				rows.columnNames[i] = C.GoStringN(cstr, length)
-			}
+	err = c.retry(ctx, func() error {
+		uSec, err := c.queryTimeout(ctx)
+		if err != nil {
+			return err
+		}
+		unlock, err := c.lock()
+		if err != nil {
+			return err
+		}
+		defer unlock()
+		cancelled := c.watchCancel(ctx)
+		c.stats.call()
+		rc := C.nuodb_query(c.db, csql, parameters.ptr(), C.int(len(parameters.values)), C.int(fetchSize),
+			C.int(serverRows(rows.maxRows)), &rows.st, &rows.rs, &columnCount, uSec)
+		if cancelled() && rc != 0 {
+			return contextError(ctx)
 		}
+		if rc != 0 {
+			return c.lastError(rc)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	if parse.CallStatement(sql) {
+		rows.call = rows.st
+	} else {
+		rows.query = rows.st
+	}
+	if err := rows.fetchColumnNames(columnCount); err != nil {
+		rows.Close()
+		return nil, err
+	}
+	c.trackLeak(rows, sql)
 	return rows, nil
 }
 
-func (stmt *Stmt) addTimeoutFromContext(ctx context.Context) error {
-	uSec, err := getMicrosecondsUntilDeadline(ctx)
-	if err != nil {
-		return err
+// trackUncommitted records whether the statements executed with
+// autocommit=false wait for a COMMIT or ROLLBACK: a DML statement or a call
+// which succeeded leaves them pending, and a COMMIT or ROLLBACK ends them.
+func (c *Conn) trackUncommitted(sql string, err *error) {
+	if !c.manualCommit || c.inTx || *err != nil {
+		return
 	}
+	switch parse.Classify(sql) {
+	case parse.KindDML, parse.KindCall:
+		c.uncommitted = true
+	case parse.KindOther:
+		switch parse.LeadingKeyword(sql) {
+		case "COMMIT":
+			c.uncommitted = false
+		case "ROLLBACK":
+			c.uncommitted = false
+			c.sessionContext = nil // its storing may have been rolled back too
+		}
+	}
+}
 
-	C.nuodb_statement_set_query_micros(stmt.c.db, stmt.st, uSec)
-
+// ResetSession implements driver.SessionResetter. It rolls back any
+// transaction left open, ending the Tx, restores autocommit, the transaction
+// isolation and the default schema before the connection is reused from the
+// pool. The schema is only restored if a USE or SET SCHEMA statement has
+// been executed on the connection. A connection routed to other TEs than
+// WithLBQuery or WithLBTag of ctx ask for is discarded, so that the pool
+// connects one routed as asked. So is a connection with uncommitted
+// statements of autocommit=false, which are rolled back by closing it,
+// rather than quietly by the reset.
+func (c *Conn) ResetSession(ctx context.Context) (err error) {
+	if c == nil || c.db == nil {
+		return driver.ErrBadConn
+	}
+	defer func() {
+		if err != nil {
+			c.stats.discarded()
+		}
+	}()
+	if c.uncommitted {
+		c.log(LevelWarn, "discarding connection with uncommitted statements")
+		return driver.ErrBadConn
+	}
+	if c.keepaliveInterval > 0 {
+		c.stopKeepalive()
+	}
+	c.flushCloses()
+	if err := c.endOpenTx(); err != nil {
+		return driver.ErrBadConn
+	}
+	if c.defaultRoute.route(ctx) != c.route {
+		return driver.ErrBadConn // on other TEs than the call asks for
+	}
+	if c.bad || c.draining {
+		return driver.ErrBadConn // e.g. a failed keepalive ping
+	}
+	c.opts = callOptions{}
+	c.stats.call()
+	if err := c.cgo(func() C.int {
+		return C.nuodb_reset(c.db, c.isolation, boolInt(c.readOnly), boolInt(!c.manualCommit))
+	}); err != nil {
+		return driver.ErrBadConn
+	}
+	if err := c.clearSessionContext(ctx); err != nil {
+		return driver.ErrBadConn
+	}
+	if err := c.restoreRoles(ctx); err != nil {
+		return driver.ErrBadConn
+	}
+	if c.schemaChanged && c.schema != "" {
+		if _, err := c.exec(ctx, "USE "+c.schema, nil); err != nil {
+			return driver.ErrBadConn
+		}
+	}
+	c.schemaChanged = false
+	c.noAutoCommit = false
+	c.used = false
 	return nil
 }
 
-// getMicrosecondsUntilDeadline returns the number of micro seconds until the context's deadline is reached.
-// Returns an error if the context is already done.
-// N.B. A value of zero means no limit.
-func getMicrosecondsUntilDeadline(ctx context.Context) (uSec C.int64_t, err error) {
-	if deadline, ok := ctx.Deadline(); ok {
-		uSec = C.int64_t(time.Until(deadline).Microseconds())
+func (c *Conn) Close() error {
+	if c != nil && c.db != nil {
+		if c.keepaliveInterval > 0 {
+			c.stopKeepalive()
+		}
+		c.flushCloses()
+		c.endTx()
+		c.txc = nil
+		c.log(LevelDebug, "connection closed")
+		c.metrics.closed()
+		c.stats.closed()
+		unlock, err := c.lock()
+		if err != nil {
+			return nil // closed concurrently
+		}
+		defer unlock()
+		if rc := C.nuodb_close(&c.db); rc != 0 {
+			// can't use lastError here
+			return fmt.Errorf("nuodb: conn close failed: %d", rc)
+		}
+	}
+	return nil
+}
+
+func (stmt *Stmt) NumInput() int {
+	if stmt.names != nil {
+		return -1 // a name may be used by several placeholders
+	}
+	return int(stmt.parameterCount)
+}
+
+func (stmt *Stmt) bind(args []driver.Value) error {
+	stmt.outs = nil
+	parameterCount := int(stmt.parameterCount)
+	if parameterCount == 0 || len(args) == 0 {
+		return nil
+	}
+	if len(args) > parameterCount {
+		args = args[:parameterCount] // go1.0.3 allowed extra args; ignore
+	}
+	outs, err := stmt.bindOuts(args)
+	if err != nil {
+		return err
+	}
+	releaseLobs(stmt.lobs)
+	stmt.lobs = nil
+	lobs, err := stmt.c.writeLobs(args)
+	if err != nil {
+		return err
+	}
+	stmt.lobs = lobs
+	parameters, err := stmt.c.encodeValues(args)
+	if err != nil {
+		return err
+	}
+	defer parameters.free()
+	if len(parameters.values) < parameterCount {
+		parameters.values = append(parameters.values, make([]C.struct_nuodb_value, parameterCount-len(parameters.values))...)
+	}
+	if err := stmt.bindValues(parameters.values); err != nil {
+		return err
+	}
+	return stmt.registerOuts(outs)
+}
+
+func (stmt *Stmt) bindValues(parameters []C.struct_nuodb_value) error {
+	c := stmt.c
+	return stmt.cgo(func() C.int {
+		return C.nuodb_statement_bind(c.db, stmt.st, (*C.struct_nuodb_value)(unsafe.Pointer(&parameters[0])))
+	})
+}
+
+// cValues holds the C representation of parameters. The data of strings
+// and byte slices is copied into a single C allocation, as C may not be
+// passed Go pointers hidden in the integers of the values. The values must
+// be freed once they have been bound or executed.
+type cValues struct {
+	values []C.struct_nuodb_value
+	data   unsafe.Pointer // C allocation of the string and bytes data, if any
+	size   int            // of data
+	used   int            // bytes of data taken
+	loc    *time.Location // of the bound Dates and TimesOfDay
+
+	emptyAsNull bool // bind the empty strings as NULL
+}
+
+// newCValues returns empty cValues with room for count values whose string
+// and bytes data takes size bytes. Dates and times of day are bound in loc.
+func newCValues(count, size int, loc *time.Location) *cValues {
+	cv := &cValues{values: make([]C.struct_nuodb_value, 0, count), size: size, loc: loc}
+	if size > 0 {
+		cv.data = C.malloc(C.size_t(size))
+	}
+	return cv
+}
+
+// dataSize returns the size of the string and bytes data of args.
+func dataSize(args []driver.Value) int {
+	size := 0
+	for _, v := range args {
+		n := 0
+		switch v := v.(type) {
+		case string:
+			n = len(v)
+		case []byte:
+			n = len(v)
+		}
+		if n <= maxParameterBytes { // a larger one is rejected by add
+			size += n
+		}
+	}
+	return size
+}
+
+// encodeValues converts args to their C representation, binding dates and
+// times of day in loc, and the empty strings as NULL if emptyAsNull.
+func encodeValues(args []driver.Value, loc *time.Location, emptyAsNull bool) (*cValues, error) {
+	cv := newCValues(len(args), dataSize(args), loc)
+	cv.emptyAsNull = emptyAsNull
+	for i, v := range args {
+		if err := cv.add(v); err != nil {
+			cv.free()
+			return nil, fmt.Errorf("nuodb: parameter %d: %s", i+1, err)
+		}
+	}
+	return cv, nil
+}
+
+// encodeValues is encodeValues with the time zone and the options of c.
+func (c *Conn) encodeValues(args []driver.Value) (*cValues, error) {
+	cv, err := encodeValues(args, c.loc, c.emptyStringAsNull)
+	if err == nil {
+		c.metrics.sent(cv.size)
+	}
+	return cv, err
+}
+
+func (cv *cValues) ptr() *C.struct_nuodb_value {
+	if len(cv.values) == 0 {
+		return nil
+	}
+	if debug {
+		cv.check()
+	}
+	return (*C.struct_nuodb_value)(unsafe.Pointer(&cv.values[0]))
+}
+
+func (cv *cValues) free() {
+	if cv.data != nil {
+		C.free(cv.data)
+		cv.data = nil
+	}
+}
+
+// check panics unless the data of every string and bytes value lies in the
+// taken part of the C allocation, which catches values passed after free
+// and pointers which escaped reserve.
+func (cv *cValues) check() {
+	base, end := uintptr(cv.data), uintptr(cv.data)+uintptr(cv.used)
+	for i := range cv.values {
+		value := &cv.values[i]
+		if (value.vt != C.NUODB_TYPE_STRING && value.vt != C.NUODB_TYPE_BYTES) || value.i32 == 0 {
+			continue
+		}
+		p := uintptr(valueData(value))
+		if cv.data == nil || p < base || p+uintptr(value.i32) > end {
+			panic(fmt.Sprintf("nuodb: parameter %d data outside of its C allocation", i+1))
+		}
+	}
+}
+
+// maxParameterBytes is the largest string or bytes parameter.
+const maxParameterBytes = 1 << 30
+
+// reserve takes the next n bytes of the C allocation, failing rather than
+// writing past its end, and returns them.
+func (cv *cValues) reserve(n int) ([]byte, error) {
+	if n > maxParameterBytes {
+		return nil, fmt.Errorf("%d bytes, more than %d", n, maxParameterBytes)
+	}
+	if n > cv.size-cv.used {
+		return nil, fmt.Errorf("%d bytes, more than the %d bytes left of the data", n, cv.size-cv.used)
+	}
+	dst := unsafe.Slice((*byte)(unsafe.Add(cv.data, cv.used)), n)
+	cv.used += n
+	return dst, nil
+}
+
+// copyData copies b into the C allocation and returns its address.
+func (cv *cValues) copyData(b []byte) (C.int64_t, error) {
+	if len(b) == 0 {
+		return 0, nil
+	}
+	dst, err := cv.reserve(len(b))
+	if err != nil {
+		return 0, err
+	}
+	copy(dst, b)
+	return C.int64_t(uintptr(unsafe.Pointer(&dst[0]))), nil
+}
+
+// copyString is copyData for a string, without converting it.
+func (cv *cValues) copyString(s string) (C.int64_t, error) {
+	if len(s) == 0 {
+		return 0, nil
+	}
+	dst, err := cv.reserve(len(s))
+	if err != nil {
+		return 0, err
+	}
+	copy(dst, s)
+	return C.int64_t(uintptr(unsafe.Pointer(&dst[0]))), nil
+}
+
+// add appends the C representation of v. Types other than the
+// driver.Value types are rejected rather than bound as NULL.
+func (cv *cValues) add(v driver.Value) (err error) {
+	var value C.struct_nuodb_value
+	switch v := v.(type) {
+	case int64:
+		value.vt = C.NUODB_TYPE_INT64
+		value.i64 = C.int64_t(v)
+	case float64:
+		value.vt = C.NUODB_TYPE_FLOAT64
+		value.i64 = *(*C.int64_t)(unsafe.Pointer(&v))
+	case bool:
+		value.vt = C.NUODB_TYPE_BOOL
+		if v {
+			value.i64 = 1
+		}
+	case string:
+		if v == "" && cv.emptyAsNull {
+			value.vt = C.NUODB_TYPE_NULL
+			break
+		}
+		value.vt = C.NUODB_TYPE_STRING
+		value.i32 = C.int32_t(len(v))
+		if value.i64, err = cv.copyString(v); err != nil {
+			return err
+		}
+	case []byte:
+		value.vt = C.NUODB_TYPE_BYTES
+		value.i32 = C.int32_t(len(v))
+		if value.i64, err = cv.copyData(v); err != nil {
+			return err
+		}
+	case time.Time:
+		value.vt = C.NUODB_TYPE_TIME
+		value.i32 = C.int32_t(v.Nanosecond())
+		value.i64 = C.int64_t(v.Unix()) // seconds
+	case Date:
+		value.vt = C.NUODB_TYPE_DATE
+		value.i64 = C.int64_t(v.In(cv.loc).Unix())
+	case TimeOfDay:
+		value.vt = C.NUODB_TYPE_TIME_OF_DAY
+		value.i32 = C.int32_t(v.Nanosecond)
+		value.i64 = C.int64_t(v.On(epochDate, cv.loc).Unix())
+	case *lobParam:
+		value.vt = v.vt
+		value.i64 = C.int64_t(uintptr(unsafe.Pointer(v.lob)))
+	case nil:
+		value.vt = C.NUODB_TYPE_NULL
+	default:
+		return fmt.Errorf("unsupported type %T", v)
+	}
+	cv.values = append(cv.values, value)
+	return nil
+}
+
+// bytes returns a copy of the string or bytes data of the value i.
+func (cv *cValues) bytes(i int) []byte {
+	value := cv.values[i]
+	if value.i32 == 0 {
+		return []byte{}
+	}
+	return C.GoBytes(valueData(&value), C.int(value.i32))
+}
+
+func (stmt *Stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return stmt.execQuery(context.Background(), args)
+}
+
+func (stmt *Stmt) ExecQuery(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return stmt.ExecContext(ctx, args)
+}
+
+func (stmt *Stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (res driver.Result, err error) {
+	defer captureStatement(stmt.c, CaptureExec, stmt.sql, args, time.Now(), &err)
+	defer stmt.c.logSlowExec(stmt.sql, time.Now(), &res, &err)
+	defer stmt.c.beforeHooks(ctx, false, stmt.sql, args)(&err)
+	defer stmt.c.traceStatement(ctx, SpanExec, stmt.sql)(&err)
+	defer stmt.c.annotateError(stmt.sql, args, &err)
+	defer stmt.c.trackUncommitted(stmt.sql, &err)
+	values, err := namedValuesToValues(args, stmt.names)
+	if err != nil {
+		return nil, err
+	}
+	if hasLists(values) {
+		ls, values, err := stmt.listStatement(values)
+		if err != nil {
+			return nil, err
+		}
+		defer ls.Close()
+		return ls.execQuery(ctx, values)
+	}
+	return stmt.execQuery(ctx, values)
+}
+
+func (stmt *Stmt) execQuery(ctx context.Context, args []driver.Value) (driver.Result, error) {
+	c := stmt.c
+	if c.db == nil {
+		return nil, errClosed
+	}
+	exit, err := c.enter()
+	if err != nil {
+		return nil, err
+	}
+	defer exit()
+	if c.bad {
+		return nil, driver.ErrBadConn
+	}
+	var res driver.Result
+	err = stmt.reprepareOnce(func() (err error) {
+		if err = stmt.bind(args); err != nil {
+			return fmt.Errorf("bind: %w", err)
+		}
+		res, err = stmt.execute(ctx)
+		return err
+	})
+	return res, err
+}
+
+func (stmt *Stmt) execute(ctx context.Context) (_ driver.Result, err error) {
+	defer observeStatement(stmt.sql, time.Now(), &err)
+	c := stmt.c
+	defer c.metrics.observe(metricExec, time.Now(), &err)
+	c.takeOptions()
+	if err := c.applyContext(ctx); err != nil {
+		return nil, err
+	}
+	restore, err := c.enterReadOnly(ctx, stmt.sql)
+	if err != nil {
+		return nil, err
+	}
+	defer restore()
+	result := &Result{}
+	err = c.retry(ctx, func() error {
+		if err := stmt.addTimeoutFromContext(ctx); err != nil {
+			return err
+		}
+		unlock, err := stmt.lock()
+		if err != nil {
+			return err
+		}
+		defer unlock()
+		cancelled := stmt.watchCancel(ctx)
+		c.stats.call()
+		rc := C.nuodb_statement_execute(c.db, stmt.st, &result.rowsAffected, &result.lastInsertId)
+		if cancelled() && rc != 0 {
+			return contextError(ctx)
+		}
+		if rc != 0 {
+			return c.lastError(rc)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := stmt.readOuts(c.scanLocation(ctx)); err != nil {
+		return nil, err
+	}
+	if stmt.schemaChange {
+		c.meta.invalidate()
+		c.ddlCount++
+	}
+	if result.rowsAffected == 0 && stmt.ddlStatement {
+		return driver.ResultNoRows, nil
+	}
+	return result, nil
+}
+
+func (stmt *Stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return stmt.queryContext(context.Background(), args)
+}
+
+func (stmt *Stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (rs driver.Rows, err error) {
+	defer captureStatement(stmt.c, CaptureQuery, stmt.sql, args, time.Now(), &err)
+	defer stmt.c.logSlowQuery(stmt.sql, time.Now(), &rs, &err)
+	defer stmt.c.beforeHooks(ctx, true, stmt.sql, args)(&err)
+	defer stmt.c.traceQuery(ctx, stmt.sql)(&rs, &err)
+	defer stmt.c.annotateError(stmt.sql, args, &err)
+	defer stmt.c.trackUncommitted(stmt.sql, &err)
+	values, err := namedValuesToValues(args, stmt.names)
+	if err != nil {
+		return nil, err
+	}
+	if hasLists(values) {
+		ls, values, err := stmt.listStatement(values)
+		if err != nil {
+			return nil, err
+		}
+		defer ls.Close()
+		dr, err := ls.queryContext(ctx, values)
+		if err != nil {
+			return nil, err
+		}
+		rows := dr.(*Rows)
+		rows.st, ls.st = ls.st, nil // closed with the rows
+		return rows, nil
+	}
+	if cursor := stmt.c.opts.cursor; cursor != (Cursor{}) && !stmt.call {
+		cs, err := stmt.prepareCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		defer cs.Close()
+		dr, err := cs.queryContext(ctx, values)
+		if err != nil {
+			return nil, err
+		}
+		rows := dr.(*Rows)
+		rows.st, cs.st = cs.st, nil // closed with the rows
+		return rows, nil
+	}
+	return stmt.queryContext(ctx, values)
+}
+
+func (stmt *Stmt) queryContext(ctx context.Context, args []driver.Value) (driver.Rows, error) {
+	c := stmt.c
+	if c.db == nil {
+		return nil, errClosed
+	}
+	exit, err := c.enter()
+	if err != nil {
+		return nil, err
+	}
+	defer exit()
+	if c.bad {
+		return nil, driver.ErrBadConn
+	}
+	var rows driver.Rows
+	err = stmt.reprepareOnce(func() (err error) {
+		if err = stmt.bind(args); err != nil {
+			return fmt.Errorf("bind: %w", err)
+		}
+		rows, err = stmt.query(ctx)
+		return err
+	})
+	return rows, err
+}
+
+func (stmt *Stmt) query(ctx context.Context) (_ driver.Rows, err error) {
+	defer observeStatement(stmt.sql, time.Now(), &err)
+	c := stmt.c
+	defer c.metrics.observe(metricQuery, time.Now(), &err)
+	opts := c.takeOptions()
+	if err := c.applyContext(ctx); err != nil {
+		return nil, err
+	}
+	restore, err := c.enterReadOnly(ctx, stmt.sql)
+	if err != nil {
+		return nil, err
+	}
+	defer restore()
+	rows := &Rows{c: c, loc: c.scanLocation(ctx), streamLobs: opts.streamLobs, progress: newProgressState(opts.progress)}
+	rows.scrollable = opts.cursor.Scrollable && !stmt.call
+	var fetchSize int
+	rows.maxRows, fetchSize = c.rowLimit(ctx, opts.fetchSize)
+	rows.columnLimit, rows.truncate = c.columnLimit(ctx)
+	if err := stmt.cgo(func() C.int {
+		if rc := C.nuodb_statement_set_fetch_size(c.db, stmt.st, C.int(fetchSize)); rc != 0 {
+			return rc
+		}
+		return C.nuodb_statement_set_max_rows(c.db, stmt.st, C.int(serverRows(rows.maxRows)))
+	}); err != nil {
+		return nil, err
+	}
+	if opts.reuseBuffers {
+		rows.buffer = getRowBuffer()
+	}
+	var columnCount C.int
+	err = c.retry(ctx, func() error {
+		if err := stmt.addTimeoutFromContext(ctx); err != nil {
+			return err
+		}
+		unlock, err := stmt.lock()
+		if err != nil {
+			return err
+		}
+		defer unlock()
+		cancelled := stmt.watchCancel(ctx)
+		c.stats.call()
+		rc := C.nuodb_statement_query(c.db, stmt.st, &rows.rs, &columnCount)
+		if cancelled() && rc != 0 {
+			return contextError(ctx)
+		}
+		if rc != 0 {
+			return c.lastError(rc)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if stmt.call {
+		rows.call = stmt.st
+	} else {
+		rows.query = stmt.st
+	}
+	if err := stmt.readOuts(c.scanLocation(ctx)); err != nil {
+		rows.Close()
+		return nil, err
 	}
+	rows.columns = stmt.resultColumns()
+	if err := rows.fetchColumnNames(columnCount); err != nil {
+		rows.Close()
+		return nil, err
+	}
+	c.trackLeak(rows, stmt.sql)
+	return rows, nil
+}
 
-	if err = ctx.Err(); err != nil {
+func (stmt *Stmt) addTimeoutFromContext(ctx context.Context) error {
+	uSec, err := stmt.c.queryTimeout(ctx)
+	if err != nil {
+		return err
+	}
+	return stmt.cgo(func() C.int {
+		C.nuodb_statement_set_query_micros(stmt.c.db, stmt.st, uSec)
+		return 0
+	})
+}
+
+// watchCancel cancels the execution of the statement if ctx is cancelled
+// before the returned function is called. The function reports whether the
+// statement was cancelled; a statement which completed regardless keeps its
+// result.
+func (stmt *Stmt) watchCancel(ctx context.Context) func() bool {
+	if ctx.Done() == nil {
+		return func() bool { return false }
+	}
+	st := stmt.st
+	done := make(chan struct{})
+	result := make(chan bool, 1)
+	go func() {
+		select {
+		case <-ctx.Done():
+			C.nuodb_statement_cancel(st)
+			result <- true
+		case <-done:
+			result <- false
+		}
+	}()
+	return func() bool {
+		close(done)
+		return <-result
+	}
+}
+
+// watchCancel cancels the statement of the direct call on c, ExecContext or
+// QueryContext, if ctx is cancelled before the returned function is called,
+// like Stmt.watchCancel. A cancel which came too late to interrupt the call
+// is reset for the next one.
+func (c *Conn) watchCancel(ctx context.Context) func() bool {
+	if ctx.Done() == nil {
+		return func() bool { return false }
+	}
+	db := c.db
+	done := make(chan struct{})
+	result := make(chan bool, 1)
+	go func() {
+		select {
+		case <-ctx.Done():
+			C.nuodb_cancel(db)
+			result <- true
+		case <-done:
+			result <- false
+		}
+	}()
+	return func() bool {
+		close(done)
+		cancelled := <-result
+		if cancelled {
+			C.nuodb_cancel_reset(db)
+		}
+		return cancelled
+	}
+}
+
+// queryTimeout returns the statement timeout in micro seconds for the
+// context's deadline or its WithQueryTimeout, whichever is sooner, limited
+// to the maxQueryTimeout of the connection. A context without either gets
+// the defaultTimeout, or else the maxQueryTimeout.
+func (c *Conn) queryTimeout(ctx context.Context) (C.int64_t, error) {
+	uSec, err := getMicrosecondsUntilDeadline(ctx)
+	if err != nil {
 		return 0, err
 	}
+	if d := queryOptionsFrom(ctx).timeout; d > 0 {
+		if t := C.int64_t(d.Microseconds()); uSec == 0 || t < uSec {
+			uSec = t
+		}
+	}
+	c.timeoutDefault = 0
+	if uSec == 0 && c.defaultTimeout > 0 {
+		uSec = C.int64_t(c.defaultTimeout.Microseconds())
+		c.timeoutDefault = c.defaultTimeout
+	}
+	if uSec != 0 || c.maxQueryTimeout != 0 {
+		if err := c.require(featureQueryTimeout); err != nil {
+			return 0, err
+		}
+	}
+	c.timeoutLimit = 0
+	if max := C.int64_t(c.maxQueryTimeout.Microseconds()); max > 0 && (uSec == 0 || uSec > max) {
+		if uSec != 0 {
+			c.timeoutLimit = c.maxQueryTimeout
+		}
+		uSec = max
+	}
+	return uSec, nil
+}
+
+// defaultContext returns ctx with the defaultTimeout of the connection as
+// its deadline, if it has none, e.g. for waiting for the StatementLimiter.
+func (c *Conn) defaultContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok || c.defaultTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.defaultTimeout)
+}
 
+// getMicrosecondsUntilDeadline returns the number of micro seconds until the context's deadline is reached.
+// Returns an error if the context is already done, or if the deadline has passed even though the context
+// has not noticed it yet.
+// N.B. A value of zero means no limit, so a deadline less than a micro second away is rounded up.
+func getMicrosecondsUntilDeadline(ctx context.Context) (uSec C.int64_t, err error) {
+	if ctx.Err() != nil {
+		return 0, contextError(ctx)
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0, nil
+	}
+	// time.Until uses the monotonic clock reading of deadline, if any
+	remaining := time.Until(deadline)
+	if remaining <= 0 {
+		return 0, context.DeadlineExceeded
+	}
+	if uSec = C.int64_t(remaining.Microseconds()); uSec == 0 {
+		uSec = 1
+	}
 	return uSec, nil
 }
 
-func namedValuesToValues(namedValues []driver.NamedValue) ([]driver.Value, error) {
-	values := make([]driver.Value, 0, len(namedValues))
+// namedValuesToValues orders the args by the names of the placeholders of
+// a statement with named parameters. Without names the args are positional.
+func namedValuesToValues(namedValues []driver.NamedValue, names []string) ([]driver.Value, error) {
+	if names == nil {
+		values := make([]driver.Value, 0, len(namedValues))
+		for _, namedValue := range namedValues {
+			if len(namedValue.Name) != 0 {
+				return nil, fmt.Errorf("nuodb: statement has no named parameter :%s", namedValue.Name)
+			}
+			values = append(values, namedValue.Value)
+		}
+		return values, nil
+	}
+	byName := make(map[string]driver.Value, len(namedValues))
 	for _, namedValue := range namedValues {
-		if len(namedValue.Name) != 0 {
-			return nil, fmt.Errorf("sql driver doesn't support named values")
+		if len(namedValue.Name) == 0 {
+			return nil, fmt.Errorf("nuodb: positional parameter %d in a statement with named parameters", namedValue.Ordinal)
+		}
+		byName[namedValue.Name] = namedValue.Value
+	}
+	values := make([]driver.Value, len(names))
+	used := make(map[string]bool, len(names))
+	for i, name := range names {
+		value, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("nuodb: missing named parameter :%s", name)
+		}
+		values[i] = value
+		used[name] = true
+	}
+	for name := range byName {
+		if !used[name] {
+			return nil, fmt.Errorf("nuodb: statement has no named parameter :%s", name)
 		}
-		values = append(values, namedValue.Value)
 	}
 	return values, nil
 }
 
+// namedParameters rewrites the named placeholders of sql if named args are
+// passed to it.
+func namedParameters(sql string, args []driver.NamedValue) (string, []string) {
+	for _, arg := range args {
+		if len(arg.Name) != 0 {
+			return parse.NamedParameters(sql)
+		}
+	}
+	return sql, nil
+}
+
+func valuesToNamedValues(values []driver.Value) []driver.NamedValue {
+	namedValues := make([]driver.NamedValue, len(values))
+	for i, value := range values {
+		namedValues[i] = driver.NamedValue{Ordinal: i + 1, Value: value}
+	}
+	return namedValues
+}
+
 func (stmt *Stmt) Close() error {
+	if stmt != nil {
+		stmt.c.untrackLeak(stmt)
+	}
 	if stmt != nil && stmt.c.db != nil {
+		exit, _ := stmt.c.enter() // closed after a rollback as well
+		defer exit()
+		unlock, err := stmt.c.lock()
+		if err != nil {
+			return nil // closed with the connection
+		}
+		defer unlock()
+		if stmt.c.deferClose {
+			stmt.c.queueClose(closeHandles{st: stmt.st, lobs: stmt.lobs})
+			stmt.st, stmt.lobs = nil, nil
+			return nil
+		}
+		defer func() {
+			releaseLobs(stmt.lobs)
+			stmt.lobs = nil
+		}()
 		if rc := C.nuodb_statement_close(stmt.c.db, &stmt.st); rc != 0 {
 			return stmt.c.lastError(rc)
 		}
@@ -413,81 +1662,424 @@ func (result *Result) RowsAffected() (int64, error) {
 	return int64(result.rowsAffected), nil
 }
 
+func (rows *Rows) fetchColumnNames(columnCount C.int) error {
+	if columnCount <= 0 {
+		return nil
+	}
+	c := rows.c
+	cc := int(columnCount)
+	rows.rowValues = make([]C.struct_nuodb_value, cc)
+	if rc := rows.columns; rc.cached(cc) {
+		rows.columnNames, rows.maskers, rows.emptyNulls = rc.names, rc.maskers, rc.emptyNulls
+		return nil
+	}
+	if err := rows.cgo(func() C.int {
+		return C.nuodb_resultset_column_names(c.db, rows.rs, (*C.struct_nuodb_value)(unsafe.Pointer(&rows.rowValues[0])))
+	}); err != nil {
+		return err
+	}
+	rows.columnNames = columnLabels(rows.rowValues)
+	rows.maskers = nil
+	if c.masks != nil {
+		rows.maskers = c.masks.maskers(rows.columnNames)
+	}
+	rows.emptyNulls = nil
+	if c.nullStringAsEmpty {
+		types, err := rows.columnTypes()
+		if err != nil {
+			return err
+		}
+		rows.emptyNulls = make([]bool, len(types))
+		for i, t := range types {
+			rows.emptyNulls[i] = characterType(t.databaseTypeName)
+		}
+	}
+	if rc := rows.columns; rc != nil {
+		rc.names, rc.maskers, rc.emptyNulls = rows.columnNames, rows.maskers, rows.emptyNulls
+	}
+	return nil
+}
+
 func (rows *Rows) Columns() []string {
 	return rows.columnNames
 }
 
 func (rows *Rows) Next(dest []driver.Value) error {
+	if rows.peeked != nil {
+		copy(dest, rows.peeked)
+		rows.peeked = nil
+		return nil
+	}
+	if err := rows.peekErr; err != nil {
+		rows.peekErr = nil
+		return err
+	}
+	return rows.next(dest)
+}
+
+func (rows *Rows) next(dest []driver.Value) error {
 	c := rows.c
-	var hasValues C.int
-	if len(rows.rowValues) == 0 {
-		return io.EOF
+	exit, err := c.enter()
+	if err != nil {
+		return err
 	}
-	if rc := C.nuodb_resultset_next(c.db, rows.rs, &hasValues,
-		(*C.struct_nuodb_value)(unsafe.Pointer(&rows.rowValues[0]))); rc != 0 {
-		return c.lastError(rc)
+	defer exit()
+	values, err := rows.advance()
+	if err != nil {
+		return err
 	}
-	if hasValues == 0 {
-		return io.EOF
+	if rows.buffer != nil {
+		rows.buffer.reset()
 	}
-	for i, value := range rows.rowValues {
-		switch value.vt {
-		case C.NUODB_TYPE_NULL:
-			dest[i] = nil
-		case C.NUODB_TYPE_INT64:
-			dest[i] = int64(value.i64)
-		case C.NUODB_TYPE_FLOAT64:
-			dest[i] = *(*float64)(unsafe.Pointer(&value.i64))
-		case C.NUODB_TYPE_BOOL:
-			dest[i] = value.i64 != 0
-		case C.NUODB_TYPE_TIME:
-			seconds := int64(value.i64)
-			nanos := int64(value.i32)
-			dest[i] = time.Unix(seconds, nanos).In(c.loc)
-		default:
-			// byte slice
-			length := (C.int)(value.i32)
-			if length > 0 {
-				dest[i] = C.GoBytes(unsafe.Pointer((uintptr)(value.i64)), length)
+	for i, value := range values {
+		switch {
+		case value.vt == C.NUODB_TYPE_BLOB || value.vt == C.NUODB_TYPE_CLOB:
+			dest[i] = newLob(rows, value)
+		case value.vt == C.NUODB_TYPE_NULL && rows.emptyNulls != nil && rows.emptyNulls[i]:
+			dest[i] = ""
+		case (value.vt == C.NUODB_TYPE_BYTES || value.vt == C.NUODB_TYPE_STRING) && rows.buffer != nil:
+			// bytes even of the character columns, for sql.RawBytes; an
+			// empty value may come without data
+			if n := int(value.i32); n > 0 {
+				dest[i] = rows.buffer.copy(unsafe.Slice((*byte)(valueData(&value)), n))
 			} else {
 				dest[i] = []byte{}
 			}
+		default:
+			dest[i] = c.decodeValue(value, rows.loc)
 		}
 	}
+	if rows.maskers != nil {
+		mask(rows.maskers, dest)
+	}
+	if rows.progress != nil {
+		rows.progress.row(dest)
+	}
 	return nil
 }
 
+// advance fetches the next row within the limits of rows, and returns its
+// values, which are valid until the next fetch. The call must have entered
+// the connection.
+func (rows *Rows) advance() ([]C.struct_nuodb_value, error) {
+	c := rows.c
+	if rows.closed {
+		return nil, errRowsClosed
+	}
+	if len(rows.rowValues) == 0 {
+		return nil, io.EOF
+	}
+	if rows.maxRows > 0 && rows.count >= rows.maxRows {
+		if _, err := rows.fetch(); err != nil {
+			return nil, err // io.EOF at the limit
+		}
+		return nil, ErrMaxRows
+	}
+	rows.row++
+	values, err := rows.fetch()
+	if err == io.EOF && rows.progress != nil {
+		rows.progress.done()
+	}
+	if err != nil {
+		return nil, err
+	}
+	rows.count++
+	if rows.columnLimit > 0 {
+		if err := rows.limitColumns(values); err != nil {
+			return nil, err
+		}
+	}
+	if c.metrics != nil {
+		n := 0
+		for _, value := range values {
+			if value.vt == C.NUODB_TYPE_BYTES || value.vt == C.NUODB_TYPE_STRING {
+				n += int(value.i32)
+			}
+		}
+		c.metrics.fetched(n)
+	}
+	return values, nil
+}
+
+// fetch advances to the next row and returns its values, which are valid
+// until the next fetch. Unless lobs are streamed, whose handles belong to
+// the current row of the result set, or the cursor is scrollable, whose
+// position must be that of the rows returned, the rows are fetched in
+// batches.
+func (rows *Rows) fetch() ([]C.struct_nuodb_value, error) {
+	c := rows.c
+	if rows.streamLobs || rows.scrollable {
+		var hasValues C.int
+		c.stats.call()
+		if err := rows.cgo(func() C.int {
+			return C.nuodb_resultset_next(c.db, rows.rs, &hasValues, (*C.struct_nuodb_value)(unsafe.Pointer(&rows.rowValues[0])),
+				boolInt(rows.streamLobs))
+		}); err != nil {
+			return nil, err
+		}
+		if hasValues == 0 {
+			rows.batch.done = true
+			return nil, io.EOF
+		}
+		return rows.rowValues, nil
+	}
+	b := &rows.batch
+	for b.next == b.count {
+		if b.err != nil {
+			return nil, b.err
+		}
+		if b.done {
+			return nil, io.EOF
+		}
+		if err := rows.fetchBatch(); err != nil {
+			return nil, err
+		}
+	}
+	cc := len(rows.rowValues)
+	values := b.values[b.next*cc : (b.next+1)*cc]
+	b.next++
+	return values, nil
+}
+
+// fetchBatch fetches the next batch of rows. The rows fetched before an
+// error are returned before the error. It fails only if the rows are closed.
+func (rows *Rows) fetchBatch() error {
+	c := rows.c
+	b := &rows.batch
+	unlock, err := rows.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if b.values == nil {
+		b.values = make([]C.struct_nuodb_value, fetchBatchRows*len(rows.rowValues))
+		b.buffer = C.malloc(fetchBatchBuffer)
+	}
+	var count, done C.int
+	c.stats.call()
+	rc := C.nuodb_resultset_next_batch(c.db, rows.rs, fetchBatchRows,
+		(*C.struct_nuodb_value)(unsafe.Pointer(&b.values[0])), (*C.uchar)(b.buffer),
+		fetchBatchBuffer, &count, &done)
+	b.count, b.next, b.done = int(count), 0, done != 0
+	if rc != 0 {
+		b.err = c.lastError(rc)
+	}
+	return nil
+}
+
+// decodeValue converts a fetched value to its Go representation, with the
+// times in loc. The data of byte slices is copied.
+// valueData returns the address which the i64 of value holds, of its string
+// or bytes data or of its lob, read as the pointer it is rather than
+// converted from an integer.
+func valueData(value *C.struct_nuodb_value) unsafe.Pointer {
+	return *(*unsafe.Pointer)(unsafe.Pointer(&value.i64))
+}
+
+func (c *Conn) decodeValue(value C.struct_nuodb_value, loc *time.Location) driver.Value {
+	switch value.vt {
+	case C.NUODB_TYPE_NULL:
+		return nil
+	case C.NUODB_TYPE_INT64:
+		return int64(value.i64)
+	case C.NUODB_TYPE_FLOAT64:
+		return *(*float64)(unsafe.Pointer(&value.i64))
+	case C.NUODB_TYPE_BOOL:
+		return value.i64 != 0
+	case C.NUODB_TYPE_TIME:
+		seconds := int64(value.i64)
+		nanos := int64(value.i32)
+		return time.Unix(seconds, nanos).In(loc)
+	case C.NUODB_TYPE_DATE:
+		return dateValue(int64(value.i64), c.loc, loc)
+	case C.NUODB_TYPE_TIME_OF_DAY:
+		return timeOfDayValue(int64(value.i64), int64(value.i32), c.loc, loc)
+	case C.NUODB_TYPE_STRING:
+		// a string of a character column, which database/sql scans into a
+		// string without copying it again
+		return C.GoStringN((*C.char)(valueData(&value)), C.int(value.i32))
+	default:
+		// byte slice
+		length := (C.int)(value.i32)
+		if length > 0 {
+			return C.GoBytes(valueData(&value), length)
+		}
+		return []byte{}
+	}
+}
+
+var _ driver.RowsNextResultSet = (*Rows)(nil)
+
+// HasNextResultSet implements driver.RowsNextResultSet. Only the results of
+// a stored procedure call may have more than one result set.
+func (rows *Rows) HasNextResultSet() bool {
+	return rows.call != nil
+}
+
+// NextResultSet implements driver.RowsNextResultSet. It returns io.EOF when
+// there are no more result sets.
+func (rows *Rows) NextResultSet() error {
+	c := rows.c
+	if rows.call == nil {
+		return io.EOF
+	}
+	exit, err := c.enter()
+	if err != nil {
+		return err
+	}
+	defer exit()
+	columnCount, err := rows.nextResultSet()
+	if err != nil {
+		return err
+	}
+	if rows.progress != nil {
+		rows.progress.reset()
+	}
+	return rows.fetchColumnNames(columnCount)
+}
+
+// nextResultSet advances the procedure call of rows to its next result set
+// and returns the number of its columns.
+func (rows *Rows) nextResultSet() (C.int, error) {
+	c := rows.c
+	unlock, err := c.lock()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+	if rows.closed {
+		return 0, errRowsClosed
+	}
+	rows.row++
+	rows.peeked, rows.peekErr = nil, nil
+	rows.rs = nil // closed by advancing to the next result set
+	rows.rowValues, rows.columnNames, rows.types, rows.columns = nil, nil, nil, nil
+	rows.count = 0
+	rows.batch.free()
+	rows.batch = rowBatch{}
+	var columnCount C.int
+	c.stats.call()
+	if rc := C.nuodb_statement_next_resultset(c.db, rows.call, &rows.rs, &columnCount); rc != 0 {
+		return 0, c.lastError(rc)
+	}
+	if rows.rs == nil {
+		rows.call = nil
+		return 0, io.EOF
+	}
+	return columnCount, nil
+}
+
 func (rows *Rows) Close() error {
+	if rows != nil {
+		rows.c.untrackLeak(rows)
+	}
+	if rows != nil && rows.sql != "" {
+		rows.c.logSlow(rows.sql, time.Since(rows.started), int64(rows.row), nil)
+		rows.sql = ""
+	}
+	if rows != nil && rows.span != nil {
+		endSpan(rows.span, nil, SpanAttribute{"db.nuodb.rows", int64(rows.row)})
+		rows.span = nil
+	}
+	if rows != nil && rows.buffer != nil {
+		putRowBuffer(rows.buffer)
+		rows.buffer = nil
+	}
+	if rows != nil && rows.c.db == nil {
+		rows.batch.free() // nothing to race with on a closed connection
+	}
 	if rows != nil && rows.c.db != nil {
+		exit, _ := rows.c.enter() // closed after a rollback as well
+		defer exit()
+		unlock, err := rows.c.lock()
+		if err != nil {
+			rows.batch.free()
+			return nil // closed with the connection
+		}
+		defer unlock()
+		rows.closed = true
+		if rows.query != nil && !rows.batch.done && rows.rs != nil {
+			// stop the TE from sending the rows left unread
+			rows.c.stats.call()
+			C.nuodb_resultset_cancel(rows.c.db, rows.query, &rows.rs)
+			rows.c.log(LevelDebug, "unread rows cancelled", "rows", rows.row)
+		}
+		if rows.c.deferClose {
+			rows.c.queueClose(closeHandles{rs: rows.rs, st: rows.st, buffer: rows.batch.buffer})
+			rows.rs, rows.st = nil, nil
+			rows.batch = rowBatch{}
+			return nil
+		}
+		rows.batch.free()
 		if rc := C.nuodb_resultset_close(rows.c.db, &rows.rs); rc != 0 {
 			return rows.c.lastError(rc)
 		}
+		if rc := C.nuodb_statement_close(rows.c.db, &rows.st); rc != 0 {
+			return rows.c.lastError(rc)
+		}
 	}
 	return nil
 }
 
-func (tx *Tx) restoreAutoCommit() {
-	_ = C.nuodb_autocommit_set(tx.c.db, tx.autoCommit)
+// endOpenTx ends a transaction left open on c, rolling it back unless it
+// has already been rolled back, e.g. by the cancellation of its context.
+func (c *Conn) endOpenTx() error {
+	exit, _ := c.enter() // waits for a rollback in progress
+	exit()
+	if tx := c.tx; tx != nil {
+		return tx.Rollback()
+	}
+	c.endTx()
+	return nil
+}
+
+// end ends the transaction on the connection, unless it has already been
+// ended by the cancellation of its context.
+func (tx *Tx) end() {
+	if tx.c.tx != tx {
+		return
+	}
+	tx.c.tx = nil
+	tx.c.inTx = false
 }
 
-func (tx *Tx) Commit() error {
-	if tx.c.db == nil {
+func (tx *Tx) Commit() (err error) {
+	if tx.c.txc == nil {
 		return errClosed
 	}
-	defer tx.restoreAutoCommit()
-	if rc := C.nuodb_commit(tx.c.db); rc != 0 {
-		return tx.c.lastError(rc)
+	defer captureStatement(tx.c, CaptureCommit, "", nil, time.Now(), &err)
+	exit, err := tx.c.enter()
+	defer exit()
+	defer tx.c.endTx() // before exit, which would roll back an expired transaction
+	defer tx.end()
+	if err != nil {
+		return err
+	}
+	if err = tx.c.txc.commit(); err != nil {
+		_ = tx.c.txc.rollback() // so that the failed transaction doesn't stay open
+		tx.rolledBack()
 	}
-	return nil
+	return err
 }
 
-func (tx *Tx) Rollback() error {
-	if tx.c.db == nil {
+func (tx *Tx) Rollback() (err error) {
+	if tx.c.txc == nil {
 		return errClosed
 	}
-	defer tx.restoreAutoCommit()
-	if rc := C.nuodb_rollback(tx.c.db); rc != 0 {
-		return tx.c.lastError(rc)
+	defer captureStatement(tx.c, CaptureRollback, "", nil, time.Now(), &err)
+	exit, err := tx.c.enter()
+	defer exit()
+	defer tx.c.endTx()
+	defer tx.end()
+	if err != nil || tx.c.tx != tx {
+		return nil // already rolled back, e.g. after its maximum duration
 	}
-	return nil
+	tx.rolledBack()
+	return tx.c.txc.rollback()
+}
+
+// rolledBack restores the session context of the connection to the one the
+// rollback of the Tx leaves in the SessionContextTable.
+func (tx *Tx) rolledBack() {
+	tx.c.sessionContext = tx.sessionContext
 }
@@ -0,0 +1,297 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+// Package nuodbadmin is a client of the NuoDB Admin REST API, which manages
+// the databases of a domain and their processes, the TEs and the SMs:
+//
+//	admin, err := nuodbadmin.NewClient("https://admin:8888", cfg.TLS)
+//	processes, err := admin.Processes(ctx, "tests")
+//	for _, p := range processes {
+//		fmt.Println(p.StartID, p.Type, p.State, p.Address)
+//	}
+//
+// The client uses the TLS settings of the Config of the driver, so that the
+// tooling of a domain needs to be given its certificates only once. The
+// Admin API authenticates the client by its certificate.
+package nuodbadmin
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/tilinna/go-nuodb"
+)
+
+// apiPath is the prefix of the paths of the Admin API.
+const apiPath = "/api/1/"
+
+// Client is a client of the Admin REST API of a domain. It is safe for
+// concurrent use.
+type Client struct {
+	url  string // without a trailing slash
+	http *http.Client
+}
+
+// Database is a database of the domain.
+type Database struct {
+	Name  string `json:"name"`
+	State string `json:"state"` // e.g. RUNNING or NOT_RUNNING
+}
+
+// Process is a TE or an SM of a database.
+type Process struct {
+	StartID   string `json:"startId"`
+	Type      string `json:"type"`  // TE or SM
+	State     string `json:"state"` // e.g. RUNNING or SYNCING
+	DBName    string `json:"dbName"`
+	Host      string `json:"host"`
+	Address   string `json:"address"`
+	Port      int    `json:"port"`
+	PID       int    `json:"pid"`
+	NodeID    int    `json:"nodeId"`
+	ServerID  string `json:"serverId"`            // of the admin server which started it
+	ArchiveID int    `json:"archiveId,omitempty"` // of an SM
+}
+
+// StartRequest is the process to be started by StartProcess.
+type StartRequest struct {
+	DBName     string            `json:"dbName"`
+	EngineType string            `json:"engineType"` // TE or SM
+	ServerID   string            `json:"serverId"`   // of the admin server to start it
+	ArchiveID  *int              `json:"archiveId,omitempty"`
+	Options    map[string]string `json:"overrideOptions,omitempty"`
+}
+
+// Error is an error response of the Admin API.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("nuodb: admin: %d %s", e.StatusCode, e.Message)
+}
+
+// NewClient returns a client of the Admin API at adminURL, e.g.
+// https://admin:8888. A nil tlsConfig leaves the TLS to the defaults of
+// net/http. The TrustStore must be a PEM file of certificates, as must be
+// the ClientCertificate and the ClientKey, if set.
+func NewClient(adminURL string, tlsConfig *nuodb.TLSConfig) (*Client, error) {
+	u, err := url.Parse(adminURL)
+	if err != nil {
+		return nil, fmt.Errorf("nuodb: admin url: %v", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("nuodb: admin url %q is not an http(s) url", adminURL)
+	}
+	transport := http.DefaultTransport
+	if tlsConfig != nil {
+		c, err := clientTLS(tlsConfig)
+		if err != nil {
+			return nil, err
+		}
+		transport = &http.Transport{Proxy: http.ProxyFromEnvironment, TLSClientConfig: c}
+	}
+	return &Client{url: strings.TrimRight(adminURL, "/"), http: &http.Client{Transport: transport}}, nil
+}
+
+// clientTLS returns the crypto/tls configuration of c.
+func clientTLS(c *nuodb.TLSConfig) (*tls.Config, error) {
+	config := &tls.Config{}
+	if c.TrustStore != "" {
+		pem, err := ioutil.ReadFile(c.TrustStore)
+		if err != nil {
+			return nil, fmt.Errorf("nuodb: trust store: %v", err)
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("nuodb: trust store %s has no PEM certificates", c.TrustStore)
+		}
+	}
+	if c.ClientCertificate != "" || c.ClientKey != "" {
+		cert, err := tls.LoadX509KeyPair(c.ClientCertificate, c.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("nuodb: client certificate: %v", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	if c.SkipVerifyHostname {
+		// verify the chain, but not the name, as crypto/tls can't do
+		// without a ServerName
+		config.InsecureSkipVerify = true
+		config.VerifyPeerCertificate = func(raw [][]byte, _ [][]*x509.Certificate) error {
+			return verifyChain(raw, config.RootCAs)
+		}
+	}
+	return config, nil
+}
+
+// verifyChain verifies the certificates of a server against roots, or the
+// system roots if nil.
+func verifyChain(raw [][]byte, roots *x509.CertPool) error {
+	if len(raw) == 0 {
+		return errors.New("nuodb: admin server sent no certificate")
+	}
+	certs := make([]*x509.Certificate, len(raw))
+	for i, b := range raw {
+		cert, err := x509.ParseCertificate(b)
+		if err != nil {
+			return err
+		}
+		certs[i] = cert
+	}
+	opts := x509.VerifyOptions{Roots: roots, Intermediates: x509.NewCertPool()}
+	for _, cert := range certs[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	_, err := certs[0].Verify(opts)
+	return err
+}
+
+// Databases returns the databases of the domain.
+func (c *Client) Databases(ctx context.Context) ([]Database, error) {
+	var databases []Database
+	err := c.list(ctx, "databases", func(data json.RawMessage) error {
+		var page []Database
+		err := json.Unmarshal(data, &page)
+		databases = append(databases, page...)
+		return err
+	})
+	return databases, err
+}
+
+// Database returns the named database.
+func (c *Client) Database(ctx context.Context, name string) (*Database, error) {
+	var db Database
+	if err := c.do(ctx, http.MethodGet, "databases/"+url.PathEscape(name), nil, &db); err != nil {
+		return nil, err
+	}
+	return &db, nil
+}
+
+// Processes returns the processes of the named database, or of all the
+// databases of the domain if dbName is empty.
+func (c *Client) Processes(ctx context.Context, dbName string) ([]Process, error) {
+	var processes []Process
+	err := c.list(ctx, "processes", func(data json.RawMessage) error {
+		var page []Process
+		if err := json.Unmarshal(data, &page); err != nil {
+			return err
+		}
+		for _, p := range page {
+			if dbName == "" || p.DBName == dbName {
+				processes = append(processes, p)
+			}
+		}
+		return nil
+	})
+	return processes, err
+}
+
+// StartProcess starts a process, which is returned as it is starting up.
+func (c *Client) StartProcess(ctx context.Context, r StartRequest) (*Process, error) {
+	var p Process
+	if err := c.do(ctx, http.MethodPost, "processes", r, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// StopProcess shuts down the process of startID, gracefully unless kill.
+func (c *Client) StopProcess(ctx context.Context, startID string, kill bool) error {
+	path := "processes/" + url.PathEscape(startID)
+	if kill {
+		path += "?kill=true"
+	}
+	return c.do(ctx, http.MethodDelete, path, nil, nil)
+}
+
+// page is a page of the response of a list.
+type page struct {
+	Data    json.RawMessage `json:"data"`
+	HasNext bool            `json:"hasNext"`
+}
+
+// list calls add with the data of each page of the list of path.
+func (c *Client) list(ctx context.Context, path string, add func(json.RawMessage) error) error {
+	for offset := 0; ; {
+		var p page
+		if err := c.do(ctx, http.MethodGet, path+"?offset="+strconv.Itoa(offset), nil, &p); err != nil {
+			return err
+		}
+		var items []json.RawMessage
+		if err := json.Unmarshal(p.Data, &items); err != nil {
+			return fmt.Errorf("nuodb: admin: %s: %v", path, err)
+		}
+		if err := add(p.Data); err != nil {
+			return fmt.Errorf("nuodb: admin: %s: %v", path, err)
+		}
+		if !p.HasNext || len(items) == 0 {
+			return nil
+		}
+		offset += len(items)
+	}
+}
+
+// do sends a request of the Admin API with the JSON of in, if not nil, and
+// decodes the JSON of the response into out, if not nil.
+func (c *Client) do(ctx context.Context, method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, c.url+apiPath+path, body)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 4096))
+		return &Error{StatusCode: resp.StatusCode, Message: errorMessage(b, resp.Status)}
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("nuodb: admin: %s: %v", path, err)
+	}
+	return nil
+}
+
+// errorMessage returns the message of the body of an error response, or
+// status if it has none.
+func errorMessage(body []byte, status string) string {
+	var e struct {
+		Messages []string `json:"messages"`
+	}
+	if json.Unmarshal(body, &e) == nil && len(e.Messages) > 0 {
+		return strings.Join(e.Messages, "; ")
+	}
+	if s := strings.TrimSpace(string(body)); s != "" {
+		return s
+	}
+	return status
+}
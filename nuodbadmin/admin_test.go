@@ -0,0 +1,102 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodbadmin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func testServer(t *testing.T, handler http.HandlerFunc) (*Client, func()) {
+	s := httptest.NewServer(handler)
+	c, err := NewClient(s.URL+"/", nil)
+	if err != nil {
+		s.Close()
+		t.Fatal(err)
+	}
+	return c, s.Close
+}
+
+func TestNewClientErrors(t *testing.T) {
+	for _, u := range []string{"", "admin:8888", "ftp://admin", "http://"} {
+		if _, err := NewClient(u, nil); err == nil {
+			t.Fatalf("Expected an error for %q", u)
+		}
+	}
+}
+
+func TestProcesses(t *testing.T) {
+	c, done := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/1/processes" {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.URL.Query().Get("offset") {
+		case "0":
+			w.Write([]byte(`{"data": [{"startId": "0", "type": "SM", "state": "RUNNING", "dbName": "tests", "archiveId": 1},
+				{"startId": "1", "type": "TE", "state": "RUNNING", "dbName": "other"}], "hasNext": true}`))
+		case "2":
+			w.Write([]byte(`{"data": [{"startId": "2", "type": "TE", "state": "RUNNING", "dbName": "tests", "port": 48006}], "hasNext": false}`))
+		default:
+			t.Errorf("Unexpected offset of %s", r.URL)
+		}
+	})
+	defer done()
+	processes, err := c.Processes(context.Background(), "tests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(processes) != 2 || processes[0].ArchiveID != 1 || processes[1].StartID != "2" || processes[1].Port != 48006 {
+		t.Fatalf("Unexpected processes %+v", processes)
+	}
+	all, err := c.Processes(context.Background(), "")
+	if err != nil || len(all) != 3 {
+		t.Fatalf("Expected 3 processes, got %+v (%v)", all, err)
+	}
+}
+
+func TestStartStopProcess(t *testing.T) {
+	var stopped string
+	c, done := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/1/processes":
+			var req StartRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.DBName != "tests" || req.EngineType != "TE" {
+				t.Errorf("Unexpected start request %+v (%v)", req, err)
+			}
+			w.Write([]byte(`{"startId": "7", "type": "TE", "state": "STARTING", "dbName": "tests"}`))
+		case r.Method == http.MethodDelete:
+			stopped = r.URL.String()
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	defer done()
+	ctx := context.Background()
+	p, err := c.StartProcess(ctx, StartRequest{DBName: "tests", EngineType: "TE", ServerID: "admin-0"})
+	if err != nil || p.StartID != "7" || p.State != "STARTING" {
+		t.Fatalf("Unexpected process %+v (%v)", p, err)
+	}
+	if err := c.StopProcess(ctx, p.StartID, true); err != nil {
+		t.Fatal(err)
+	}
+	if stopped != "/api/1/processes/7?kill=true" {
+		t.Fatalf("Unexpected stop request %q", stopped)
+	}
+}
+
+func TestError(t *testing.T) {
+	c, done := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"code": 404, "messages": ["No database with name nope"]}`))
+	})
+	defer done()
+	_, err := c.Database(context.Background(), "nope")
+	e, ok := err.(*Error)
+	if !ok || e.StatusCode != http.StatusNotFound || e.Message != "No database with name nope" {
+		t.Fatalf("Unexpected error %#v", err)
+	}
+}
@@ -0,0 +1,10 @@
+module github.com/tilinna/go-nuodb/nuodbmigrate
+
+go 1.25.11
+
+require (
+	github.com/golang-migrate/migrate/v4 v4.20.1
+	github.com/tilinna/go-nuodb v0.0.0
+)
+
+replace github.com/tilinna/go-nuodb => ../
@@ -0,0 +1,283 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+// Package nuodbmigrate runs the schema migrations of golang-migrate
+// (github.com/golang-migrate/migrate/v4) against NuoDB:
+//
+//	driver, err := nuodbmigrate.WithInstance(db, &nuodbmigrate.Config{})
+//	m, err := migrate.NewWithDatabaseInstance("file://migrations", "nuodb", driver)
+//	err = m.Up()
+//
+// Driver implements the database.Driver interface of golang-migrate and is
+// registered for the nuodb:// URLs. The package is a module of its own, so
+// that the driver doesn't depend on golang-migrate.
+//
+// The version is kept in the MigrationsTable, a single row of version and
+// dirty. Lock holds the lock of the row of the LockTable in a transaction
+// of its own, so that the migrations of concurrent instances of an
+// application run one at a time, and the lock is released by the server
+// should the instance holding it die. A migration may hold several
+// statements separated by semicolons, as run by nuodb.ExecScript.
+package nuodbmigrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/tilinna/go-nuodb"
+)
+
+// DefaultMigrationsTable is the table of the version by default.
+const DefaultMigrationsTable = "schema_migrations"
+
+// nilVersion is the version of a database without migrations, the
+// database.NilVersion of golang-migrate.
+const nilVersion = -1
+
+// ErrLocked is returned by Lock if the Driver already holds the lock.
+var ErrLocked = errors.New("nuodb: migrations already locked")
+
+// Config configures a Driver.
+type Config struct {
+	// MigrationsTable is the table of the version, DefaultMigrationsTable
+	// by default. It may be qualified by a schema. The x-migrations-table
+	// parameter of a URL sets it.
+	MigrationsTable string
+
+	// LockTable is the table of the lock, the MigrationsTable with a
+	// _lock suffix by default. The x-lock-table parameter of a URL sets
+	// it.
+	LockTable string
+}
+
+// Driver runs the migrations on a database. It is not safe for concurrent
+// use, as golang-migrate uses it from one goroutine.
+type Driver struct {
+	db     *sql.DB
+	cfg    Config
+	opened bool // db was opened by Open, so Close closes it
+
+	lockConn *sql.Conn
+	lockTx   *sql.Tx
+}
+
+// tableRegexp matches the unquoted, optionally schema qualified, table
+// names.
+var tableRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*(\.[A-Za-z_][A-Za-z0-9_$]*)?$`)
+
+// WithInstance returns a Driver of the migrations of db, configured by cfg,
+// and creates its tables unless they exist.
+func WithInstance(db *sql.DB, cfg *Config) (*Driver, error) {
+	d := &Driver{db: db, cfg: *cfg}
+	if d.cfg.MigrationsTable == "" {
+		d.cfg.MigrationsTable = DefaultMigrationsTable
+	}
+	if d.cfg.LockTable == "" {
+		d.cfg.LockTable = d.cfg.MigrationsTable + "_lock"
+	}
+	for _, table := range []string{d.cfg.MigrationsTable, d.cfg.LockTable} {
+		if !tableRegexp.MatchString(table) {
+			return nil, fmt.Errorf("nuodb: invalid migrations table name: %q", table)
+		}
+	}
+	if err := d.ensureTables(context.Background()); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// open opens the database of a golang-migrate URL, a data source name of
+// the driver with the x-migrations-table and x-lock-table parameters of
+// the Config.
+func open(rawURL string) (*Driver, error) {
+	dsn, cfg, err := parseURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("nuodb", dsn)
+	if err != nil {
+		return nil, err
+	}
+	d, err := WithInstance(db, cfg)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	d.opened = true
+	return d, nil
+}
+
+// parseURL splits a golang-migrate URL into the data source name of the
+// driver and the Config of its x- parameters.
+func parseURL(rawURL string) (string, *Config, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", nil, fmt.Errorf("nuodb: invalid migrations url: %s", err)
+	}
+	query := u.Query()
+	cfg := &Config{MigrationsTable: query.Get("x-migrations-table"), LockTable: query.Get("x-lock-table")}
+	for name := range query {
+		if strings.HasPrefix(name, "x-") {
+			query.Del(name)
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), cfg, nil
+}
+
+func (d *Driver) ensureTables(ctx context.Context) error {
+	for _, stmt := range []string{
+		"CREATE TABLE IF NOT EXISTS " + d.cfg.MigrationsTable + " (version BIGINT NOT NULL, dirty BOOLEAN NOT NULL)",
+		"CREATE TABLE IF NOT EXISTS " + d.cfg.LockTable + " (id INTEGER NOT NULL PRIMARY KEY)",
+	} {
+		if _, err := d.db.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	_, err := d.db.ExecContext(ctx, "INSERT INTO "+d.cfg.LockTable+" (id) VALUES (1)")
+	if errors.Is(err, nuodb.ErrUniqueViolation) {
+		err = nil // inserted before, or concurrently by another instance
+	}
+	return err
+}
+
+// Close closes the database if the Driver opened it, releasing the lock.
+func (d *Driver) Close() error {
+	err := d.Unlock()
+	if d.opened {
+		if cerr := d.db.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// Lock waits for the lock of the migrations, held by another instance,
+// and takes it.
+func (d *Driver) Lock() error {
+	if d.lockTx != nil {
+		return ErrLocked
+	}
+	ctx := context.Background()
+	conn, err := d.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	var id int
+	if err := tx.QueryRowContext(ctx, "SELECT id FROM "+d.cfg.LockTable+" WHERE id = 1 FOR UPDATE").Scan(&id); err != nil {
+		tx.Rollback()
+		conn.Close()
+		return fmt.Errorf("nuodb: locking the migrations: %w", err)
+	}
+	d.lockConn, d.lockTx = conn, tx
+	return nil
+}
+
+// Unlock releases the lock of the migrations, if held.
+func (d *Driver) Unlock() error {
+	if d.lockTx == nil {
+		return nil
+	}
+	err := d.lockTx.Rollback()
+	if cerr := d.lockConn.Close(); err == nil {
+		err = cerr
+	}
+	d.lockConn, d.lockTx = nil, nil
+	return err
+}
+
+// Run executes a migration, whose statements are separated by semicolons.
+func (d *Driver) Run(migration io.Reader) error {
+	script, err := ioutil.ReadAll(migration)
+	if err != nil {
+		return err
+	}
+	_, err = nuodb.ExecScript(context.Background(), d.db, string(script))
+	return err
+}
+
+// SetVersion records version as the version of the database, and whether
+// its migration failed halfway. The nil version of golang-migrate, -1,
+// clears it unless dirty.
+func (d *Driver) SetVersion(version int, dirty bool) error {
+	ctx := context.Background()
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM "+d.cfg.MigrationsTable); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if version >= 0 || version == nilVersion && dirty {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO "+d.cfg.MigrationsTable+" (version, dirty) VALUES (?, ?)",
+			version, dirty); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Version returns the version of the database, and whether its migration
+// failed halfway, or -1 if no migration has been run.
+func (d *Driver) Version() (version int, dirty bool, err error) {
+	err = d.db.QueryRowContext(context.Background(),
+		"SELECT version, dirty FROM "+d.cfg.MigrationsTable+" LIMIT 1").Scan(&version, &dirty)
+	if err == sql.ErrNoRows {
+		return nilVersion, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return version, dirty, nil
+}
+
+// Drop drops the tables and the views of the current schema, including
+// the MigrationsTable, e.g. to migrate a test database from scratch, and
+// creates the MigrationsTable again. The LockTable is kept, as its row may
+// be locked by the Driver.
+func (d *Driver) Drop() error {
+	ctx := context.Background()
+	var schema string
+	if err := d.db.QueryRowContext(ctx, "SELECT CURRENT_SCHEMA FROM DUAL").Scan(&schema); err != nil {
+		return err
+	}
+	tables, err := nuodb.ListTables(ctx, d.db, schema)
+	if err != nil {
+		return err
+	}
+	lockTable := d.cfg.LockTable[strings.LastIndex(d.cfg.LockTable, ".")+1:]
+	// the views first, as they depend on the tables
+	for _, view := range []bool{true, false} {
+		for _, t := range tables {
+			if (t.Type == "VIEW") != view || strings.EqualFold(t.Name, lockTable) {
+				continue
+			}
+			stmt := "DROP TABLE IF EXISTS " + quote(t.Schema) + "." + quote(t.Name) + " CASCADE"
+			if view {
+				stmt = "DROP VIEW IF EXISTS " + quote(t.Schema) + "." + quote(t.Name)
+			}
+			if _, err := d.db.ExecContext(ctx, stmt); err != nil {
+				return err
+			}
+		}
+	}
+	return d.ensureTables(ctx)
+}
+
+// quote quotes an identifier as reported by the server.
+func quote(s string) string {
+	return `"` + strings.Replace(s, `"`, `""`, -1) + `"`
+}
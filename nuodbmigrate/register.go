@@ -0,0 +1,18 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodbmigrate
+
+import "github.com/golang-migrate/migrate/v4/database"
+
+func init() {
+	database.Register("nuodb", &Driver{})
+}
+
+var _ database.Driver = (*Driver)(nil)
+
+// Open implements database.Driver. It opens the database of a data source
+// name of the driver, which may have the x-migrations-table and
+// x-lock-table parameters of the Config.
+func (d *Driver) Open(url string) (database.Driver, error) {
+	return open(url)
+}
@@ -0,0 +1,134 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodbtest
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// startTimeout bounds the start of the docker database of DSN.
+const startTimeout = 5 * time.Minute
+
+// Docker is a throwaway NuoDB domain in docker: an admin server, an SM and
+// a TE, each in a container of its own on a network of their own. The
+// admin and the TE ports are published on localhost, so they must be free.
+type Docker struct {
+	Image    string // defaults to nuodb/nuodb-ce:latest
+	Database string // defaults to tests
+	User     string // the DBA, defaults to dba
+	Password string // defaults to goalie
+
+	name       string // the prefix of the containers and of the network
+	containers []string
+}
+
+func (d *Docker) defaults() {
+	if d.Image == "" {
+		d.Image = "nuodb/nuodb-ce:latest"
+	}
+	if d.Database == "" {
+		d.Database = "tests"
+	}
+	if d.User == "" {
+		d.User = "dba"
+	}
+	if d.Password == "" {
+		d.Password = "goalie"
+	}
+}
+
+// Start starts the domain and returns the DSN of its database once it
+// accepts connections. A failed start stops what was started.
+func (d *Docker) Start(ctx context.Context) (dsn string, err error) {
+	d.defaults()
+	d.name = "nuodbtest-" + randomSuffix()
+	defer func() {
+		if err != nil {
+			_ = d.Stop()
+		}
+	}()
+	if err := docker(ctx, "network", "create", d.name); err != nil {
+		return "", err
+	}
+	admin := d.name + "-admin"
+	if err := d.run(ctx, admin, []string{"-p", "48004:48004", "-p", "48005:48005",
+		"-e", "NUODB_DOMAIN_ENTRYPOINT=" + admin}, "nuoadmin"); err != nil {
+		return "", err
+	}
+	api := []string{"nuodocker", "--api-server", admin + ":8888", "start"}
+	if err := d.run(ctx, d.name+"-sm", nil, append(api, "sm", "--db-name", d.Database,
+		"--server-id", admin, "--dba-user", d.User, "--dba-password", d.Password)...); err != nil {
+		return "", err
+	}
+	if err := d.run(ctx, d.name+"-te", []string{"-p", "48006:48006"}, append(api, "te",
+		"--db-name", d.Database, "--server-id", admin, "--options", "alt-address localhost")...); err != nil {
+		return "", err
+	}
+	dsn = fmt.Sprintf("nuodb://%s:%s@localhost:48004/%s", d.User, d.Password, d.Database)
+	return dsn, waitReady(ctx, dsn)
+}
+
+// Stop removes the containers and the network of the domain.
+func (d *Docker) Stop() error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	var err error
+	if len(d.containers) > 0 {
+		err = docker(ctx, append([]string{"rm", "-f"}, d.containers...)...)
+		d.containers = nil
+	}
+	if d.name != "" {
+		if netErr := docker(ctx, "network", "rm", d.name); err == nil {
+			err = netErr
+		}
+		d.name = ""
+	}
+	return err
+}
+
+// run starts a container of the domain.
+func (d *Docker) run(ctx context.Context, name string, flags []string, command ...string) error {
+	args := []string{"run", "-d", "--name", name, "--hostname", name, "--net", d.name}
+	args = append(append(append(args, flags...), d.Image), command...)
+	if err := docker(ctx, args...); err != nil {
+		return err
+	}
+	d.containers = append(d.containers, name)
+	return nil
+}
+
+func docker(ctx context.Context, args ...string) error {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "docker", args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("docker %s: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// waitReady waits until the database of dsn accepts connections.
+func waitReady(ctx context.Context, dsn string) error {
+	db, err := sql.Open("nuodb", dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	for {
+		err := db.PingContext(ctx)
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("database not ready: %v", err)
+		case <-time.After(2 * time.Second):
+		}
+	}
+}
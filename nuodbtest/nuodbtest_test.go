@@ -0,0 +1,33 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodbtest
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestSchemaName(t *testing.T) {
+	valid := regexp.MustCompile(`^T_[A-Z0-9_]*_[A-F0-9]+$`)
+	for _, test := range []string{"TestOrders", "TestOrders/by_id #1", "", "TestAVeryLongNameOfATestWhichIsTruncatedToFitTheSchemaNames"} {
+		name := schemaName(test)
+		if !valid.MatchString(name) || len(name) > 60 {
+			t.Errorf("%q: invalid schema name %q", test, name)
+		}
+		if name == schemaName(test) {
+			t.Errorf("%q: expected unique schema names, got %q twice", test, name)
+		}
+	}
+}
+
+func TestOpen(t *testing.T) {
+	db := Open(t)
+	defer db.Close()
+	var schema string
+	if err := db.QueryRow("SELECT CURRENT_SCHEMA FROM DUAL").Scan(&schema); err != nil {
+		t.Fatal(err)
+	}
+	if schema != db.Schema {
+		t.Fatalf("Expected schema %s, got %s", db.Schema, schema)
+	}
+}
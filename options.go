@@ -0,0 +1,81 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"io"
+)
+
+// Option is a driver-specific setting which can be passed among the
+// arguments of a single Exec or Query call, e.g.
+//
+//	db.QueryContext(ctx, "SELECT * FROM t WHERE x > ?", nuodb.FetchSize(500), 10)
+//
+// Options are removed from the argument list before the statement is bound
+// and they apply only to the call they were passed to.
+type Option interface {
+	apply(*callOptions)
+}
+
+var _ driver.NamedValueChecker = (*Conn)(nil)
+
+type callOptions struct {
+	fetchSize    int
+	streamLobs   bool
+	reuseBuffers bool
+	progress     *FetchProgress
+	cursor       Cursor
+}
+
+// FetchSize sets the number of rows fetched from the server per round trip.
+// Zero lets the server decide.
+type FetchSize int
+
+func (n FetchSize) apply(o *callOptions) {
+	o.fetchSize = int(n)
+}
+
+// CheckNamedValue implements driver.NamedValueChecker. It collects any
+// Option arguments for the next execution on the connection, keeps the
+// sql.Out arguments, keeps the LobSource and io.Reader arguments for
+// streaming, converts the Go types supported by the driver and keeps the
+// slices, other than []byte, to be expanded into lists of placeholders. The
+// conversion of other arguments is left to database/sql.
+func (c *Conn) CheckNamedValue(nv *driver.NamedValue) error {
+	switch v := nv.Value.(type) {
+	case Option:
+		v.apply(&c.opts)
+		return driver.ErrRemoveArgument
+	case LobSource, sql.Out:
+		return nil
+	case driver.Valuer:
+		// converted by database/sql
+	case io.Reader:
+		nv.Value = LobSource{Reader: v}
+		return nil
+	}
+	v, ok, err := convertValue(nv.Value)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if v, ok, err = listValue(nv); err != nil {
+			return err
+		}
+	}
+	if !ok {
+		return driver.ErrSkip
+	}
+	nv.Value = v
+	return nil
+}
+
+// takeOptions returns the options collected for the current call and
+// resets them, so they never leak into the following calls.
+func (c *Conn) takeOptions() callOptions {
+	opts := c.opts
+	c.opts = callOptions{}
+	return opts
+}
@@ -0,0 +1,46 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"database/sql/driver"
+	"testing"
+)
+
+func TestCheckNamedValueOptions(t *testing.T) {
+	c := &Conn{}
+	nv := &driver.NamedValue{Ordinal: 1, Value: FetchSize(500)}
+	if err := c.CheckNamedValue(nv); err != driver.ErrRemoveArgument {
+		t.Fatalf("Expected ErrRemoveArgument, got %v", err)
+	}
+	nv = &driver.NamedValue{Ordinal: 2, Value: int64(1)}
+	if err := c.CheckNamedValue(nv); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+	if opts := c.takeOptions(); opts.fetchSize != 500 {
+		t.Fatalf("Expected fetch size 500, got %d", opts.fetchSize)
+	}
+	if opts := c.takeOptions(); opts.fetchSize != 0 {
+		t.Fatalf("Expected options to be reset, got %+v", opts)
+	}
+}
+
+func TestFetchSizeOption(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	exec(t, db, "CREATE TABLE tests.FooBarFetch (id BIGINT)")
+	exec(t, db, "INSERT INTO tests.FooBarFetch VALUES (1),(2),(3)")
+
+	rows := query(t, db, "SELECT id FROM tests.FooBarFetch WHERE id > ?", FetchSize(2), 1)
+	defer rows.Close()
+	n := 0
+	for rows.Next() {
+		n++
+	}
+	if rows.Err() != nil {
+		t.Fatal(rows.Err())
+	}
+	if n != 2 {
+		t.Fatalf("Expected 2 rows, got %d", n)
+	}
+}
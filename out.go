@@ -0,0 +1,119 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+// #include "cnuodb.h"
+import "C"
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// outParam is an output parameter of a stored procedure call.
+type outParam struct {
+	index int
+	dest  interface{}
+}
+
+func hasOuts(args []driver.NamedValue) bool {
+	for _, arg := range args {
+		if _, ok := arg.Value.(sql.Out); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// bindOuts replaces the sql.Out args with their input values, or with NULL
+// for pure output parameters, and returns the output parameters.
+func (stmt *Stmt) bindOuts(args []driver.Value) ([]outParam, error) {
+	var outs []outParam
+	for i, v := range args {
+		out, ok := v.(sql.Out)
+		if !ok {
+			continue
+		}
+		if !stmt.call {
+			return nil, fmt.Errorf("nuodb: parameter %d: sql.Out requires a CALL or EXECUTE statement", i+1)
+		}
+		dest := reflect.ValueOf(out.Dest)
+		if dest.Kind() != reflect.Ptr || dest.IsNil() {
+			return nil, fmt.Errorf("nuodb: parameter %d: sql.Out destination must be a non-nil pointer", i+1)
+		}
+		args[i] = nil
+		if out.In {
+			in, err := convertIn(dest.Elem().Interface())
+			if err != nil {
+				return nil, fmt.Errorf("nuodb: parameter %d: %s", i+1, err)
+			}
+			args[i] = in
+		}
+		outs = append(outs, outParam{index: i, dest: out.Dest})
+	}
+	return outs, nil
+}
+
+// convertIn converts the input value of an INOUT parameter like
+// CheckNamedValue converts the other arguments.
+func convertIn(v interface{}) (driver.Value, error) {
+	if in, ok, err := convertValue(v); ok || err != nil {
+		return in, err
+	}
+	return driver.DefaultParameterConverter.ConvertValue(v)
+}
+
+func (stmt *Stmt) registerOuts(outs []outParam) error {
+	c := stmt.c
+	for _, out := range outs {
+		if err := stmt.cgo(func() C.int {
+			return C.nuodb_statement_register_out(c.db, stmt.st, C.int(out.index))
+		}); err != nil {
+			return err
+		}
+	}
+	stmt.outs = outs
+	return nil
+}
+
+// readOuts assigns the values of the output parameters to their
+// destinations after the execution, with the times in loc.
+func (stmt *Stmt) readOuts(loc *time.Location) error {
+	c := stmt.c
+	for _, out := range stmt.outs {
+		var value C.struct_nuodb_value
+		if err := stmt.cgo(func() C.int {
+			return C.nuodb_statement_out_value(c.db, stmt.st, C.int(out.index), &value)
+		}); err != nil {
+			return err
+		}
+		if err := assignOut(out.dest, c.decodeValue(value, loc)); err != nil {
+			return fmt.Errorf("nuodb: parameter %d: %s", out.index+1, err)
+		}
+	}
+	return nil
+}
+
+// assignOut stores v into dest, which is a sql.Scanner or a pointer to a
+// type v is convertible to. A NULL sets dest to its zero value.
+func assignOut(dest interface{}, v driver.Value) error {
+	if scanner, ok := dest.(sql.Scanner); ok {
+		return scanner.Scan(v)
+	}
+	d := reflect.ValueOf(dest).Elem()
+	if v == nil {
+		d.Set(reflect.Zero(d.Type()))
+		return nil
+	}
+	if b, ok := v.([]byte); ok && d.Kind() == reflect.Interface {
+		v = string(b)
+	}
+	sv := reflect.ValueOf(v)
+	if !sv.Type().ConvertibleTo(d.Type()) {
+		return fmt.Errorf("cannot assign %T to %s", v, d.Type())
+	}
+	d.Set(sv.Convert(d.Type()))
+	return nil
+}
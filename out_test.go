@@ -0,0 +1,81 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"testing"
+)
+
+func TestBindOuts(t *testing.T) {
+	var b int64
+	c := "abc"
+	args := []driver.Value{int64(1), sql.Out{Dest: &b}, sql.Out{Dest: &c, In: true}}
+	stmt := &Stmt{call: true}
+	outs, err := stmt.bindOuts(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(args, []driver.Value{int64(1), nil, "abc"}) {
+		t.Fatalf("Unexpected args: %v", args)
+	}
+	if len(outs) != 2 || outs[0].index != 1 || outs[1].index != 2 {
+		t.Fatalf("Unexpected outs: %+v", outs)
+	}
+
+	stmt = &Stmt{}
+	if _, err := stmt.bindOuts([]driver.Value{sql.Out{Dest: &b}}); err == nil {
+		t.Fatal("Expected error for a non-call statement")
+	}
+	stmt = &Stmt{call: true}
+	if _, err := stmt.bindOuts([]driver.Value{sql.Out{Dest: b}}); err == nil {
+		t.Fatal("Expected error for a non-pointer destination")
+	}
+}
+
+func TestAssignOut(t *testing.T) {
+	var i int
+	if err := assignOut(&i, int64(42)); err != nil || i != 42 {
+		t.Fatalf("Expected 42, got %d (%v)", i, err)
+	}
+	var s string
+	if err := assignOut(&s, []byte("abc")); err != nil || s != "abc" {
+		t.Fatalf("Expected abc, got %q (%v)", s, err)
+	}
+	var v interface{}
+	if err := assignOut(&v, []byte("abc")); err != nil || v != "abc" {
+		t.Fatalf("Expected abc, got %#v (%v)", v, err)
+	}
+	var ns sql.NullString
+	if err := assignOut(&ns, nil); err != nil || ns.Valid {
+		t.Fatalf("Expected NULL, got %+v (%v)", ns, err)
+	}
+	if err := assignOut(&i, nil); err != nil || i != 0 {
+		t.Fatalf("Expected zero, got %d (%v)", i, err)
+	}
+	var f float64
+	if err := assignOut(&f, true); err == nil {
+		t.Fatal("Expected error for an incompatible destination")
+	}
+}
+
+func TestOutParameters(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	exec(t, db, `CREATE PROCEDURE tests.FooBarProc (IN a INTEGER, OUT b INTEGER, INOUT c STRING)
+		AS
+			b = a * 2;
+			c = c || '!';
+		END_PROCEDURE`)
+
+	var b int64
+	c := "abc"
+	if _, err := db.Exec("CALL tests.FooBarProc(?, ?, ?)", 21, sql.Out{Dest: &b}, sql.Out{Dest: &c, In: true}); err != nil {
+		t.Fatal(err)
+	}
+	if b != 42 || c != "abc!" {
+		t.Fatalf("Expected 42 and abc!, got %d and %q", b, c)
+	}
+}
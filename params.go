@@ -0,0 +1,106 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"runtime"
+	"time"
+)
+
+// EncodedParams holds statement parameters which have already been converted
+// to their C representation. They can be bound to any number of executions of
+// a Stmt without repeating the per-call conversion and allocation of bind.
+// The data of string and []byte arguments is copied, so the arguments may be
+// modified after encoding. Close frees the encoded parameters; otherwise they
+// are freed when garbage collected.
+type EncodedParams struct {
+	values *cValues
+}
+
+// EncodeParams converts args to an EncodedParams. The supported argument
+// types are the driver.Value types, Date and TimeOfDay. The Dates and
+// TimesOfDay are encoded in the Local time zone, so bind them only on
+// connections without a timezone property. The empty strings are bound as
+// they are, regardless of the emptyStringAsNull property.
+func EncodeParams(args ...driver.Value) (*EncodedParams, error) {
+	values, err := encodeValues(args, time.Local, false)
+	if err != nil {
+		return nil, err
+	}
+	p := &EncodedParams{values: values}
+	runtime.SetFinalizer(p, (*EncodedParams).Close)
+	return p, nil
+}
+
+// Len returns the number of encoded parameters.
+func (p *EncodedParams) Len() int {
+	return len(p.values.values)
+}
+
+// Close frees the encoded parameters. They can't be bound after that.
+func (p *EncodedParams) Close() error {
+	p.values.free()
+	p.values.values = nil
+	runtime.SetFinalizer(p, nil)
+	return nil
+}
+
+func (stmt *Stmt) bindEncoded(p *EncodedParams) error {
+	parameterCount := int(stmt.parameterCount)
+	if parameterCount == 0 {
+		return nil
+	}
+	if p.Len() < parameterCount {
+		return fmt.Errorf("nuodb: encoded params: expected %d parameters, got %d", parameterCount, p.Len())
+	}
+	err := stmt.bindValues(p.values.values)
+	runtime.KeepAlive(p) // not finalized while bound
+	return err
+}
+
+// ExecEncoded executes a prepared statement with pre-encoded parameters.
+func (stmt *Stmt) ExecEncoded(ctx context.Context, p *EncodedParams) (driver.Result, error) {
+	c := stmt.c
+	if c.db == nil {
+		return nil, errClosed
+	}
+	exit, err := c.enter()
+	if err != nil {
+		return nil, err
+	}
+	defer exit()
+	var res driver.Result
+	err = stmt.reprepareOnce(func() (err error) {
+		if err = stmt.bindEncoded(p); err != nil {
+			return fmt.Errorf("bind: %s", err)
+		}
+		res, err = stmt.execute(ctx)
+		return err
+	})
+	return res, err
+}
+
+// QueryEncoded executes a prepared query with pre-encoded parameters.
+func (stmt *Stmt) QueryEncoded(ctx context.Context, p *EncodedParams) (driver.Rows, error) {
+	c := stmt.c
+	if c.db == nil {
+		return nil, errClosed
+	}
+	exit, err := c.enter()
+	if err != nil {
+		return nil, err
+	}
+	defer exit()
+	var rows driver.Rows
+	err = stmt.reprepareOnce(func() (err error) {
+		if err = stmt.bindEncoded(p); err != nil {
+			return fmt.Errorf("bind: %s", err)
+		}
+		rows, err = stmt.query(ctx)
+		return err
+	})
+	return rows, err
+}
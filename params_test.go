@@ -0,0 +1,102 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestEncodeParams(t *testing.T) {
+	p, err := EncodeParams(int64(1), 2.5, true, "str", []byte{1, 2, 3}, time.Now(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Len() != 7 {
+		t.Fatalf("Expected 7 parameters, got %d", p.Len())
+	}
+	defer p.Close()
+
+	// the data is copied, so modifying the arguments doesn't affect it
+	b := []byte{1, 2, 3}
+	q, err := EncodeParams("str", b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b[0] = 9
+	if s, data := q.values.bytes(0), q.values.bytes(1); string(s) != "str" || string(data) != "\x01\x02\x03" {
+		t.Fatalf("Unexpected encoded data %q, %v", s, data)
+	}
+	q.Close()
+	if q.Len() != 0 {
+		t.Fatalf("Expected no parameters after Close, got %d", q.Len())
+	}
+
+	_, err = EncodeParams(int64(1), struct{}{})
+	if err == nil || err.Error() != "nuodb: parameter 2: unsupported type struct {}" {
+		t.Fatalf("Expected unsupported type error, got %v", err)
+	}
+}
+
+func TestParameterDataOverflow(t *testing.T) {
+	// values which don't fit the allocation fail rather than overrun it
+	cv := newCValues(2, 2, time.UTC)
+	defer cv.free()
+	if err := cv.add("ab"); err != nil {
+		t.Fatal(err)
+	}
+	err := cv.add([]byte{1})
+	if err == nil || err.Error() != "1 bytes, more than the 0 bytes left of the data" {
+		t.Fatalf("Expected an error for the data over the allocation, got %v", err)
+	}
+}
+
+func TestExecEncoded(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	exec(t, db, "CREATE TABLE tests.FooBarEncoded (id BIGINT, str STRING)")
+
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	err = conn.Raw(func(driverConn interface{}) error {
+		c := driverConn.(*Conn)
+		st, err := c.Prepare("INSERT INTO tests.FooBarEncoded (id, str) VALUES (?, ?)")
+		if err != nil {
+			return err
+		}
+		defer st.Close()
+		stmt := st.(*Stmt)
+		p, err := EncodeParams(int64(7), "seven")
+		if err != nil {
+			return err
+		}
+		for i := 0; i < 3; i++ {
+			if _, err := stmt.ExecEncoded(context.Background(), p); err != nil {
+				return err
+			}
+		}
+		p, err = EncodeParams(int64(8))
+		if err != nil {
+			return err
+		}
+		if _, err := stmt.ExecEncoded(context.Background(), p); err == nil {
+			t.Fatal("Expected too few parameters error")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var count int64
+	if err := db.QueryRow("SELECT COUNT(*) FROM tests.FooBarEncoded WHERE str = ?", "seven").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 {
+		t.Fatalf("Expected 3 rows, got %d", count)
+	}
+}
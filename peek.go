@@ -0,0 +1,48 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"database/sql/driver"
+	"errors"
+)
+
+// Peek fetches the next row and returns its values without consuming
+// them: the following Next returns the same row. It returns io.EOF if
+// there are no more rows. Calling Peek again before Next returns the same
+// values. Peek is reachable through the driver connection:
+//
+//	err := conn.Raw(func(driverConn interface{}) error {
+//		dr, err := driverConn.(*nuodb.Conn).QueryContext(ctx, query, nil)
+//		...
+//		rows := dr.(*nuodb.Rows)
+//		defer rows.Close()
+//		if _, err := rows.Peek(); err == io.EOF {
+//			// the last page
+//		}
+//		...
+//	})
+//
+// This serves e.g. to tell whether a page is the last one, or to merge
+// several sorted results on the client. As with Next, the byte slices of
+// the current row are overwritten by Peek with the ReuseBuffers option.
+// Peek is not supported with the StreamLobs option, as the lobs of the
+// current row would be lost.
+func (rows *Rows) Peek() ([]driver.Value, error) {
+	if rows.peeked != nil {
+		return rows.peeked, nil
+	}
+	if rows.peekErr != nil {
+		return nil, rows.peekErr
+	}
+	if rows.streamLobs {
+		return nil, errors.New("nuodb: rows with streamed lobs can't be peeked")
+	}
+	values := make([]driver.Value, len(rows.rowValues))
+	if err := rows.next(values); err != nil {
+		rows.peekErr = err
+		return nil, err
+	}
+	rows.peeked = values
+	return values, nil
+}
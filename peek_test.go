@@ -0,0 +1,79 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestPeekedRowIsNext(t *testing.T) {
+	rows := &Rows{peeked: []driver.Value{int64(1), "a"}}
+	if values, err := rows.Peek(); err != nil || values[0] != int64(1) {
+		t.Fatal(values, err)
+	}
+	dest := make([]driver.Value, 2)
+	if err := rows.Next(dest); err != nil || dest[0] != int64(1) || dest[1] != "a" {
+		t.Fatal(dest, err)
+	}
+	if rows.peeked != nil {
+		t.Fatal("Expected the peeked row to be consumed")
+	}
+	failed := errors.New("failed")
+	rows = &Rows{peekErr: failed}
+	if _, err := rows.Peek(); err != failed {
+		t.Fatal(err)
+	}
+	if err := rows.Next(dest); err != failed {
+		t.Fatal(err)
+	}
+	if rows.peekErr != nil {
+		t.Fatal("Expected the error of the peek to be consumed")
+	}
+}
+
+func TestPeek(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	exec(t, db, "CREATE TABLE FooBar (id BIGINT)")
+	exec(t, db, "INSERT INTO FooBar VALUES (1), (2)")
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	err = conn.Raw(func(driverConn interface{}) error {
+		dr, err := driverConn.(*Conn).QueryContext(ctx, "SELECT id FROM FooBar ORDER BY id", nil)
+		if err != nil {
+			return err
+		}
+		rows := dr.(*Rows)
+		defer rows.Close()
+		dest := make([]driver.Value, 1)
+		for _, id := range []int64{1, 2} {
+			for i := 0; i < 2; i++ {
+				if values, err := rows.Peek(); err != nil || values[0] != id {
+					t.Fatalf("Expected to peek %d, got %v, %v", id, values, err)
+				}
+			}
+			if err := rows.Next(dest); err != nil || dest[0] != id {
+				t.Fatalf("Expected %d, got %v, %v", id, dest[0], err)
+			}
+		}
+		if _, err := rows.Peek(); err != io.EOF {
+			t.Fatalf("Expected io.EOF, got %v", err)
+		}
+		if err := rows.Next(dest); err != io.EOF {
+			t.Fatalf("Expected io.EOF, got %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
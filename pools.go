@@ -0,0 +1,92 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// PoolLimits configures the database/sql pool of one workload.
+type PoolLimits struct {
+	MaxOpenConns    int           // see sql.DB.SetMaxOpenConns
+	MaxIdleConns    int           // see sql.DB.SetMaxIdleConns
+	ConnMaxLifetime time.Duration // see sql.DB.SetConnMaxLifetime
+	ConnMaxIdleTime time.Duration // see sql.DB.SetConnMaxIdleTime
+}
+
+// Pools maintains a separate database/sql pool per workload label, e.g.
+// "oltp", "batch" and "admin", for the same data source. Isolating the
+// workloads keeps e.g. long running batch queries from starving the pool of
+// the interactive queries. Pools is safe for concurrent use.
+type Pools struct {
+	dsn    string
+	limits map[string]PoolLimits
+
+	mu  sync.Mutex
+	dbs map[string]*sql.DB
+}
+
+// OpenPools returns the pools of the given workload labels for dsn. The
+// pools are opened on first use.
+func OpenPools(dsn string, limits map[string]PoolLimits) *Pools {
+	p := &Pools{
+		dsn:    dsn,
+		limits: make(map[string]PoolLimits, len(limits)),
+		dbs:    make(map[string]*sql.DB, len(limits)),
+	}
+	for label, l := range limits {
+		p.limits[label] = l
+	}
+	return p
+}
+
+// DB returns the pool of the workload label.
+func (p *Pools) DB(label string) (*sql.DB, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if db, ok := p.dbs[label]; ok {
+		return db, nil
+	}
+	l, ok := p.limits[label]
+	if !ok {
+		return nil, fmt.Errorf("nuodb: unknown workload label: %s", label)
+	}
+	db, err := sql.Open("nuodb", p.dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(l.MaxOpenConns)
+	db.SetMaxIdleConns(l.MaxIdleConns)
+	db.SetConnMaxLifetime(l.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(l.ConnMaxIdleTime)
+	p.dbs[label] = db
+	return db, nil
+}
+
+// Stats returns the statistics of the opened pools by workload label.
+func (p *Pools) Stats() map[string]sql.DBStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	stats := make(map[string]sql.DBStats, len(p.dbs))
+	for label, db := range p.dbs {
+		stats[label] = db.Stats()
+	}
+	return stats
+}
+
+// Close closes all the pools.
+func (p *Pools) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var err error
+	for label, db := range p.dbs {
+		if cerr := db.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+		delete(p.dbs, label)
+	}
+	return err
+}
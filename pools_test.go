@@ -0,0 +1,44 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"testing"
+)
+
+func TestPools(t *testing.T) {
+	pools := OpenPools(default_dsn, map[string]PoolLimits{
+		"oltp":  {MaxOpenConns: 4, MaxIdleConns: 2},
+		"batch": {MaxOpenConns: 1},
+	})
+	defer pools.Close()
+
+	oltp, err := pools.DB("oltp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if db, _ := pools.DB("oltp"); db != oltp {
+		t.Fatal("Expected the same pool for the same label")
+	}
+	batch, err := pools.DB("batch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if batch == oltp {
+		t.Fatal("Expected separate pools")
+	}
+	if _, err := pools.DB("admin"); err == nil {
+		t.Fatal("Expected unknown label error")
+	}
+
+	stats := pools.Stats()
+	if stats["oltp"].MaxOpenConnections != 4 || stats["batch"].MaxOpenConnections != 1 {
+		t.Fatalf("Unexpected stats: %+v", stats)
+	}
+	if err := pools.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(pools.Stats()) != 0 {
+		t.Fatal("Expected no pools after Close")
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"database/sql/driver"
+	"time"
+)
+
+// FetchProgress is an Option which reports the progress of fetching the
+// rows of a query, e.g. for a progress bar or a watchdog of a long export:
+//
+//	rows, err := db.QueryContext(ctx, "SELECT * FROM events", nuodb.FetchProgress{
+//		Every: 10000,
+//		Func: func(p nuodb.Progress) {
+//			log.Printf("exported %d rows, %d bytes in %s", p.Rows, p.Bytes, p.Elapsed)
+//		},
+//	})
+//
+// Func is called from Rows.Next after every Every rows and once at the end
+// of each result set, on the goroutine iterating the rows.
+type FetchProgress struct {
+	Every int // rows between the calls, 1000 by default
+	Func  func(Progress)
+}
+
+func (p FetchProgress) apply(o *callOptions) {
+	o.progress = &p
+}
+
+const defaultProgressEvery = 1000
+
+// Progress is the fetch progress of a result set.
+type Progress struct {
+	Rows    int64         // rows fetched
+	Bytes   int64         // approximate size of the fetched values
+	Elapsed time.Duration // since the query started, or the result set was advanced to
+	Done    bool          // all the rows of the result set have been fetched
+}
+
+// progressState tracks the progress of the current result set of Rows.
+type progressState struct {
+	every int64
+	fn    func(Progress)
+	start time.Time
+	p     Progress
+}
+
+// newProgressState returns the state of the option p, or nil without one.
+func newProgressState(p *FetchProgress) *progressState {
+	if p == nil || p.Func == nil {
+		return nil
+	}
+	every := p.Every
+	if every <= 0 {
+		every = defaultProgressEvery
+	}
+	return &progressState{every: int64(every), fn: p.Func, start: time.Now()}
+}
+
+// row counts a fetched row.
+func (s *progressState) row(dest []driver.Value) {
+	s.p.Rows++
+	for _, v := range dest {
+		s.p.Bytes += valueSize(v)
+	}
+	if s.p.Rows%s.every == 0 {
+		s.report()
+	}
+}
+
+// done reports the end of the result set once.
+func (s *progressState) done() {
+	if !s.p.Done {
+		s.p.Done = true
+		s.report()
+	}
+}
+
+func (s *progressState) report() {
+	s.p.Elapsed = time.Since(s.start)
+	s.fn(s.p)
+}
+
+// reset starts tracking the next result set.
+func (s *progressState) reset() {
+	s.p = Progress{}
+	s.start = time.Now()
+}
+
+// valueSize approximates the size of a fetched value.
+func valueSize(v driver.Value) int64 {
+	switch v := v.(type) {
+	case nil:
+		return 0
+	case bool:
+		return 1
+	case string:
+		return int64(len(v))
+	case []byte:
+		return int64(len(v))
+	case *Lob:
+		n, _ := v.Len()
+		return n
+	}
+	return 8
+}
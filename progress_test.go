@@ -0,0 +1,63 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"database/sql/driver"
+	"testing"
+	"time"
+)
+
+func TestProgressState(t *testing.T) {
+	if newProgressState(nil) != nil || newProgressState(&FetchProgress{Every: 2}) != nil {
+		t.Fatal("Expected no progress without a callback")
+	}
+	var reports []Progress
+	s := newProgressState(&FetchProgress{Every: 2, Func: func(p Progress) { reports = append(reports, p) }})
+	row := []driver.Value{int64(1), "abc", nil, true, time.Now()}
+	for i := 0; i < 5; i++ {
+		s.row(row)
+	}
+	s.done()
+	s.done()
+	if len(reports) != 3 {
+		t.Fatalf("Expected 3 reports, got %+v", reports)
+	}
+	if p := reports[1]; p.Rows != 4 || p.Bytes != 4*20 || p.Done {
+		t.Fatalf("Unexpected progress %+v", p)
+	}
+	if p := reports[2]; p.Rows != 5 || p.Bytes != 5*20 || !p.Done {
+		t.Fatalf("Unexpected final progress %+v", p)
+	}
+
+	s.reset()
+	s.row(row)
+	s.done()
+	if p := reports[len(reports)-1]; p.Rows != 1 || !p.Done {
+		t.Fatalf("Expected the next result set to start over, got %+v", p)
+	}
+}
+
+func TestFetchProgress(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	exec(t, db, "CREATE TABLE FooBar (id INTEGER)")
+	exec(t, db, "INSERT INTO FooBar VALUES (1), (2), (3)")
+	var last Progress
+	calls := 0
+	rows, err := db.Query("SELECT id FROM FooBar", FetchProgress{Every: 2, Func: func(p Progress) {
+		calls++
+		last = p
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.Next() {
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 || last.Rows != 3 || !last.Done {
+		t.Fatalf("Expected 2 calls ending with 3 rows, got %d and %+v", calls, last)
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tilinna/go-nuodb/internal/parse"
+)
+
+// queryOptions are the options of the statements executed with a context,
+// set by WithQueryTimeout, WithMaxRows, WithMaxColumnBytes, WithReadOnly,
+// WithQueryTag and WithGeneratedKeys.
+type queryOptions struct {
+	timeout        time.Duration
+	maxRows        int
+	hasMaxRows     bool // maxRows overrides the maxRows property
+	maxColumnBytes int
+	truncate       bool
+	hasMaxColumn   bool // maxColumnBytes and truncate override the properties
+	readOnly       bool
+	tag            string
+	generatedKeys  []string // columns of the generated keys, if not the implicit ones
+}
+
+type queryOptionsKey struct{}
+
+func queryOptionsFrom(ctx context.Context) queryOptions {
+	o, _ := ctx.Value(queryOptionsKey{}).(queryOptions)
+	return o
+}
+
+func withQueryOptions(ctx context.Context, set func(*queryOptions)) context.Context {
+	o := queryOptionsFrom(ctx)
+	set(&o)
+	return context.WithValue(ctx, queryOptionsKey{}, o)
+}
+
+// WithQueryTimeout returns a copy of ctx which limits the execution of each
+// statement executed with it to d, on the server, e.g. to bound the queries
+// of a request without bounding the request as a whole:
+//
+//	rows, err := db.QueryContext(nuodb.WithQueryTimeout(ctx, 2*time.Second), sql)
+//
+// The deadline of ctx still applies if it is sooner. The timeout replaces
+// the defaultTimeout property, but is limited by the maxQueryTimeout
+// property. Zero removes the timeout.
+func WithQueryTimeout(ctx context.Context, d time.Duration) context.Context {
+	return withQueryOptions(ctx, func(o *queryOptions) { o.timeout = d })
+}
+
+// WithMaxRows returns a copy of ctx which limits each result set of the
+// queries executed with it to n rows, overriding the maxRows property. The
+// limit is applied on the server, which stops after a row more than n, and
+// Next fails with ErrMaxRows after the first n rows of a result set with
+// more. Zero removes the limit.
+func WithMaxRows(ctx context.Context, n int) context.Context {
+	return withQueryOptions(ctx, func(o *queryOptions) { o.maxRows, o.hasMaxRows = n, true })
+}
+
+// ErrMaxRows is returned by Next for a result set with more rows than the
+// limit of WithMaxRows or of the maxRows property, after the rows within
+// the limit, so that an unexpectedly unbounded query fails rather than
+// exhausts the memory of the program.
+var ErrMaxRows = errors.New("nuodb: result set exceeds the maximum number of rows")
+
+// WithMaxColumnBytes returns a copy of ctx which limits the size of the
+// character and binary values of the queries executed with it to n bytes,
+// overriding the maxColumnBytes and truncateColumns properties, e.g. to
+// guard a query of user supplied columns against a huge BLOB:
+//
+//	rows, err := db.QueryContext(nuodb.WithMaxColumnBytes(ctx, 1<<20, false), sql)
+//
+// Next fails with ErrColumnTooLarge on a row with a larger value, unless
+// truncate is set, which cuts the value to the limit instead. Zero removes
+// the limit.
+func WithMaxColumnBytes(ctx context.Context, n int, truncate bool) context.Context {
+	return withQueryOptions(ctx, func(o *queryOptions) {
+		o.maxColumnBytes, o.truncate, o.hasMaxColumn = n, truncate, true
+	})
+}
+
+// WithReadOnly returns a copy of ctx with which the statements are
+// executed in a read-only session, so that a write fails with a
+// READ_ONLY_ERROR. A statement in a transaction, whose session can't be
+// changed, is checked by the driver instead: any other statement than a
+// query is refused.
+func WithReadOnly(ctx context.Context) context.Context {
+	return withQueryOptions(ctx, func(o *queryOptions) { o.readOnly = true })
+}
+
+// WithQueryTag returns a copy of ctx which tags the statements executed or
+// prepared with it with a /* tag */ comment appended to their SQL, so that
+// the load of the statements on the server, e.g. in the system tables of
+// the queries, can be attributed to the call sites of the program:
+//
+//	ctx = nuodb.WithQueryTag(ctx, "checkout-service:order-create")
+//
+// The characters of tag other than ASCII letters, digits and
+// " -_.,:;=/@#()[]+" are replaced with _, and it is cut to 200 characters.
+// A prepared statement keeps the tag of the context it was prepared with.
+// The SQL passed to the hooks, tracers and logs of the driver isn't tagged.
+// An empty tag removes the tag.
+func WithQueryTag(ctx context.Context, tag string) context.Context {
+	return withQueryOptions(ctx, func(o *queryOptions) { o.tag = tag })
+}
+
+// WithGeneratedKeys returns a copy of ctx with which an INSERT returns the
+// values of the named columns as its generated keys, rather than the
+// implicit key of the table, e.g. a generated column besides the identity:
+//
+//	ctx = nuodb.WithGeneratedKeys(ctx, "id", "created_at")
+//	rows, err := db.QueryContext(ctx, "INSERT INTO orders (item) VALUES (?), (?)", a, b)
+//
+// The rows of QueryContext are then the values of the columns, in the order
+// given, one row per inserted row, and the LastInsertId of ExecContext is
+// the value of the first column, if it is a number. The statements are
+// prepared with the columns, and a statement prepared with the context
+// keeps them. No columns remove the option.
+func WithGeneratedKeys(ctx context.Context, columns ...string) context.Context {
+	return withQueryOptions(ctx, func(o *queryOptions) { o.generatedKeys = columns })
+}
+
+// tag appends the tag of ctx, if any, to sql.
+func tag(ctx context.Context, sql string) string {
+	return parse.AppendTag(sql, queryOptionsFrom(ctx).tag)
+}
+
+// enterReadOnly makes the session read-only for the execution of sql with
+// ctx, if WithReadOnly is set, until the returned function is called.
+func (c *Conn) enterReadOnly(ctx context.Context, sql string) (func(), error) {
+	if !queryOptionsFrom(ctx).readOnly || c.readOnly {
+		return func() {}, nil
+	}
+	if c.inTransaction() {
+		if parse.Classify(sql) != parse.KindQuery {
+			return nil, fmt.Errorf("nuodb: not a query in a read-only context: %s", sql)
+		}
+		return func() {}, nil
+	}
+	if err := c.txc.setReadOnly(true); err != nil {
+		return nil, err
+	}
+	return func() { _ = c.txc.setReadOnly(false) }, nil
+}
+
+// rowLimit returns the limit of the rows of each result set of a query with
+// ctx, 0 if unlimited, and the fetch size capped by it. The server is to
+// return a row more than the limit, which tells that it was exceeded.
+func (c *Conn) rowLimit(ctx context.Context, fetchSize int) (int, int) {
+	n := c.maxRows
+	if o := queryOptionsFrom(ctx); o.hasMaxRows {
+		n = o.maxRows
+	}
+	if n <= 0 {
+		return 0, fetchSize
+	}
+	if fetchSize == 0 || fetchSize > n+1 {
+		fetchSize = n + 1
+	}
+	return n, fetchSize
+}
+
+// columnLimit returns the size limit of the values of a query with ctx, 0
+// if unlimited, and whether larger values are cut rather than refused.
+func (c *Conn) columnLimit(ctx context.Context) (int, bool) {
+	if o := queryOptionsFrom(ctx); o.hasMaxColumn {
+		return o.maxColumnBytes, o.truncate
+	}
+	return c.maxColumnSize, c.truncateLarge
+}
+
+// serverRows returns the maximum number of rows for the server of the limit
+// n of rowLimit.
+func serverRows(n int) int {
+	if n > 0 {
+		return n + 1
+	}
+	return 0
+}
@@ -0,0 +1,109 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestQueryOptions(t *testing.T) {
+	ctx := WithReadOnly(WithMaxRows(WithQueryTimeout(context.Background(), time.Second), 10))
+	if o := queryOptionsFrom(ctx); !reflect.DeepEqual(o, queryOptions{timeout: time.Second, maxRows: 10, hasMaxRows: true, readOnly: true}) {
+		t.Fatalf("Unexpected options %+v", o)
+	}
+	if o := queryOptionsFrom(WithQueryTimeout(ctx, 0)); o.timeout != 0 || o.maxRows != 10 {
+		t.Fatalf("Expected the timeout to be removed, got %+v", o)
+	}
+}
+
+func TestWithGeneratedKeys(t *testing.T) {
+	ctx := WithGeneratedKeys(context.Background(), "id", "created_at")
+	if keys := queryOptionsFrom(ctx).generatedKeys; !reflect.DeepEqual(keys, []string{"id", "created_at"}) {
+		t.Fatalf("Unexpected keys %v", keys)
+	}
+	if keys := queryOptionsFrom(WithGeneratedKeys(ctx)).generatedKeys; len(keys) != 0 {
+		t.Fatalf("Expected the keys to be removed, got %v", keys)
+	}
+}
+
+func TestQueryTag(t *testing.T) {
+	ctx := WithQueryTag(context.Background(), "checkout-service:order-create")
+	if sql := tag(ctx, "SELECT 1"); sql != "SELECT 1\n/* checkout-service:order-create */" {
+		t.Fatalf("Unexpected tagged statement %q", sql)
+	}
+	if sql := tag(WithQueryTag(ctx, ""), "SELECT 1"); sql != "SELECT 1" {
+		t.Fatalf("Expected the tag to be removed, got %q", sql)
+	}
+}
+
+func TestRowLimit(t *testing.T) {
+	c := &Conn{maxRows: 100}
+	background := context.Background()
+	for _, test := range []struct {
+		ctx                             context.Context
+		fetchSize                       int
+		expectedRows, expectedFetchSize int
+	}{
+		{background, 0, 100, 101},
+		{background, 500, 100, 101},
+		{background, 5, 100, 5},
+		{WithMaxRows(background, 10), 0, 10, 11},
+		{WithMaxRows(background, 0), 500, 0, 500},
+	} {
+		n, fetchSize := c.rowLimit(test.ctx, test.fetchSize)
+		if n != test.expectedRows || fetchSize != test.expectedFetchSize {
+			t.Errorf("%+v: got %d rows and fetch size %d", test, n, fetchSize)
+		}
+	}
+	if serverRows(0) != 0 || serverRows(10) != 11 {
+		t.Fatal("Expected the server to return a row more than the limit")
+	}
+}
+
+func TestQueryTimeoutOption(t *testing.T) {
+	c := &Conn{defaultTimeout: time.Minute}
+	uSec, err := c.queryTimeout(WithQueryTimeout(context.Background(), 2*time.Second))
+	if err != nil || int64(uSec) != int64(2*time.Second/time.Microsecond) || c.timeoutDefault != 0 {
+		t.Fatalf("Expected the option timeout, got %d, %s, %v", uSec, c.timeoutDefault, err)
+	}
+
+	// the sooner deadline of the context applies
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	uSec, err = c.queryTimeout(WithQueryTimeout(ctx, time.Minute))
+	if err != nil || int64(uSec) > int64(time.Second/time.Microsecond) {
+		t.Fatalf("Expected the context timeout, got %d, %v", uSec, err)
+	}
+}
+
+func TestEnterReadOnly(t *testing.T) {
+	f := &fakeTx{auto: true}
+	c := &Conn{txc: f}
+	ctx := WithReadOnly(context.Background())
+	restore, err := c.enterReadOnly(ctx, "SELECT 1 FROM DUAL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	restore()
+	if restore, err = c.enterReadOnly(context.Background(), "DELETE FROM t"); err != nil {
+		t.Fatal(err)
+	}
+	restore()
+
+	// in a transaction the statements are checked instead
+	c.inTx = true
+	if _, err := c.enterReadOnly(ctx, "DELETE FROM t"); err == nil {
+		t.Fatal("Expected a write to be refused")
+	}
+	if restore, err = c.enterReadOnly(ctx, "SELECT * FROM t"); err != nil {
+		t.Fatal(err)
+	}
+	restore()
+	expected := []string{"read-only on", "read-only off"}
+	if calls := f.recorded(); !reflect.DeepEqual(calls, expected) {
+		t.Fatalf("Expected %q, got %q", expected, calls)
+	}
+}
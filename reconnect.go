@@ -0,0 +1,46 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+// ReconnectPolicy selects which fatal connection errors, such as a network
+// error or a shut down TE, match driver.ErrBadConn, so that database/sql
+// discards the connection and retries the call on another one, see
+// Config.Reconnect. A connection which has seen a fatal error is discarded
+// by the pool regardless of the policy.
+type ReconnectPolicy int
+
+const (
+	// ReconnectAlways retries every call which fails with a fatal error,
+	// even though its statement may have been executed nevertheless. With
+	// autocommit=false, only the first call since the pool handed out the
+	// connection is retried, so that no uncommitted statements are lost.
+	ReconnectAlways ReconnectPolicy = iota
+
+	// ReconnectFirstUse retries only a call which fails on its first use
+	// of the connection since the pool handed it out, outside a
+	// transaction, e.g. the first query on an idle connection whose TE has
+	// gone away. No work was done on the connection, so retrying the call
+	// is safe. The other fatal errors are returned to the application.
+	ReconnectFirstUse
+
+	// ReconnectNever returns every fatal error to the application.
+	ReconnectNever
+)
+
+// fatal marks c, on which err is a fatal error, as broken, and keeps err
+// from matching driver.ErrBadConn unless the reconnect policy of c allows
+// to retry the call. first tells whether the call was the first on c since
+// the pool handed it out.
+func (c *Conn) fatal(err *Error, first bool) {
+	c.bad = true
+	switch c.reconnect {
+	case ReconnectAlways:
+		// the uncommitted statements before the call would be lost, and a new
+		// connection would autocommit unlike the session it replaces
+		err.noRetry = c.manualCommit && !first || c.noAutoCommit
+	case ReconnectFirstUse:
+		err.noRetry = !first || c.inTx
+	case ReconnectNever:
+		err.noRetry = true
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+)
+
+func TestReconnectPolicy(t *testing.T) {
+	for _, tc := range []struct {
+		policy      ReconnectPolicy
+		first, inTx bool
+		badConn     bool
+	}{
+		{ReconnectAlways, false, true, true},
+		{ReconnectFirstUse, true, false, true},
+		{ReconnectFirstUse, false, false, false},
+		{ReconnectFirstUse, true, true, false},
+		{ReconnectNever, true, false, false},
+	} {
+		c := &Conn{reconnect: tc.policy, inTx: tc.inTx}
+		err := &Error{Code: IsShutdown}
+		c.fatal(err, tc.first)
+		if !c.bad {
+			t.Errorf("%+v: expected a bad connection", tc)
+		}
+		if errors.Is(err, driver.ErrBadConn) != tc.badConn {
+			t.Errorf("%+v: expected driver.ErrBadConn %v", tc, tc.badConn)
+		}
+		var nerr *Error
+		if !errors.As(err, &nerr) || !nerr.Temporary() {
+			t.Errorf("%+v: expected a temporary error", tc)
+		}
+	}
+}
+
+func TestReconnectManualCommit(t *testing.T) {
+	for _, first := range []bool{true, false} {
+		c := &Conn{manualCommit: true}
+		err := &Error{Code: IsShutdown}
+		c.fatal(err, first)
+		if errors.Is(err, driver.ErrBadConn) != first {
+			t.Errorf("Expected driver.ErrBadConn %v on the first use %v", first, first)
+		}
+	}
+	if c := (&Conn{manualCommit: true}); !c.inTransaction() {
+		t.Error("Expected the statements to be in the transaction of the session")
+	}
+}
+
+func TestReconnectFaults(t *testing.T) {
+	f := NewFaultInjector(1)
+	f.Faults = []Fault{{Code: ConnectionError, Rate: 1}}
+	c := &Conn{reconnect: ReconnectFirstUse}
+	if err := f.inject(context.Background(), c); !errors.Is(err, driver.ErrBadConn) {
+		t.Fatalf("Expected a retry on the first use, got %v", err)
+	}
+	c = &Conn{reconnect: ReconnectFirstUse, used: true}
+	if err := f.inject(context.Background(), c); errors.Is(err, driver.ErrBadConn) || !c.bad {
+		t.Fatalf("Expected no retry after the first use, got %v", err)
+	}
+}
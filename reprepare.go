@@ -0,0 +1,56 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+// #include "cnuodb.h"
+import "C"
+import "errors"
+
+// invalidStatement reports whether err is the INVALID_STATEMENT of a
+// prepared statement which the server invalidated, e.g. after an ALTER
+// TABLE of one of its tables by another connection.
+func invalidStatement(err error) bool {
+	var nerr *Error
+	return errors.As(err, &nerr) && nerr.Code == InvalidStatement
+}
+
+// reprepareOnce calls run, which binds and executes stmt. If the statement
+// was invalidated, it is prepared again from its SQL and run is called once
+// more, as the invalidated statement wasn't executed.
+func (stmt *Stmt) reprepareOnce(run func() error) error {
+	err := run()
+	if !invalidStatement(err) {
+		return err
+	}
+	if err := stmt.reprepare(); err != nil {
+		return err
+	}
+	return run()
+}
+
+// reprepare replaces the handle of stmt with a newly prepared one. The
+// columns cached for stmt and the metadata of the connection are dropped,
+// as the schema was changed elsewhere.
+func (stmt *Stmt) reprepare() error {
+	c := stmt.c
+	var st *C.struct_nuodb_statement
+	if err := stmt.prepareHandle(&st); err != nil {
+		return err
+	}
+	unlock, err := stmt.lock()
+	if err != nil {
+		// closed meanwhile
+		c.cgo(func() C.int { return C.nuodb_statement_close(c.db, &st) })
+		return err
+	}
+	old := stmt.st
+	stmt.st = st
+	C.nuodb_statement_close(c.db, &old)
+	unlock()
+	stmt.columns = nil
+	c.meta.invalidate()
+	c.ddlCount++
+	c.stats.prepared()
+	c.log(LevelInfo, "statement prepared again", "sql", stmt.sql)
+	return nil
+}
@@ -0,0 +1,54 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestInvalidStatement(t *testing.T) {
+	for _, test := range []struct {
+		err      error
+		expected bool
+	}{
+		{nil, false},
+		{&Error{Code: InvalidStatement}, true},
+		{fmt.Errorf("bind: %w", &Error{Code: InvalidStatement}), true},
+		{&Error{Code: NoSuchTable}, false},
+	} {
+		if invalid := invalidStatement(test.err); invalid != test.expected {
+			t.Fatalf("%v: expected %v, got %v", test.err, test.expected, invalid)
+		}
+	}
+}
+
+func TestReprepare(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	exec(t, db, "CREATE TABLE FooBar (id INTEGER)")
+	exec(t, db, "INSERT INTO FooBar VALUES (1)")
+	stmt, err := db.Prepare("SELECT * FROM FooBar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stmt.Close()
+	for i, expected := range []int{1, 2} {
+		if i == 1 {
+			exec(t, db, "ALTER TABLE FooBar ADD COLUMN name STRING DEFAULT 'a'")
+		}
+		rows, err := stmt.Query()
+		if err != nil {
+			t.Fatalf("%d: %s", i, err)
+		}
+		columns, err := rows.Columns()
+		rows.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(columns) != expected {
+			t.Fatalf("%d: expected %d columns, got %v", i, expected, columns)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+// resultColumns caches the columns of the result sets of a Stmt, so that
+// the queries after the first one of a reused statement neither fetch the
+// column names again nor allocate them, and the types are fetched at most
+// once.
+type resultColumns struct {
+	names      []string
+	maskers    []Masker
+	types      []columnType // fetched on demand
+	emptyNulls []bool
+	ddlCount   uint64 // of the connection when cached
+}
+
+// resultColumns returns the cache of the columns of stmt, emptied if a DDL
+// statement may have changed them since. Procedure calls, whose result sets
+// differ, have none.
+func (stmt *Stmt) resultColumns() *resultColumns {
+	if stmt.call {
+		return nil
+	}
+	if stmt.columns == nil || stmt.columns.ddlCount != stmt.c.ddlCount {
+		stmt.columns = &resultColumns{ddlCount: stmt.c.ddlCount}
+	}
+	return stmt.columns
+}
+
+// cached reports whether the cache holds the names of count columns.
+func (rc *resultColumns) cached(count int) bool {
+	return rc != nil && rc.names != nil && len(rc.names) == count
+}
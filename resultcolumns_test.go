@@ -0,0 +1,79 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestResultColumnsCache(t *testing.T) {
+	stmt := &Stmt{c: &Conn{}}
+	rc := stmt.resultColumns()
+	if rc.cached(1) {
+		t.Fatal("Expected an empty cache")
+	}
+	rc.names = []string{"ID"}
+	if stmt.resultColumns() != rc || !rc.cached(1) || rc.cached(2) {
+		t.Fatal("Expected the names of a single column to be cached")
+	}
+	stmt.c.ddlCount++
+	if stmt.resultColumns().cached(1) {
+		t.Fatal("Expected a DDL statement to empty the cache")
+	}
+	if (&Stmt{c: &Conn{}, call: true}).resultColumns() != nil {
+		t.Fatal("Expected no cache for a procedure call")
+	}
+}
+
+func TestResultColumnsReuse(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	exec(t, db, "CREATE TABLE tests.FooBarCached (id BIGINT, name STRING)")
+	exec(t, db, "INSERT INTO tests.FooBarCached VALUES (1, 'a')")
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	stmt, err := conn.PrepareContext(ctx, "SELECT * FROM tests.FooBarCached")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stmt.Close()
+	columns := func() ([]string, []string) {
+		t.Helper()
+		rows, err := stmt.Query()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+		names, err := rows.Columns()
+		if err != nil {
+			t.Fatal(err)
+		}
+		types, err := rows.ColumnTypes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		typeNames := make([]string, len(types))
+		for i, ct := range types {
+			typeNames[i] = ct.DatabaseTypeName()
+		}
+		return names, typeNames
+	}
+	names, types := columns()
+	cachedNames, cachedTypes := columns()
+	if !reflect.DeepEqual(names, cachedNames) || !reflect.DeepEqual(types, cachedTypes) {
+		t.Fatalf("Expected the cached columns %v %v, got %v %v", names, types, cachedNames, cachedTypes)
+	}
+	if _, err := conn.ExecContext(ctx, "ALTER TABLE tests.FooBarCached ADD COLUMN extra INTEGER"); err != nil {
+		t.Fatal(err)
+	}
+	if names, _ := columns(); len(names) != 3 {
+		t.Fatalf("Expected the new column after a DDL statement, got %v", names)
+	}
+}
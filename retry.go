@@ -0,0 +1,161 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// RetryBudget limits the retries of all the queries issued with one context,
+// typically the context of a single request. It prevents the amplification
+// of retries when a request runs many queries during an incident. A
+// RetryBudget is safe for concurrent use.
+type RetryBudget struct {
+	// MaxAttempts is the maximum number of retries; zero means no retries.
+	MaxAttempts int
+	// MaxDelay is the maximum total latency which the retries may add; zero
+	// means no limit.
+	MaxDelay time.Duration
+
+	mu       sync.Mutex
+	attempts int
+	delay    time.Duration
+}
+
+type retryBudgetKey struct{}
+
+// WithRetryBudget returns a copy of ctx which carries b. The retries of all
+// the operations run with the returned context are charged to b.
+func WithRetryBudget(ctx context.Context, b *RetryBudget) context.Context {
+	return context.WithValue(ctx, retryBudgetKey{}, b)
+}
+
+func retryBudgetFrom(ctx context.Context) *RetryBudget {
+	b, _ := ctx.Value(retryBudgetKey{}).(*RetryBudget)
+	return b
+}
+
+// Remaining returns the number of retries left in the budget.
+func (b *RetryBudget) Remaining() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.MaxAttempts - b.attempts
+}
+
+// take reserves a retry which is delayed by delay. It reports false if the
+// budget has been exhausted.
+func (b *RetryBudget) take(delay time.Duration) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.attempts >= b.MaxAttempts || (b.MaxDelay > 0 && b.delay+delay > b.MaxDelay) {
+		return false
+	}
+	b.attempts++
+	b.delay += delay
+	return true
+}
+
+// retryableErrorCodes are the errors after which a transaction can be
+// retried as a whole.
+var retryableErrorCodes = map[ErrorCode]bool{
+	UpdateConflict: true,
+	Deadlock:       true,
+}
+
+const (
+	retryMinBackoff = 10 * time.Millisecond
+	retryMaxBackoff = time.Second
+)
+
+// Retry calls fn until it succeeds or fails with an error which isn't
+// retryable, e.g. an UpdateConflict or a Deadlock. The retries are charged
+// to the RetryBudget of ctx; without one, fn is called only once. fn should
+// run a complete transaction.
+func Retry(ctx context.Context, fn func() error) error {
+	b := retryBudgetFrom(ctx)
+	backoff := retryMinBackoff
+	for {
+		err := fn()
+		var nerr *Error
+		if err == nil || b == nil || !errors.As(err, &nerr) || !retryableErrorCodes[nerr.Code] {
+			return err
+		}
+		if !b.take(backoff) {
+			return err
+		}
+		t := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+		if backoff *= 2; backoff > retryMaxBackoff {
+			backoff = retryMaxBackoff
+		}
+	}
+}
+
+// inTransaction reports whether the statements of c are in a transaction,
+// either of a Tx or, with autocommit turned off, of the session, so that a
+// failed statement can't be retried on its own.
+func (c *Conn) inTransaction() bool {
+	return c.inTx || c.manualCommit || c.noAutoCommit
+}
+
+// retry calls fn, which executes a single statement, and retries it with a
+// backoff while it fails with an UpdateConflict or a Deadlock, up to the
+// retryAttempts of the connection. Only statements in autocommit mode are
+// retried, as the failed statement has then been rolled back as a whole;
+// within a transaction the whole transaction must be retried instead, see
+// Retry. The retries are also charged to the RetryBudget of ctx, if any.
+// The final error of exhausted retries tells the number of attempts. The
+// defaultTimeout of the connection bounds the waits of a context without a
+// deadline.
+func (c *Conn) retry(ctx context.Context, fn func() error) error {
+	ctx, cancel := c.defaultContext(ctx)
+	defer cancel()
+	b := retryBudgetFrom(ctx)
+	backoff := c.retryBackoff
+	if backoff <= 0 {
+		backoff = retryMinBackoff
+	}
+	for attempt := 1; ; attempt++ {
+		if err := c.limiter.acquire(ctx); err != nil {
+			return err
+		}
+		err := c.faults.inject(ctx, c)
+		if err == nil {
+			err = fn()
+		}
+		c.limiter.release()
+		var nerr *Error
+		if err == nil || c.inTransaction() || !errors.As(err, &nerr) || !retryableErrorCodes[nerr.Code] {
+			return err
+		}
+		if attempt >= c.retryAttempts || (b != nil && !b.take(backoff)) {
+			if attempt > 1 {
+				nerr.Attempts = attempt
+			}
+			return err
+		}
+		c.stats.retried()
+		c.log(LevelInfo, "retrying statement", "attempt", attempt+1, "backoff", backoff, "code", nerr.Code.Name())
+		t := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			if attempt > 1 {
+				nerr.Attempts = attempt
+			}
+			return err
+		case <-t.C:
+		}
+		if backoff *= 2; backoff > retryMaxBackoff {
+			backoff = retryMaxBackoff
+		}
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetryBudget(t *testing.T) {
+	b := &RetryBudget{MaxAttempts: 3}
+	ctx := WithRetryBudget(context.Background(), b)
+	conflict := &Error{Code: UpdateConflict, Message: "update conflict"}
+
+	calls := 0
+	err := Retry(ctx, func() error {
+		calls++
+		if calls < 3 {
+			return conflict
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 || b.Remaining() != 1 {
+		t.Fatalf("Expected 3 calls and 1 remaining retry, got %d and %d", calls, b.Remaining())
+	}
+
+	// The budget is shared by all the calls with the context
+	calls = 0
+	err = Retry(ctx, func() error {
+		calls++
+		return conflict
+	})
+	if err != conflict || calls != 2 || b.Remaining() != 0 {
+		t.Fatalf("Expected conflict after 2 calls, got %v after %d", err, calls)
+	}
+
+	// Errors which aren't retryable are returned immediately
+	calls = 0
+	other := errors.New("other")
+	err = Retry(WithRetryBudget(context.Background(), &RetryBudget{MaxAttempts: 3}), func() error {
+		calls++
+		return other
+	})
+	if err != other || calls != 1 {
+		t.Fatalf("Expected other after 1 call, got %v after %d", err, calls)
+	}
+
+	// No retries without a budget
+	calls = 0
+	err = Retry(context.Background(), func() error {
+		calls++
+		return conflict
+	})
+	if err != conflict || calls != 1 {
+		t.Fatalf("Expected conflict after 1 call, got %v after %d", err, calls)
+	}
+}
+
+func TestRetryBudgetMaxDelay(t *testing.T) {
+	b := &RetryBudget{MaxAttempts: 10, MaxDelay: 25 * time.Millisecond}
+	if !b.take(10*time.Millisecond) || !b.take(10*time.Millisecond) {
+		t.Fatal("Expected retries within the budget")
+	}
+	if b.take(10 * time.Millisecond) {
+		t.Fatal("Expected the delay budget to be exhausted")
+	}
+}
+
+func TestConnRetry(t *testing.T) {
+	c := &Conn{retryAttempts: 3, retryBackoff: time.Millisecond}
+	ctx := context.Background()
+
+	calls := 0
+	err := c.retry(ctx, func() error {
+		calls++
+		return &Error{Code: Deadlock, Message: "deadlock"}
+	})
+	var nerr *Error
+	if !errors.As(err, &nerr) || nerr.Attempts != 3 || calls != 3 {
+		t.Fatalf("Expected a deadlock after 3 attempts, got %v after %d", err, calls)
+	}
+	if !errors.Is(err, ErrDeadlock) {
+		t.Fatalf("Expected %v to match ErrDeadlock", err)
+	}
+	if want := "nuodb: deadlock (gave up after 3 attempts)"; err.Error() != want {
+		t.Fatalf("Expected %q, got %q", want, err.Error())
+	}
+
+	calls = 0
+	err = c.retry(ctx, func() error {
+		if calls++; calls < 2 {
+			return &Error{Code: UpdateConflict, Message: "update conflict"}
+		}
+		return nil
+	})
+	if err != nil || calls != 2 {
+		t.Fatalf("Expected success after 2 attempts, got %v after %d", err, calls)
+	}
+
+	// Statements within a transaction are not retried
+	c.inTx = true
+	calls = 0
+	err = c.retry(ctx, func() error {
+		calls++
+		return &Error{Code: UpdateConflict, Message: "update conflict"}
+	})
+	if !errors.As(err, &nerr) || nerr.Attempts != 0 || calls != 1 {
+		t.Fatalf("Expected no retries in a transaction, got %v after %d", err, calls)
+	}
+	c.inTx = false
+
+	// The retries are opt-in
+	calls = 0
+	err = (&Conn{}).retry(ctx, func() error {
+		calls++
+		return &Error{Code: UpdateConflict, Message: "update conflict"}
+	})
+	if err == nil || calls != 1 {
+		t.Fatalf("Expected no retries by default, got %v after %d", err, calls)
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// AssumeRole enables only role on conn, with SET ROLE, so that one service
+// account can serve each request with the least privileges it needs:
+//
+//	conn, err := db.Conn(ctx)
+//	...
+//	defer conn.Close()
+//	if err := nuodb.AssumeRole(ctx, conn, "shop.reporting"); err != nil {
+//		...
+//	}
+//
+// The role must have been granted to the user of the connection. When conn
+// is returned to the pool, all the roles granted to the user are enabled
+// again with SET ROLE ALL; a connection whose roles can't be restored is
+// discarded.
+func AssumeRole(ctx context.Context, conn *sql.Conn, role string) error {
+	if !validRole(role) {
+		return fmt.Errorf("nuodb: invalid role: %q", role)
+	}
+	return conn.Raw(func(driverConn interface{}) error {
+		c, ok := driverConn.(*Conn)
+		if !ok {
+			return errors.New("nuodb: not a nuodb connection")
+		}
+		c.roleAssumed = true // also if SET ROLE fails half way
+		_, err := c.exec(ctx, "SET ROLE "+role, nil)
+		return err
+	})
+}
+
+// validRole reports whether role is a plain, possibly schema qualified,
+// identifier, as it can't be passed as a parameter of SET ROLE.
+func validRole(role string) bool {
+	for _, part := range strings.Split(role, ".") {
+		if !validIdentifier(part) {
+			return false
+		}
+	}
+	return true
+}
+
+// validIdentifier reports whether s is a plain, unquoted identifier.
+func validIdentifier(s string) bool {
+	if s == "" || s[0] >= '0' && s[0] <= '9' {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '_' || c == '$') {
+			return false
+		}
+	}
+	return true
+}
+
+// restoreRoles enables the roles of the user again after AssumeRole.
+func (c *Conn) restoreRoles(ctx context.Context) error {
+	if !c.roleAssumed {
+		return nil
+	}
+	if _, err := c.exec(ctx, "SET ROLE ALL", nil); err != nil {
+		return err
+	}
+	c.roleAssumed = false
+	return nil
+}
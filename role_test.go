@@ -0,0 +1,62 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"testing"
+)
+
+func TestValidRole(t *testing.T) {
+	for role, valid := range map[string]bool{
+		"reporting":            true,
+		"shop.reporting":       true,
+		"Role_1$":              true,
+		"":                     false,
+		"shop.":                false,
+		"1role":                false,
+		"reporting; DROP t":    false,
+		"\"quoted\"":           false,
+		"shop.reporting.extra": true,
+	} {
+		if validRole(role) != valid {
+			t.Errorf("%q: expected valid %v", role, valid)
+		}
+	}
+}
+
+func TestAssumeRole(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	ctx := context.Background()
+	exec(t, db, "CREATE ROLE tests.reader")
+	defer exec(t, db, "DROP ROLE tests.reader")
+	exec(t, db, "GRANT tests.reader TO robinh")
+	db.SetMaxOpenConns(1)
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := AssumeRole(ctx, conn, "tests.reader"); err != nil {
+		conn.Close()
+		t.Fatal(err)
+	}
+	if err := AssumeRole(ctx, conn, "no role"); err == nil {
+		t.Error("Expected an invalid role")
+	}
+	conn.Close()
+
+	// the roles are restored on the pooled connection
+	conn, err = db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.Raw(func(driverConn interface{}) error {
+		if driverConn.(*Conn).roleAssumed {
+			t.Error("Expected the roles to be restored")
+		}
+		return nil
+	})
+}
@@ -0,0 +1,140 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ColumnError is a value of a column which couldn't be scanned into its
+// destination.
+type ColumnError struct {
+	Column int // index of the column, from 0
+	Name   string
+	Err    error
+}
+
+// RowError is returned by SafeRows.Scan for the columns of a row which
+// couldn't be scanned. The other columns of the row are scanned.
+type RowError struct {
+	Row     int64 // number of the row, from 1
+	Columns []ColumnError
+}
+
+func (e *RowError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "nuodb: %d columns of row %d can't be scanned", len(e.Columns), e.Row)
+	for _, c := range e.Columns {
+		fmt.Fprintf(&b, "\ncolumn %d %s: %s", c.Column, c.Name, c.Err)
+	}
+	return b.String()
+}
+
+// SafeRows iterates over the rows of a *sql.Rows like the rows themselves,
+// but a value which can't be scanned into its destination, e.g. a string
+// which isn't a number scanned into an int, doesn't fail the other columns
+// of the row. Scan reports the failed columns of a row in a *RowError, and
+// the iteration continues with the next row, e.g. to audit the quality of
+// legacy data:
+//
+//	r := nuodb.NewSafeRows(rows)
+//	defer r.Close()
+//	for r.Next() {
+//		if err := r.Scan(&id, &amount); err != nil {
+//			var rowErr *nuodb.RowError
+//			if !errors.As(err, &rowErr) {
+//				return err
+//			}
+//			log.Print(rowErr) // id or amount is zero
+//			continue
+//		}
+//		...
+//	}
+//	err = r.Err()
+//
+// An error fetching the rows still ends the iteration, as the result can't
+// be read past it.
+type SafeRows struct {
+	rows  *sql.Rows
+	names []string
+	row   int64
+}
+
+// NewSafeRows returns a SafeRows iterating over rows.
+func NewSafeRows(rows *sql.Rows) *SafeRows {
+	return &SafeRows{rows: rows}
+}
+
+// Next advances to the next row, like sql.Rows.Next.
+func (r *SafeRows) Next() bool {
+	if !r.rows.Next() {
+		return false
+	}
+	r.row++
+	return true
+}
+
+// Row returns the number of the current row, from 1.
+func (r *SafeRows) Row() int64 {
+	return r.row
+}
+
+// Scan copies the columns of the current row into dest, like
+// sql.Rows.Scan. The destination of a column which can't be scanned is set
+// to its zero value and the column is reported in a *RowError. Other
+// errors, e.g. of a wrong number of destinations, are returned as is.
+func (r *SafeRows) Scan(dest ...interface{}) error {
+	err := r.rows.Scan(dest...)
+	if err == nil {
+		return nil
+	}
+	if r.names == nil {
+		if r.names, err = r.rows.Columns(); err != nil {
+			return err
+		}
+	}
+	if len(dest) != len(r.names) {
+		return err
+	}
+	// scan the columns one by one, discarding the others
+	discard := make([]interface{}, len(dest))
+	for i := range discard {
+		discard[i] = new(interface{})
+	}
+	rowErr := &RowError{Row: r.row}
+	for i, d := range dest {
+		single := discard[i]
+		discard[i] = d
+		if err := r.rows.Scan(discard...); err != nil {
+			rowErr.Columns = append(rowErr.Columns, ColumnError{Column: i, Name: r.names[i], Err: err})
+			setZero(d)
+		}
+		discard[i] = single
+	}
+	if rowErr.Columns == nil {
+		return nil
+	}
+	return rowErr
+}
+
+// setZero sets the value a destination points to to its zero value.
+func setZero(dest interface{}) {
+	v := reflect.ValueOf(dest)
+	if v.Kind() == reflect.Ptr && !v.IsNil() {
+		v.Elem().Set(reflect.Zero(v.Elem().Type()))
+	}
+}
+
+// Err returns the error which ended the iteration, if any, like
+// sql.Rows.Err. It doesn't include the errors returned by Scan.
+func (r *SafeRows) Err() error {
+	return r.rows.Err()
+}
+
+// Close closes the rows.
+func (r *SafeRows) Close() error {
+	return r.rows.Close()
+}
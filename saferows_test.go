@@ -0,0 +1,70 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRowErrorMessage(t *testing.T) {
+	err := &RowError{Row: 3, Columns: []ColumnError{
+		{Column: 0, Name: "ID", Err: errors.New("invalid syntax")},
+		{Column: 2, Name: "AMOUNT", Err: errors.New("out of range")},
+	}}
+	expected := "nuodb: 2 columns of row 3 can't be scanned\ncolumn 0 ID: invalid syntax\ncolumn 2 AMOUNT: out of range"
+	if err.Error() != expected {
+		t.Fatalf("Expected %q, got %q", expected, err.Error())
+	}
+}
+
+func TestSetZero(t *testing.T) {
+	i, s := 5, "x"
+	setZero(&i)
+	setZero(&s)
+	setZero(nil)
+	if i != 0 || s != "" {
+		t.Fatalf("Expected zero values, got %d %q", i, s)
+	}
+}
+
+func TestSafeRows(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	exec(t, db, "CREATE TABLE FooBar (id STRING, name STRING)")
+	exec(t, db, "INSERT INTO FooBar VALUES ('1', 'a'), ('x', 'b'), ('3', NULL)")
+
+	r := NewSafeRows(query(t, db, "SELECT id, name FROM FooBar ORDER BY id"))
+	defer r.Close()
+	var ids []int64
+	var failed []int64
+	for r.Next() {
+		var id int64
+		var name string
+		err := r.Scan(&id, &name)
+		var rowErr *RowError
+		switch {
+		case err == nil:
+			ids = append(ids, id)
+		case errors.As(err, &rowErr):
+			if rowErr.Row != r.Row() {
+				t.Fatalf("Expected row %d, got %d", r.Row(), rowErr.Row)
+			}
+			failed = append(failed, rowErr.Row)
+			if len(rowErr.Columns) == 1 && rowErr.Columns[0].Column == 1 {
+				ids = append(ids, id) // only the NULL name failed
+			}
+		default:
+			t.Fatal(err)
+		}
+	}
+	if err := r.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 2 || ids[0] != 1 || ids[1] != 3 {
+		t.Fatalf("Expected ids 1 and 3, got %v", ids)
+	}
+	if len(failed) != 2 || failed[0] != 2 || failed[1] != 3 {
+		t.Fatalf("Expected rows 2 and 3 to fail, got %v", failed)
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// SampleOptions configures SampleQuery.
+type SampleOptions struct {
+	// Fraction is the fraction of the rows to return, in (0, 1].
+	Fraction float64
+
+	// Key is an integer column of the query results. When set, the rows
+	// are sampled by the key modulo SampleBuckets, so that the same rows
+	// are returned on every run. Otherwise the sample is random.
+	Key string
+}
+
+// SampleBuckets is the number of buckets the rows are divided into for
+// sampling, i.e. the resolution of the sampled fraction.
+const SampleBuckets = 10000
+
+// Sample methods.
+const (
+	SampleRandom = "random" // RAND() filter, a different sample on every run
+	SampleMod    = "mod"    // MOD(key, SampleBuckets) filter, a repeatable sample
+)
+
+// SampleInfo describes how the results of SampleQuery were sampled.
+type SampleInfo struct {
+	Method   string  // SampleRandom or SampleMod
+	Key      string  // the key column of SampleMod
+	Buckets  int     // SampleBuckets
+	Selected int     // buckets included in the sample
+	Fraction float64 // effective fraction, Selected / Buckets
+	SQL      string  // the rewritten query
+}
+
+// SampleQuery runs query for a statistical sample of its results, e.g. for
+// data profiling. The query is wrapped in a filter which the driver
+// generates, as NuoDB has no TABLESAMPLE clause. The returned SampleInfo
+// describes the sampling of the rows.
+func SampleQuery(ctx context.Context, db *sql.DB, query string, opts SampleOptions, args ...interface{}) (*sql.Rows, *SampleInfo, error) {
+	info, err := sampleSQL(query, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	rows, err := db.QueryContext(ctx, info.SQL, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rows, info, nil
+}
+
+// sampleSQL rewrites query to return a sample of its results.
+func sampleSQL(query string, opts SampleOptions) (*SampleInfo, error) {
+	if !(opts.Fraction > 0 && opts.Fraction <= 1) {
+		return nil, fmt.Errorf("nuodb: invalid sample fraction: %v", opts.Fraction)
+	}
+	query = strings.TrimRight(strings.TrimSpace(query), ";")
+	if query == "" {
+		return nil, fmt.Errorf("nuodb: empty query")
+	}
+	info := &SampleInfo{
+		Buckets:  SampleBuckets,
+		Selected: int(math.Max(1, math.Round(opts.Fraction*SampleBuckets))),
+	}
+	info.Fraction = float64(info.Selected) / float64(info.Buckets)
+
+	var filter string
+	if opts.Key == "" {
+		info.Method = SampleRandom
+		filter = fmt.Sprintf("RAND() < %g", info.Fraction)
+	} else {
+		if !identifierRegexp.MatchString(opts.Key) || strings.Contains(opts.Key, ".") {
+			return nil, fmt.Errorf("nuodb: invalid sample key: %q", opts.Key)
+		}
+		info.Method = SampleMod
+		info.Key = opts.Key
+		filter = fmt.Sprintf("MOD(ABS(%s), %d) < %d", opts.Key, info.Buckets, info.Selected)
+	}
+	info.SQL = fmt.Sprintf("SELECT * FROM (%s) AS nuodb_sample WHERE %s", query, filter)
+	return info, nil
+}
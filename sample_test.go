@@ -0,0 +1,65 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSampleSQL(t *testing.T) {
+	info, err := sampleSQL("SELECT * FROM t; ", SampleOptions{Fraction: 0.1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "SELECT * FROM (SELECT * FROM t) AS nuodb_sample WHERE RAND() < 0.1"
+	if info.Method != SampleRandom || info.Selected != 1000 || info.SQL != expected {
+		t.Fatalf("Unexpected sample: %+v", info)
+	}
+
+	info, err = sampleSQL("SELECT id FROM t", SampleOptions{Fraction: 0.00001, Key: "id"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = "SELECT * FROM (SELECT id FROM t) AS nuodb_sample WHERE MOD(ABS(id), 10000) < 1"
+	if info.Method != SampleMod || info.Fraction != 0.0001 || info.SQL != expected {
+		t.Fatalf("Unexpected sample: %+v", info)
+	}
+
+	for _, opts := range []SampleOptions{
+		{Fraction: 0},
+		{Fraction: 1.5},
+		{Fraction: 0.5, Key: "id; DROP TABLE t"},
+	} {
+		if _, err := sampleSQL("SELECT * FROM t", opts); err == nil {
+			t.Errorf("%+v: expected error", opts)
+		}
+	}
+}
+
+func TestSampleQuery(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	exec(t, db, "CREATE TABLE tests.FooBarSample (id BIGINT)")
+	for i := 0; i < 100; i++ {
+		exec(t, db, "INSERT INTO tests.FooBarSample VALUES (?)", i)
+	}
+
+	rows, info, err := SampleQuery(context.Background(), db, "SELECT id FROM tests.FooBarSample WHERE id >= ?",
+		SampleOptions{Fraction: 0.005, Key: "id"}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	n := 0
+	for rows.Next() {
+		n++
+	}
+	if rows.Err() != nil {
+		t.Fatal(rows.Err())
+	}
+	// MOD(id, 10000) < 50 holds for the ids 0-49
+	if info.Selected != 50 || n != 50 {
+		t.Fatalf("Expected 50 rows, got %d (%+v)", n, info)
+	}
+}
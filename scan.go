@@ -0,0 +1,345 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ScanOptions configures ScanTable.
+type ScanOptions struct {
+	// Columns are the columns to scan, all the columns by default.
+	Columns []string
+
+	// Key are the primary key columns the table is walked by. By default
+	// they are looked up from the system tables, which requires a schema
+	// qualified table name.
+	Key []string
+
+	// ChunkSize is the number of rows fetched by a single query, 1000 by
+	// default.
+	ChunkSize int
+
+	// Resume is a token returned by TableScanner.Token. The scan continues
+	// after the row the token was taken at.
+	Resume string
+}
+
+const defaultScanChunkSize = 1000
+
+// TableScanner walks an entire table in primary key order, in chunks of
+// bounded queries. Its progress can be persisted with Token, e.g. to
+// checkpoint a backfill job, and resumed later without scanning the table
+// from the start:
+//
+//	s, err := nuodb.ScanTable(ctx, db, "app.users", nuodb.ScanOptions{Resume: checkpoint})
+//	...
+//	defer s.Close()
+//	for s.Next() {
+//		err = s.Scan(&id, &name)
+//		...
+//		checkpoint = s.Token()
+//	}
+//	err = s.Err()
+type TableScanner struct {
+	ctx   context.Context
+	db    *sql.DB
+	query string // chunk query after a key
+	first string // chunk query from the start
+	key   []string
+	chunk int
+
+	rows    *sql.Rows
+	n       int           // rows of the current chunk
+	last    []interface{} // key of the current row
+	started bool          // last is set
+	done    bool
+	err     error
+}
+
+// ScanTable starts walking table in primary key order.
+func ScanTable(ctx context.Context, db *sql.DB, table string, opts ScanOptions) (*TableScanner, error) {
+	if !identifierRegexp.MatchString(table) {
+		return nil, fmt.Errorf("nuodb: invalid table name: %q", table)
+	}
+	key := opts.Key
+	if len(key) == 0 {
+		var err error
+		if key, err = primaryKey(ctx, db, table); err != nil {
+			return nil, err
+		}
+	}
+	for _, names := range [][]string{key, opts.Columns} {
+		for _, name := range names {
+			if !identifierRegexp.MatchString(name) || strings.Contains(name, ".") {
+				return nil, fmt.Errorf("nuodb: invalid column name: %q", name)
+			}
+		}
+	}
+	s := &TableScanner{ctx: ctx, db: db, key: key, chunk: opts.ChunkSize}
+	if s.chunk <= 0 {
+		s.chunk = defaultScanChunkSize
+	}
+	if opts.Resume != "" {
+		last, err := decodeScanToken(opts.Resume, len(key))
+		if err != nil {
+			return nil, err
+		}
+		s.last, s.started = last, true
+	}
+	s.first, s.query = scanQueries(table, key, opts.Columns, s.chunk)
+	return s, nil
+}
+
+// scanQueries returns the chunk queries of the scan. The key columns are
+// selected first, followed by the scanned columns.
+func scanQueries(table string, key, columns []string, chunk int) (first, next string) {
+	list := make([]string, 0, len(key)+1)
+	for _, k := range key {
+		list = append(list, "nuodb_scan."+k)
+	}
+	if len(columns) == 0 {
+		list = append(list, "nuodb_scan.*")
+	} else {
+		for _, col := range columns {
+			list = append(list, "nuodb_scan."+col)
+		}
+	}
+	selectFrom := "SELECT " + strings.Join(list, ", ") + " FROM " + table + " AS nuodb_scan"
+	orderBy := fmt.Sprintf(" ORDER BY %s LIMIT %d", strings.Join(list[:len(key)], ", "), chunk)
+
+	// (k1, k2) > (?, ?) expanded as k1 > ? OR (k1 = ? AND k2 > ?)
+	var or []string
+	for i := range key {
+		var and []string
+		for _, k := range list[:i] {
+			and = append(and, k+" = ?")
+		}
+		and = append(and, list[i]+" > ?")
+		or = append(or, "("+strings.Join(and, " AND ")+")")
+	}
+	return selectFrom + orderBy, selectFrom + " WHERE " + strings.Join(or, " OR ") + orderBy
+}
+
+// scanArgs returns the args of the chunk query after the key last.
+func scanArgs(last []interface{}) []interface{} {
+	var args []interface{}
+	for i := range last {
+		args = append(args, last[:i+1]...)
+	}
+	return args
+}
+
+// primaryKey looks up the primary key columns of a schema qualified table.
+func primaryKey(ctx context.Context, db *sql.DB, table string) ([]string, error) {
+	dot := strings.IndexByte(table, '.')
+	if dot < 0 {
+		return nil, fmt.Errorf("nuodb: the key of an unqualified table name is unknown: %q", table)
+	}
+	rows, err := db.QueryContext(ctx, `SELECT f.FIELD FROM SYSTEM.INDEXES i
+		JOIN SYSTEM.INDEXFIELDS f ON f.SCHEMA = i.SCHEMA AND f.TABLENAME = i.TABLENAME AND f.INDEXNAME = i.INDEXNAME
+		WHERE i.SCHEMA = ? AND i.TABLENAME = ? AND i.INDEXTYPE = 0 ORDER BY f.POSITION`,
+		strings.ToUpper(table[:dot]), strings.ToUpper(table[dot+1:]))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var key []string
+	for rows.Next() {
+		var field string
+		if err := rows.Scan(&field); err != nil {
+			return nil, err
+		}
+		key = append(key, field)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(key) == 0 {
+		return nil, fmt.Errorf("nuodb: table %s has no primary key", table)
+	}
+	return key, nil
+}
+
+// Next advances to the next row, querying the next chunk when the current
+// one is exhausted. It returns false at the end of the table or on error.
+func (s *TableScanner) Next() bool {
+	if s.done || s.err != nil {
+		return false
+	}
+	if s.rows != nil && s.rows.Next() {
+		s.n++
+		return s.scanKey()
+	}
+	if s.rows != nil {
+		if s.err = s.rows.Err(); s.err != nil {
+			return false
+		}
+		s.rows.Close()
+		s.rows = nil
+		if s.n < s.chunk {
+			s.done = true
+			return false
+		}
+	}
+	if s.started {
+		s.rows, s.err = s.db.QueryContext(s.ctx, s.query, scanArgs(s.last)...)
+	} else {
+		s.rows, s.err = s.db.QueryContext(s.ctx, s.first)
+	}
+	if s.err != nil {
+		return false
+	}
+	s.n = 0
+	return s.Next()
+}
+
+func (s *TableScanner) scanKey() bool {
+	columns, err := s.rows.Columns()
+	if err != nil {
+		s.err = err
+		return false
+	}
+	last := make([]interface{}, len(s.key))
+	dest := make([]interface{}, len(columns))
+	for i := range dest {
+		if i < len(last) {
+			dest[i] = &last[i]
+		} else {
+			dest[i] = new(interface{})
+		}
+	}
+	if s.err = s.rows.Scan(dest...); s.err != nil {
+		return false
+	}
+	s.last, s.started = last, true
+	return true
+}
+
+// Scan copies the scanned columns of the current row into dest, like
+// sql.Rows.Scan.
+func (s *TableScanner) Scan(dest ...interface{}) error {
+	if s.rows == nil {
+		return errors.New("nuodb: Scan called without calling Next")
+	}
+	keyDest := make([]interface{}, len(s.key), len(s.key)+len(dest))
+	for i := range keyDest {
+		keyDest[i] = new(interface{})
+	}
+	return s.rows.Scan(append(keyDest, dest...)...)
+}
+
+// Err returns the error which ended the scan, if any.
+func (s *TableScanner) Err() error {
+	return s.err
+}
+
+// Token returns a token of the position of the scan, which can be passed
+// as ScanOptions.Resume to continue after the current row. It is empty
+// before the first row.
+func (s *TableScanner) Token() string {
+	if !s.started {
+		return ""
+	}
+	return encodeScanToken(s.last)
+}
+
+// Close ends the scan.
+func (s *TableScanner) Close() error {
+	s.done = true
+	if s.rows != nil {
+		return s.rows.Close()
+	}
+	return nil
+}
+
+// scanTokenValue holds a key value of a scan token with its type.
+type scanTokenValue struct {
+	Type  string          `json:"t"`
+	Value json.RawMessage `json:"v"`
+}
+
+func encodeScanToken(key []interface{}) string {
+	values := make([]scanTokenValue, len(key))
+	for i, v := range key {
+		var t string
+		switch u := v.(type) {
+		case int64:
+			t = "int"
+		case float64:
+			t = "float"
+		case bool:
+			t = "bool"
+		case time.Time:
+			t = "time"
+		case []byte:
+			t, v = "string", string(u)
+		case string:
+			t = "string"
+		case nil:
+			t = "null"
+		default:
+			t, v = "string", fmt.Sprint(u)
+		}
+		b, _ := json.Marshal(v)
+		values[i] = scanTokenValue{Type: t, Value: b}
+	}
+	b, _ := json.Marshal(values)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeScanToken(token string, keyLen int) ([]interface{}, error) {
+	invalid := fmt.Errorf("nuodb: invalid scan token: %q", token)
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, invalid
+	}
+	var values []scanTokenValue
+	if err := json.Unmarshal(b, &values); err != nil || len(values) != keyLen {
+		return nil, invalid
+	}
+	key := make([]interface{}, len(values))
+	for i, v := range values {
+		var err error
+		switch v.Type {
+		case "int":
+			var n int64
+			err = json.Unmarshal(v.Value, &n)
+			key[i] = n
+		case "float":
+			var f float64
+			err = json.Unmarshal(v.Value, &f)
+			key[i] = f
+		case "bool":
+			var b bool
+			err = json.Unmarshal(v.Value, &b)
+			key[i] = b
+		case "time":
+			var t time.Time
+			err = json.Unmarshal(v.Value, &t)
+			key[i] = t
+		case "string":
+			var s string
+			err = json.Unmarshal(v.Value, &s)
+			key[i] = s
+		case "null":
+			if !bytes.Equal(v.Value, []byte("null")) {
+				err = invalid
+			}
+		default:
+			err = invalid
+		}
+		if err != nil {
+			return nil, invalid
+		}
+	}
+	return key, nil
+}
@@ -0,0 +1,89 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestScanQueries(t *testing.T) {
+	first, next := scanQueries("app.t", []string{"a", "b"}, []string{"c"}, 10)
+	expected := "SELECT nuodb_scan.a, nuodb_scan.b, nuodb_scan.c FROM app.t AS nuodb_scan" +
+		" ORDER BY nuodb_scan.a, nuodb_scan.b LIMIT 10"
+	if first != expected {
+		t.Errorf("Expected %q, got %q", expected, first)
+	}
+	expected = "SELECT nuodb_scan.a, nuodb_scan.b, nuodb_scan.c FROM app.t AS nuodb_scan" +
+		" WHERE (nuodb_scan.a > ?) OR (nuodb_scan.a = ? AND nuodb_scan.b > ?)" +
+		" ORDER BY nuodb_scan.a, nuodb_scan.b LIMIT 10"
+	if next != expected {
+		t.Errorf("Expected %q, got %q", expected, next)
+	}
+	args := scanArgs([]interface{}{int64(1), "x"})
+	if !reflect.DeepEqual(args, []interface{}{int64(1), int64(1), "x"}) {
+		t.Errorf("Unexpected args: %v", args)
+	}
+}
+
+func TestScanToken(t *testing.T) {
+	key := []interface{}{int64(1), 1.5, true, time.Unix(1, 5).UTC(), []byte("x"), nil}
+	token := encodeScanToken(key)
+	decoded, err := decodeScanToken(token, len(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []interface{}{int64(1), 1.5, true, time.Unix(1, 5).UTC(), "x", nil}
+	if !reflect.DeepEqual(decoded, expected) {
+		t.Fatalf("Expected %v, got %v", expected, decoded)
+	}
+	for _, token := range []string{"", "!", token[:len(token)-2]} {
+		if _, err := decodeScanToken(token, len(key)); err == nil {
+			t.Errorf("%q: expected error", token)
+		}
+	}
+	if _, err := decodeScanToken(token, 1); err == nil {
+		t.Error("Expected error for a key length mismatch")
+	}
+}
+
+func TestScanTable(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	exec(t, db, "CREATE TABLE tests.FooBarScan (a BIGINT, b STRING, c BIGINT, PRIMARY KEY (a, b))")
+	for i := 0; i < 10; i++ {
+		exec(t, db, "INSERT INTO tests.FooBarScan VALUES (?, 'x', ?), (?, 'y', ?)", i/2, i, i/2, i+10)
+	}
+
+	scan := func(resume string, limit int) ([]int64, string) {
+		s, err := ScanTable(context.Background(), db, "tests.FooBarScan",
+			ScanOptions{Columns: []string{"c"}, ChunkSize: 3, Resume: resume})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer s.Close()
+		var cs []int64
+		for len(cs) < limit && s.Next() {
+			var c int64
+			if err := s.Scan(&c); err != nil {
+				t.Fatal(err)
+			}
+			cs = append(cs, c)
+		}
+		if err := s.Err(); err != nil {
+			t.Fatal(err)
+		}
+		return cs, s.Token()
+	}
+	all, _ := scan("", 100)
+	if len(all) != 20 {
+		t.Fatalf("Expected 20 rows, got %d", len(all))
+	}
+	head, token := scan("", 7)
+	tail, _ := scan(token, 100)
+	if !reflect.DeepEqual(append(head, tail...), all) {
+		t.Fatalf("Expected the resumed scan to continue, got %v and %v", head, tail)
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+)
+
+// SetSchema changes the current schema of the connection to name with USE,
+// e.g. to serve a request of a tenant with a schema of its own:
+//
+//	err := conn.Raw(func(driverConn interface{}) error {
+//		return driverConn.(*nuodb.Conn).SetSchema(ctx, "tenant42")
+//	})
+//
+// The default schema of the connection, that of the schema property, is
+// restored when the connection is returned to the pool. Without a schema
+// property, the schema of the session before the first SetSchema is
+// restored.
+func (c *Conn) SetSchema(ctx context.Context, name string) error {
+	if c == nil || c.db == nil {
+		return errUninitialized
+	}
+	if c.bad {
+		return driver.ErrBadConn
+	}
+	if !validIdentifier(name) {
+		return fmt.Errorf("nuodb: invalid schema: %q", name)
+	}
+	if c.schema == "" {
+		schema, err := c.currentSchema()
+		if err != nil {
+			return err
+		}
+		c.schema = quoteIdentifier(schema)
+	}
+	_, err := c.exec(ctx, "USE "+name, nil)
+	return err
+}
+
+// quoteIdentifier quotes an identifier as reported by the server, so that
+// it is used as is.
+func quoteIdentifier(s string) string {
+	return `"` + strings.Replace(s, `"`, `""`, -1) + `"`
+}
@@ -0,0 +1,61 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+func TestSetSchema(t *testing.T) {
+	setup := testConn(t)
+	defer setup.Close()
+	exec(t, setup, "CREATE SCHEMA other")
+	exec(t, setup, "CREATE TABLE other.FooBar (id INTEGER)")
+	exec(t, setup, "INSERT INTO other.FooBar VALUES (1)")
+
+	for _, dsn := range []string{base_dsn + "?schema=tests", base_dsn} {
+		db, err := sql.Open("nuodb", dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		db.SetMaxOpenConns(1)
+		ctx := context.Background()
+		var initial string
+		if err := db.QueryRow("SELECT current_schema() FROM DUAL").Scan(&initial); err != nil {
+			t.Fatal(err)
+		}
+
+		conn, err := db.Conn(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = conn.Raw(func(driverConn interface{}) error {
+			c := driverConn.(*Conn)
+			if err := c.SetSchema(ctx, "other; DROP TABLE x"); err == nil {
+				t.Error("Expected an invalid schema")
+			}
+			return c.SetSchema(ctx, "other")
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var count int
+		if err := conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM FooBar").Scan(&count); err != nil || count != 1 {
+			t.Fatalf("Expected 1 row in other.FooBar, got %d (%v)", count, err)
+		}
+		conn.Close()
+
+		// the schema is restored on the pooled connection
+		var schema string
+		if err := db.QueryRow("SELECT current_schema() FROM DUAL").Scan(&schema); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.EqualFold(schema, initial) {
+			t.Fatalf("%s: expected schema %s, was %s", dsn, initial, schema)
+		}
+		db.Close()
+	}
+}
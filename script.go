@@ -0,0 +1,53 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/tilinna/go-nuodb/internal/parse"
+)
+
+// ScriptError is returned by ExecScript for the statement which failed.
+type ScriptError struct {
+	Index int // of the statement in the script, from 0
+	SQL   string
+	Err   error
+}
+
+func (e *ScriptError) Error() string {
+	return fmt.Sprintf("nuodb: script statement %d: %s\n\t%s", e.Index, e.Err, e.SQL)
+}
+
+func (e *ScriptError) Unwrap() error {
+	return e.Err
+}
+
+// ExecScript executes a script of statements separated by semicolons,
+// e.g. a migration, in order on a single connection of db, so that a USE
+// or SET statement applies to the statements following it. Semicolons in
+// quotes, comments and the bodies of stored procedures, functions and
+// triggers don't separate statements, so a script need not change the
+// delimiter as it would in nuosql. It returns the number of statements
+// executed.
+//
+// The execution stops at the first statement which fails, with a
+// *ScriptError. Each statement is executed in autocommit mode, unless the
+// script begins a transaction, so the statements before the failed one
+// stay executed.
+func ExecScript(ctx context.Context, db *sql.DB, script string) (int, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	statements := parse.SplitStatements(script)
+	for i, statement := range statements {
+		if _, err := conn.ExecContext(ctx, statement); err != nil {
+			return i, &ScriptError{Index: i, SQL: statement, Err: err}
+		}
+	}
+	return len(statements), nil
+}
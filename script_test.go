@@ -0,0 +1,56 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestScriptErrorMessage(t *testing.T) {
+	cause := errors.New("syntax error")
+	err := &ScriptError{Index: 2, SQL: "SELEC 1", Err: cause}
+	expected := "nuodb: script statement 2: syntax error\n\tSELEC 1"
+	if err.Error() != expected {
+		t.Fatalf("Expected %q, got %q", expected, err.Error())
+	}
+	if !errors.Is(err, cause) {
+		t.Fatal("Expected the error to wrap its cause")
+	}
+}
+
+func TestExecScript(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	ctx := context.Background()
+
+	n, err := ExecScript(ctx, db, `USE tests;
+		CREATE TABLE FooBar (id BIGINT, name STRING);
+		-- a procedure body has semicolons of its own
+		CREATE PROCEDURE addFoo(IN n BIGINT) AS
+			INSERT INTO FooBar VALUES (n, 'proc; one');
+			INSERT INTO FooBar VALUES (n + 1, 'proc; two');
+		END_PROCEDURE;
+		CALL addFoo(1);
+		INSERT INTO FooBar VALUES (3, 'it''s; three');`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 5 {
+		t.Fatalf("Expected 5 statements, got %d", n)
+	}
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM tests.FooBar").Scan(&count); err != nil || count != 3 {
+		t.Fatal(count, err)
+	}
+
+	n, err = ExecScript(ctx, db, "USE tests; INSERT INTO FooBar VALUES (4, 'four'); SELEC 1; INSERT INTO FooBar VALUES (5, 'five')")
+	var serr *ScriptError
+	if !errors.As(err, &serr) || serr.Index != 2 || n != 2 {
+		t.Fatalf("Expected statement 2 to fail after 2, got %d: %v", n, err)
+	}
+	if err := db.QueryRow("SELECT COUNT(*) FROM tests.FooBar").Scan(&count); err != nil || count != 4 {
+		t.Fatal(count, err)
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"database/sql/driver"
+	"io"
+	"strings"
+
+	"github.com/tilinna/go-nuodb/internal/parse"
+)
+
+// qualify qualifies the unqualified table names of sql which are found in
+// the other schemas of the search path than the default schema. A DDL
+// statement makes the tables be looked up again.
+func (c *Conn) qualify(sql string) string {
+	if len(c.searchPath) < 2 {
+		return sql
+	}
+	if parse.Classify(sql) == parse.KindOther {
+		c.tables = nil
+		return sql
+	}
+	return parse.QualifyTables(sql, c.resolveTable)
+}
+
+// resolveTable returns the schema of the first table of the search path
+// named table, unless it is the default schema.
+func (c *Conn) resolveTable(table string) (string, bool) {
+	if c.tables == nil {
+		if err := c.loadSearchPathTables(); err != nil {
+			return "", false // looked up again by the next statement
+		}
+	}
+	schema, ok := c.tables[strings.ToUpper(table)]
+	if !ok || strings.EqualFold(schema, c.searchPath[0]) {
+		return "", false
+	}
+	return schema, true
+}
+
+// loadSearchPathTables looks up the tables of the schemas of the search
+// path.
+func (c *Conn) loadSearchPathTables() error {
+	if len(c.searchPath) < 2 {
+		return nil
+	}
+	args := make([]driver.NamedValue, len(c.searchPath))
+	for i, schema := range c.searchPath {
+		args[i] = driver.NamedValue{Ordinal: i + 1, Value: strings.ToUpper(schema)}
+	}
+	// SYSTEM.TABLES is qualified, so the query doesn't resolve its tables
+	query := "SELECT SCHEMA, TABLENAME FROM SYSTEM.TABLES WHERE SCHEMA IN (" +
+		strings.TrimSuffix(strings.Repeat("?, ", len(args)), ", ") + ")"
+	rows, err := c.QueryContext(context.Background(), query, args)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	rank := make(map[string]int, len(c.searchPath)) // of the schemas
+	for i, schema := range c.searchPath {
+		rank[strings.ToUpper(schema)] = i
+	}
+	tables := make(map[string]string)
+	dest := make([]driver.Value, 2)
+	for {
+		if err := rows.Next(dest); err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		schema, table := strings.ToUpper(valueString(dest[0])), strings.ToUpper(valueString(dest[1]))
+		if current, ok := tables[table]; !ok || rank[schema] < rank[current] {
+			tables[table] = schema
+		}
+	}
+	c.tables = tables
+	return nil
+}
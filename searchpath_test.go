@@ -0,0 +1,46 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestSearchPath(t *testing.T) {
+	setup := testConn(t)
+	defer setup.Close()
+	exec(t, setup, "DROP SCHEMA CASCADE IF EXISTS tests_legacy")
+	defer exec(t, setup, "DROP SCHEMA CASCADE IF EXISTS tests_legacy")
+	exec(t, setup, "CREATE TABLE tests.FooBar (id INTEGER, name STRING)")
+	exec(t, setup, "CREATE TABLE tests_legacy.FooBar (id INTEGER, name STRING)")
+	exec(t, setup, "CREATE TABLE tests_legacy.Archive (id INTEGER, name STRING)")
+	exec(t, setup, "INSERT INTO tests_legacy.Archive VALUES (1, 'archived')")
+
+	db, err := sql.Open("nuodb", base_dsn+"?searchPath=tests,tests_legacy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// FooBar of the first schema shadows the one of the second
+	exec(t, db, "INSERT INTO FooBar VALUES (2, 'current')")
+	exec(t, db, "INSERT INTO FooBar SELECT id, name FROM Archive")
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM tests.FooBar").Scan(&count); err != nil || count != 2 {
+		t.Fatalf("Expected 2 rows in tests.FooBar, got %d (%v)", count, err)
+	}
+	if err := db.QueryRow("SELECT COUNT(*) FROM tests_legacy.FooBar").Scan(&count); err != nil || count != 0 {
+		t.Fatalf("Expected no rows in tests_legacy.FooBar, got %d (%v)", count, err)
+	}
+	var name string
+	if err := db.QueryRow("SELECT a.name FROM Archive a JOIN FooBar f ON f.id = a.id WHERE f.name = ?",
+		"archived").Scan(&name); err != nil || name != "archived" {
+		t.Fatalf("Expected the archived row, got %q (%v)", name, err)
+	}
+
+	// a table created later is found after the DDL
+	exec(t, setup, "CREATE TABLE tests_legacy.Later (id INTEGER)")
+	exec(t, db, "CREATE TABLE tests.Other (id INTEGER)")
+	exec(t, db, "INSERT INTO Later VALUES (1)")
+}
@@ -0,0 +1,117 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/tilinna/go-nuodb/internal/parse"
+)
+
+// SecurityInfo describes the security of a connection, e.g. for a security
+// team auditing at runtime that the connections meet a policy. The NuoDB
+// client library negotiates TLS itself and doesn't expose the negotiated
+// cipher suite, protocol version or server certificate, so SecurityInfo
+// holds what the connection was opened with: whether TLS was required,
+// with hostname verification, and the certificates it trusted and
+// presented.
+type SecurityInfo struct {
+	TLS            bool // opened with a trust store, so encrypted with TLS
+	VerifyHostname bool // the host name of the broker had to match its certificate
+
+	TrustedCertificates []CertificateInfo // of the trust store
+	ClientCertificate   *CertificateInfo  // presented to the server, if any
+
+	ClientVersion Version // of the NuoDB client library
+	ServerVersion Version
+}
+
+// CertificateInfo describes an X.509 certificate of SecurityInfo.
+type CertificateInfo struct {
+	Subject           string
+	Issuer            string
+	SerialNumber      string // in hex
+	NotBefore         time.Time
+	NotAfter          time.Time
+	SHA256Fingerprint string // of the DER encoding, in hex
+}
+
+// Expired reports whether the certificate isn't valid at t.
+func (c CertificateInfo) Expired(t time.Time) bool {
+	return t.Before(c.NotBefore) || t.After(c.NotAfter)
+}
+
+// Security implements NuoConn. The certificate files are read on every
+// call, so that a rotated certificate is reported as of the time of the
+// audit.
+func (c *Conn) Security() (*SecurityInfo, error) {
+	if c == nil || c.db == nil {
+		return nil, errUninitialized
+	}
+	server, err := c.ServerVersion()
+	if err != nil {
+		return nil, err
+	}
+	info := &SecurityInfo{TLS: c.tls(), VerifyHostname: c.props[parse.PropVerifyHostname] != "false",
+		ClientVersion: c.clientVersion, ServerVersion: server}
+	if !info.TLS {
+		info.VerifyHostname = false
+	} else if info.TrustedCertificates, err = readCertificates(c.props[parse.NuoTrustStore]); err != nil {
+		return nil, err
+	}
+	if path, ok := c.props[parse.NuoClientCertificate]; ok {
+		certs, err := readCertificates(path)
+		if err != nil {
+			return nil, err
+		}
+		if len(certs) > 0 {
+			info.ClientCertificate = &certs[0]
+		}
+	}
+	return info, nil
+}
+
+// tls reports whether c was opened with a trust store.
+func (c *Conn) tls() bool {
+	_, ok := c.props[parse.NuoTrustStore]
+	return ok
+}
+
+// readCertificates reads the PEM encoded certificates of a file. The other
+// PEM blocks, e.g. of a key, are skipped.
+func readCertificates(path string) ([]CertificateInfo, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("nuodb: reading certificates: %w", err)
+	}
+	var certs []CertificateInfo
+	for {
+		var block *pem.Block
+		if block, data = pem.Decode(data); block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("nuodb: certificate of %s: %w", path, err)
+		}
+		sum := sha256.Sum256(cert.Raw)
+		certs = append(certs, CertificateInfo{
+			Subject:           cert.Subject.String(),
+			Issuer:            cert.Issuer.String(),
+			SerialNumber:      cert.SerialNumber.Text(16),
+			NotBefore:         cert.NotBefore,
+			NotAfter:          cert.NotAfter,
+			SHA256Fingerprint: hex.EncodeToString(sum[:]),
+		})
+	}
+	return certs, nil
+}
@@ -0,0 +1,101 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tilinna/go-nuodb/internal/parse"
+)
+
+func TestReadCertificates(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notBefore := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	template := &x509.Certificate{SerialNumber: big.NewInt(0xabc), Subject: pkix.Name{CommonName: "nuodb-ca"},
+		NotBefore: notBefore, NotAfter: notBefore.AddDate(1, 0, 0)}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "nuodb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "client.pem")
+	data := append(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})...)
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	certs, err := readCertificates(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(certs) != 1 {
+		t.Fatalf("Expected 1 certificate, got %d", len(certs))
+	}
+	c := certs[0]
+	if c.Subject != "CN=nuodb-ca" || c.Issuer != "CN=nuodb-ca" || c.SerialNumber != "abc" ||
+		!c.NotBefore.Equal(notBefore) || len(c.SHA256Fingerprint) != 64 {
+		t.Fatalf("Unexpected certificate %+v", c)
+	}
+	if c.Expired(notBefore.AddDate(0, 6, 0)) || !c.Expired(notBefore.AddDate(2, 0, 0)) {
+		t.Fatal("Unexpected expiry")
+	}
+	if _, err := readCertificates(path + ".missing"); err == nil {
+		t.Fatal("Expected an error for a missing file")
+	}
+}
+
+func TestConnTLS(t *testing.T) {
+	if (&Conn{}).tls() {
+		t.Fatal("Expected no TLS without a trust store")
+	}
+	if !(&Conn{props: map[string]string{parse.NuoTrustStore: "ca.pem"}}).tls() {
+		t.Fatal("Expected TLS with a trust store")
+	}
+}
+
+func TestSecurity(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	err = conn.Raw(func(driverConn interface{}) error {
+		info, err := driverConn.(NuoConn).Security()
+		if err != nil {
+			return err
+		}
+		if info.TLS || info.TrustedCertificates != nil || !info.ServerVersion.Known() {
+			t.Fatalf("Unexpected security info %+v", info)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// SessionContextTable is the session scoped temporary table which holds the
+// session context of a connection. Row-level security views, policies and
+// audit triggers can read the identifiers of the end user from it, e.g.
+//
+//	SELECT value FROM session_context WHERE name = 'tenant'
+const SessionContextTable = "session_context"
+
+// SessionContext holds per-request identifiers, like the end user or the
+// tenant, which are made visible to the server side of the session.
+type SessionContext map[string]string
+
+type sessionContextKey struct{}
+
+// WithSessionContext returns a copy of ctx which carries sc. Before a
+// statement is run with the returned context the driver stores sc into the
+// SessionContextTable of the connection, replacing any previous content. In
+// a transaction sc is stored by the transaction, so a rollback undoes it and
+// the next statement stores it again. The table is cleared when the
+// connection is returned to the pool.
+func WithSessionContext(ctx context.Context, sc SessionContext) context.Context {
+	return context.WithValue(ctx, sessionContextKey{}, sc)
+}
+
+func sessionContextFrom(ctx context.Context) SessionContext {
+	sc, _ := ctx.Value(sessionContextKey{}).(SessionContext)
+	return sc
+}
+
+// applyContext applies the per-call state carried by ctx to the connection
+// before a statement: the session context and the commit info.
+func (c *Conn) applyContext(ctx context.Context) error {
+	if err := c.applyCommitInfo(ctx); err != nil {
+		return err
+	}
+	return c.applySessionContext(ctx)
+}
+
+// applySessionContext stores the session context of ctx, if it differs from
+// the one already applied on the connection, with a DELETE and a single
+// INSERT of all of its entries.
+func (c *Conn) applySessionContext(ctx context.Context) error {
+	sc := sessionContextFrom(ctx)
+	if sc == nil || reflect.DeepEqual(sc, c.sessionContext) {
+		return nil
+	}
+	if c.sessionContext == nil {
+		if _, err := c.exec(ctx, "CREATE TEMPORARY TABLE IF NOT EXISTS "+SessionContextTable+
+			" (name STRING NOT NULL PRIMARY KEY, value STRING)", nil); err != nil {
+			return err
+		}
+	}
+	if _, err := c.exec(ctx, "DELETE FROM "+SessionContextTable, nil); err != nil {
+		c.bad = true // the content is unknown; never reuse the connection
+		return err
+	}
+	c.sessionContext = nil
+	if len(sc) > 0 {
+		sql, args := insertSessionContext(sc)
+		if _, err := c.exec(ctx, sql, args); err != nil {
+			c.bad = true
+			return err
+		}
+	}
+	applied := make(SessionContext, len(sc))
+	for name, value := range sc {
+		applied[name] = value
+	}
+	c.sessionContext = applied
+	return nil
+}
+
+// insertSessionContext returns the INSERT of the entries of sc, in the order
+// of their names, and its arguments.
+func insertSessionContext(sc SessionContext) (string, []driver.NamedValue) {
+	names := make([]string, 0, len(sc))
+	for name := range sc {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	rows := make([]string, len(names))
+	args := make([]driver.NamedValue, 0, 2*len(names))
+	for i, name := range names {
+		rows[i] = "(?, ?)"
+		args = append(args, driver.NamedValue{Ordinal: len(args) + 1, Value: name},
+			driver.NamedValue{Ordinal: len(args) + 2, Value: sc[name]})
+	}
+	return "INSERT INTO " + SessionContextTable + " (name, value) VALUES " + strings.Join(rows, ", "), args
+}
+
+// clearSessionContext removes the applied session context, if any.
+func (c *Conn) clearSessionContext(ctx context.Context) error {
+	if c.sessionContext == nil {
+		return nil
+	}
+	if _, err := c.exec(ctx, "DELETE FROM "+SessionContextTable, nil); err != nil {
+		return err
+	}
+	c.sessionContext = nil
+	return nil
+}
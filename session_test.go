@@ -0,0 +1,55 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"testing"
+)
+
+func TestInsertSessionContext(t *testing.T) {
+	sql, args := insertSessionContext(SessionContext{"user": "robinh", "tenant": "sherwood"})
+	if expected := "INSERT INTO session_context (name, value) VALUES (?, ?), (?, ?)"; sql != expected {
+		t.Fatalf("Expected %q, got %q", expected, sql)
+	}
+	expected := []driver.NamedValue{{Ordinal: 1, Value: "tenant"}, {Ordinal: 2, Value: "sherwood"},
+		{Ordinal: 3, Value: "user"}, {Ordinal: 4, Value: "robinh"}}
+	if !reflect.DeepEqual(args, expected) {
+		t.Fatalf("Expected %v, got %v", expected, args)
+	}
+}
+
+func TestSessionContextRollback(t *testing.T) {
+	before := SessionContext{"tenant": "sherwood"}
+	c := &Conn{txc: &fakeTx{auto: true}, sessionContext: before}
+	tx, err := c.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.sessionContext = SessionContext{"tenant": "nottingham"} // applied in the Tx
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(c.sessionContext, before) {
+		t.Fatalf("Expected %v after the rollback, got %v", before, c.sessionContext)
+	}
+	// a commit keeps the context applied in the Tx
+	if tx, err = c.Begin(); err != nil {
+		t.Fatal(err)
+	}
+	after := SessionContext{"tenant": "nottingham"}
+	c.sessionContext = after
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(c.sessionContext, after) {
+		t.Fatalf("Expected %v after the commit, got %v", after, c.sessionContext)
+	}
+	// a ROLLBACK with autocommit=false may undo it, so it is forgotten
+	c.manualCommit = true
+	c.trackUncommitted("ROLLBACK", &err)
+	if c.sessionContext != nil {
+		t.Fatalf("Expected no session context after a ROLLBACK, got %v", c.sessionContext)
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+// #include "cnuodb.h"
+import "C"
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/tilinna/go-nuodb/internal/parse"
+)
+
+// sessionProperty sets and gets a property of the session of a connection.
+type sessionProperty struct {
+	set func(c *Conn, ctx context.Context, value string) error
+	get func(c *Conn) (string, error)
+}
+
+// sessionProperties are the properties of SetSessionProperty, by the
+// lower-case name of the connection property of their default.
+var sessionProperties = map[string]sessionProperty{
+	"schema": {
+		set: func(c *Conn, ctx context.Context, value string) error { return c.SetSchema(ctx, value) },
+		get: func(c *Conn) (string, error) { return c.currentSchema() },
+	},
+	strings.ToLower(parse.PropIsolation): {
+		set: func(c *Conn, ctx context.Context, value string) error {
+			level, ok := parse.IsolationLevel(value)
+			if !ok {
+				return fmt.Errorf("nuodb: invalid %s: %s", parse.PropIsolation, value)
+			}
+			c.stats.call()
+			return c.cgo(func() C.int { return C.nuodb_isolation_set(c.db, C.int(level)) })
+		},
+		get: func(c *Conn) (string, error) {
+			var level C.int
+			if err := c.cgo(func() C.int { return C.nuodb_isolation(c.db, &level) }); err != nil {
+				return "", err
+			}
+			if name, ok := parse.IsolationName(int(level)); ok {
+				return name, nil
+			}
+			return strconv.Itoa(int(level)), nil
+		},
+	},
+	strings.ToLower(parse.PropReadOnly): {
+		set: func(c *Conn, ctx context.Context, value string) error {
+			on, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("nuodb: invalid %s: %s", parse.PropReadOnly, value)
+			}
+			return c.txc.setReadOnly(on)
+		},
+		get: func(c *Conn) (string, error) {
+			var state C.int
+			if err := c.cgo(func() C.int { return C.nuodb_read_only(c.db, &state) }); err != nil {
+				return "", err
+			}
+			return strconv.FormatBool(state != 0), nil
+		},
+	},
+	strings.ToLower(parse.PropAutoCommit): {
+		set: func(c *Conn, ctx context.Context, value string) error {
+			on, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("nuodb: invalid %s: %s", parse.PropAutoCommit, value)
+			}
+			if c.inTx {
+				return fmt.Errorf("nuodb: %s can't be changed in a transaction", parse.PropAutoCommit)
+			}
+			c.noAutoCommit = !on // also if it fails half way
+			c.stats.call()
+			return c.cgo(func() C.int { return C.nuodb_autocommit_set(c.db, boolInt(on)) })
+		},
+		get: func(c *Conn) (string, error) {
+			on, err := c.txc.autoCommit()
+			if err != nil {
+				return "", err
+			}
+			return strconv.FormatBool(on), nil
+		},
+	},
+}
+
+// SetSessionProperty implements NuoConn. It changes a property of the
+// session of the connection, with the client library or the statement of
+// the property, so that the session is tuned in one place rather than with
+// ad-hoc SQL:
+//
+//	err := conn.Raw(func(driverConn interface{}) error {
+//		return driverConn.(nuodb.NuoConn).SetSessionProperty(ctx, "isolation", "write_committed")
+//	})
+//
+// The properties are schema, isolation, readOnly and autocommit, case
+// insensitively, with the values of the connection properties of the same
+// names, which are the defaults of the connector applied on connect. The
+// defaults are restored when the connection is returned to the pool.
+// Turning autocommit off ends the automatic retries of the statements of
+// the connection, and the uncommitted statements are rolled back when it is
+// returned.
+func (c *Conn) SetSessionProperty(ctx context.Context, name, value string) error {
+	p, err := c.sessionProperty(ctx, name)
+	if err != nil {
+		return err
+	}
+	return p.set(c, ctx, value)
+}
+
+// GetSessionProperty implements NuoConn. It returns the current value of a property of the session
+// of the connection, one of the properties of SetSessionProperty.
+func (c *Conn) GetSessionProperty(ctx context.Context, name string) (string, error) {
+	p, err := c.sessionProperty(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	return p.get(c)
+}
+
+// sessionProperty returns the session property of name, if c can be used
+// with ctx.
+func (c *Conn) sessionProperty(ctx context.Context, name string) (sessionProperty, error) {
+	if c == nil || c.db == nil {
+		return sessionProperty{}, errUninitialized
+	}
+	if c.bad {
+		return sessionProperty{}, driver.ErrBadConn
+	}
+	if ctx.Err() != nil {
+		return sessionProperty{}, contextError(ctx)
+	}
+	p, ok := sessionProperties[strings.ToLower(name)]
+	if !ok {
+		return sessionProperty{}, fmt.Errorf("nuodb: unknown session property: %q", name)
+	}
+	return p, nil
+}
@@ -0,0 +1,75 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestSessionProperties(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	ctx := context.Background()
+	exec(t, db, "CREATE SCHEMA other")
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var defaults map[string]string
+	err = conn.Raw(func(driverConn interface{}) error {
+		c := driverConn.(*Conn)
+		get := func() map[string]string {
+			values := map[string]string{}
+			for _, name := range []string{"schema", "isolation", "readOnly", "autocommit"} {
+				v, err := c.GetSessionProperty(ctx, name)
+				if err != nil {
+					t.Fatal(name, err)
+				}
+				values[name] = v
+			}
+			return values
+		}
+		defaults = get()
+		for name, value := range map[string]string{"schema": "other", "ISOLATION": "write_committed", "readonly": "true", "autocommit": "false"} {
+			if err := c.SetSessionProperty(ctx, name, value); err != nil {
+				t.Fatal(name, err)
+			}
+		}
+		expected := map[string]string{"schema": "OTHER", "isolation": "write_committed", "readOnly": "true", "autocommit": "false"}
+		if values := get(); !reflect.DeepEqual(values, expected) {
+			t.Errorf("Expected %v, got %v", expected, values)
+		}
+		if !c.inTransaction() {
+			t.Error("Expected no retries with autocommit off")
+		}
+		for name, value := range map[string]string{"isolation": "repeatable_read", "readOnly": "maybe", "timezone": "UTC"} {
+			if err := c.SetSessionProperty(ctx, name, value); err == nil {
+				t.Errorf("Expected %s=%s to fail", name, value)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+
+	// the defaults are restored on the pooled connection
+	conn, err = db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.Raw(func(driverConn interface{}) error {
+		for name, expected := range defaults {
+			if v, err := driverConn.(*Conn).GetSessionProperty(ctx, name); err != nil || v != expected {
+				t.Errorf("%s: expected %s, got %s %v", name, expected, v, err)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,295 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+// Package shard routes keys to several NuoDB databases used as the shards
+// of one data set. A key, e.g. a customer id, is mapped to its shard with
+// consistent hashing, so that adding a shard moves only a share of the
+// keys to it rather than reshuffling them all:
+//
+//	r, err := shard.NewRouter([]shard.Shard{{Name: "eu1", Connector: eu1}, {Name: "eu2", Connector: eu2}})
+//	db, err := r.DB(customerID)
+//	err = db.QueryRowContext(ctx, "SELECT name FROM customers WHERE id = ?", customerID).Scan(&name)
+//
+// Each shard has a connection pool of its own. CheckHealth marks the shards
+// whose databases can't be reached, so that the keys of a down shard fail
+// fast instead of waiting for a connect, and the scatter-gather queries
+// report them without querying them.
+package shard
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tilinna/go-nuodb"
+)
+
+// Shard is one of the databases of a Router.
+type Shard struct {
+	// Name identifies the shard on the hash ring, so it must be unique
+	// and stay the same when the shards are reconfigured.
+	Name      string
+	Connector driver.Connector // e.g. of nuodb.NewConnector
+}
+
+// ErrUnavailable is wrapped by the errors of the shards marked down by
+// CheckHealth.
+var ErrUnavailable = errors.New("nuodb: shard is unavailable")
+
+// replicas is the number of points of each shard on the hash ring, which
+// spread the keys evenly between the shards.
+const replicas = 128
+
+// Router maps keys to shards. It is safe for concurrent use.
+type Router struct {
+	shards []*state // in the order of NewRouter
+	ring   []point  // sorted by hash
+}
+
+type point struct {
+	hash  uint32
+	shard *state
+}
+
+// state is a shard and its pool.
+type state struct {
+	name string
+	db   *sql.DB
+
+	mu     sync.Mutex
+	health Health
+}
+
+// NewRouter returns a Router of shards, opening a connection pool for each
+// of them. The pools connect lazily, as with sql.OpenDB.
+func NewRouter(shards []Shard) (*Router, error) {
+	if len(shards) == 0 {
+		return nil, errors.New("nuodb: no shards")
+	}
+	r := &Router{}
+	names := make(map[string]bool, len(shards))
+	for _, s := range shards {
+		switch {
+		case s.Name == "":
+			return nil, errors.New("nuodb: shard without a name")
+		case names[s.Name]:
+			return nil, fmt.Errorf("nuodb: duplicate shard %q", s.Name)
+		case s.Connector == nil:
+			return nil, fmt.Errorf("nuodb: shard %q has no connector", s.Name)
+		}
+		names[s.Name] = true
+		r.shards = append(r.shards, &state{name: s.Name, health: Health{Shard: s.Name, Up: true}})
+	}
+	for i, s := range r.shards {
+		s.db = sql.OpenDB(shards[i].Connector)
+		for j := 0; j < replicas; j++ {
+			r.ring = append(r.ring, point{hash: hash(strconv.Itoa(j) + s.name), shard: s})
+		}
+	}
+	sort.Slice(r.ring, func(i, j int) bool { return r.ring[i].hash < r.ring[j].hash })
+	return r, nil
+}
+
+func hash(s string) uint32 {
+	return crc32.ChecksumIEEE([]byte(s))
+}
+
+// locate returns the shard of key: the one of the first point of the ring
+// at or after the hash of the key.
+func (r *Router) locate(key string) *state {
+	h := hash(key)
+	i := sort.Search(len(r.ring), func(i int) bool { return r.ring[i].hash >= h })
+	if i == len(r.ring) {
+		i = 0
+	}
+	return r.ring[i].shard
+}
+
+// Shard returns the name of the shard of key.
+func (r *Router) Shard(key string) string {
+	return r.locate(key).name
+}
+
+// Shards returns the names of the shards.
+func (r *Router) Shards() []string {
+	names := make([]string, len(r.shards))
+	for i, s := range r.shards {
+		names[i] = s.name
+	}
+	return names
+}
+
+// DB returns the pool of the shard of key. It fails with an error wrapping
+// ErrUnavailable if CheckHealth marked the shard down.
+func (r *Router) DB(key string) (*sql.DB, error) {
+	s := r.locate(key)
+	if err := s.available(); err != nil {
+		return nil, err
+	}
+	return s.db, nil
+}
+
+// Close closes the pools of the shards.
+func (r *Router) Close() error {
+	var err error
+	for _, s := range r.shards {
+		if cerr := s.db.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// Health is the state of a shard seen by CheckHealth.
+type Health struct {
+	Shard   string
+	Up      bool
+	Node    *nuodb.Node // the TE of the checked connection, if up
+	Err     error       // of the check, if down
+	Checked time.Time   // zero if never checked
+}
+
+func (s *state) available() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.health.Up {
+		return fmt.Errorf("%w: %s: %v", ErrUnavailable, s.name, s.health.Err)
+	}
+	return nil
+}
+
+// CheckHealth checks the shards concurrently by asking a connection of
+// each pool for the TE it is connected to, marks the shards which fail up
+// or down accordingly and returns their health in the order of NewRouter.
+// Call it periodically, e.g. every few seconds, to keep the routing
+// current. A shard is up until its first failed check.
+func (r *Router) CheckHealth(ctx context.Context) []Health {
+	health := make([]Health, len(r.shards))
+	var wg sync.WaitGroup
+	for i, s := range r.shards {
+		wg.Add(1)
+		go func(i int, s *state) {
+			defer wg.Done()
+			node, err := s.check(ctx)
+			h := Health{Shard: s.name, Up: err == nil, Node: node, Err: err, Checked: time.Now()}
+			s.mu.Lock()
+			s.health = h
+			s.mu.Unlock()
+			health[i] = h
+		}(i, s)
+	}
+	wg.Wait()
+	return health
+}
+
+func (s *state) check(ctx context.Context) (*nuodb.Node, error) {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	var node *nuodb.Node
+	err = conn.Raw(func(driverConn interface{}) error {
+		c, ok := driverConn.(nuodb.NuoConn)
+		if !ok {
+			return errors.New("nuodb: not a nuodb connection")
+		}
+		node, err = c.ConnectedNode()
+		return err
+	})
+	return node, err
+}
+
+// Health returns the health of the shards seen by the last CheckHealth,
+// in the order of NewRouter.
+func (r *Router) Health() []Health {
+	health := make([]Health, len(r.shards))
+	for i, s := range r.shards {
+		s.mu.Lock()
+		health[i] = s.health
+		s.mu.Unlock()
+	}
+	return health
+}
+
+// ShardError is the failure of a shard in a scatter-gather call.
+type ShardError struct {
+	Shard string
+	Err   error
+}
+
+// ScatterError is returned by Scatter and Query for the shards which
+// failed, while the others completed.
+type ScatterError struct {
+	Shards int // called
+	Errors []ShardError
+}
+
+func (e *ScatterError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "nuodb: %d of %d shards failed", len(e.Errors), e.Shards)
+	for _, se := range e.Errors {
+		fmt.Fprintf(&b, "\nshard %s: %s", se.Shard, se.Err)
+	}
+	return b.String()
+}
+
+// Scatter calls fn concurrently with the pool of each shard. The shards
+// marked down are not called and are reported as failed, with an error
+// wrapping ErrUnavailable, in a *ScatterError together with the shards
+// whose fn failed.
+func (r *Router) Scatter(ctx context.Context, fn func(ctx context.Context, shard string, db *sql.DB) error) error {
+	errs := make([]error, len(r.shards))
+	var wg sync.WaitGroup
+	for i, s := range r.shards {
+		if errs[i] = s.available(); errs[i] != nil {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, s *state) {
+			defer wg.Done()
+			errs[i] = fn(ctx, s.name, s.db)
+		}(i, s)
+	}
+	wg.Wait()
+	result := &ScatterError{Shards: len(r.shards)}
+	for i, err := range errs {
+		if err != nil {
+			result.Errors = append(result.Errors, ShardError{Shard: r.shards[i].name, Err: err})
+		}
+	}
+	if len(result.Errors) > 0 {
+		return result
+	}
+	return nil
+}
+
+// Query executes query on every shard and calls gather with the rows of
+// each, one shard at a time, so that gather can merge the rows without
+// locking. The shards are queried concurrently and the order of the calls
+// is that of the completion of the queries. The failures are reported as
+// with Scatter.
+func (r *Router) Query(ctx context.Context, gather func(shard string, rows *sql.Rows) error, query string, args ...interface{}) error {
+	var mu sync.Mutex
+	return r.Scatter(ctx, func(ctx context.Context, shard string, db *sql.DB) error {
+		rows, err := db.QueryContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		mu.Lock()
+		err = gather(shard, rows)
+		mu.Unlock()
+		if err != nil {
+			return err
+		}
+		return rows.Err()
+	})
+}
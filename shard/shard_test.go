@@ -0,0 +1,132 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package shard
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strconv"
+	"testing"
+)
+
+// downConnector fails every connect.
+type downConnector struct{}
+
+var errDown = errors.New("connection refused")
+
+func (downConnector) Connect(context.Context) (driver.Conn, error) { return nil, errDown }
+func (downConnector) Driver() driver.Driver                        { return nil }
+
+func testShards(names ...string) []Shard {
+	shards := make([]Shard, len(names))
+	for i, name := range names {
+		shards[i] = Shard{Name: name, Connector: downConnector{}}
+	}
+	return shards
+}
+
+func TestNewRouterErrors(t *testing.T) {
+	for _, shards := range [][]Shard{
+		nil,
+		{{Name: "", Connector: downConnector{}}},
+		testShards("a", "a"),
+		{{Name: "a"}},
+	} {
+		if _, err := NewRouter(shards); err == nil {
+			t.Fatalf("Expected an error for %v", shards)
+		}
+	}
+}
+
+func TestConsistentHashing(t *testing.T) {
+	r, err := NewRouter(testShards("a", "b", "c"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	const keys = 30000
+	counts := map[string]int{}
+	before := make([]string, keys)
+	for i := range before {
+		before[i] = r.Shard(strconv.Itoa(i))
+		counts[before[i]]++
+	}
+	for _, name := range r.Shards() {
+		if n := counts[name]; n < keys/6 {
+			t.Fatalf("Expected the keys to spread evenly, got %v", counts)
+		}
+	}
+
+	grown, err := NewRouter(testShards("a", "b", "c", "d"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer grown.Close()
+	moved := 0
+	for i, shard := range before {
+		if s := grown.Shard(strconv.Itoa(i)); s != shard {
+			if s != "d" {
+				t.Fatalf("Expected key %d to stay on %s or move to d, got %s", i, shard, s)
+			}
+			moved++
+		}
+	}
+	if moved == 0 || moved > keys/2 {
+		t.Fatalf("Expected about a quarter of the keys to move, got %d of %d", moved, keys)
+	}
+}
+
+func TestHealth(t *testing.T) {
+	r, err := NewRouter(testShards("a", "b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if _, err := r.DB("key"); err != nil {
+		t.Fatalf("Expected an unchecked shard to be up, got %v", err)
+	}
+	for _, h := range r.CheckHealth(context.Background()) {
+		if h.Up || !errors.Is(h.Err, errDown) {
+			t.Fatalf("Expected %s to be down, got %+v", h.Shard, h)
+		}
+	}
+	if _, err := r.DB("key"); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("Expected %v, got %v", ErrUnavailable, err)
+	}
+	called := false
+	err = r.Scatter(context.Background(), func(context.Context, string, *sql.DB) error {
+		called = true
+		return nil
+	})
+	var scatterErr *ScatterError
+	if !errors.As(err, &scatterErr) || len(scatterErr.Errors) != 2 || scatterErr.Shards != 2 {
+		t.Fatalf("Expected both shards to fail, got %v", err)
+	}
+	if called || !errors.Is(scatterErr.Errors[0].Err, ErrUnavailable) {
+		t.Fatalf("Expected the down shards to be skipped, got %v", err)
+	}
+}
+
+func TestScatter(t *testing.T) {
+	r, err := NewRouter(testShards("a", "b", "c"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	failed := errors.New("failed")
+	err = r.Scatter(context.Background(), func(_ context.Context, shard string, db *sql.DB) error {
+		if shard == "b" {
+			return failed
+		}
+		return nil
+	})
+	var scatterErr *ScatterError
+	if !errors.As(err, &scatterErr) || len(scatterErr.Errors) != 1 || scatterErr.Errors[0].Shard != "b" {
+		t.Fatalf("Expected shard b to fail, got %v", err)
+	}
+	if err := r.Scatter(context.Background(), func(context.Context, string, *sql.DB) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+}
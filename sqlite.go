@@ -0,0 +1,305 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SQLiteExportOptions configures ExportSQLite.
+type SQLiteExportOptions struct {
+	// CommitRows is the number of rows inserted into SQLite by a single
+	// transaction, 10000 by default.
+	CommitRows int
+
+	// Replace drops the existing SQLite tables of the exported tables.
+	// Otherwise an existing table fails the export.
+	Replace bool
+}
+
+// SQLiteExportResult reports the progress of ExportSQLite.
+type SQLiteExportResult struct {
+	Tables []ExportedTable // the exported tables, and the table being exported on error
+}
+
+// ExportedTable is a table exported by ExportSQLite.
+type ExportedTable struct {
+	Name string // of the SQLite table
+	Rows int64
+}
+
+const defaultSQLiteCommitRows = 10000
+
+// ExportSQLite copies the schema and the rows of tables of src into dst, a
+// SQLite database opened with a SQLite driver of the caller's choice, e.g.
+// for offline debugging of a production snapshot:
+//
+//	dst, err := sql.Open("sqlite3", "snapshot.db")
+//	...
+//	result, err := nuodb.ExportSQLite(ctx, db, dst, []string{"app.users", "app.orders"},
+//		nuodb.SQLiteExportOptions{})
+//
+// The tables are read in a single transaction of src, so they are a
+// consistent snapshot, and the rows are streamed into dst without holding
+// a table in memory. A SQLite table is named after its table without the
+// schema, and has its columns with the NuoDB types mapped to SQLite:
+//
+//	SMALLINT, INTEGER, BIGINT    INTEGER
+//	DOUBLE, FLOAT                REAL
+//	BOOLEAN                      INTEGER, 0 or 1
+//	DECIMAL, NUMERIC             TEXT, the exact decimal text
+//	CHAR, VARCHAR, STRING, CLOB  TEXT
+//	BINARY, VARBINARY, BLOB      BLOB
+//	DATE                         TEXT, as 2006-01-02
+//	TIME                         TEXT, as 15:04:05.999999999
+//	TIMESTAMP                    TEXT, in RFC 3339 format
+//
+// Nothing is written to src. The returned SQLiteExportResult tells how far
+// the export got also on error.
+func ExportSQLite(ctx context.Context, src, dst *sql.DB, tables []string, opts SQLiteExportOptions) (*SQLiteExportResult, error) {
+	if opts.CommitRows <= 0 {
+		opts.CommitRows = defaultSQLiteCommitRows
+	}
+	names := make(map[string]bool)
+	for _, table := range tables {
+		if !identifierRegexp.MatchString(table) {
+			return nil, fmt.Errorf("nuodb: invalid table name: %q", table)
+		}
+		name := strings.ToUpper(sqliteTableName(table))
+		if names[name] {
+			return nil, fmt.Errorf("nuodb: tables of the same name in different schemas: %s", name)
+		}
+		names[name] = true
+	}
+	tx, err := src.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	result := &SQLiteExportResult{}
+	for _, table := range tables {
+		result.Tables = append(result.Tables, ExportedTable{Name: sqliteTableName(table)})
+		if err := exportSQLiteTable(ctx, tx, dst, table, opts, &result.Tables[len(result.Tables)-1]); err != nil {
+			return result, fmt.Errorf("nuodb: export of %s: %w", table, err)
+		}
+	}
+	return result, nil
+}
+
+// sqliteTableName returns the name of the SQLite table of table.
+func sqliteTableName(table string) string {
+	return table[strings.IndexByte(table, '.')+1:]
+}
+
+func exportSQLiteTable(ctx context.Context, tx *sql.Tx, dst *sql.DB, table string, opts SQLiteExportOptions,
+	exported *ExportedTable) error {
+	rows, err := tx.QueryContext(ctx, "SELECT * FROM "+table)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return err
+	}
+	columns := make([]sqliteColumn, len(types))
+	for i, ct := range types {
+		nullable, ok := ct.Nullable()
+		columns[i] = newSQLiteColumn(ct.Name(), ct.DatabaseTypeName(), ok && !nullable)
+	}
+	if opts.Replace {
+		if _, err := dst.ExecContext(ctx, "DROP TABLE IF EXISTS "+sqliteQuote(exported.Name)); err != nil {
+			return err
+		}
+	}
+	if _, err := dst.ExecContext(ctx, sqliteCreateTable(exported.Name, columns)); err != nil {
+		return err
+	}
+
+	w := &sqliteWriter{dst: dst, insert: sqliteInsert(exported.Name, columns)}
+	defer w.rollback()
+	values := make([]interface{}, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range dest {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		for i, c := range columns {
+			values[i] = c.convert(values[i])
+		}
+		if err := w.write(ctx, values); err != nil {
+			return err
+		}
+		if w.rows == opts.CommitRows {
+			if err := w.commit(); err != nil {
+				return err
+			}
+		}
+		exported.Rows++
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return w.commit()
+}
+
+// sqliteWriter inserts the rows of a table into SQLite in transactions.
+type sqliteWriter struct {
+	dst    *sql.DB
+	insert string
+	tx     *sql.Tx
+	stmt   *sql.Stmt
+	rows   int // inserted by the current transaction
+}
+
+func (w *sqliteWriter) write(ctx context.Context, values []interface{}) error {
+	if w.tx == nil {
+		tx, err := w.dst.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		stmt, err := tx.PrepareContext(ctx, w.insert)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		w.tx, w.stmt, w.rows = tx, stmt, 0
+	}
+	if _, err := w.stmt.ExecContext(ctx, values...); err != nil {
+		return err
+	}
+	w.rows++
+	return nil
+}
+
+func (w *sqliteWriter) commit() error {
+	if w.tx == nil {
+		return nil
+	}
+	w.stmt.Close()
+	err := w.tx.Commit()
+	w.tx, w.stmt = nil, nil
+	return err
+}
+
+func (w *sqliteWriter) rollback() {
+	if w.tx != nil {
+		w.stmt.Close()
+		w.tx.Rollback()
+		w.tx, w.stmt = nil, nil
+	}
+}
+
+// sqliteKind is the conversion of the values of a column for SQLite.
+type sqliteKind int
+
+const (
+	sqliteAsIs sqliteKind = iota
+	sqliteText
+	sqliteBool
+	sqliteDate
+	sqliteTimeOfDay
+	sqliteTimestamp
+)
+
+type sqliteColumn struct {
+	name    string
+	typ     string // SQLite type
+	kind    sqliteKind
+	notNull bool
+}
+
+// newSQLiteColumn maps a column of the NuoDB type databaseTypeName to
+// SQLite.
+func newSQLiteColumn(name, databaseTypeName string, notNull bool) sqliteColumn {
+	c := sqliteColumn{name: name, notNull: notNull}
+	switch t := databaseTypeName; {
+	case strings.Contains(t, "DECIMAL"), strings.Contains(t, "NUMERIC"):
+		c.typ, c.kind = "TEXT", sqliteText
+	case strings.Contains(t, "INT"):
+		c.typ = "INTEGER"
+	case strings.Contains(t, "DOUBLE"), strings.Contains(t, "FLOAT"), strings.Contains(t, "REAL"):
+		c.typ = "REAL"
+	case strings.Contains(t, "BOOL"):
+		c.typ, c.kind = "INTEGER", sqliteBool
+	case strings.Contains(t, "BINARY"), strings.Contains(t, "BLOB"):
+		c.typ = "BLOB"
+	case strings.Contains(t, "TIMESTAMP"):
+		c.typ, c.kind = "TEXT", sqliteTimestamp
+	case strings.Contains(t, "DATE"):
+		c.typ, c.kind = "TEXT", sqliteDate
+	case strings.Contains(t, "TIME"):
+		c.typ, c.kind = "TEXT", sqliteTimeOfDay
+	default:
+		c.typ, c.kind = "TEXT", sqliteText
+	}
+	return c
+}
+
+// convert converts a scanned value of the column for SQLite.
+func (c sqliteColumn) convert(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	switch c.kind {
+	case sqliteText:
+		switch u := v.(type) {
+		case []byte:
+			return string(u)
+		case string:
+			return u
+		}
+		return fmt.Sprint(v)
+	case sqliteBool:
+		if b, ok := v.(bool); ok {
+			if b {
+				return int64(1)
+			}
+			return int64(0)
+		}
+	case sqliteDate:
+		if t, ok := v.(time.Time); ok {
+			return DateOf(t).String()
+		}
+	case sqliteTimeOfDay:
+		if t, ok := v.(time.Time); ok {
+			return TimeOfDayOf(t).String()
+		}
+	case sqliteTimestamp:
+		if t, ok := v.(time.Time); ok {
+			return t.Format(time.RFC3339Nano)
+		}
+	}
+	return v
+}
+
+func sqliteQuote(name string) string {
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
+
+func sqliteCreateTable(table string, columns []sqliteColumn) string {
+	defs := make([]string, len(columns))
+	for i, c := range columns {
+		defs[i] = sqliteQuote(c.name) + " " + c.typ
+		if c.notNull {
+			defs[i] += " NOT NULL"
+		}
+	}
+	return "CREATE TABLE " + sqliteQuote(table) + " (" + strings.Join(defs, ", ") + ")"
+}
+
+func sqliteInsert(table string, columns []sqliteColumn) string {
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		names[i] = sqliteQuote(c.name)
+	}
+	return "INSERT INTO " + sqliteQuote(table) + " (" + strings.Join(names, ", ") + ") VALUES (" +
+		strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ") + ")"
+}
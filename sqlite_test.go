@@ -0,0 +1,74 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSQLiteColumns(t *testing.T) {
+	ts := time.Date(2024, time.March, 5, 13, 4, 5, 500000000, time.UTC)
+	for _, test := range []struct {
+		databaseTypeName string
+		typ              string
+		in, expected     interface{}
+	}{
+		{"BIGINT", "INTEGER", int64(7), int64(7)},
+		{"SMALLINT", "INTEGER", int64(-1), int64(-1)},
+		{"DOUBLE", "REAL", 0.5, 0.5},
+		{"BOOLEAN", "INTEGER", true, int64(1)},
+		{"BOOLEAN", "INTEGER", false, int64(0)},
+		{"DECIMAL", "TEXT", []byte("1.50"), "1.50"},
+		{"STRING", "TEXT", []byte("abc"), "abc"},
+		{"CLOB", "TEXT", "abc", "abc"},
+		{"BLOB", "BLOB", []byte{0, 1}, []byte{0, 1}},
+		{"DATE", "TEXT", ts, "2024-03-05"},
+		{"TIME", "TEXT", ts, "13:04:05.5"},
+		{"TIMESTAMP", "TEXT", ts, "2024-03-05T13:04:05.5Z"},
+		{"STRING", "TEXT", nil, nil},
+	} {
+		c := newSQLiteColumn("X", test.databaseTypeName, false)
+		if c.typ != test.typ {
+			t.Fatalf("%s: expected %s, got %s", test.databaseTypeName, test.typ, c.typ)
+		}
+		v := c.convert(test.in)
+		if b, ok := test.expected.([]byte); ok {
+			if string(v.([]byte)) != string(b) {
+				t.Fatalf("%s: expected %v, got %v", test.databaseTypeName, b, v)
+			}
+		} else if v != test.expected {
+			t.Fatalf("%s: expected %#v, got %#v", test.databaseTypeName, test.expected, v)
+		}
+	}
+}
+
+func TestSQLiteStatements(t *testing.T) {
+	columns := []sqliteColumn{
+		newSQLiteColumn("ID", "BIGINT", true),
+		newSQLiteColumn(`NA"ME`, "STRING", false),
+	}
+	if s, expected := sqliteCreateTable("USERS", columns),
+		`CREATE TABLE "USERS" ("ID" INTEGER NOT NULL, "NA""ME" TEXT)`; s != expected {
+		t.Fatalf("Expected %q, got %q", expected, s)
+	}
+	if s, expected := sqliteInsert("USERS", columns),
+		`INSERT INTO "USERS" ("ID", "NA""ME") VALUES (?, ?)`; s != expected {
+		t.Fatalf("Expected %q, got %q", expected, s)
+	}
+	if name := sqliteTableName("app.users"); name != "users" {
+		t.Fatalf("Unexpected name %q", name)
+	}
+	if name := sqliteTableName("users"); name != "users" {
+		t.Fatalf("Unexpected name %q", name)
+	}
+}
+
+func TestExportSQLiteInvalidTables(t *testing.T) {
+	for _, tables := range [][]string{{"app.users; DROP"}, {"app.users", "legacy.USERS"}} {
+		if _, err := ExportSQLite(context.Background(), nil, nil, tables, SQLiteExportOptions{}); err == nil {
+			t.Fatalf("%q: expected an error", tables)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"github.com/tilinna/go-nuodb/internal/parse"
+)
+
+// StatementKind is the kind of an SQL statement, as classified by
+// ClassifyStatement.
+type StatementKind int
+
+const (
+	StatementOther   = StatementKind(parse.KindOther)   // DDL and other statements
+	StatementQuery   = StatementKind(parse.KindQuery)   // SELECT, EXPLAIN, VALUES or WITH ... SELECT
+	StatementDML     = StatementKind(parse.KindDML)     // DELETE, INSERT, REPLACE, TRUNCATE, UPDATE or WITH ... DML
+	StatementCall    = StatementKind(parse.KindCall)    // CALL or EXECUTE of a stored procedure
+	StatementSession = StatementKind(parse.KindSession) // SET or USE
+)
+
+var statementKindNames = map[StatementKind]string{
+	StatementOther:   "other",
+	StatementQuery:   "query",
+	StatementDML:     "dml",
+	StatementCall:    "call",
+	StatementSession: "session",
+}
+
+func (k StatementKind) String() string {
+	if name, ok := statementKindNames[k]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// ReadOnly reports whether statements of the kind only read, e.g. to route
+// them to a read replica. Note that a SELECT ... FOR UPDATE is classified
+// as a query although it locks rows.
+func (k StatementKind) ReadOnly() bool {
+	return k == StatementQuery
+}
+
+// ClassifyStatement returns the kind of sql by its leading keyword, as the
+// driver classifies its statements. The leading whitespace and comments
+// are skipped and the kind of a WITH statement is the kind of the
+// statement following its common table expressions.
+func ClassifyStatement(sql string) StatementKind {
+	return StatementKind(parse.Classify(sql))
+}
@@ -0,0 +1,31 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"testing"
+)
+
+func TestClassifyStatement(t *testing.T) {
+	tests := []struct {
+		sql      string
+		kind     StatementKind
+		readOnly bool
+	}{
+		{"/* report */ SELECT * FROM t", StatementQuery, true},
+		{"WITH t AS (SELECT 1 FROM DUAL) SELECT * FROM t", StatementQuery, true},
+		{"WITH t AS (SELECT 1 FROM DUAL) INSERT INTO u SELECT * FROM t", StatementDML, false},
+		{"CALL proc(?)", StatementCall, false},
+		{"SET SCHEMA tests", StatementSession, false},
+		{"CREATE TABLE t (id INTEGER)", StatementOther, false},
+	}
+	for _, test := range tests {
+		kind := ClassifyStatement(test.sql)
+		if kind != test.kind || kind.ReadOnly() != test.readOnly {
+			t.Errorf("%q: expected %v (read-only %v), got %v", test.sql, test.kind, test.readOnly, kind)
+		}
+	}
+	if s := StatementDML.String(); s != "dml" {
+		t.Errorf("Expected dml, got %s", s)
+	}
+}
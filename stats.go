@@ -0,0 +1,147 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tilinna/go-nuodb/internal/parse"
+)
+
+// StatementStat holds the execution statistics of the statements which
+// share a fingerprint, i.e. which differ only by their literal values,
+// comments or whitespace. The durations cover the execution, but not the
+// fetching of the result rows.
+type StatementStat struct {
+	Fingerprint string        `json:"fingerprint"`
+	Count       int64         `json:"count"`
+	Errors      int64         `json:"errors"`
+	TotalTime   time.Duration `json:"total_time"`
+	MaxTime     time.Duration `json:"max_time"`
+}
+
+// MeanTime returns the mean execution time.
+func (s StatementStat) MeanTime() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.TotalTime / time.Duration(s.Count)
+}
+
+// StatementStats collects the execution statistics of the statements by
+// their fingerprint. Enable the collection with CollectStatementStats.
+// StatementStats is safe for concurrent use.
+type StatementStats struct {
+	maxStatements int
+
+	mu           sync.Mutex
+	stats        map[string]*StatementStat // by fingerprint
+	fingerprints map[string]string         // normalization cache by sql
+}
+
+// maxFingerprints limits the size of the normalization cache.
+const maxFingerprints = 10000
+
+// NewStatementStats returns a StatementStats which tracks at most
+// maxStatements fingerprints. When full, the fingerprint with the least
+// total execution time is evicted.
+func NewStatementStats(maxStatements int) *StatementStats {
+	return &StatementStats{
+		maxStatements: maxStatements,
+		stats:         make(map[string]*StatementStat),
+		fingerprints:  make(map[string]string),
+	}
+}
+
+var statementStats atomic.Value // *StatementStats
+
+// CollectStatementStats starts collecting the statistics of all statements
+// executed by the driver into s. A nil s stops the collection.
+func CollectStatementStats(s *StatementStats) {
+	statementStats.Store(s)
+}
+
+// observeStatement records the execution of sql which started at start, if
+// the statistics are being collected.
+func observeStatement(sql string, start time.Time, err *error) {
+	if s, _ := statementStats.Load().(*StatementStats); s != nil {
+		s.observe(sql, time.Since(start), *err != nil)
+	}
+}
+
+func (s *StatementStats) observe(sql string, d time.Duration, failed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fp, ok := s.fingerprints[sql]
+	if !ok {
+		if len(s.fingerprints) >= maxFingerprints {
+			s.fingerprints = make(map[string]string)
+		}
+		fp = parse.Fingerprint(sql)
+		s.fingerprints[sql] = fp
+	}
+	stat, ok := s.stats[fp]
+	if !ok {
+		if len(s.stats) >= s.maxStatements {
+			if !s.evict(d) {
+				return
+			}
+		}
+		stat = &StatementStat{Fingerprint: fp}
+		s.stats[fp] = stat
+	}
+	stat.Count++
+	if failed {
+		stat.Errors++
+	}
+	stat.TotalTime += d
+	if d > stat.MaxTime {
+		stat.MaxTime = d
+	}
+}
+
+// evict removes the fingerprint with the least total time, if it is less
+// than d.
+func (s *StatementStats) evict(d time.Duration) bool {
+	var least *StatementStat
+	for _, stat := range s.stats {
+		if least == nil || stat.TotalTime < least.TotalTime {
+			least = stat
+		}
+	}
+	if least == nil || least.TotalTime >= d {
+		return false
+	}
+	delete(s.stats, least.Fingerprint)
+	return true
+}
+
+// Top returns the n statements with the most total execution time.
+func (s *StatementStats) Top(n int) []StatementStat {
+	s.mu.Lock()
+	top := make([]StatementStat, 0, len(s.stats))
+	for _, stat := range s.stats {
+		top = append(top, *stat)
+	}
+	s.mu.Unlock()
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].TotalTime != top[j].TotalTime {
+			return top[i].TotalTime > top[j].TotalTime
+		}
+		return top[i].Fingerprint < top[j].Fingerprint
+	})
+	if n < len(top) {
+		top = top[:n]
+	}
+	return top
+}
+
+// Reset discards the collected statistics.
+func (s *StatementStats) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stats = make(map[string]*StatementStat)
+}
@@ -0,0 +1,61 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStatementStats(t *testing.T) {
+	s := NewStatementStats(2)
+	s.observe("SELECT * FROM t WHERE id = 1", 10*time.Millisecond, false)
+	s.observe("select * from t where id = 2", 30*time.Millisecond, true)
+	s.observe("UPDATE t SET x = 1", 5*time.Millisecond, false)
+	s.observe("DELETE FROM t", time.Millisecond, false) // evicts nothing; least total time
+
+	top := s.Top(10)
+	if len(top) != 2 {
+		t.Fatalf("Expected 2 statements, got %+v", top)
+	}
+	first := top[0]
+	if first.Fingerprint != "SELECT * FROM T WHERE ID = ?" || first.Count != 2 || first.Errors != 1 ||
+		first.TotalTime != 40*time.Millisecond || first.MaxTime != 30*time.Millisecond ||
+		first.MeanTime() != 20*time.Millisecond {
+		t.Fatalf("Unexpected stat: %+v", first)
+	}
+	if top[1].Fingerprint != "UPDATE T SET X = ?" {
+		t.Fatalf("Unexpected stat: %+v", top[1])
+	}
+
+	s.observe("INSERT INTO t VALUES (1)", 50*time.Millisecond, false) // evicts the UPDATE
+	top = s.Top(1)
+	if len(top) != 1 || top[0].Fingerprint != "INSERT INTO T VALUES (?)" {
+		t.Fatalf("Unexpected top: %+v", top)
+	}
+
+	s.Reset()
+	if len(s.Top(10)) != 0 {
+		t.Fatal("Expected no statements after Reset")
+	}
+}
+
+func TestObserveStatement(t *testing.T) {
+	s := NewStatementStats(10)
+	CollectStatementStats(s)
+	defer CollectStatementStats(nil)
+
+	err := errors.New("failed")
+	observeStatement("SELECT 1 FROM DUAL", time.Now(), &err)
+	top := s.Top(1)
+	if len(top) != 1 || top[0].Errors != 1 {
+		t.Fatalf("Unexpected top: %+v", top)
+	}
+
+	CollectStatementStats(nil)
+	observeStatement("SELECT 1 FROM DUAL", time.Now(), &err)
+	if s.Top(1)[0].Count != 1 {
+		t.Fatal("Expected no collection when disabled")
+	}
+}
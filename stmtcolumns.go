@@ -0,0 +1,162 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+// #include "cnuodb.h"
+import "C"
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"strings"
+	"unsafe"
+)
+
+// ColumnCount returns the number of the result columns of the prepared
+// statement from its metadata, without executing it. It is 0 for a
+// statement which returns no rows.
+func (stmt *Stmt) ColumnCount() (int, error) {
+	c := stmt.c
+	if c.db == nil {
+		return 0, errClosed
+	}
+	var count C.int
+	if err := stmt.cgo(func() C.int { return C.nuodb_statement_column_count(c.db, stmt.st, &count) }); err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
+
+// ColumnNames returns the names of the result columns of the prepared
+// statement from its metadata, without executing it, as Rows.Columns would
+// return them.
+func (stmt *Stmt) ColumnNames() ([]string, error) {
+	count, err := stmt.ColumnCount()
+	if err != nil || count == 0 {
+		return nil, err
+	}
+	c := stmt.c
+	names := make([]C.struct_nuodb_value, count)
+	if err := stmt.cgo(func() C.int {
+		return C.nuodb_statement_column_names(c.db, stmt.st, (*C.struct_nuodb_value)(unsafe.Pointer(&names[0])))
+	}); err != nil {
+		return nil, err
+	}
+	return columnLabels(names), nil
+}
+
+// ResultColumn describes a result column of a prepared statement, as the
+// sql.ColumnType of its rows would.
+type ResultColumn struct {
+	Name             string
+	DatabaseTypeName string       // e.g. "VARCHAR", as by ColumnTypeDatabaseTypeName
+	ScanType         reflect.Type // of the values scanned from the column
+	Nullable         bool
+	NullableKnown    bool  // whether the server reported Nullable
+	Length           int64 // of a variable length character or binary type, otherwise -1
+	Precision        int64 // of a decimal, TIME or TIMESTAMP type, otherwise -1
+	Scale            int64 // likewise; the fractional second digits of a time
+}
+
+// ColumnTypes returns the result columns of the prepared statement from its
+// metadata, with their types, without executing it. They are nil for a
+// statement which returns no rows.
+func (stmt *Stmt) ColumnTypes() ([]ResultColumn, error) {
+	names, err := stmt.ColumnNames()
+	if err != nil || len(names) == 0 {
+		return nil, err
+	}
+	c := stmt.c
+	values := make([]C.struct_nuodb_value, len(names))
+	info := make([]C.struct_nuodb_column_info, len(names))
+	if err := stmt.cgo(func() C.int {
+		return C.nuodb_statement_column_types(c.db, stmt.st, (*C.struct_nuodb_value)(unsafe.Pointer(&values[0])),
+			(*C.struct_nuodb_column_info)(unsafe.Pointer(&info[0])))
+	}); err != nil {
+		return nil, err
+	}
+	columns := make([]ResultColumn, len(names))
+	for i, typeName := range columnLabels(values) {
+		columns[i] = ResultColumn{
+			Name:             names[i],
+			DatabaseTypeName: strings.ToUpper(typeName),
+			ScanType:         scanType(values[i].vt),
+			Nullable:         info[i].nullable == 1,
+			NullableKnown:    info[i].nullable != 2,
+			Length:           int64(info[i].length),
+			Precision:        int64(info[i].precision),
+			Scale:            int64(info[i].scale),
+		}
+	}
+	return columns, nil
+}
+
+// PreparedColumnTypes prepares query on conn and returns its result columns
+// with their types without executing it, like PreparedColumns, e.g. for a
+// query builder or a BI tool which validates the SQL of its users:
+//
+//	columns, err := nuodb.PreparedColumnTypes(ctx, conn, "SELECT id, name FROM users WHERE id = ?")
+func PreparedColumnTypes(ctx context.Context, conn *sql.Conn, query string) ([]ResultColumn, error) {
+	var columns []ResultColumn
+	err := conn.Raw(func(driverConn interface{}) error {
+		c, ok := driverConn.(*Conn)
+		if !ok {
+			return errors.New("nuodb: not a nuodb connection")
+		}
+		if ctx.Err() != nil {
+			return contextError(ctx)
+		}
+		ds, err := c.Prepare(query)
+		if err != nil {
+			return err
+		}
+		stmt := ds.(*Stmt)
+		defer stmt.Close()
+		columns, err = stmt.ColumnTypes()
+		return err
+	})
+	return columns, err
+}
+
+// PreparedColumns prepares query on conn and returns the names of its result
+// columns without executing it, e.g. to verify at startup that the SELECT
+// lists of the queries of an application match the fields they are scanned
+// into:
+//
+//	columns, err := nuodb.PreparedColumns(ctx, conn, "SELECT id, name FROM users WHERE id = ?")
+//
+// The names are nil for a statement which returns no rows.
+func PreparedColumns(ctx context.Context, conn *sql.Conn, query string) ([]string, error) {
+	var columns []string
+	err := conn.Raw(func(driverConn interface{}) error {
+		c, ok := driverConn.(*Conn)
+		if !ok {
+			return errors.New("nuodb: not a nuodb connection")
+		}
+		if ctx.Err() != nil {
+			return contextError(ctx)
+		}
+		ds, err := c.Prepare(query)
+		if err != nil {
+			return err
+		}
+		stmt := ds.(*Stmt)
+		defer stmt.Close()
+		columns, err = stmt.ColumnNames()
+		return err
+	})
+	return columns, err
+}
+
+// columnLabels returns the column names stored by the C layer.
+func columnLabels(names []C.struct_nuodb_value) []string {
+	labels := make([]string, len(names))
+	for i, value := range names {
+		if length := (C.int)(value.i32); length > 0 {
+			cstr := (*C.char)(valueData(&value))
+			labels[i] = C.GoStringN(cstr, length)
+		}
+	}
+	return labels
+}
@@ -0,0 +1,73 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestPreparedColumns(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	exec(t, db, "CREATE TABLE FooBar (id BIGINT, name STRING)")
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	columns, err := PreparedColumns(ctx, conn, "SELECT id, name AS label FROM FooBar WHERE id = ?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"ID", "LABEL"}; !reflect.DeepEqual(columns, expected) {
+		t.Fatalf("Expected %v, got %v", expected, columns)
+	}
+	columns, err = PreparedColumns(ctx, conn, "UPDATE FooBar SET name = ? WHERE id = ?")
+	if err != nil || columns != nil {
+		t.Fatalf("Expected no columns, got %v, %v", columns, err)
+	}
+	if _, err := PreparedColumns(ctx, conn, "SELECT missing FROM FooBar"); err == nil {
+		t.Fatal("Expected an error preparing an invalid query")
+	}
+	// nothing was executed
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM FooBar").Scan(&count); err != nil || count != 0 {
+		t.Fatal(count, err)
+	}
+}
+
+func TestPreparedColumnTypes(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	exec(t, db, "CREATE TABLE FooBar (id BIGINT NOT NULL, name VARCHAR(20), price DECIMAL(10, 2))")
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	columns, err := PreparedColumnTypes(ctx, conn, "SELECT id, name, price FROM FooBar WHERE id = ?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []ResultColumn{
+		{Name: "ID", DatabaseTypeName: "BIGINT", ScanType: scanTypeInt64, NullableKnown: true,
+			Length: -1, Precision: -1, Scale: -1},
+		{Name: "NAME", DatabaseTypeName: "VARCHAR", ScanType: scanTypeString, Nullable: true, NullableKnown: true,
+			Length: 20, Precision: -1, Scale: -1},
+		{Name: "PRICE", DatabaseTypeName: "DECIMAL", ScanType: scanTypeString, Nullable: true, NullableKnown: true,
+			Length: -1, Precision: 10, Scale: 2},
+	}
+	if !reflect.DeepEqual(columns, expected) {
+		t.Fatalf("Expected %+v, got %+v", expected, columns)
+	}
+	columns, err = PreparedColumnTypes(ctx, conn, "DELETE FROM FooBar")
+	if err != nil || columns != nil {
+		t.Fatalf("Expected no columns, got %v, %v", columns, err)
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+// #include "cnuodb.h"
+import "C"
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"unsafe"
+)
+
+// StmtMetadata is the metadata of a prepared statement, which tooling that
+// relays queries, e.g. a proxy or a GUI client, can read before executing
+// the statement. A *Stmt prepared on the driver connection of sql.Conn.Raw
+// implements it:
+//
+//	err := conn.Raw(func(driverConn interface{}) error {
+//		ds, err := driverConn.(*nuodb.Conn).Prepare("UPDATE users SET name = ? WHERE id = ?")
+//		if err != nil {
+//			return err
+//		}
+//		defer ds.Close()
+//		params, err := ds.(nuodb.StmtMetadata).ParameterTypes()
+//		...
+//	})
+type StmtMetadata interface {
+	// NumInput returns the number of the parameters, or -1 if the
+	// statement has named parameters, as by driver.Stmt.
+	NumInput() int
+
+	// ParameterTypes returns the types of the placeholders of the
+	// statement, in their order in the statement.
+	ParameterTypes() ([]ParameterType, error)
+
+	// ColumnNames returns the names of the result columns of the
+	// statement, nil if it returns no rows.
+	ColumnNames() ([]string, error)
+
+	// ColumnTypes returns the result columns of the statement with their
+	// types, nil if it returns no rows.
+	ColumnTypes() ([]ResultColumn, error)
+}
+
+var _ StmtMetadata = (*Stmt)(nil)
+
+// ParameterType describes a placeholder of a prepared statement.
+type ParameterType struct {
+	DatabaseTypeName string // e.g. "VARCHAR", as by ColumnTypeDatabaseTypeName
+	Nullable         bool
+	NullableKnown    bool  // whether the server reported Nullable
+	Precision        int64 // of a decimal, TIME or TIMESTAMP type, otherwise -1
+	Scale            int64 // likewise; the fractional second digits of a time
+}
+
+// ParameterTypes implements StmtMetadata.
+func (stmt *Stmt) ParameterTypes() ([]ParameterType, error) {
+	c := stmt.c
+	if c.db == nil {
+		return nil, errClosed
+	}
+	count := int(stmt.parameterCount)
+	if count == 0 {
+		return nil, nil
+	}
+	names := make([]C.struct_nuodb_value, count)
+	info := make([]C.struct_nuodb_column_info, count)
+	if err := stmt.cgo(func() C.int {
+		return C.nuodb_statement_parameter_info(c.db, stmt.st, (*C.struct_nuodb_value)(unsafe.Pointer(&names[0])),
+			(*C.struct_nuodb_column_info)(unsafe.Pointer(&info[0])))
+	}); err != nil {
+		return nil, err
+	}
+	types := make([]ParameterType, count)
+	for i, name := range columnLabels(names) {
+		types[i] = ParameterType{
+			DatabaseTypeName: strings.ToUpper(name),
+			Nullable:         info[i].nullable == 1,
+			NullableKnown:    info[i].nullable != 2,
+			Precision:        int64(info[i].precision),
+			Scale:            int64(info[i].scale),
+		}
+	}
+	return types, nil
+}
+
+// PreparedParameters prepares query on conn and returns the types of its
+// placeholders without executing it, like PreparedColumns:
+//
+//	params, err := nuodb.PreparedParameters(ctx, conn, "SELECT name FROM users WHERE id = ?")
+func PreparedParameters(ctx context.Context, conn *sql.Conn, query string) ([]ParameterType, error) {
+	var types []ParameterType
+	err := conn.Raw(func(driverConn interface{}) error {
+		c, ok := driverConn.(*Conn)
+		if !ok {
+			return errors.New("nuodb: not a nuodb connection")
+		}
+		if ctx.Err() != nil {
+			return contextError(ctx)
+		}
+		ds, err := c.Prepare(query)
+		if err != nil {
+			return err
+		}
+		stmt := ds.(*Stmt)
+		defer stmt.Close()
+		types, err = stmt.ParameterTypes()
+		return err
+	})
+	return types, err
+}
@@ -0,0 +1,38 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"testing"
+)
+
+func TestPreparedParameters(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	exec(t, db, "CREATE TABLE FooBar (id BIGINT NOT NULL, name STRING, price DECIMAL(10, 2))")
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	params, err := PreparedParameters(ctx, conn, "UPDATE FooBar SET name = ?, price = ? WHERE id = ?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(params) != 3 {
+		t.Fatalf("Expected 3 parameters, got %+v", params)
+	}
+	if p := params[1]; p.Precision != 10 || p.Scale != 2 {
+		t.Fatalf("Expected DECIMAL(10, 2), got %+v", p)
+	}
+	if p := params[2]; p.DatabaseTypeName != "BIGINT" || p.Precision != -1 {
+		t.Fatalf("Expected BIGINT, got %+v", p)
+	}
+	params, err = PreparedParameters(ctx, conn, "SELECT * FROM FooBar")
+	if err != nil || params != nil {
+		t.Fatalf("Expected no parameters, got %v, %v", params, err)
+	}
+}
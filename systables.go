@@ -0,0 +1,107 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// SystemConnection is a connection to the database, as listed in
+// SYSTEM.CONNECTIONS, with the statement it is executing, if any.
+type SystemConnection struct {
+	NodeID          int    // of the TE serving the connection
+	ConnID          int64  // GETCONNECTIONID() of the connection
+	User            string // logged in
+	Schema          string // current schema
+	ClientHost      string
+	ClientProcessID string
+	ClientInfo      string
+
+	SQL         string        // of the executing statement; empty if idle
+	Params      string        // parameters of the statement, as listed by NuoDB
+	Runtime     time.Duration // of the statement so far
+	TxID        int64         // of the open transaction; 0 if none
+	Isolation   int           // the transaction isolation level, as in SYSTEM.CONNECTIONS
+	AutoCommit  bool
+	OpenResults int // open result sets
+}
+
+// SystemConnections returns the connections to the database, as listed in
+// SYSTEM.CONNECTIONS, e.g. for an agent shipping the sessions of the
+// database to a monitoring system. The user needs the privileges to read
+// the SYSTEM tables to see the connections of the other users.
+func SystemConnections(ctx context.Context, db *sql.DB) ([]SystemConnection, error) {
+	rows, err := db.QueryContext(ctx, `SELECT NODEID, CONNID, USER, SCHEMA, CLIENTHOST, CLIENTPROCESSID,
+		CLIENTINFO, SQLSTRING, PARAMS, RUNTIME, TRANSID, ISOLATIONLEVEL, AUTOCOMMIT, OPEN
+		FROM SYSTEM.CONNECTIONS ORDER BY NODEID, CONNID`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var conns []SystemConnection
+	for rows.Next() {
+		var c SystemConnection
+		var host, pid, info, sqlString, params sql.NullString
+		var runtime, txID, isolation, autoCommit, open sql.NullInt64
+		if err := rows.Scan(&c.NodeID, &c.ConnID, &c.User, &c.Schema, &host, &pid, &info,
+			&sqlString, &params, &runtime, &txID, &isolation, &autoCommit, &open); err != nil {
+			return nil, err
+		}
+		c.ClientHost, c.ClientProcessID, c.ClientInfo = host.String, pid.String, info.String
+		c.SQL, c.Params = sqlString.String, params.String
+		c.Runtime = time.Duration(runtime.Int64) * time.Microsecond
+		c.TxID, c.Isolation = txID.Int64, int(isolation.Int64)
+		c.AutoCommit, c.OpenResults = autoCommit.Int64 != 0, int(open.Int64)
+		conns = append(conns, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return conns, nil
+}
+
+// QueryStat is a statement logged in SYSTEM.QUERYSTATS, where each TE
+// keeps the most expensive statements it executed, once the query stats
+// are enabled on the database.
+type QueryStat struct {
+	NodeID  int   // of the TE which executed the statement
+	ConnID  int64 // of the connection which executed the statement
+	TxID    int64
+	User    string
+	Schema  string
+	SQL     string
+	Params  string        // of the statement, as listed by NuoDB
+	Count   int64         // executions of the statement
+	Runtime time.Duration // of the executions
+}
+
+// QueryStats returns the statements logged in SYSTEM.QUERYSTATS, the most
+// expensive first. The table is empty unless the query stats are enabled.
+func QueryStats(ctx context.Context, db *sql.DB) ([]QueryStat, error) {
+	rows, err := db.QueryContext(ctx, `SELECT NODEID, CONNID, TRANSID, USER, SCHEMA, SQLSTRING, PARAMS,
+		COUNT, RUNTIME FROM SYSTEM.QUERYSTATS ORDER BY RUNTIME DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var stats []QueryStat
+	for rows.Next() {
+		var s QueryStat
+		var txID, count, runtime sql.NullInt64
+		var user, schema, params sql.NullString
+		if err := rows.Scan(&s.NodeID, &s.ConnID, &txID, &user, &schema, &s.SQL, &params,
+			&count, &runtime); err != nil {
+			return nil, err
+		}
+		s.TxID, s.Count = txID.Int64, count.Int64
+		s.User, s.Schema, s.Params = user.String, schema.String, params.String
+		s.Runtime = time.Duration(runtime.Int64) * time.Microsecond
+		stats = append(stats, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
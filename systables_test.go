@@ -0,0 +1,45 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSystemConnections(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	var connID int64
+	if err := conn.QueryRowContext(ctx, "SELECT GETCONNECTIONID() FROM DUAL").Scan(&connID); err != nil {
+		t.Fatal(err)
+	}
+
+	conns, err := SystemConnections(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range conns {
+		if c.ConnID == connID {
+			if c.User != "ROBINH" {
+				t.Fatalf("Unexpected connection %+v", c)
+			}
+			return
+		}
+	}
+	t.Fatalf("Expected connection %d to be listed in %+v", connID, conns)
+}
+
+func TestQueryStats(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	if _, err := QueryStats(context.Background(), db); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+
+	"github.com/tilinna/go-nuodb/internal/parse"
+)
+
+// SpanAttribute is an attribute of a span started by a Tracer.
+type SpanAttribute struct {
+	Key   string
+	Value interface{} // string, int64 or bool
+}
+
+// Span is a span started by a Tracer.
+type Span interface {
+	// End ends the span with the error of the operation, if any, and the
+	// attributes known at its end, e.g. the NuoDB error code.
+	End(err error, attrs ...SpanAttribute)
+}
+
+// Tracer starts the spans of the operations of the connections of a
+// Connector: nuodb.connect, nuodb.prepare, nuodb.exec and nuodb.query, and
+// nuodb.rows for the iteration over the rows of a query, from the end of
+// the query until the rows are closed. It is the seam to a tracing
+// library, e.g. an adapter to OpenTelemetry:
+//
+//	type otelTracer struct{ t trace.Tracer }
+//
+//	func (o otelTracer) Start(ctx context.Context, name string, attrs []nuodb.SpanAttribute) (context.Context, nuodb.Span) {
+//		ctx, span := o.t.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient),
+//			trace.WithAttributes(otelAttributes(attrs)...))
+//		return ctx, otelSpan{span}
+//	}
+//
+//	type otelSpan struct{ s trace.Span }
+//
+//	func (o otelSpan) End(err error, attrs ...nuodb.SpanAttribute) {
+//		o.s.SetAttributes(otelAttributes(attrs)...)
+//		if err != nil {
+//			o.s.RecordError(err)
+//			o.s.SetStatus(codes.Error, err.Error())
+//		}
+//		o.s.End()
+//	}
+//
+// The spans have the attributes db.system (nuodb), db.name, and, once
+// connected, db.nuodb.conn, db.nuodb.session and net.peer.name and
+// net.peer.port of the TE, which is looked up once per connection. The
+// spans of the statements have db.statement, the fingerprint of the
+// statement as in StatementStats, without its literals, and
+// db.nuodb.statement_digest, a hash of it. A failed operation ends with
+// db.nuodb.error_code, the name of its NuoDB error code, and the rows span
+// with db.nuodb.rows, the number of rows fetched.
+//
+// A Tracer must be safe for concurrent use by the connections.
+type Tracer interface {
+	Start(ctx context.Context, name string, attrs []SpanAttribute) (context.Context, Span)
+}
+
+// Names of the spans of a Tracer.
+const (
+	SpanConnect = "nuodb.connect"
+	SpanPrepare = "nuodb.prepare"
+	SpanExec    = "nuodb.exec"
+	SpanQuery   = "nuodb.query"
+	SpanRows    = "nuodb.rows"
+)
+
+// StatementDigest returns the digest of sql of the spans of a Tracer, a
+// hash of sql with its literals, comments and whitespace normalized, so
+// that the executions of a statement with different values share it.
+func StatementDigest(sql string) string {
+	return statementDigest(parse.Fingerprint(sql))
+}
+
+func statementDigest(fingerprint string) string {
+	h := fnv.New64a()
+	io.WriteString(h, fingerprint)
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// spanAttributes returns the attributes of the spans of c.
+func (c *Conn) spanAttributes() []SpanAttribute {
+	attrs := []SpanAttribute{{"db.system", "nuodb"}, {"db.name", c.database},
+		{"db.nuodb.conn", int64(c.id)}, {"db.nuodb.session", c.sessionID}}
+	if c.node != nil {
+		attrs = append(attrs, SpanAttribute{"net.peer.name", c.node.Address},
+			SpanAttribute{"net.peer.port", int64(c.node.Port)})
+	}
+	return attrs
+}
+
+// endSpan ends span, if any, with err and its NuoDB error code.
+func endSpan(span Span, err error, attrs ...SpanAttribute) {
+	if span == nil {
+		return
+	}
+	var nerr *Error
+	if errors.As(err, &nerr) {
+		attrs = append(attrs, SpanAttribute{"db.nuodb.error_code", nerr.Code.Name()})
+	}
+	span.End(err, attrs...)
+}
+
+// startSpan starts a span of sql on c, if c has a Tracer.
+func (c *Conn) startSpan(ctx context.Context, name, sql string) Span {
+	if c.tracer == nil {
+		return nil
+	}
+	attrs := c.spanAttributes()
+	if sql != "" {
+		fp := parse.Fingerprint(sql)
+		attrs = append(attrs, SpanAttribute{"db.statement", fp},
+			SpanAttribute{"db.nuodb.statement_digest", statementDigest(fp)})
+	}
+	_, span := c.tracer.Start(ctx, name, attrs)
+	return span
+}
+
+// traceStatement starts a span of sql on c and returns the function which
+// ends it with the error of the statement.
+func (c *Conn) traceStatement(ctx context.Context, name, sql string) func(err *error) {
+	span := c.startSpan(ctx, name, sql)
+	return func(err *error) {
+		endSpan(span, *err)
+	}
+}
+
+// traceQuery starts the span of a query of sql on c and returns the
+// function which ends it and starts the span of the iteration over its
+// rows, which ends when the rows are closed.
+func (c *Conn) traceQuery(ctx context.Context, sql string) func(rows *driver.Rows, err *error) {
+	span := c.startSpan(ctx, SpanQuery, sql)
+	return func(rows *driver.Rows, err *error) {
+		endSpan(span, *err)
+		if r, ok := (*rows).(*Rows); ok && span != nil && *err == nil {
+			r.span = c.startSpan(ctx, SpanRows, sql)
+		}
+	}
+}
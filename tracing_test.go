@@ -0,0 +1,134 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// recordingTracer records the spans ended.
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []*recordedSpan
+}
+
+type recordedSpan struct {
+	t     *recordingTracer
+	name  string
+	attrs map[string]interface{}
+	err   error
+}
+
+func (r *recordingTracer) Start(ctx context.Context, name string, attrs []SpanAttribute) (context.Context, Span) {
+	s := &recordedSpan{t: r, name: name, attrs: make(map[string]interface{})}
+	for _, a := range attrs {
+		s.attrs[a.Key] = a.Value
+	}
+	return ctx, s
+}
+
+func (s *recordedSpan) End(err error, attrs ...SpanAttribute) {
+	for _, a := range attrs {
+		s.attrs[a.Key] = a.Value
+	}
+	s.err = err
+	s.t.mu.Lock()
+	s.t.spans = append(s.t.spans, s)
+	s.t.mu.Unlock()
+}
+
+func (r *recordingTracer) ended() []*recordedSpan {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*recordedSpan(nil), r.spans...)
+}
+
+func TestStatementDigest(t *testing.T) {
+	a := StatementDigest("SELECT * FROM t WHERE id = 1")
+	b := StatementDigest("select *  from t where id = 42 -- again")
+	if a != b || len(a) != 16 {
+		t.Fatalf("Expected equal digests, got %q and %q", a, b)
+	}
+	if c := StatementDigest("SELECT * FROM u WHERE id = 1"); c == a {
+		t.Fatal("Expected another digest for another statement")
+	}
+}
+
+func TestTraceStatement(t *testing.T) {
+	tracer := &recordingTracer{}
+	c := &Conn{tracer: tracer, id: 4, sessionID: 9, database: "tests", node: &Node{Address: "te1", Port: 48006}}
+	err := error(&Error{Code: UniqueDuplicate, Message: "duplicate value"})
+	c.traceStatement(context.Background(), SpanExec, "INSERT INTO t VALUES (1)")(&err)
+
+	err = nil
+	var rows driver.Rows = &Rows{c: c, row: 3}
+	c.traceQuery(context.Background(), "SELECT * FROM t")(&rows, &err)
+	if err := rows.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := tracer.ended()
+	if len(spans) != 3 {
+		t.Fatalf("Expected 3 spans, got %d", len(spans))
+	}
+	exec, query, iteration := spans[0], spans[1], spans[2]
+	for _, test := range []struct {
+		span      *recordedSpan
+		name, key string
+		expected  interface{}
+	}{
+		{exec, SpanExec, "db.system", "nuodb"},
+		{exec, SpanExec, "db.name", "tests"},
+		{exec, SpanExec, "db.nuodb.conn", int64(4)},
+		{exec, SpanExec, "db.nuodb.session", int64(9)},
+		{exec, SpanExec, "net.peer.name", "te1"},
+		{exec, SpanExec, "net.peer.port", int64(48006)},
+		{exec, SpanExec, "db.statement", "INSERT INTO T VALUES (?)"},
+		{exec, SpanExec, "db.nuodb.statement_digest", StatementDigest("INSERT INTO t VALUES (1)")},
+		{exec, SpanExec, "db.nuodb.error_code", "UNIQUE_DUPLICATE"},
+		{query, SpanQuery, "db.statement", "SELECT * FROM T"},
+		{iteration, SpanRows, "db.nuodb.rows", int64(3)},
+	} {
+		if test.span.name != test.name || fmt.Sprint(test.span.attrs[test.key]) != fmt.Sprint(test.expected) {
+			t.Errorf("%s %s: expected %v, got %s %v", test.name, test.key, test.expected,
+				test.span.name, test.span.attrs[test.key])
+		}
+	}
+	if exec.err == nil || query.err != nil {
+		t.Fatalf("Unexpected errors %v %v", exec.err, query.err)
+	}
+}
+
+func TestTracer(t *testing.T) {
+	cfg, err := ParseDSN(default_dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracer := &recordingTracer{}
+	cfg.Tracer = tracer
+	connector, err := NewConnector(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := sql.OpenDB(connector)
+	defer db.Close()
+	var n int
+	if err := db.QueryRow("SELECT 1 FROM DUAL").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, s := range tracer.ended() {
+		names = append(names, s.name)
+		if s.name != SpanConnect && s.attrs["net.peer.name"] == nil {
+			t.Fatalf("Expected the TE of the span %s", s.name)
+		}
+	}
+	if fmt.Sprint(names) != fmt.Sprint([]string{SpanConnect, SpanQuery, SpanRows}) {
+		t.Fatalf("Unexpected spans %q", names)
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+// #include "cnuodb.h"
+// #include <stdlib.h>
+import "C"
+import (
+	"context"
+	"unsafe"
+)
+
+// txControl controls the transactions of a connection. It is the seam
+// between the transaction handling of the driver, i.e. Begin, Commit,
+// Rollback and the rollback of an expired transaction, and the client
+// library, so that the handling can be unit tested with a fake, without
+// NuoDB. It is nil once the connection is closed. It is the only such seam:
+// preparing, binding, fetching and the errors of the statements call the
+// client library directly, since their values are in C memory.
+type txControl interface {
+	autoCommit() (bool, error)
+	setReadOnly(on bool) error
+	begin(level int, readOnly bool) error
+	commit() error
+	rollback() error
+}
+
+// startTransaction returns the statement which begins a transaction with the
+// isolation level, unless it is 0 for the level of the connection. The
+// session stays in autocommit mode, which the transaction suspends until it
+// is committed or rolled back.
+func startTransaction(level int, readOnly bool) string {
+	sql := "START TRANSACTION"
+	if readOnly {
+		sql += " READ ONLY"
+	}
+	if name, ok := isolationNames[level]; ok {
+		sql += " ISOLATION LEVEL " + name
+	}
+	return sql
+}
+
+// boolInt converts b to a C boolean.
+func boolInt(b bool) C.int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// clientTx is the txControl of the client library.
+type clientTx struct {
+	c *Conn
+}
+
+var _ txControl = clientTx{}
+
+func (t clientTx) autoCommit() (bool, error) {
+	var state C.int
+	if err := t.c.cgo(func() C.int { return C.nuodb_autocommit(t.c.db, &state) }); err != nil {
+		return false, err
+	}
+	return state != 0, nil
+}
+
+func (t clientTx) setReadOnly(on bool) error {
+	t.c.stats.call()
+	return t.c.cgo(func() C.int { return C.nuodb_read_only_set(t.c.db, boolInt(on)) })
+}
+
+func (t clientTx) begin(level int, readOnly bool) error {
+	return t.execute(startTransaction(level, readOnly))
+}
+
+func (t clientTx) commit() error {
+	return t.execute("COMMIT")
+}
+
+func (t clientTx) rollback() error {
+	return t.execute("ROLLBACK")
+}
+
+// execute executes a transaction control statement, with the defaultTimeout
+// of the connection, as it has no context.
+func (t clientTx) execute(sql string) error {
+	uSec, err := t.c.queryTimeout(context.Background())
+	if err != nil {
+		return err
+	}
+	csql := C.CString(sql)
+	defer C.free(unsafe.Pointer(csql))
+	var rowsAffected, lastInsertID C.int64_t
+	t.c.stats.call()
+	return t.c.cgo(func() C.int {
+		return C.nuodb_execute(t.c.db, csql, nil, 0, &rowsAffected, &lastInsertID, uSec)
+	})
+}
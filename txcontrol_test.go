@@ -0,0 +1,357 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeTx is a txControl recording its calls.
+type fakeTx struct {
+	mu    sync.Mutex
+	auto  bool
+	calls []string
+	err   error // of the commits and rollbacks
+}
+
+func (f *fakeTx) record(call string) {
+	f.mu.Lock()
+	f.calls = append(f.calls, call)
+	f.mu.Unlock()
+}
+
+func (f *fakeTx) recorded() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.calls...)
+}
+
+func (f *fakeTx) autoCommit() (bool, error) {
+	f.record("autocommit")
+	return f.auto, nil
+}
+
+func (f *fakeTx) setReadOnly(on bool) error {
+	if on {
+		f.record("read-only on")
+	} else {
+		f.record("read-only off")
+	}
+	return nil
+}
+
+func (f *fakeTx) begin(level int, readOnly bool) error {
+	f.record(startTransaction(level, readOnly))
+	return nil
+}
+
+func (f *fakeTx) commit() error {
+	f.record("commit")
+	return f.err
+}
+
+func (f *fakeTx) rollback() error {
+	f.record("rollback")
+	return f.err
+}
+
+func TestTxControl(t *testing.T) {
+	f := &fakeTx{auto: true}
+	c := &Conn{txc: f}
+	tx, err := c.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.inTx || c.tx != tx {
+		t.Fatal("Expected a transaction")
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if c.inTx || c.tx != nil {
+		t.Fatal("Expected the transaction to end")
+	}
+
+	f.err = errors.New("conflict")
+	if tx, err = c.Begin(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Rollback(); err != f.err {
+		t.Fatalf("Expected %v, got %v", f.err, err)
+	}
+	expected := []string{"START TRANSACTION", "commit", "START TRANSACTION", "rollback"}
+	if calls := f.recorded(); !reflect.DeepEqual(calls, expected) {
+		t.Fatalf("Expected %q, got %q", expected, calls)
+	}
+
+	c.txc = nil // closed
+	if _, err := c.Begin(); err != errUninitialized {
+		t.Fatalf("Expected %v, got %v", errUninitialized, err)
+	}
+	if err := tx.Commit(); err != errClosed {
+		t.Fatalf("Expected %v, got %v", errClosed, err)
+	}
+}
+
+func TestReadOnlyTx(t *testing.T) {
+	f := &fakeTx{auto: true}
+	c := &Conn{txc: f}
+	ctx := context.Background()
+	tx, err := c.BeginTx(ctx, driver.TxOptions{ReadOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	// a read-only session stays read-only
+	c.readOnly = true
+	if tx, err = c.BeginTx(ctx, driver.TxOptions{ReadOnly: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"START TRANSACTION READ ONLY", "commit", "START TRANSACTION", "rollback"}
+	if calls := f.recorded(); !reflect.DeepEqual(calls, expected) {
+		t.Fatalf("Expected %q, got %q", expected, calls)
+	}
+}
+
+func TestIsolationTx(t *testing.T) {
+	f := &fakeTx{auto: true}
+	c := &Conn{txc: f, isolation: 7}
+	ctx := context.Background()
+	for _, level := range []sql.IsolationLevel{LevelWriteCommitted, sql.LevelRepeatableRead} {
+		tx, err := c.BeginTx(ctx, driver.TxOptions{Isolation: driver.IsolationLevel(level)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the level of the connection is left out
+	expected := []string{"START TRANSACTION ISOLATION LEVEL WRITE COMMITTED", "commit", "START TRANSACTION", "commit"}
+	if calls := f.recorded(); !reflect.DeepEqual(calls, expected) {
+		t.Fatalf("Expected %q, got %q", expected, calls)
+	}
+}
+
+func TestManualCommitTx(t *testing.T) {
+	f := &fakeTx{}
+	c := &Conn{txc: f, manualCommit: true}
+	var err error
+	c.trackUncommitted("SELECT 1 FROM DUAL", &err)
+	c.trackUncommitted("CREATE TABLE t (id INT)", &err)
+	if c.uncommitted {
+		t.Fatal("Expected no uncommitted statements")
+	}
+	c.trackUncommitted("INSERT INTO t VALUES (1)", &err)
+	if _, err := c.Begin(); err != errUncommitted {
+		t.Fatalf("Expected %v, got %v", errUncommitted, err)
+	}
+	c.trackUncommitted("COMMIT", &err)
+	tx, err := c.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the statements of the Tx are committed with it
+	c.trackUncommitted("UPDATE t SET id = 2", &err)
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if c.uncommitted {
+		t.Fatal("Expected no uncommitted statements after the Tx")
+	}
+	c.trackUncommitted("DELETE FROM t", &err)
+	c.trackUncommitted("rollback", &err)
+	if c.uncommitted {
+		t.Fatal("Expected the ROLLBACK to end the uncommitted statements")
+	}
+	// a failed statement leaves nothing to commit
+	failed := errors.New("failed")
+	c.trackUncommitted("INSERT INTO t VALUES (1)", &failed)
+	if c.uncommitted {
+		t.Fatal("Expected a failed statement to leave nothing to commit")
+	}
+	expected := []string{"START TRANSACTION", "commit"}
+	if calls := f.recorded(); !reflect.DeepEqual(calls, expected) {
+		t.Fatalf("Expected %q, got %q", expected, calls)
+	}
+}
+
+func TestTxExpiredWhileIdle(t *testing.T) {
+	f := &fakeTx{auto: true}
+	events := make(chan TxExpiredEvent, 1)
+	c := &Conn{txc: f, id: 7, onTxExpired: func(e TxExpiredEvent) { events <- e }}
+	tx, err := c.begin(context.Background(), time.Millisecond, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case e := <-events:
+		if e.Conn != 7 || e.Duration != time.Millisecond || e.Err != nil {
+			t.Fatalf("Unexpected event %+v", e)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the transaction to expire")
+	}
+	if !c.bad {
+		t.Fatal("Expected the connection to be marked bad")
+	}
+	if _, err := c.enter(); err != ErrTxExpired {
+		t.Fatalf("Expected %v, got %v", ErrTxExpired, err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"START TRANSACTION", "rollback"}
+	if calls := f.recorded(); !reflect.DeepEqual(calls, expected) {
+		t.Fatalf("Expected %q, got %q", expected, calls)
+	}
+}
+
+func TestTxExpiredDuringCall(t *testing.T) {
+	f := &fakeTx{auto: true}
+	events := make(chan TxExpiredEvent, 1)
+	c := &Conn{txc: f, onTxExpired: func(e TxExpiredEvent) { events <- e }}
+	tx, err := c.begin(context.Background(), time.Millisecond, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exit, err := c.enter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if calls := f.recorded(); len(calls) != 1 {
+		t.Fatalf("Expected no rollback during the call, got %q", calls)
+	}
+	exit() // rolls back
+	select {
+	case <-events:
+	default:
+		t.Fatal("Expected the transaction to be rolled back by the call")
+	}
+	if err := tx.Commit(); err != ErrTxExpired {
+		t.Fatalf("Expected %v, got %v", ErrTxExpired, err)
+	}
+	expected := []string{"START TRANSACTION", "rollback"}
+	if calls := f.recorded(); !reflect.DeepEqual(calls, expected) {
+		t.Fatalf("Expected %q, got %q", expected, calls)
+	}
+}
+
+func TestTxCommittedInTime(t *testing.T) {
+	f := &fakeTx{auto: true}
+	c := &Conn{txc: f, onTxExpired: func(TxExpiredEvent) { t.Error("Unexpected expiry") }}
+	tx, err := c.begin(context.Background(), time.Hour, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if c.watch != nil || c.bad {
+		t.Fatal("Expected the watch to end with the transaction")
+	}
+}
+
+func TestTxCancelled(t *testing.T) {
+	f := &fakeTx{auto: true}
+	c := &Conn{txc: f}
+	ctx, cancel := context.WithCancel(context.Background())
+	tx, err := c.BeginTx(ctx, driver.TxOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exit, err := c.enter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	time.Sleep(20 * time.Millisecond)
+	if calls := f.recorded(); len(calls) != 1 {
+		t.Fatalf("Expected no rollback during the call, got %q", calls)
+	}
+	exit() // rolls back
+	if c.tx != nil || c.inTx || c.bad {
+		t.Fatal("Expected the transaction to end")
+	}
+	if _, err := c.enter(); err != context.Canceled {
+		t.Fatalf("Expected %v, got %v", context.Canceled, err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	if c.watch != nil {
+		t.Fatal("Expected the watch to end with the transaction")
+	}
+	expected := []string{"START TRANSACTION", "rollback"}
+	if calls := f.recorded(); !reflect.DeepEqual(calls, expected) {
+		t.Fatalf("Expected %q, got %q", expected, calls)
+	}
+}
+
+func TestEndOpenTx(t *testing.T) {
+	f := &fakeTx{auto: true}
+	c := &Conn{txc: f}
+	if _, err := c.BeginTx(context.Background(), driver.TxOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.endOpenTx(); err != nil {
+		t.Fatal(err)
+	}
+	if c.tx != nil || c.inTx {
+		t.Fatal("Expected the transaction to end")
+	}
+	expected := []string{"START TRANSACTION", "rollback"}
+	if calls := f.recorded(); !reflect.DeepEqual(calls, expected) {
+		t.Fatalf("Expected %q, got %q", expected, calls)
+	}
+}
+
+func TestTxCommitFailed(t *testing.T) {
+	f := &fakeTx{auto: true, err: errors.New("conflict")}
+	c := &Conn{txc: f}
+	tx, err := c.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != f.err {
+		t.Fatalf("Expected %v, got %v", f.err, err)
+	}
+	if c.tx != nil {
+		t.Fatal("Expected the transaction to end")
+	}
+	expected := []string{"START TRANSACTION", "commit", "rollback"}
+	if calls := f.recorded(); !reflect.DeepEqual(calls, expected) {
+		t.Fatalf("Expected %q, got %q", expected, calls)
+	}
+}
+
+func TestAutoCommitInTx(t *testing.T) {
+	f := &fakeTx{auto: true}
+	c := &Conn{txc: f}
+	tx, err := c.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if auto, err := c.AutoCommit(); err != nil || auto {
+		t.Fatalf("Expected no autocommit in a transaction, got %v, %v", auto, err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if auto, err := c.AutoCommit(); err != nil || !auto {
+		t.Fatalf("Expected autocommit after the transaction, got %v, %v", auto, err)
+	}
+}
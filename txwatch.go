@@ -0,0 +1,250 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"log"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// ErrTxExpired is returned by the statements and the commit of a
+// transaction which the driver rolled back because it was open for longer
+// than its maximum duration. Rolling back the transaction succeeds.
+var ErrTxExpired = errors.New("nuodb: transaction rolled back after its maximum duration")
+
+// TxExpiredEvent describes a transaction which the driver rolled back
+// because it was open for longer than its maximum duration.
+type TxExpiredEvent struct {
+	Conn     uint64        // local number of the connection
+	Session  int64         // server side id of the connection; 0 if unknown
+	Duration time.Duration // maximum duration of the transaction
+	Err      error         // of the rollback, if it failed
+}
+
+// LongTxEvent describes a transaction which is still open after the
+// LongTxThreshold of its connection.
+type LongTxEvent struct {
+	Conn     uint64        // local number of the connection
+	Session  int64         // server side id of the connection; 0 if unknown
+	Started  time.Time     // when the transaction was begun
+	Duration time.Duration // for which the transaction has been open
+	Stack    string        // of the Begin or BeginTx of the transaction
+}
+
+type maxTxDurationKey struct{}
+
+// WithMaxTxDuration returns a copy of ctx which carries the maximum
+// duration of the transactions begun with it, overriding the maxTxDuration
+// property. Zero disables the limit for the transaction:
+//
+//	tx, err := db.BeginTx(nuodb.WithMaxTxDuration(ctx, time.Minute), nil)
+func WithMaxTxDuration(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, maxTxDurationKey{}, d)
+}
+
+// BeginTx implements driver.ConnBeginTx. The isolation level is either a
+// standard level which NuoDB supports or a NuoDB level, e.g.
+// LevelConsistentRead; the default is the level of the connection. The
+// writes of a read-only transaction fail with a READ_ONLY_ERROR.
+func (c *Conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	level, err := isolationLevel(sql.IsolationLevel(opts.Isolation))
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Err() != nil {
+		return nil, contextError(ctx)
+	}
+	d := c.maxTxDuration
+	if v, ok := ctx.Value(maxTxDurationKey{}).(time.Duration); ok {
+		d = v
+	}
+	return c.begin(ctx, d, level, opts.ReadOnly)
+}
+
+// txWatch rolls back a transaction which is open for longer than its
+// maximum duration, or whose context is cancelled. The rollback can't run
+// concurrently with the calls of the driver on the connection, so the
+// transaction is rolled back by the timer or the cancellation if the
+// connection is idle, or else when the last call in progress returns.
+type txWatch struct {
+	c        *Conn
+	duration time.Duration
+	timer    *time.Timer   // nil without a maximum duration
+	done     chan struct{} // closed when the transaction ends
+	started  time.Time
+	long     *time.Timer // of the long transaction threshold, if any
+	begunAt  []uintptr   // stack of the begin, with a long transaction threshold
+
+	mu          sync.Mutex
+	cond        *sync.Cond
+	calls       int   // in progress on the connection, possibly nested
+	err         error // ErrTxExpired or the error of the context, once the transaction is to be rolled back
+	rollingBack bool
+	rolledBack  bool
+	closed      bool // the transaction has ended
+}
+
+// watchTx starts the watch of the transaction just begun on c with ctx,
+// which has the maximum duration d unless it is 0, and which is reported
+// if it is open for longer than the long transaction threshold of c.
+func (c *Conn) watchTx(ctx context.Context, d time.Duration) {
+	w := &txWatch{c: c, duration: d, done: make(chan struct{}), started: time.Now()}
+	w.cond = sync.NewCond(&w.mu)
+	if d > 0 {
+		w.timer = time.AfterFunc(d, w.expire)
+	}
+	if c.longTxThreshold > 0 {
+		w.begunAt = make([]uintptr, maxLeakStackDepth)
+		w.begunAt = w.begunAt[:runtime.Callers(3, w.begunAt)] // from the Begin or BeginTx
+		w.long = time.AfterFunc(c.longTxThreshold, w.reportLong)
+	}
+	if ctx.Done() != nil {
+		go w.awaitCancel(ctx)
+	}
+	c.watch = w
+}
+
+// endTx stops the watch of the transaction, if any.
+func (c *Conn) endTx() {
+	w := c.watch
+	if w == nil {
+		return
+	}
+	c.watch = nil
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	if w.long != nil {
+		w.long.Stop()
+	}
+	close(w.done)
+	w.mu.Lock()
+	for w.rollingBack {
+		w.cond.Wait()
+	}
+	w.closed = true
+	w.mu.Unlock()
+}
+
+// enter marks a call of the driver in progress on c until the returned
+// function is called. It waits for a rollback in progress and returns
+// ErrTxExpired, or the error of the cancelled context, if the transaction
+// has been rolled back.
+func (c *Conn) enter() (func(), error) {
+	if c.keepaliveInterval > 0 {
+		c.stopKeepalive() // in case the pool reuses c without ResetSession
+	}
+	w := c.watch
+	if w == nil {
+		return func() {}, nil
+	}
+	w.mu.Lock()
+	for w.rollingBack {
+		w.cond.Wait()
+	}
+	if w.rolledBack {
+		err := w.err
+		w.mu.Unlock()
+		return func() {}, err
+	}
+	w.calls++
+	w.mu.Unlock()
+	return w.exit, nil
+}
+
+func (w *txWatch) exit() {
+	w.mu.Lock()
+	w.calls--
+	rollback := w.calls == 0 && w.err != nil && !w.rolledBack && !w.closed
+	w.rollingBack = rollback
+	w.mu.Unlock()
+	if rollback {
+		w.rollback()
+	}
+}
+
+// reportLong reports the transaction, which is still open after the long
+// transaction threshold, to the onLongTx callback and the logger of the
+// connection, or to the standard logger without either.
+func (w *txWatch) reportLong() {
+	w.mu.Lock()
+	ended := w.closed || w.err != nil
+	w.mu.Unlock()
+	if ended {
+		return
+	}
+	c := w.c
+	e := LongTxEvent{Conn: c.id, Session: c.sessionID, Started: w.started, Duration: time.Since(w.started),
+		Stack: formatStack(w.begunAt)}
+	c.log(LevelWarn, "long transaction", "duration", e.Duration, "stack", e.Stack)
+	if c.onLongTx != nil {
+		c.onLongTx(e)
+	} else if c.logger == nil {
+		log.Printf("nuodb %s: transaction open for %s\nbegun at:\n%s", LevelWarn, e.Duration, e.Stack)
+	}
+}
+
+func (w *txWatch) expire() {
+	w.end(ErrTxExpired)
+}
+
+func (w *txWatch) awaitCancel(ctx context.Context) {
+	select {
+	case <-ctx.Done():
+		w.end(contextError(ctx))
+	case <-w.done:
+	}
+}
+
+// end rolls back the transaction for err, now if the connection is idle.
+func (w *txWatch) end(err error) {
+	w.mu.Lock()
+	if w.closed || w.err != nil {
+		w.mu.Unlock()
+		return
+	}
+	w.err = err
+	rollback := w.calls == 0
+	w.rollingBack = rollback
+	w.mu.Unlock()
+	if rollback {
+		w.rollback()
+	}
+}
+
+// rollback rolls back the transaction. An expired transaction marks the
+// connection bad, so that the pool validates it before it is reused, whereas
+// a cancelled one ends the Tx, whose statements fail with the error of the
+// context anyway.
+func (w *txWatch) rollback() {
+	c := w.c
+	err := c.txc.rollback()
+	if c.tx != nil {
+		c.tx.rolledBack()
+	}
+	expired := w.err == ErrTxExpired
+	if expired || err != nil {
+		c.bad = true
+	} else if c.tx != nil {
+		c.tx.end()
+	}
+	w.mu.Lock()
+	w.rollingBack = false
+	w.rolledBack = true
+	w.cond.Broadcast()
+	w.mu.Unlock()
+	if !expired {
+		c.log(LevelDebug, "transaction rolled back on cancellation", "rolled_back", err == nil)
+		return
+	}
+	c.log(LevelWarn, "transaction expired", "duration", w.duration, "rolled_back", err == nil)
+	if c.onTxExpired != nil {
+		c.onTxExpired(TxExpiredEvent{Conn: c.id, Session: c.sessionID, Duration: w.duration, Err: err})
+	}
+}
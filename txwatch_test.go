@@ -0,0 +1,142 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBeginTxOptions(t *testing.T) {
+	c := &Conn{}
+	ctx := context.Background()
+	if _, err := c.BeginTx(ctx, driver.TxOptions{Isolation: driver.IsolationLevel(sql.LevelReadUncommitted)}); err == nil {
+		t.Fatal("Expected an error for an unsupported isolation level")
+	}
+}
+
+func TestTxWatchCalls(t *testing.T) {
+	c := &Conn{}
+	c.watchTx(context.Background(), time.Hour)
+	w := c.watch
+	exit1, err := c.enter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	exit2, err := c.enter() // nested
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.calls != 2 {
+		t.Fatalf("Expected 2 calls, got %d", w.calls)
+	}
+	exit2()
+	exit1()
+	if w.calls != 0 {
+		t.Fatalf("Expected no calls, got %d", w.calls)
+	}
+	c.endTx()
+	if c.watch != nil || !w.closed {
+		t.Fatal("Expected the watch to be closed")
+	}
+	w.expire() // of an ended transaction
+	if w.err != nil || w.rolledBack {
+		t.Fatal("Expected an ended transaction not to expire")
+	}
+	if exit, err := c.enter(); err != nil {
+		t.Fatal(err)
+	} else {
+		exit()
+	}
+}
+
+func TestLongTx(t *testing.T) {
+	events := make(chan LongTxEvent, 1)
+	c := &Conn{id: 7, longTxThreshold: 10 * time.Millisecond, onLongTx: func(e LongTxEvent) { events <- e }}
+	begin := func() { c.watchTx(context.Background(), 0) } // as called by Conn.begin
+	begin()
+	select {
+	case e := <-events:
+		if e.Conn != 7 || e.Duration < 10*time.Millisecond || e.Started.IsZero() {
+			t.Fatalf("Unexpected event: %+v", e)
+		}
+		if !strings.Contains(e.Stack, "TestLongTx") {
+			t.Fatalf("Expected the stack of the begin, got:\n%s", e.Stack)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected a long transaction")
+	}
+	c.endTx()
+
+	begin()
+	c.endTx()
+	select {
+	case e := <-events:
+		t.Fatalf("Expected an ended transaction not to be reported, got: %+v", e)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestMaxTxDuration(t *testing.T) {
+	db := testConn(t)
+	exec(t, db, "CREATE TABLE FooBar (id INTEGER)")
+	db.Close()
+	cfg, err := ParseDSN(default_dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Schema = "tests"
+	cfg.MaxTxDuration = time.Hour
+	events := make(chan TxExpiredEvent, 1)
+	cfg.OnTxExpired = func(e TxExpiredEvent) { events <- e }
+	connector, err := NewConnector(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db = sql.OpenDB(connector)
+	defer db.Close()
+
+	ctx := WithMaxTxDuration(context.Background(), 100*time.Millisecond)
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Exec("INSERT INTO FooBar VALUES (1)"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case e := <-events:
+		if e.Duration != 100*time.Millisecond || e.Err != nil {
+			t.Fatalf("Unexpected event %+v", e)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Expected the transaction to expire")
+	}
+	if _, err := tx.Exec("INSERT INTO FooBar VALUES (2)"); !errors.Is(err, ErrTxExpired) {
+		t.Fatalf("Expected %v, got %v", ErrTxExpired, err)
+	}
+	if err := tx.Commit(); !errors.Is(err, ErrTxExpired) {
+		t.Fatalf("Expected %v, got %v", ErrTxExpired, err)
+	}
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM FooBar").Scan(&count); err != nil || count != 0 {
+		t.Fatalf("Expected the insert to be rolled back, got %d rows (%v)", count, err)
+	}
+
+	// within the maximum duration of the connector
+	tx, err = db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Exec("INSERT INTO FooBar VALUES (3)"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+}
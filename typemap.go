@@ -0,0 +1,117 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+// #include "cnuodb.h"
+import "C"
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"time"
+)
+
+// Directions of a TypeMapping.
+const (
+	DirectionBind = "bind" // a Go value bound to a parameter
+	DirectionScan = "scan" // a column value returned by the driver
+)
+
+// TypeMapping is a conversion of the driver between a Go type and the
+// NuoDB types. A bound Go type is converted into BoundAs, the driver.Value
+// type passed to NuoDB. A scanned column of NuoDB types is returned as a
+// value of GoType, which database/sql then converts into the destination
+// of Scan.
+type TypeMapping struct {
+	Direction  string
+	GoType     reflect.Type
+	BoundAs    reflect.Type // nil for a scan
+	NuoDBTypes []string
+	Lossy      bool   // the conversion may lose precision or information
+	Note       string // of the conversion, if any
+}
+
+// bindMappings are the bound types, with a sample value of each.
+var bindMappings = []struct {
+	sample     interface{}
+	nuodbTypes []string
+	lossy      bool
+	note       string
+}{
+	{int64(0), []string{"BIGINT"}, false, ""},
+	{int(0), []string{"BIGINT"}, false, ""},
+	{int8(0), []string{"BIGINT"}, false, ""},
+	{int16(0), []string{"BIGINT"}, false, ""},
+	{int32(0), []string{"BIGINT"}, false, ""},
+	{uint(0), []string{"BIGINT"}, false, "values above math.MaxInt64 are rejected"},
+	{uint8(0), []string{"BIGINT"}, false, ""},
+	{uint16(0), []string{"BIGINT"}, false, ""},
+	{uint32(0), []string{"BIGINT"}, false, ""},
+	{uint64(0), []string{"BIGINT"}, false, "values above math.MaxInt64 are rejected"},
+	{float64(0), []string{"DOUBLE"}, false, ""},
+	{float32(0), []string{"DOUBLE"}, false, ""},
+	{false, []string{"BOOLEAN"}, false, ""},
+	{"", []string{"STRING", "CLOB"}, false, ""},
+	{[]byte{}, []string{"BINARY", "BLOB"}, false, ""},
+	{time.Time{}, []string{"TIMESTAMP", "DATE", "TIME"}, true,
+		"bound as the type of the parameter; a DATE or TIME keeps the date or time of day in the time zone of the connection"},
+	{Date{}, []string{"DATE"}, false, ""},
+	{TimeOfDay{}, []string{"TIME"}, false, ""},
+	{time.Duration(0), []string{"BIGINT"}, false, "nanoseconds, as NuoDB has no INTERVAL type"},
+	{json.RawMessage{}, []string{"STRING"}, false, ""},
+	{JSON{V: 0}, []string{"STRING"}, false, "marshalled with encoding/json"},
+	{UUID{}, []string{"STRING"}, false, "the canonical text form, as are other UUID types"},
+	{Decimal("0"), []string{"DECIMAL"}, false, ""},
+	{new(big.Int), []string{"DECIMAL"}, false, ""},
+	{new(big.Rat), []string{"DECIMAL"}, false, "values without a finite decimal form, e.g. 1/3, are rejected"},
+	{new(big.Float), []string{"DECIMAL"}, false, ""},
+}
+
+// scanMappings are the column types, by the type of their fetched values.
+var scanMappings = []struct {
+	valueType  C.enum_nuodb_value_type
+	nuodbTypes []string
+	lossy      bool
+	note       string
+}{
+	{C.NUODB_TYPE_INT64, []string{"SMALLINT", "INTEGER", "BIGINT"}, false, ""},
+	{C.NUODB_TYPE_FLOAT64, []string{"DOUBLE", "FLOAT"}, false, ""},
+	{C.NUODB_TYPE_BOOL, []string{"BOOLEAN"}, false, ""},
+	{C.NUODB_TYPE_BYTES, []string{"DECIMAL", "NUMERIC"}, false,
+		"the exact decimal text, as are integer types with a scale; scan into a Decimal"},
+	{C.NUODB_TYPE_STRING, []string{"CHAR", "VARCHAR", "STRING", "CLOB"}, false, ""},
+	{C.NUODB_TYPE_BYTES, []string{"BINARY", "VARBINARY", "BLOB"}, false, ""},
+	{C.NUODB_TYPE_TIME, []string{"TIMESTAMP"}, false, "in the time zone of the connection, or of WithLocation"},
+	{C.NUODB_TYPE_DATE, []string{"DATE"}, false, "the midnight of the date; scan into a Date"},
+	{C.NUODB_TYPE_TIME_OF_DAY, []string{"TIME"}, false, "the time of day on January 1, 1970; scan into a TimeOfDay"},
+}
+
+// TypeMap returns the conversions of the driver between the Go types and
+// the NuoDB types, e.g. for a framework mapping the fields of its models
+// or for a tool explaining how a value is stored. The bound types are
+// converted as they would be bound, so BoundAs is the actual driver.Value
+// type of each. Other types are bound by their driver.Valuer, or else as
+// their underlying basic type.
+func TypeMap() []TypeMapping {
+	var mappings []TypeMapping
+	for _, m := range bindMappings {
+		v, ok, err := convertValue(m.sample)
+		if !ok && err == nil {
+			if valuer, isValuer := m.sample.(driver.Valuer); isValuer {
+				v, err = valuer.Value()
+			}
+		}
+		if err != nil {
+			panic("nuodb: type map: " + err.Error())
+		}
+		mappings = append(mappings, TypeMapping{Direction: DirectionBind, GoType: reflect.TypeOf(m.sample),
+			BoundAs: reflect.TypeOf(v), NuoDBTypes: m.nuodbTypes, Lossy: m.lossy, Note: m.note})
+	}
+	for _, m := range scanMappings {
+		mappings = append(mappings, TypeMapping{Direction: DirectionScan, GoType: scanType(m.valueType),
+			NuoDBTypes: m.nuodbTypes, Lossy: m.lossy, Note: m.note})
+	}
+	return mappings
+}
@@ -0,0 +1,61 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTypeMap(t *testing.T) {
+	valueTypes := map[reflect.Type]bool{}
+	for _, v := range []interface{}{int64(0), float64(0), false, "", []byte{}, time.Time{}, Date{}, TimeOfDay{}} {
+		valueTypes[reflect.TypeOf(v)] = true
+	}
+	bound := map[reflect.Type]reflect.Type{}
+	scanned := map[string]reflect.Type{}
+	for _, m := range TypeMap() {
+		if m.GoType == nil || len(m.NuoDBTypes) == 0 {
+			t.Fatalf("Incomplete mapping %+v", m)
+		}
+		switch m.Direction {
+		case DirectionBind:
+			if !valueTypes[m.BoundAs] {
+				t.Fatalf("%s is bound as %v, which isn't a driver.Value type", m.GoType, m.BoundAs)
+			}
+			bound[m.GoType] = m.BoundAs
+		case DirectionScan:
+			for _, name := range m.NuoDBTypes {
+				scanned[name] = m.GoType
+			}
+		default:
+			t.Fatalf("Unexpected direction %q", m.Direction)
+		}
+	}
+	for v, expected := range map[interface{}]interface{}{
+		int8(0):          int64(0),
+		float32(0):       float64(0),
+		time.Duration(0): int64(0),
+		UUID{}:           "",
+		Decimal("0"):     "",
+		JSON{}:           "",
+	} {
+		if b := bound[reflect.TypeOf(v)]; b != reflect.TypeOf(expected) {
+			t.Errorf("Expected %T to be bound as %T, got %v", v, expected, b)
+		}
+	}
+	for name, expected := range map[string]reflect.Type{
+		"BIGINT":    scanTypeInt64,
+		"DECIMAL":   scanTypeBytes,
+		"VARCHAR":   scanTypeString,
+		"BLOB":      scanTypeBytes,
+		"TIMESTAMP": scanTypeTime,
+		"DATE":      scanTypeTime,
+		"BOOLEAN":   scanTypeBool,
+	} {
+		if s := scanned[name]; s != expected {
+			t.Errorf("Expected %s to be scanned as %v, got %v", name, expected, s)
+		}
+	}
+}
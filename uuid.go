@@ -0,0 +1,106 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"database/sql/driver"
+	"encoding"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// UUID is a UUID stored in its canonical text form, e.g.
+// "f47ac10b-58cc-4372-a567-0e02b2c3d479", in a CHAR(36) or STRING column.
+// Scan a UUID column into a UUID to read it without parsing it by hand.
+//
+// A UUID, a [16]byte, any other type of 16 bytes, like uuid.UUID of
+// github.com/google/uuid, and the encoding.TextMarshaler types whose text is
+// a UUID are bound in the canonical text form. Bind u[:] instead to store a
+// UUID in a BINARY(16) column, which a UUID can also be scanned from.
+type UUID [16]byte
+
+var _ driver.Valuer = UUID{}
+
+// ParseUUID parses a UUID in the canonical form, with or without the
+// hyphens, in upper or lower case.
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+	h := s
+	if len(h) == 36 {
+		if h[8] != '-' || h[13] != '-' || h[18] != '-' || h[23] != '-' {
+			return u, fmt.Errorf("nuodb: invalid UUID: %q", s)
+		}
+		h = h[:8] + h[9:13] + h[14:18] + h[19:23] + h[24:]
+	}
+	if len(h) != 32 || strings.Contains(h, "-") {
+		return u, fmt.Errorf("nuodb: invalid UUID: %q", s)
+	}
+	if _, err := hex.Decode(u[:], []byte(h)); err != nil {
+		return u, fmt.Errorf("nuodb: invalid UUID: %q", s)
+	}
+	return u, nil
+}
+
+// String returns u in the canonical lower case form.
+func (u UUID) String() string {
+	var b [36]byte
+	hex.Encode(b[0:8], u[0:4])
+	b[8] = '-'
+	hex.Encode(b[9:13], u[4:6])
+	b[13] = '-'
+	hex.Encode(b[14:18], u[6:8])
+	b[18] = '-'
+	hex.Encode(b[19:23], u[8:10])
+	b[23] = '-'
+	hex.Encode(b[24:], u[10:])
+	return string(b[:])
+}
+
+// Value implements driver.Valuer.
+func (u UUID) Value() (driver.Value, error) {
+	return u.String(), nil
+}
+
+// Scan implements sql.Scanner. It accepts the text forms of ParseUUID and
+// the 16 bytes of a BINARY(16) value.
+func (u *UUID) Scan(src interface{}) error {
+	var err error
+	switch src := src.(type) {
+	case []byte:
+		if len(src) == len(u) {
+			copy(u[:], src)
+			return nil
+		}
+		*u, err = ParseUUID(string(src))
+	case string:
+		*u, err = ParseUUID(src)
+	default:
+		return fmt.Errorf("nuodb: cannot scan %T into UUID", src)
+	}
+	return err
+}
+
+var uuidType = reflect.TypeOf(UUID{})
+
+// uuidValue returns the canonical text form of a value of a UUID type: an
+// array of 16 bytes or an encoding.TextMarshaler whose text is a UUID.
+func uuidValue(v interface{}) (string, bool) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Array && rv.Type().ConvertibleTo(uuidType) {
+		return rv.Convert(uuidType).Interface().(UUID).String(), true
+	}
+	if m, ok := v.(encoding.TextMarshaler); ok && (rv.Kind() != reflect.Ptr || !rv.IsNil()) {
+		text, err := m.MarshalText()
+		if err != nil {
+			return "", false
+		}
+		u, err := ParseUUID(string(text))
+		if err != nil {
+			return "", false
+		}
+		return u.String(), true
+	}
+	return "", false
+}
@@ -0,0 +1,93 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"database/sql/driver"
+	"testing"
+)
+
+const testUUID = "f47ac10b-58cc-4372-a567-0e02b2c3d479"
+
+var testUUIDBytes = [16]byte{0xf4, 0x7a, 0xc1, 0x0b, 0x58, 0xcc, 0x43, 0x72,
+	0xa5, 0x67, 0x0e, 0x02, 0xb2, 0xc3, 0xd4, 0x79}
+
+// googleUUID is shaped like uuid.UUID of github.com/google/uuid, without
+// its driver.Valuer.
+type googleUUID [16]byte
+
+// textUUID is a UUID type which only implements encoding.TextMarshaler.
+type textUUID struct{ text string }
+
+func (u textUUID) MarshalText() ([]byte, error) { return []byte(u.text), nil }
+
+func TestParseUUID(t *testing.T) {
+	for _, s := range []string{testUUID, "F47AC10B-58CC-4372-A567-0E02B2C3D479", "f47ac10b58cc4372a5670e02b2c3d479"} {
+		u, err := ParseUUID(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u != testUUIDBytes || u.String() != testUUID {
+			t.Fatalf("%s: got %s", s, u)
+		}
+	}
+	for _, s := range []string{"", "f47ac10b-58cc-4372-a567-0e02b2c3d47", "f47ac10b+58cc-4372-a567-0e02b2c3d479",
+		"f47ac10b-58cc-4372-a567-0e02b2c3d47x", "f47ac10b58cc4372a5670e02b2c3d479ff"} {
+		if _, err := ParseUUID(s); err == nil {
+			t.Fatalf("Expected an error parsing %q", s)
+		}
+	}
+}
+
+func TestUUIDScan(t *testing.T) {
+	for _, src := range []interface{}{testUUID, []byte(testUUID), testUUIDBytes[:]} {
+		var u UUID
+		if err := u.Scan(src); err != nil {
+			t.Fatal(err)
+		}
+		if u != testUUIDBytes {
+			t.Fatalf("%v: got %s", src, u)
+		}
+	}
+	var u UUID
+	for _, src := range []interface{}{"nope", int64(1), nil} {
+		if err := u.Scan(src); err == nil {
+			t.Fatalf("Expected an error scanning %v", src)
+		}
+	}
+}
+
+func TestCheckNamedValueUUID(t *testing.T) {
+	c := &Conn{}
+	for _, v := range []interface{}{testUUIDBytes, googleUUID(testUUIDBytes), textUUID{testUUID}} {
+		nv := &driver.NamedValue{Ordinal: 1, Value: v}
+		if err := c.CheckNamedValue(nv); err != nil {
+			t.Fatalf("%T: %s", v, err)
+		}
+		if nv.Value != testUUID {
+			t.Fatalf("%T: expected %s, got %#v", v, testUUID, nv.Value)
+		}
+	}
+	for _, v := range []interface{}{UUID(testUUIDBytes), textUUID{"not a uuid"}, [15]byte{}} {
+		nv := &driver.NamedValue{Ordinal: 1, Value: v}
+		if err := c.CheckNamedValue(nv); err != driver.ErrSkip {
+			t.Fatalf("%T: expected ErrSkip, got %v", v, err)
+		}
+	}
+}
+
+func TestUUIDRoundTrip(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	exec(t, db, "CREATE TABLE FooBar (id CHAR(36), bin BINARY(16))")
+	exec(t, db, "INSERT INTO FooBar VALUES (?, ?)", UUID(testUUIDBytes), testUUIDBytes[:])
+
+	var id, bin UUID
+	var s string
+	if err := db.QueryRow("SELECT id, bin, id FROM FooBar WHERE id = ?", googleUUID(testUUIDBytes)).Scan(&id, &bin, &s); err != nil {
+		t.Fatal(err)
+	}
+	if id != testUUIDBytes || bin != testUUIDBytes || s != testUUID {
+		t.Fatalf("Expected %s, got %s, %s, %s", testUUID, id, bin, s)
+	}
+}
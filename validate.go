@@ -0,0 +1,97 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// StatementProblem is a statement which failed ValidateStatements.
+type StatementProblem struct {
+	Index int // of the statement in the validated list
+	SQL   string
+	Err   error
+}
+
+// ValidationError is returned by ValidateStatements for the statements
+// which failed the validation.
+type ValidationError struct {
+	Statements int // validated
+	Problems   []StatementProblem
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "nuodb: %d of %d statements are invalid", len(e.Problems), e.Statements)
+	for _, p := range e.Problems {
+		fmt.Fprintf(&b, "\nstatement %d: %s\n\t%s", p.Index, p.Err, p.SQL)
+	}
+	return b.String()
+}
+
+// ValidateStatements prepares each of the statements on a connection of
+// db, without executing them, and reports all the statements which fail
+// at once in a *ValidationError: syntax errors, unknown tables and
+// columns, and named placeholders mixed with ? markers. It is meant to run
+// in CI or at the startup of a service against a staging database, so that
+// a broken query is found before it is first executed:
+//
+//	if err := nuodb.ValidateStatements(ctx, db, queries); err != nil {
+//		log.Fatal(err)
+//	}
+//
+// Other errors, e.g. of connecting, stop the validation and are returned
+// as is.
+func ValidateStatements(ctx context.Context, db *sql.DB, statements []string) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	result := &ValidationError{Statements: len(statements)}
+	err = conn.Raw(func(driverConn interface{}) error {
+		c, ok := driverConn.(*Conn)
+		if !ok {
+			return errors.New("nuodb: not a nuodb connection")
+		}
+		for i, sql := range statements {
+			if ctx.Err() != nil {
+				return contextError(ctx)
+			}
+			err := c.validate(sql)
+			if c.bad {
+				return err
+			}
+			if err != nil {
+				result.Problems = append(result.Problems, StatementProblem{Index: i, SQL: sql, Err: err})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(result.Problems) > 0 {
+		return result
+	}
+	return nil
+}
+
+// validate prepares sql and checks its placeholders.
+func (c *Conn) validate(sql string) error {
+	ds, err := c.Prepare(sql)
+	if err != nil {
+		return err
+	}
+	stmt := ds.(*Stmt)
+	defer stmt.Close()
+	if stmt.names != nil && int(stmt.parameterCount) != len(stmt.names) {
+		return fmt.Errorf("nuodb: %d named placeholders mixed with %d ? markers",
+			len(stmt.names), int(stmt.parameterCount)-len(stmt.names))
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestValidationErrorMessage(t *testing.T) {
+	err := &ValidationError{Statements: 3, Problems: []StatementProblem{
+		{Index: 1, SQL: "SELEC 1", Err: errors.New("syntax error")},
+	}}
+	expected := "nuodb: 1 of 3 statements are invalid\nstatement 1: syntax error\n\tSELEC 1"
+	if err.Error() != expected {
+		t.Fatalf("Expected %q, got %q", expected, err.Error())
+	}
+}
+
+func TestValidateStatements(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	exec(t, db, "CREATE TABLE tests.FooBar (id BIGINT, name STRING)")
+
+	ctx := context.Background()
+	valid := []string{
+		"SELECT id, name FROM tests.FooBar WHERE id = ?",
+		"UPDATE tests.FooBar SET name = :name WHERE id = :id",
+	}
+	if err := ValidateStatements(ctx, db, valid); err != nil {
+		t.Fatal(err)
+	}
+	err := ValidateStatements(ctx, db, append(valid,
+		"SELEC id FROM tests.FooBar",
+		"SELECT missing FROM tests.FooBar",
+		"SELECT id FROM tests.FooBar WHERE name = :name AND id = ?"))
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Expected a ValidationError, got %v", err)
+	}
+	if verr.Statements != 5 || len(verr.Problems) != 3 {
+		t.Fatalf("Expected 3 of 5 invalid statements, got %v", err)
+	}
+	for i, p := range verr.Problems {
+		if p.Index != i+2 {
+			t.Fatalf("Expected statement %d, got %d: %v", i+2, p.Index, p.Err)
+		}
+	}
+	if verr.Problems[2].Err.Error() != "nuodb: 1 named placeholders mixed with 1 ? markers" {
+		t.Fatal(verr.Problems[2].Err)
+	}
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM tests.FooBar").Scan(&count); err != nil || count != 0 {
+		t.Fatal(count, err)
+	}
+}
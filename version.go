@@ -0,0 +1,107 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is the version of a NuoDB release.
+type Version struct {
+	Major, Minor, Patch int
+	Build               string // the full version string, e.g. "2.4.1-7"
+}
+
+// ParseVersion parses a version string of the form major.minor[.patch]
+// followed by any build information. The numbers of an unrecognized
+// version are zero.
+func ParseVersion(s string) Version {
+	v := Version{Build: s}
+	end := strings.IndexFunc(s, func(r rune) bool { return r != '.' && (r < '0' || r > '9') })
+	if end < 0 {
+		end = len(s)
+	}
+	parts := strings.Split(s[:end], ".")
+	if len(parts) < 2 {
+		return v
+	}
+	numbers := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i := 0; i < len(parts) && i < len(numbers); i++ {
+		n, err := strconv.Atoi(parts[i])
+		if err != nil {
+			return Version{Build: s}
+		}
+		*numbers[i] = n
+	}
+	return v
+}
+
+// Known reports whether the version was recognized.
+func (v Version) Known() bool {
+	return v.Major != 0 || v.Minor != 0 || v.Patch != 0
+}
+
+// AtLeast reports whether v is the version major.minor.patch or later.
+func (v Version) AtLeast(major, minor, patch int) bool {
+	if v.Major != major {
+		return v.Major > major
+	}
+	if v.Minor != minor {
+		return v.Minor > minor
+	}
+	return v.Patch >= patch
+}
+
+func (v Version) String() string {
+	if v.Build != "" {
+		return v.Build
+	}
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// ClientVersion returns the version of the NuoDB client library which the
+// connections of db use.
+func ClientVersion(ctx context.Context, db *sql.DB) (Version, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return Version{}, err
+	}
+	defer conn.Close()
+	var v Version
+	err = conn.Raw(func(driverConn interface{}) error {
+		c, ok := driverConn.(*Conn)
+		if !ok {
+			return errors.New("nuodb: not a nuodb connection")
+		}
+		v = c.clientVersion
+		return nil
+	})
+	return v, err
+}
+
+// feature is a driver feature which depends on the client library.
+type feature struct {
+	name                string
+	major, minor, patch int
+}
+
+var (
+	featureQueryTimeout = feature{"query timeouts", 2, 0, 0}
+	featureLDAP         = feature{"LDAP logins", 4, 0, 0}
+)
+
+// require returns an error if the client library is known to be too old
+// for f. An unrecognized version is assumed to be recent enough.
+func (c *Conn) require(f feature) error {
+	v := c.clientVersion
+	if !v.Known() || v.AtLeast(f.major, f.minor, f.patch) {
+		return nil
+	}
+	return fmt.Errorf("nuodb: %s require NuoDB client %d.%d.%d or later, the client is %s",
+		f.name, f.major, f.minor, f.patch, v)
+}
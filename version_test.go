@@ -0,0 +1,70 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		s        string
+		expected Version
+	}{
+		{"2.4.1-7-abcdef", Version{2, 4, 1, "2.4.1-7-abcdef"}},
+		{"3.0", Version{3, 0, 0, "3.0"}},
+		{"10.2.3.4", Version{10, 2, 3, "10.2.3.4"}},
+		{"", Version{}},
+		{"unknown", Version{Build: "unknown"}},
+		{"7", Version{Build: "7"}},
+	}
+	for _, test := range tests {
+		if v := ParseVersion(test.s); v != test.expected {
+			t.Errorf("%q: expected %+v, got %+v", test.s, test.expected, v)
+		}
+	}
+}
+
+func TestVersionAtLeast(t *testing.T) {
+	v := Version{Major: 2, Minor: 4, Patch: 1}
+	for _, ok := range [][3]int{{2, 4, 1}, {2, 3, 9}, {1, 9, 9}} {
+		if !v.AtLeast(ok[0], ok[1], ok[2]) {
+			t.Errorf("Expected %v to be at least %v", v, ok)
+		}
+	}
+	for _, tooNew := range [][3]int{{2, 4, 2}, {2, 5, 0}, {3, 0, 0}} {
+		if v.AtLeast(tooNew[0], tooNew[1], tooNew[2]) {
+			t.Errorf("Expected %v to be older than %v", v, tooNew)
+		}
+	}
+}
+
+func TestRequireFeature(t *testing.T) {
+	c := &Conn{clientVersion: ParseVersion("1.2.0")}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if _, err := c.queryTimeout(ctx); err == nil {
+		t.Fatal("Expected error for an old client")
+	}
+	if _, err := c.queryTimeout(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	c.clientVersion = Version{}
+	if _, err := c.queryTimeout(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClientVersion(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	v, err := ClientVersion(context.Background(), db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !v.Known() {
+		t.Fatalf("Expected a known version, got %q", v)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+)
+
+// WarmUp opens and pings n connections of db concurrently and returns them
+// to its pool, so that the first burst of traffic after starting a program
+// doesn't wait for the brokers to connect and authenticate the connections
+// one by one:
+//
+//	db.SetMaxIdleConns(20)
+//	if err := nuodb.WarmUp(ctx, db, 20); err != nil {
+//		log.Printf("warm-up: %v", err)
+//	}
+//
+// The pool keeps at most as many idle connections as SetMaxIdleConns
+// allows, 2 by default, and closes the rest, so raise it to n first. n is
+// capped by SetMaxOpenConns. The connections opened stay in the pool even
+// if others fail, and the first error is returned.
+func WarmUp(ctx context.Context, db *sql.DB, n int) error {
+	if max := db.Stats().MaxOpenConnections; max > 0 && n > max {
+		n = max
+	}
+	conns := make([]*sql.Conn, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := range conns {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			conn, err := db.Conn(ctx)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			conns[i] = conn
+			errs[i] = conn.PingContext(ctx)
+		}(i)
+	}
+	wg.Wait()
+	// held until all are open, so that the pool doesn't hand out the same
+	// connection twice
+	for _, conn := range conns {
+		if conn != nil {
+			conn.Close()
+		}
+	}
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
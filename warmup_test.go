@@ -0,0 +1,21 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWarmUp(t *testing.T) {
+	db := testConn(t)
+	defer db.Close()
+	db.SetMaxIdleConns(3)
+	db.SetMaxOpenConns(3)
+	if err := WarmUp(context.Background(), db, 5); err != nil {
+		t.Fatal(err)
+	}
+	if stats := db.Stats(); stats.OpenConnections != 3 || stats.Idle != 3 {
+		t.Fatalf("Expected 3 idle connections, got %+v", stats)
+	}
+}
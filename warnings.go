@@ -0,0 +1,52 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+// #include "cnuodb.h"
+import "C"
+import "fmt"
+
+// Warning is a warning of the server about a statement which succeeded,
+// e.g. about a value which was truncated or converted.
+type Warning struct {
+	Code    int
+	Message string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("%s (%d)", w.Message, w.Code)
+}
+
+// Warnings implements NuoConn. It returns the warnings of the last statement
+// executed on the connection, nil if there were none:
+//
+//	if _, err := conn.ExecContext(ctx, "INSERT ..."); err != nil {
+//		...
+//	}
+//	err := conn.Raw(func(driverConn interface{}) error {
+//		for _, w := range driverConn.(nuodb.NuoConn).Warnings() {
+//			log.Print(w)
+//		}
+//		return nil
+//	})
+//
+// A failed statement has no warnings, as its error replaces them.
+func (c *Conn) Warnings() []Warning {
+	unlock, err := c.lock()
+	if err != nil {
+		return nil
+	}
+	defer unlock()
+	n := int(C.nuodb_warning_count(c.db))
+	if n == 0 {
+		return nil
+	}
+	warnings := make([]Warning, n)
+	for i := range warnings {
+		var code C.int
+		var message *C.char
+		C.nuodb_warning(c.db, C.int(i), &code, &message)
+		warnings[i] = Warning{Code: int(code), Message: C.GoString(message)}
+	}
+	return warnings
+}
@@ -0,0 +1,15 @@
+// Copyright (C) 2013 Timo Linna. All Rights Reserved.
+
+package nuodb
+
+import "testing"
+
+func TestWarning(t *testing.T) {
+	w := Warning{Code: 12, Message: "value truncated"}
+	if s := w.String(); s != "value truncated (12)" {
+		t.Fatalf("Unexpected %q", s)
+	}
+	if warnings := (&Conn{}).Warnings(); warnings != nil {
+		t.Fatalf("Expected no warnings of a closed connection, got %v", warnings)
+	}
+}